@@ -0,0 +1,169 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"time"
+)
+
+const (
+	stagingPrefix = ".staging/"
+	backupPrefix  = ".backup/"
+)
+
+type publishObject struct {
+	name string
+	data []byte
+}
+
+// putAll writes objects in order, stopping at the first failure.
+func (p *Publisher) putAll(ctx context.Context, storage Storage, objects []publishObject) error {
+	for _, obj := range objects {
+		if err := storage.Put(ctx, obj.name, obj.data, p.meta(obj.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putAtomic uploads every object under .staging/<id>/ and, once all
+// uploads have succeeded, promotes them over their live names with the
+// index last, so the live index only ever lists shards that are in place.
+// Live objects about to be replaced are first copied under .backup/<id>/,
+// which needs Storage to implement ObjectReader, and are restored if a
+// later step fails; once every object is promoted the backups are deleted.
+// A failed upload leaves the live objects untouched.
+func (p *Publisher) putAtomic(ctx context.Context, storage Storage, objects []publishObject) (err error) {
+	mover, ok := storage.(MoveStorage)
+	if !ok {
+		return fmt.Errorf("%w: %T cannot move", ErrUnsupportedStorage, storage)
+	}
+	deleter, ok := storage.(DeleteStorage)
+	if !ok {
+		return fmt.Errorf("%w: %T cannot delete", ErrUnsupportedStorage, storage)
+	}
+	existing, err := p.existingObjects(ctx, storage)
+	if err != nil {
+		return err
+	}
+	id := time.Now().UTC().Format(snapshotIDLayout)
+	staged := func(name string) string { return stagingPrefix + id + "/" + name }
+	backup := func(name string) string { return backupPrefix + id + "/" + name }
+
+	// Shards and the stylesheet go live before the index that lists them.
+	if i := slices.IndexFunc(objects, func(o publishObject) bool { return o.name == p.indexName() }); i >= 0 {
+		objects = append(slices.Delete(slices.Clone(objects), i, i+1), objects[i])
+	}
+
+	// Rollback work is done with a fresh context, as ctx may be the reason
+	// the publish failed.
+	cleanupCtx := context.WithoutCancel(ctx)
+	var uploaded, promoted, backedUp []string
+	defer func() {
+		if err == nil {
+			return
+		}
+		var errs []error
+		for _, name := range slices.Backward(promoted) {
+			if slices.Contains(backedUp, name) {
+				errs = append(errs, mover.Move(cleanupCtx, backup(name), name))
+			} else {
+				errs = append(errs, deleter.Delete(cleanupCtx, name))
+			}
+		}
+		for _, name := range backedUp {
+			if !slices.Contains(promoted, name) {
+				errs = append(errs, deleter.Delete(cleanupCtx, backup(name)))
+			}
+		}
+		for _, name := range uploaded {
+			if !slices.Contains(promoted, name) {
+				errs = append(errs, deleter.Delete(cleanupCtx, staged(name)))
+			}
+		}
+		if rbErr := errors.Join(errs...); rbErr != nil {
+			err = fmt.Errorf("%w (rollback: %v)", err, rbErr)
+		}
+	}()
+
+	for _, obj := range objects {
+		if err := storage.Put(ctx, staged(obj.name), obj.data, p.meta(obj.name)); err != nil {
+			return err
+		}
+		uploaded = append(uploaded, obj.name)
+	}
+	for _, obj := range objects {
+		if !existing[obj.name] {
+			continue
+		}
+		copied, err := copyObject(ctx, storage, obj.name, backup(obj.name))
+		if err != nil {
+			return fmt.Errorf("back up %s: %w", obj.name, err)
+		}
+		if copied {
+			backedUp = append(backedUp, obj.name)
+		}
+	}
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := mover.Move(ctx, staged(obj.name), obj.name); err != nil {
+			return err
+		}
+		promoted = append(promoted, obj.name)
+	}
+
+	// The publish has succeeded at this point, so a backup that cannot be
+	// deleted is left behind rather than reported.
+	for _, name := range backedUp {
+		_ = deleter.Delete(cleanupCtx, backup(name))
+	}
+	return nil
+}
+
+// copyObject copies the object name to the name to, reporting false when
+// it turns out not to exist.
+func copyObject(ctx context.Context, storage Storage, name, to string) (bool, error) {
+	reader, ok := storage.(ObjectReader)
+	if !ok {
+		return false, fmt.Errorf("%w: %T cannot read", ErrUnsupportedStorage, storage)
+	}
+	body, info, err := reader.Open(ctx, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return false, err
+	}
+	return true, storage.Put(ctx, to, data, info.Meta)
+}
+
+// existingObjects returns the names that may already be live: those
+// listed by storage, or else those of the previous publish.
+func (p *Publisher) existingObjects(ctx context.Context, storage Storage) (map[string]bool, error) {
+	out := map[string]bool{}
+	if l, ok := storage.(ListStorage); ok {
+		names, err := l.List(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			out[name] = true
+		}
+		return out, nil
+	}
+	for name := range p.hashes {
+		out[name] = true
+	}
+	return out, nil
+}
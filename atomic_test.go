@@ -0,0 +1,125 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// watchedStorage is a DirStorage that checks, after every move, that the
+// live index only lists shards that exist, and can fail the move of one
+// name.
+type watchedStorage struct {
+	DirStorage
+	t      *testing.T
+	failTo string
+}
+
+var errMoveFailed = errors.New("move failed")
+
+func (s *watchedStorage) Move(ctx context.Context, from, to string) error {
+	if to == s.failTo {
+		return errMoveFailed
+	}
+	if err := s.DirStorage.Move(ctx, from, to); err != nil {
+		return err
+	}
+	s.checkIndex()
+	return nil
+}
+
+func (s *watchedStorage) checkIndex() {
+	s.t.Helper()
+	data, err := os.ReadFile(filepath.Join(s.Dir, "sitemap.xml"))
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	index, err := ParseXMLSitemapIndex(string(data))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	for _, entry := range index.Sitemaps {
+		loc, _ := url.Parse(entry.Loc)
+		if _, err := os.Stat(filepath.Join(s.Dir, filepath.FromSlash(strings.TrimPrefix(loc.Path, "/")))); err != nil {
+			s.t.Errorf("live index lists missing shard %s", entry.Loc)
+		}
+	}
+}
+
+func numberedSet(t *testing.T, n int, suffix string) *URLSet {
+	t.Helper()
+	set := MakeUrlSet()
+	for i := range n {
+		set.URLs = append(set.URLs, &URL{Loc: fmt.Sprintf("https://example.com/%d%s", i, suffix)})
+	}
+	return &set
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestPutAtomicNeverDangles(t *testing.T) {
+	storage := &watchedStorage{DirStorage: DirStorage{Dir: t.TempDir()}, t: t}
+	p := &Publisher{Storage: storage, BaseURL: "https://example.com/", Atomic: true, Shards: ShardOptions{MaxURLs: 2}}
+	ctx := context.Background()
+	if _, err := p.Publish(ctx, numberedSet(t, 3, "")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Publish(ctx, numberedSet(t, 5, "?v=2")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, storage.Dir, "sitemap-3.xml"); !strings.Contains(got, "4?v=2") {
+		t.Errorf("sitemap-3.xml not published: %s", got)
+	}
+	if _, err := os.Stat(filepath.Join(storage.Dir, backupPrefix)); err == nil {
+		names, _ := storage.List(ctx, backupPrefix)
+		if len(names) > 0 {
+			t.Errorf("backups left behind: %v", names)
+		}
+	}
+}
+
+func TestPutAtomicRollback(t *testing.T) {
+	storage := &watchedStorage{DirStorage: DirStorage{Dir: t.TempDir()}, t: t}
+	p := &Publisher{Storage: storage, BaseURL: "https://example.com/", Atomic: true, Shards: ShardOptions{MaxURLs: 2}}
+	ctx := context.Background()
+	if _, err := p.Publish(ctx, numberedSet(t, 3, "")); err != nil {
+		t.Fatal(err)
+	}
+	oldIndex := readFile(t, storage.Dir, "sitemap.xml")
+	oldShard := readFile(t, storage.Dir, "sitemap-1.xml")
+
+	storage.failTo = "sitemap.xml"
+	if _, err := p.Publish(ctx, numberedSet(t, 5, "?v=2")); !errors.Is(err, errMoveFailed) {
+		t.Fatalf("err = %v, want %v", err, errMoveFailed)
+	}
+	if got := readFile(t, storage.Dir, "sitemap.xml"); got != oldIndex {
+		t.Errorf("index changed:\n%s", got)
+	}
+	if got := readFile(t, storage.Dir, "sitemap-1.xml"); got != oldShard {
+		t.Errorf("shard not restored:\n%s", got)
+	}
+	if _, err := os.Stat(filepath.Join(storage.Dir, "sitemap-3.xml")); !os.IsNotExist(err) {
+		t.Errorf("new shard left behind: %v", err)
+	}
+	names, _ := storage.List(ctx, "")
+	for _, name := range names {
+		if strings.HasPrefix(name, stagingPrefix) || strings.HasPrefix(name, backupPrefix) {
+			t.Errorf("left behind %s", name)
+		}
+	}
+}
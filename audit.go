@@ -0,0 +1,143 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MaxSitemapBytes is the protocol's limit on the uncompressed size of a
+// sitemap or sitemap index.
+const MaxSitemapBytes = 50 * 1024 * 1024
+
+var (
+	ErrSitemapTooLarge   = errors.New("sitemap exceeds the uncompressed size limit")
+	ErrTooManyURLs       = errors.New("sitemap exceeds the URL count limit")
+	ErrStaleIndexLastMod = errors.New("index lastmod is older than the sitemap's content")
+)
+
+type ChildAudit struct {
+	Entry SitemapEntry
+	// StatusCode is 200 for children that were fetched, the response status
+	// when the server refused, and 0 when no response was received.
+	StatusCode int
+	// Bytes is the uncompressed size, counted up to one byte past
+	// MaxSitemapBytes.
+	Bytes int
+	URLs  int
+	// NewestLastMod is the most recent lastmod among the child's URLs.
+	NewestLastMod *time.Time
+	Errs          []error
+}
+
+func (c ChildAudit) OK() bool {
+	return len(c.Errs) == 0
+}
+
+type IndexAudit struct {
+	Loc      string
+	Children []ChildAudit
+	// Errs holds problems with the index document itself.
+	Errs []error
+}
+
+func (a IndexAudit) OK() bool {
+	return len(a.Errs) == 0 && len(a.Failed()) == 0
+}
+
+func (a IndexAudit) Failed() []ChildAudit {
+	var out []ChildAudit
+	for _, c := range a.Children {
+		if !c.OK() {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// AuditIndex fetches the index at loc and checks every child sitemap: it
+// must respond 200, parse, stay within MaxSitemapBytes and
+// MaxURLsPerSitemap, and its lastmod in the index must not be older than
+// the newest lastmod it contains. Children are audited concurrently and
+// reported in index order. Only failing to fetch or parse the index itself
+// is returned as an error; an index past MaxSitemapBytes is reported in
+// Errs, without auditing its children.
+func (f *Fetcher) AuditIndex(ctx context.Context, loc string) (IndexAudit, error) {
+	body, err := f.fetch(ctx, loc, -1)
+	if err != nil {
+		return IndexAudit{}, err
+	}
+	if len(body) > MaxSitemapBytes {
+		err := fmt.Errorf("%w: more than %d bytes", ErrSitemapTooLarge, MaxSitemapBytes)
+		return IndexAudit{Loc: loc, Errs: []error{err}}, nil
+	}
+	index, err := decodeSitemapIndex(bytes.NewReader(body), ParseOptions{MaxBytes: -1})
+	if err != nil {
+		return IndexAudit{}, err
+	}
+
+	audit := IndexAudit{Loc: loc, Children: make([]ChildAudit, len(index.Sitemaps))}
+	if len(index.Sitemaps) > MaxURLsPerSitemap {
+		audit.Errs = append(audit.Errs, fmt.Errorf("%w: %d sitemaps", ErrTooManyURLs, len(index.Sitemaps)))
+	}
+
+	concurrency := f.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultFetchConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, entry := range index.Sitemaps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			audit.Children[i] = f.auditChild(ctx, entry)
+		}()
+	}
+	wg.Wait()
+	return audit, nil
+}
+
+func (f *Fetcher) auditChild(ctx context.Context, entry SitemapEntry) ChildAudit {
+	out := ChildAudit{Entry: entry}
+	body, err := f.fetch(ctx, entry.Loc, -1)
+	if err != nil {
+		var status *HTTPStatusError
+		if errors.As(err, &status) {
+			out.StatusCode = status.StatusCode
+		}
+		out.Errs = append(out.Errs, err)
+		return out
+	}
+	out.StatusCode = 200
+	out.Bytes = len(body)
+	if out.Bytes > MaxSitemapBytes {
+		out.Errs = append(out.Errs, fmt.Errorf("%w: more than %d bytes", ErrSitemapTooLarge, MaxSitemapBytes))
+		return out
+	}
+
+	set, err := ParseXMLUrlSetWithOptions(string(body), ParseOptions{MaxBytes: -1})
+	if err != nil {
+		out.Errs = append(out.Errs, err)
+		return out
+	}
+	out.URLs = len(set.URLs)
+	if out.URLs > MaxURLsPerSitemap {
+		out.Errs = append(out.Errs, fmt.Errorf("%w: %d urls", ErrTooManyURLs, out.URLs))
+	}
+	for _, u := range set.URLs {
+		if u.LastMod != nil && (out.NewestLastMod == nil || u.LastMod.After(*out.NewestLastMod)) {
+			out.NewestLastMod = u.LastMod
+		}
+	}
+	if entry.LastMod != nil && out.NewestLastMod != nil && entry.LastMod.Before(*out.NewestLastMod) {
+		out.Errs = append(out.Errs, fmt.Errorf("%w: %s < %s", ErrStaleIndexLastMod,
+			entry.LastMod.Format(time.RFC3339), out.NewestLastMod.Format(time.RFC3339)))
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuditIndex(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		urlset := func(n int, lastMod string) {
+			io.WriteString(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+			for i := range n {
+				fmt.Fprintf(w, "<url><loc>https://example.com/%d</loc><lastmod>%s</lastmod></url>", i, lastMod)
+			}
+			io.WriteString(w, `</urlset>`)
+		}
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			io.WriteString(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+			for _, child := range []string{"ok", "missing", "stale", "large", "broken"} {
+				fmt.Fprintf(w, "<sitemap><loc>%s/%s.xml</loc><lastmod>2024-02-01</lastmod></sitemap>", srv.URL, child)
+			}
+			io.WriteString(w, `</sitemapindex>`)
+		case "/ok.xml":
+			urlset(2, "2024-01-15")
+		case "/stale.xml":
+			urlset(1, "2024-03-01")
+		case "/large.xml":
+			urlset(MaxURLsPerSitemap+1, "2024-01-01")
+		case "/broken.xml":
+			io.WriteString(w, "<urlset><url>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	audit, err := testFetcher(srv).AuditIndex(context.Background(), srv.URL+"/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audit.OK() || len(audit.Children) != 5 || len(audit.Failed()) != 4 {
+		t.Fatalf("audit = %+v", audit)
+	}
+	ok, missing, stale, large, broken := audit.Children[0], audit.Children[1], audit.Children[2], audit.Children[3], audit.Children[4]
+	if !ok.OK() || ok.URLs != 2 || ok.StatusCode != 200 || ok.NewestLastMod == nil {
+		t.Errorf("ok: %+v", ok)
+	}
+	if missing.StatusCode != http.StatusNotFound || missing.OK() {
+		t.Errorf("missing: %+v", missing)
+	}
+	if len(stale.Errs) != 1 || !errors.Is(stale.Errs[0], ErrStaleIndexLastMod) {
+		t.Errorf("stale: %v", stale.Errs)
+	}
+	if len(large.Errs) != 1 || !errors.Is(large.Errs[0], ErrTooManyURLs) {
+		t.Errorf("large: %v", large.Errs)
+	}
+	if broken.OK() || !strings.HasSuffix(broken.Entry.Loc, "/broken.xml") {
+		t.Errorf("broken: %+v", broken)
+	}
+}
+
+func TestAuditIndexOversized(t *testing.T) {
+	// Spaces compress well, so the bodies inflate past the limit from a
+	// small transfer.
+	oversized := gzipped(t, func(w io.Writer) {
+		io.WriteString(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+		io.WriteString(w, strings.Repeat(" ", MaxSitemapBytes))
+		io.WriteString(w, `</urlset>`)
+	})
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><sitemap><loc>%s/huge.xml</loc></sitemap></sitemapindex>`, srv.URL)
+		default:
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(oversized)
+		}
+	}))
+	defer srv.Close()
+	f := testFetcher(srv)
+
+	audit, err := f.AuditIndex(context.Background(), srv.URL+"/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audit.Errs) != 0 || len(audit.Children) != 1 {
+		t.Fatalf("audit = %+v", audit)
+	}
+	huge := audit.Children[0]
+	if huge.StatusCode != http.StatusOK || huge.Bytes != MaxSitemapBytes+1 || len(huge.Errs) != 1 || !errors.Is(huge.Errs[0], ErrSitemapTooLarge) {
+		t.Errorf("huge: status %d, %d bytes, %v", huge.StatusCode, huge.Bytes, huge.Errs)
+	}
+
+	audit, err = f.AuditIndex(context.Background(), srv.URL+"/huge-index.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audit.Errs) != 1 || !errors.Is(audit.Errs[0], ErrSitemapTooLarge) || len(audit.Children) != 0 {
+		t.Errorf("oversized index: %+v", audit.Errs)
+	}
+}
@@ -0,0 +1,138 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// The expected signature was computed independently from the string to
+// sign described in "Authorize with Shared Key".
+func TestAzureSharedKey(t *testing.T) {
+	s := &AzureBlobStorage{Account: "myaccount", AccountKey: "c2l0ZW1hcC10ZXN0LWFjY291bnQta2V5"}
+	req, err := http.NewRequest(http.MethodPut, "https://myaccount.blob.core.windows.net/mycontainer/dir/a%20b.xml?restype=container&comp=list&tag=b&tag=a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("x-ms-version", azureStorageVersion)
+	req.Header.Set("x-ms-date", "Fri, 26 Jun 2015 23:39:12 GMT")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("x-ms-blob-content-type", "application/xml")
+	if err := s.sign(req, 5); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := req.Header.Get("Authorization"), "SharedKey myaccount:DoLmLjhXzN53HwmR0OhevCorXIvvUmLovuSzSe3nxV8="; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	s.AccountKey = "not base64!"
+	if err := s.sign(req, 5); err == nil {
+		t.Error("invalid account key accepted")
+	}
+}
+
+func TestAzureBlobStorage(t *testing.T) {
+	var mu sync.Mutex
+	var reqs []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reqs = append(reqs, r)
+		mu.Unlock()
+		switch {
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusCreated)
+		case strings.HasSuffix(r.URL.Path, "missing.xml"):
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasSuffix(r.URL.Path, "locked.xml"):
+			http.Error(w, "LeaseIdMissing", http.StatusPreconditionFailed)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	s := &AzureBlobStorage{Account: "acct", Container: "web", AccountKey: "c2VjcmV0", Endpoint: srv.URL + "/", HTTPClient: srv.Client()}
+	meta := ObjectMeta{ContentType: "application/xml", ContentEncoding: "gzip", CacheControl: "max-age=60"}
+	if err := s.Put(ctx, "a b/sitemap.xml", []byte("<urlset/>"), meta); err != nil {
+		t.Fatal(err)
+	}
+	put := reqs[0]
+	if put.URL.EscapedPath() != "/web/a%20b/sitemap.xml" || put.Header.Get("x-ms-blob-type") != "BlockBlob" {
+		t.Errorf("put %s with %v", put.URL, put.Header)
+	}
+	if put.Header.Get("x-ms-blob-content-type") != "application/xml" || put.Header.Get("x-ms-blob-content-encoding") != "gzip" || put.Header.Get("x-ms-blob-cache-control") != "max-age=60" {
+		t.Errorf("blob properties not set: %v", put.Header)
+	}
+	if !strings.HasPrefix(put.Header.Get("Authorization"), "SharedKey acct:") || put.Header.Get("x-ms-version") != azureStorageVersion {
+		t.Errorf("put not signed: %v", put.Header)
+	}
+
+	if err := s.Delete(ctx, "missing.xml"); err != nil {
+		t.Errorf("deleting a missing blob: %v", err)
+	}
+	if err := s.Delete(ctx, "locked.xml"); err == nil || !strings.Contains(err.Error(), "LeaseIdMissing") {
+		t.Errorf("err = %v", err)
+	}
+
+	s.SASToken = "?sv=2021-08-06&sig=abc"
+	if err := s.Delete(ctx, "sitemap.xml"); err != nil {
+		t.Fatal(err)
+	}
+	sas := reqs[len(reqs)-1]
+	if sas.URL.RawQuery != "sv=2021-08-06&sig=abc" || sas.Header.Get("Authorization") != "" {
+		t.Errorf("SAS request %s with %v", sas.URL, sas.Header)
+	}
+}
+
+func TestAzureBlobURL(t *testing.T) {
+	s := &AzureBlobStorage{Account: "acct", Container: "site maps"}
+	if got, want := s.blobURL("de/sitemap #1.xml"), "https://acct.blob.core.windows.net/site%20maps/de/sitemap%20%231.xml"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestAzureBlobStorageOpen(t *testing.T) {
+	var auth, accept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, accept = r.Header.Get("Authorization"), r.Header.Get("Accept-Encoding")
+		if r.URL.Path != "/web/sitemap.xml.gz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("ETag", `"0x8D"`)
+		w.Write([]byte("blob"))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	s := &AzureBlobStorage{Account: "acct", Container: "web", AccountKey: "c2VjcmV0", Endpoint: srv.URL + "/", HTTPClient: srv.Client()}
+	r, info, err := s.Open(ctx, "sitemap.xml.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "blob" || info.Size != 4 || info.ETag != `"0x8D"` || info.Meta.ContentEncoding != "gzip" || info.Meta.ContentType != "application/xml" {
+		t.Errorf("got %q, info %+v", data, info)
+	}
+	if !strings.HasPrefix(auth, "SharedKey acct:") || !strings.Contains(accept, "gzip") {
+		t.Errorf("Authorization %q, Accept-Encoding %q", auth, accept)
+	}
+
+	s.SASToken = "sv=2021-08-06&sig=abc"
+	if _, _, err := s.Open(ctx, "missing.xml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("err = %v, want %v", err, fs.ErrNotExist)
+	}
+	if auth != "" {
+		t.Errorf("SAS request signed: %q", auth)
+	}
+}
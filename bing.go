@@ -0,0 +1,141 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const BingWebmasterEndpoint = "https://ssl.bing.com/webmaster/api.svc/json"
+
+// BingMaxBatchURLs is the largest urlList the SubmitUrlBatch method accepts.
+const BingMaxBatchURLs = 500
+
+type BingWebmasterClient struct {
+	APIKey     string
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+type BingAPIError struct {
+	StatusCode int
+	ErrorCode  int    `json:"ErrorCode"`
+	Message    string `json:"Message"`
+}
+
+func (e *BingAPIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("bing webmaster: http status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("bing webmaster: %s (error code %d, http status %d)", e.Message, e.ErrorCode, e.StatusCode)
+}
+
+type BingURLQuota struct {
+	DailyQuota   int `json:"DailyQuota"`
+	MonthlyQuota int `json:"MonthlyQuota"`
+}
+
+func MakeBingWebmasterClient(apiKey string) *BingWebmasterClient {
+	return &BingWebmasterClient{
+		APIKey:   apiKey,
+		Endpoint: BingWebmasterEndpoint,
+	}
+}
+
+// SubmitSitemap registers a sitemap (a "feed" in Bing's terms) for siteURL.
+func (c *BingWebmasterClient) SubmitSitemap(ctx context.Context, siteURL, sitemapURL string) error {
+	return c.call(ctx, http.MethodPost, "SubmitFeed", nil, map[string]any{
+		"siteUrl": siteURL,
+		"feedUrl": sitemapURL,
+	}, nil)
+}
+
+func (c *BingWebmasterClient) SubmitURL(ctx context.Context, siteURL, loc string) error {
+	return c.call(ctx, http.MethodPost, "SubmitUrl", nil, map[string]any{
+		"siteUrl": siteURL,
+		"url":     loc,
+	}, nil)
+}
+
+// SubmitURLBatch submits locs in chunks of BingMaxBatchURLs, stopping at the
+// first rejected chunk.
+func (c *BingWebmasterClient) SubmitURLBatch(ctx context.Context, siteURL string, locs []string) error {
+	for start := 0; start < len(locs); start += BingMaxBatchURLs {
+		end := min(start+BingMaxBatchURLs, len(locs))
+		err := c.call(ctx, http.MethodPost, "SubmitUrlBatch", nil, map[string]any{
+			"siteUrl": siteURL,
+			"urlList": locs[start:end],
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SubmitURLSet submits every loc in the set.
+func (c *BingWebmasterClient) SubmitURLSet(ctx context.Context, siteURL string, set *URLSet) error {
+	locs := make([]string, 0, len(set.URLs))
+	for _, u := range set.URLs {
+		locs = append(locs, u.Loc)
+	}
+	return c.SubmitURLBatch(ctx, siteURL, locs)
+}
+
+func (c *BingWebmasterClient) URLSubmissionQuota(ctx context.Context, siteURL string) (BingURLQuota, error) {
+	var out struct {
+		D BingURLQuota `json:"d"`
+	}
+	err := c.call(ctx, http.MethodGet, "GetUrlSubmissionQuota", url.Values{"siteUrl": {siteURL}}, nil, &out)
+	return out.D, err
+}
+
+func (c *BingWebmasterClient) call(ctx context.Context, method, op string, query url.Values, body any, out any) error {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = BingWebmasterEndpoint
+	}
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("apikey", c.APIKey)
+
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+"/"+op+"?"+query.Encode(), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &BingAPIError{StatusCode: resp.StatusCode}
+		_ = json.NewDecoder(resp.Body).Decode(apiErr)
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,84 @@
+package sitemap_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBingSubmitURLBatchChunks(t *testing.T) {
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/SubmitUrlBatch" || r.URL.Query().Get("apikey") != "key" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		var body struct {
+			SiteURL string   `json:"siteUrl"`
+			URLList []string `json:"urlList"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		if body.SiteURL != "https://example.com/" {
+			t.Errorf("siteUrl = %q", body.SiteURL)
+		}
+		batches = append(batches, len(body.URLList))
+	}))
+	defer srv.Close()
+
+	c := MakeBingWebmasterClient("key")
+	c.Endpoint = srv.URL
+	locs := make([]string, BingMaxBatchURLs*2+1)
+	for i := range locs {
+		locs[i] = fmt.Sprintf("https://example.com/%d", i)
+	}
+	if err := c.SubmitURLBatch(context.Background(), "https://example.com/", locs); err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 3 || batches[0] != BingMaxBatchURLs || batches[2] != 1 {
+		t.Errorf("batches = %v", batches)
+	}
+}
+
+func TestBingAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ErrorCode":14,"Message":"NotAuthorized"}`))
+	}))
+	defer srv.Close()
+
+	c := MakeBingWebmasterClient("key")
+	c.Endpoint = srv.URL
+	err := c.SubmitSitemap(context.Background(), "https://example.com/", "https://example.com/sitemap.xml")
+	var apiErr *BingAPIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want a BingAPIError", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.ErrorCode != 14 || apiErr.Message != "NotAuthorized" {
+		t.Errorf("got %+v", apiErr)
+	}
+}
+
+func TestBingURLSubmissionQuota(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Query().Get("siteUrl") != "https://example.com/" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		w.Write([]byte(`{"d":{"DailyQuota":10,"MonthlyQuota":300}}`))
+	}))
+	defer srv.Close()
+
+	c := MakeBingWebmasterClient("key")
+	c.Endpoint = srv.URL
+	quota, err := c.URLSubmissionQuota(context.Background(), "https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quota != (BingURLQuota{DailyQuota: 10, MonthlyQuota: 300}) {
+		t.Errorf("quota = %+v", quota)
+	}
+}
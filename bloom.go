@@ -0,0 +1,96 @@
+package sitemap_go
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+var ErrInvalidFalsePositiveRate = errors.New("false positive rate must be between 0 and 1")
+
+// BloomFilter is an approximate set of strings in bounded memory: Has never
+// misses a string that was added, but may report one that was not with
+// about the false positive rate it was sized for. It is safe for concurrent
+// use.
+type BloomFilter struct {
+	mu     sync.Mutex
+	bits   []uint64
+	m      uint64
+	hashes int
+}
+
+// NewBloomFilter sizes a filter for expected strings at falsePositiveRate.
+// Adding more than expected raises the rate.
+func NewBloomFilter(expected int, falsePositiveRate float64) (*BloomFilter, error) {
+	if !(falsePositiveRate > 0 && falsePositiveRate < 1) {
+		return nil, ErrInvalidFalsePositiveRate
+	}
+	n := float64(max(expected, 1))
+	m := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := max(int(math.Round(m/n*math.Ln2)), 1)
+	words := (uint64(m) + 63) / 64
+	return &BloomFilter{bits: make([]uint64, words), m: words * 64, hashes: k}, nil
+}
+
+// Add adds s and reports whether it may have been added before.
+func (f *BloomFilter) Add(s string) bool {
+	h1, h2 := bloomHashes(s)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	present := true
+	for i := range uint64(f.hashes) {
+		bit := (h1 + i*h2) % f.m
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if f.bits[word]&mask == 0 {
+			present = false
+			f.bits[word] |= mask
+		}
+	}
+	return present
+}
+
+func (f *BloomFilter) Has(s string) bool {
+	h1, h2 := bloomHashes(s)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range uint64(f.hashes) {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes splits a 128-bit FNV-1a hash into the two hashes combined
+// for each probe.
+func bloomHashes(s string) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write([]byte(s))
+	sum := h.Sum(nil)
+	var h1, h2 uint64
+	for i := range 8 {
+		h1 = h1<<8 | uint64(sum[i])
+		h2 = h2<<8 | uint64(sum[8+i])
+	}
+	return h1, h2 | 1
+}
+
+// BloomDedup drops URLs whose loc has already passed through it, remembering
+// locs in a BloomFilter sized for expected URLs instead of an exact index.
+// With probability about falsePositiveRate a URL seen for the first time is
+// dropped as well. It suits Writer Transformers streaming more URLs than an
+// exact set of their locs could hold.
+func BloomDedup(expected int, falsePositiveRate float64) (Transformer, error) {
+	filter, err := NewBloomFilter(expected, falsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	return TransformFunc(func(u *URL) (*URL, error) {
+		if filter.Add(u.Loc) {
+			return nil, nil
+		}
+		return u, nil
+	}), nil
+}
@@ -0,0 +1,66 @@
+package sitemap_go
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestNewBloomFilter(t *testing.T) {
+	f, err := NewBloomFilter(1000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// m = -n ln p / ln² 2 = 9586 bits, rounded up to 150 words; k = m/n ln 2.
+	if f.m != 9600 || f.hashes != 7 {
+		t.Errorf("m = %d, k = %d; want 9600 and 7", f.m, f.hashes)
+	}
+	for _, rate := range []float64{0, 1, -0.5, 2} {
+		if _, err := NewBloomFilter(10, rate); !errors.Is(err, ErrInvalidFalsePositiveRate) {
+			t.Errorf("rate %v: err = %v", rate, err)
+		}
+	}
+}
+
+func TestBloomFilter(t *testing.T) {
+	const n, rate = 10000, 0.01
+	f, err := NewBloomFilter(n, rate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range n {
+		f.Add(fmt.Sprintf("https://example.com/%d", i))
+	}
+	for i := range n {
+		if loc := fmt.Sprintf("https://example.com/%d", i); !f.Has(loc) || !f.Add(loc) {
+			t.Fatalf("%s missing after Add", loc)
+		}
+	}
+	falsePositives := 0
+	for i := range n {
+		if f.Has(fmt.Sprintf("https://example.org/%d", i)) {
+			falsePositives++
+		}
+	}
+	if got := float64(falsePositives) / n; got > 2*rate {
+		t.Errorf("false positive rate %.4f, sized for %.2f", got, rate)
+	}
+}
+
+func TestBloomDedup(t *testing.T) {
+	set := setOf(t, "https://example.com/a", "https://example.com/b", "https://example.com/a", "https://example.com/c", "https://example.com/b")
+	dedup, err := BloomDedup(100, 0.001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := set.Transform(dedup); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}; !slices.Equal(locsOf(set), want) {
+		t.Errorf("got %q, want %q", locsOf(set), want)
+	}
+	if _, err := BloomDedup(100, 0); !errors.Is(err, ErrInvalidFalsePositiveRate) {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package sitemap_go
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrInvalidPriority = errors.New("priority must be between 0.0 and 1.0")
+
+// NewURL is MakeUrl with up-front validation: loc must pass CheckLoc and be
+// an absolute http or https URL, priority must be within [0, 1] and
+// changefreq, when set, must be one of the protocol's values.
+func NewURL(loc string, options ...UrlOption) (*URL, error) {
+	u := MakeUrl(loc, options...)
+	if err := checkAbsoluteLoc(u.Loc); err != nil {
+		return nil, err
+	}
+	if u.Priority != nil && !(*u.Priority >= 0 && *u.Priority <= 1) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPriority, *u.Priority)
+	}
+	if u.ChangeFreq != "" && !u.ChangeFreq.Valid() {
+		return nil, fmt.Errorf("%w %q", ErrInvalidChangeFreq, string(u.ChangeFreq))
+	}
+	return u, nil
+}
+
+// MustURL is NewURL for tests and static initialization. It panics if the
+// URL is invalid.
+func MustURL(loc string, options ...UrlOption) *URL {
+	u, err := NewURL(loc, options...)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func checkAbsoluteLoc(loc string) error {
+	if err := CheckLoc(loc); err != nil {
+		return err
+	}
+	parsed, err := url.Parse(loc)
+	if err != nil || parsed.Host == "" ||
+		!strings.EqualFold(parsed.Scheme, "http") && !strings.EqualFold(parsed.Scheme, "https") {
+		return fmt.Errorf("%w: %s", ErrInvalidLoc, loc)
+	}
+	return nil
+}
+
+// Limits from Google's video sitemap documentation.
+const (
+	maxVideoTitle       = 100
+	maxVideoDescription = 2048
+	maxVideoCategory    = 256
+	maxVideoTags        = 32
+	maxVideoDuration    = 28800
+	maxVideoRating      = 5
+)
+
+var ErrInvalidVideo = errors.New("invalid video")
+
+type VideoOption func(*Video)
+
+// WithDuration sets the duration in seconds.
+func WithDuration(seconds int) VideoOption {
+	return func(v *Video) {
+		v.Duration = seconds
+	}
+}
+
+func WithTags(tags ...string) VideoOption {
+	return func(v *Video) {
+		v.Tags = append(v.Tags, tags...)
+	}
+}
+
+func WithCategory(category string) VideoOption {
+	return func(v *Video) {
+		v.Category = category
+	}
+}
+
+func WithPlayerLoc(loc string) VideoOption {
+	return func(v *Video) {
+		v.PlayerLoc = loc
+	}
+}
+
+func WithRating(rating float64) VideoOption {
+	return func(v *Video) {
+		v.Rating = &rating
+	}
+}
+
+func WithPublicationDate(t time.Time) VideoOption {
+	return func(v *Video) {
+		v.PublicationDate = &t
+	}
+}
+
+func WithExpirationDate(t time.Time) VideoOption {
+	return func(v *Video) {
+		v.ExpirationDate = &t
+	}
+}
+
+// NewVideo builds a video entry for the video file at contentLoc and checks
+// it against Google's constraints. Pass an empty contentLoc together with
+// WithPlayerLoc for videos only available through a player.
+func NewVideo(contentLoc, thumbnailLoc, title, description string, options ...VideoOption) (Video, error) {
+	v := Video{
+		ThumbnailLoc: thumbnailLoc,
+		Title:        title,
+		Description:  description,
+		ContentLoc:   contentLoc,
+	}
+	for _, option := range options {
+		option(&v)
+	}
+	return v, v.Validate()
+}
+
+// Validate checks v against Google's video sitemap constraints.
+func (v Video) Validate() error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: "+format, append([]any{ErrInvalidVideo}, args...)...)
+	}
+	if v.Title == "" || utf8.RuneCountInString(v.Title) > maxVideoTitle {
+		return invalid("title must have 1 to %d characters", maxVideoTitle)
+	}
+	if v.Description == "" || utf8.RuneCountInString(v.Description) > maxVideoDescription {
+		return invalid("description must have 1 to %d characters", maxVideoDescription)
+	}
+	if err := checkAbsoluteLoc(v.ThumbnailLoc); err != nil {
+		return invalid("thumbnail_loc: %v", err)
+	}
+	if v.ContentLoc == "" && v.PlayerLoc == "" {
+		return invalid("content_loc or player_loc is required")
+	}
+	for _, loc := range []string{v.ContentLoc, v.PlayerLoc} {
+		if loc == "" {
+			continue
+		}
+		if err := checkAbsoluteLoc(loc); err != nil {
+			return invalid("%v", err)
+		}
+	}
+	if v.Duration != 0 && (v.Duration < 1 || v.Duration > maxVideoDuration) {
+		return invalid("duration must be between 1 and %d seconds", maxVideoDuration)
+	}
+	if v.Rating != nil && !(*v.Rating >= 0 && *v.Rating <= maxVideoRating) {
+		return invalid("rating must be between 0.0 and %d.0", maxVideoRating)
+	}
+	if utf8.RuneCountInString(v.Category) > maxVideoCategory {
+		return invalid("category must have at most %d characters", maxVideoCategory)
+	}
+	if len(v.Tags) > maxVideoTags {
+		return invalid("at most %d tags are allowed", maxVideoTags)
+	}
+	return nil
+}
+
+var ErrInvalidImage = errors.New("invalid image")
+
+type ImageOption func(*Image)
+
+func WithCaption(caption string) ImageOption {
+	return func(img *Image) {
+		img.Caption = caption
+	}
+}
+
+func WithTitle(title string) ImageOption {
+	return func(img *Image) {
+		img.Title = title
+	}
+}
+
+// WithGeoLocation sets the place the image was taken, e.g. "Limerick,
+// Ireland".
+func WithGeoLocation(location string) ImageOption {
+	return func(img *Image) {
+		img.GeoLocation = location
+	}
+}
+
+func WithLicense(licenseURL string) ImageOption {
+	return func(img *Image) {
+		img.License = licenseURL
+	}
+}
+
+func NewImage(loc string, options ...ImageOption) (Image, error) {
+	img := Image{Loc: loc}
+	for _, option := range options {
+		option(&img)
+	}
+	return img, img.Validate()
+}
+
+// Validate checks that the image and license locations are absolute URLs.
+func (img Image) Validate() error {
+	if err := checkAbsoluteLoc(img.Loc); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if img.License != "" {
+		if err := checkAbsoluteLoc(img.License); err != nil {
+			return fmt.Errorf("%w: license: %v", ErrInvalidImage, err)
+		}
+	}
+	return nil
+}
+
+var ErrInvalidHreflang = errors.New("hreflang is not a language code")
+
+// hreflangPattern matches the language[-script][-region] subset of BCP 47
+// that search engines accept in hreflang.
+var hreflangPattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z]{4})?(-([a-zA-Z]{2}|[0-9]{3}))?$`)
+
+// NewAlternate returns an alternate with Rel set to "alternate", checking
+// that hreflang is a language code or "x-default" and href an absolute URL.
+func NewAlternate(hreflang, href string) (Alternate, error) {
+	alt := Alternate{Rel: "alternate", HrefLang: hreflang, Href: href}
+	return alt, alt.Validate()
+}
+
+func (a Alternate) Validate() error {
+	if !strings.EqualFold(a.HrefLang, "x-default") && !hreflangPattern.MatchString(a.HrefLang) {
+		return fmt.Errorf("%w: %q", ErrInvalidHreflang, a.HrefLang)
+	}
+	return checkAbsoluteLoc(a.Href)
+}
@@ -0,0 +1,140 @@
+package sitemap_go
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		loc     string
+		options []UrlOption
+		err     error
+	}{
+		{"valid", "https://example.com/a", []UrlOption{WithPriority(1), WithChangeFreq(ChangeFreqDaily)}, nil},
+		{"http", "HTTP://example.com/", []UrlOption{WithPriority(0)}, nil},
+		{"relative", "/a", nil, ErrInvalidLoc},
+		{"no host", "https:///a", nil, ErrInvalidLoc},
+		{"ftp", "ftp://example.com/a", nil, ErrInvalidLoc},
+		{"priority high", "https://example.com/", []UrlOption{WithPriority(1.1)}, ErrInvalidPriority},
+		{"priority negative", "https://example.com/", []UrlOption{WithPriority(-0.1)}, ErrInvalidPriority},
+		{"changefreq", "https://example.com/", []UrlOption{WithChangeFreq("Daily")}, ErrInvalidChangeFreq},
+	}
+	for _, tt := range tests {
+		u, err := NewURL(tt.loc, tt.options...)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+		if (u == nil) != (tt.err != nil) {
+			t.Errorf("%s: got %v", tt.name, u)
+		}
+	}
+}
+
+func TestMustURL(t *testing.T) {
+	if u := MustURL("https://example.com/"); u.Loc != "https://example.com/" {
+		t.Errorf("loc = %q", u.Loc)
+	}
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrInvalidLoc) {
+			t.Errorf("recovered %v, want %v", err, ErrInvalidLoc)
+		}
+	}()
+	MustURL("/relative")
+}
+
+func TestNewVideo(t *testing.T) {
+	const thumb, content = "https://example.com/t.jpg", "https://example.com/v.mp4"
+	v, err := NewVideo(content, thumb, "Title", "Description",
+		WithDuration(60), WithTags("a", "b"), WithCategory("c"), WithRating(4.5), WithPlayerLoc("https://example.com/player"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ContentLoc != content || v.ThumbnailLoc != thumb || v.Duration != 60 || len(v.Tags) != 2 || *v.Rating != 4.5 || v.PlayerLoc == "" {
+		t.Errorf("got %+v", v)
+	}
+
+	long := func(n int) string { return strings.Repeat("é", n) }
+	tags := make([]string, maxVideoTags+1)
+	tests := []struct {
+		name       string
+		contentLoc string
+		title      string
+		options    []VideoOption
+	}{
+		{"no title", content, "", nil},
+		{"long title", content, long(maxVideoTitle + 1), nil},
+		{"no location", "", "Title", nil},
+		{"relative content", "/v.mp4", "Title", nil},
+		{"relative player", "", "Title", []VideoOption{WithPlayerLoc("/player")}},
+		{"duration", content, "Title", []VideoOption{WithDuration(maxVideoDuration + 1)}},
+		{"negative duration", content, "Title", []VideoOption{WithDuration(-1)}},
+		{"rating", content, "Title", []VideoOption{WithRating(5.5)}},
+		{"category", content, "Title", []VideoOption{WithCategory(long(maxVideoCategory + 1))}},
+		{"tags", content, "Title", []VideoOption{WithTags(tags...)}},
+	}
+	for _, tt := range tests {
+		if _, err := NewVideo(tt.contentLoc, thumb, tt.title, "Description", tt.options...); !errors.Is(err, ErrInvalidVideo) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, ErrInvalidVideo)
+		}
+	}
+	if _, err := NewVideo("", thumb, "Title", "Description", WithPlayerLoc("https://example.com/player")); err != nil {
+		t.Errorf("player only: %v", err)
+	}
+	if _, err := NewVideo(content, "/t.jpg", "Title", "Description"); !errors.Is(err, ErrInvalidVideo) {
+		t.Errorf("relative thumbnail: err = %v", err)
+	}
+	if _, err := NewVideo(content, thumb, long(maxVideoTitle), long(maxVideoDescription)); err != nil {
+		t.Errorf("limits are counted in characters: %v", err)
+	}
+	if _, err := NewVideo(content, thumb, "Title", long(maxVideoDescription+1)); !errors.Is(err, ErrInvalidVideo) {
+		t.Errorf("long description: err = %v", err)
+	}
+}
+
+func TestNewImage(t *testing.T) {
+	img, err := NewImage("https://example.com/a.jpg",
+		WithCaption("caption"), WithTitle("title"), WithGeoLocation("Limerick, Ireland"), WithLicense("https://example.com/license"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Image{Loc: "https://example.com/a.jpg", Caption: "caption", Title: "title", GeoLocation: "Limerick, Ireland", License: "https://example.com/license"}
+	if img != want {
+		t.Errorf("got %+v, want %+v", img, want)
+	}
+	if _, err := NewImage("/a.jpg"); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("relative loc: err = %v", err)
+	}
+	if _, err := NewImage("https://example.com/a.jpg", WithLicense("license.html")); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("relative license: err = %v", err)
+	}
+}
+
+func TestNewAlternate(t *testing.T) {
+	tests := []struct {
+		hreflang string
+		href     string
+		err      error
+	}{
+		{"en", "https://example.com/en", nil},
+		{"de-AT", "https://example.com/at", nil},
+		{"zh-Hant-TW", "https://example.com/tw", nil},
+		{"es-419", "https://example.com/latam", nil},
+		{"X-Default", "https://example.com/", nil},
+		{"english", "https://example.com/", ErrInvalidHreflang},
+		{"en_US", "https://example.com/", ErrInvalidHreflang},
+		{"", "https://example.com/", ErrInvalidHreflang},
+		{"en", "/en", ErrInvalidLoc},
+	}
+	for _, tt := range tests {
+		alt, err := NewAlternate(tt.hreflang, tt.href)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%q %q: err = %v, want %v", tt.hreflang, tt.href, err, tt.err)
+		}
+		if alt.Rel != "alternate" || alt.HrefLang != tt.hreflang || alt.Href != tt.href {
+			t.Errorf("%q %q: got %+v", tt.hreflang, tt.href, alt)
+		}
+	}
+}
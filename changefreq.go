@@ -0,0 +1,70 @@
+package sitemap_go
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidChangeFreq = errors.New("invalid changefreq")
+
+var changeFreqs = []ChangeFreq{
+	ChangeFreqAlways,
+	ChangeFreqHourly,
+	ChangeFreqDaily,
+	ChangeFreqWeekly,
+	ChangeFreqMonthly,
+	ChangeFreqYearly,
+	ChangeFreqNever,
+}
+
+// ParseChangeFreq accepts exactly the lowercase values of the protocol.
+func ParseChangeFreq(s string) (ChangeFreq, error) {
+	for _, f := range changeFreqs {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", ErrInvalidChangeFreq, s)
+}
+
+// ParseChangeFreqLenient is ParseChangeFreq ignoring case and surrounding
+// whitespace, so "Monthly" maps to ChangeFreqMonthly.
+func ParseChangeFreqLenient(s string) (ChangeFreq, error) {
+	f, err := ParseChangeFreq(strings.ToLower(strings.TrimSpace(s)))
+	if err != nil {
+		return "", fmt.Errorf("%w %q", ErrInvalidChangeFreq, s)
+	}
+	return f, nil
+}
+
+// Valid reports whether f is one of the protocol's values. The empty
+// ChangeFreq, meaning unset, is not valid.
+func (f ChangeFreq) Valid() bool {
+	_, err := ParseChangeFreq(string(f))
+	return err == nil
+}
+
+// MarshalText rejects values outside the protocol. The empty value is
+// allowed and means unset.
+func (f ChangeFreq) MarshalText() ([]byte, error) {
+	if f != "" && !f.Valid() {
+		return nil, fmt.Errorf("%w %q", ErrInvalidChangeFreq, string(f))
+	}
+	return []byte(f), nil
+}
+
+// UnmarshalText parses text strictly with ParseChangeFreq. Empty text
+// leaves the value unset.
+func (f *ChangeFreq) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*f = ""
+		return nil
+	}
+	parsed, err := ParseChangeFreq(string(text))
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
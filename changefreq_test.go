@@ -0,0 +1,64 @@
+package sitemap_go
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseChangeFreq(t *testing.T) {
+	tests := []struct {
+		in      string
+		strict  ChangeFreq
+		lenient ChangeFreq
+	}{
+		{"daily", ChangeFreqDaily, ChangeFreqDaily},
+		{"never", ChangeFreqNever, ChangeFreqNever},
+		{"Monthly", "", ChangeFreqMonthly},
+		{" HOURLY\n", "", ChangeFreqHourly},
+		{"sometimes", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		got, err := ParseChangeFreq(tt.in)
+		if got != tt.strict || (err == nil) != (tt.strict != "") {
+			t.Errorf("ParseChangeFreq(%q) = %q, %v", tt.in, got, err)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidChangeFreq) {
+			t.Errorf("ParseChangeFreq(%q): err = %v", tt.in, err)
+		}
+		got, err = ParseChangeFreqLenient(tt.in)
+		if got != tt.lenient || (err == nil) != (tt.lenient != "") {
+			t.Errorf("ParseChangeFreqLenient(%q) = %q, %v", tt.in, got, err)
+		}
+		if f := ChangeFreq(tt.in); f.Valid() != (tt.strict != "") {
+			t.Errorf("ChangeFreq(%q).Valid() = %v", tt.in, f.Valid())
+		}
+	}
+}
+
+func TestChangeFreqText(t *testing.T) {
+	if _, err := ChangeFreq("Weekly").MarshalText(); !errors.Is(err, ErrInvalidChangeFreq) {
+		t.Errorf("marshaling an invalid changefreq: %v", err)
+	}
+	var f ChangeFreq = ChangeFreqDaily
+	if err := f.UnmarshalText(nil); err != nil || f != "" {
+		t.Errorf("empty text = %q, %v", f, err)
+	}
+	if err := f.UnmarshalText([]byte("Daily")); !errors.Is(err, ErrInvalidChangeFreq) {
+		t.Errorf("err = %v", err)
+	}
+	if text, err := ChangeFreq("").MarshalText(); err != nil || len(text) != 0 {
+		t.Errorf("unset = %q, %v", text, err)
+	}
+}
+
+func TestDecodeChangeFreq(t *testing.T) {
+	doc := `<urlset><url><loc>https://example.com/</loc><changefreq>Monthly</changefreq></url></urlset>`
+	if _, err := ParseXMLUrlSet(doc); !errors.Is(err, ErrInvalidChangeFreq) {
+		t.Errorf("strict: err = %v", err)
+	}
+	set, err := ParseXMLUrlSetWithOptions(doc, ParseOptions{LenientChangeFreq: true})
+	if err != nil || set.URLs[0].ChangeFreq != ChangeFreqMonthly {
+		t.Errorf("lenient: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package sitemap_go
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrUnsupportedStorage = errors.New("storage does not support the operation")
+
+type CleanupMode int
+
+const (
+	// CleanupNone leaves obsolete shards in place.
+	CleanupNone CleanupMode = iota
+	// CleanupDelete deletes obsolete shards; Storage must implement
+	// DeleteStorage.
+	CleanupDelete
+	// CleanupArchive moves obsolete shards under ArchivePrefix; Storage
+	// must implement MoveStorage.
+	CleanupArchive
+)
+
+const defaultArchivePrefix = "archive/"
+
+// cleanup removes the shards that are not among the objects just written,
+// recording them in result. Listed names are only taken for shards when
+// they are unsectioned or in one of sections.
+func (p *Publisher) cleanup(ctx context.Context, storage Storage, written map[string][sha256.Size]byte, sections []string, result *PublishResult) error {
+	if p.Cleanup == CleanupNone {
+		return nil
+	}
+	candidates := make([]string, 0, len(p.hashes))
+	for name := range p.hashes {
+		candidates = append(candidates, name)
+	}
+	archive := orDefault(p.ArchivePrefix, defaultArchivePrefix)
+	if l, ok := storage.(ListStorage); ok {
+		names, err := l.List(ctx, "")
+		if err != nil {
+			return err
+		}
+		namer, prefix := p.Shards.names(), p.Shards.prefix()
+		for _, name := range names {
+			if !strings.HasPrefix(name, archive) && namer.OwnsShardName(prefix, stripCodingExt(name), sections...) {
+				candidates = append(candidates, name)
+			}
+		}
+	}
+	slices.Sort(candidates)
+
+	for _, name := range slices.Compact(candidates) {
+		if _, ok := written[name]; ok {
+			continue
+		}
+		var err error
+		switch p.Cleanup {
+		case CleanupDelete:
+			d, ok := storage.(DeleteStorage)
+			if !ok {
+				return fmt.Errorf("%w: %T cannot delete", ErrUnsupportedStorage, storage)
+			}
+			err = d.Delete(ctx, name)
+		case CleanupArchive:
+			m, ok := storage.(MoveStorage)
+			if !ok {
+				return fmt.Errorf("%w: %T cannot move", ErrUnsupportedStorage, storage)
+			}
+			err = m.Move(ctx, name, archive+name)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		result.Obsolete = append(result.Obsolete, name)
+		result.PurgedURLs = append(result.PurgedURLs, p.url(name))
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPublishCleanup(t *testing.T) {
+	for _, mode := range []CleanupMode{CleanupDelete, CleanupArchive} {
+		dir := t.TempDir()
+		storage := DirStorage{Dir: dir}
+		ctx := context.Background()
+		// A shard left by an earlier process is found by listing.
+		if err := storage.Put(ctx, "sitemap-9.xml", []byte("<urlset/>"), ObjectMeta{}); err != nil {
+			t.Fatal(err)
+		}
+		p := &Publisher{Storage: storage, BaseURL: "https://example.com/", Shards: ShardOptions{MaxURLs: 2}, Cleanup: mode}
+		if _, err := p.Publish(ctx, numberedSet(t, 5, "")); err != nil {
+			t.Fatal(err)
+		}
+		result, err := p.Publish(ctx, numberedSet(t, 2, ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"sitemap-2.xml", "sitemap-3.xml"}; !slices.Equal(result.Obsolete, want) {
+			t.Errorf("mode %d: obsolete = %q, want %q", mode, result.Obsolete, want)
+		}
+		if !slices.Contains(result.PurgedURLs, "https://example.com/sitemap-3.xml") {
+			t.Errorf("mode %d: obsolete shard not purged: %q", mode, result.PurgedURLs)
+		}
+		names, err := storage.List(ctx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"sitemap-1.xml", "sitemap.xml"}
+		if mode == CleanupArchive {
+			want = []string{"archive/sitemap-2.xml", "archive/sitemap-3.xml", "archive/sitemap-9.xml", "sitemap-1.xml", "sitemap.xml"}
+		}
+		if !slices.Equal(names, want) {
+			t.Errorf("mode %d: left %q, want %q", mode, names, want)
+		}
+	}
+}
+
+func TestPublishCleanupSharedPrefix(t *testing.T) {
+	dir := t.TempDir()
+	storage := DirStorage{Dir: dir}
+	ctx := context.Background()
+	// Another pipeline publishes its news shards under the same prefix; an
+	// earlier process left a blog shard.
+	for _, name := range []string{"sitemap-news-1.xml", "sitemap-blog-7.xml", "sitemap-9.xml"} {
+		if err := storage.Put(ctx, name, []byte("<urlset/>"), ObjectMeta{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := &Publisher{Storage: storage, BaseURL: "https://example.com/", Cleanup: CleanupDelete}
+	var b SectionedBuilder
+	for _, section := range []string{"blog", "products"} {
+		if err := b.Add(section, &URL{Loc: "https://example.com/" + section}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := p.PublishSections(ctx, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sitemap-9.xml", "sitemap-blog-7.xml"}; !slices.Equal(result.Obsolete, want) {
+		t.Errorf("obsolete = %q, want %q", result.Obsolete, want)
+	}
+
+	// An unsectioned publish drops the sections published before, but not
+	// the other pipeline's.
+	if _, err := p.Publish(ctx, numberedSet(t, 1, "")); err != nil {
+		t.Fatal(err)
+	}
+	names, err := storage.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sitemap-1.xml", "sitemap-news-1.xml", "sitemap.xml"}; !slices.Equal(names, want) {
+		t.Errorf("left %q, want %q", names, want)
+	}
+}
+
+func TestPublishCleanupUnsupported(t *testing.T) {
+	p := &Publisher{Storage: &memStorage{}, BaseURL: "https://example.com/", Shards: ShardOptions{MaxURLs: 1}, Cleanup: CleanupArchive}
+	ctx := context.Background()
+	if _, err := p.Publish(ctx, numberedSet(t, 2, "")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Publish(ctx, numberedSet(t, 1, "")); !errors.Is(err, ErrUnsupportedStorage) {
+		t.Errorf("err = %v, want %v", err, ErrUnsupportedStorage)
+	}
+}
+
+func TestPublishCleanupDryRun(t *testing.T) {
+	dir := t.TempDir()
+	p := &Publisher{Storage: DirStorage{Dir: dir}, BaseURL: "https://example.com/", Shards: ShardOptions{MaxURLs: 1}, Cleanup: CleanupDelete}
+	ctx := context.Background()
+	if _, err := p.Publish(ctx, numberedSet(t, 2, "")); err != nil {
+		t.Fatal(err)
+	}
+	p.DryRun = true
+	result, err := p.Publish(ctx, numberedSet(t, 1, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(result.DryRun, StorageOp{Kind: StorageDelete, Name: "sitemap-2.xml"}) {
+		t.Errorf("ops = %+v", result.DryRun)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sitemap-2.xml")); err != nil {
+		t.Errorf("dry run deleted the shard: %v", err)
+	}
+}
+
+func TestPrefixStorageList(t *testing.T) {
+	dir := t.TempDir()
+	s := PrefixStorage{Storage: DirStorage{Dir: dir}, Prefix: "sites/a/"}
+	ctx := context.Background()
+	for _, name := range []string{"sitemap.xml", "sitemap-1.xml"} {
+		if err := s.Put(ctx, name, []byte("x"), ObjectMeta{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Move(ctx, "sitemap-1.xml", "old/sitemap-1.xml"); err != nil {
+		t.Fatal(err)
+	}
+	names, err := s.List(ctx, "")
+	if want := []string{"old/sitemap-1.xml", "sitemap.xml"}; err != nil || !slices.Equal(names, want) {
+		t.Errorf("List = %q, %v; want %q", names, err, want)
+	}
+	if err := s.Delete(ctx, "missing.xml"); err != nil {
+		t.Errorf("deleting a missing object: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package sitemap_go
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ContentCoding is an HTTP content coding the package can decode when
+// fetching and, when NewWriter is set, produce when sharding. Gzip and
+// deflate are built in; others, such as Brotli, can be added with
+// RegisterContentCoding:
+//
+//	sitemap.RegisterContentCoding(sitemap.ContentCoding{
+//		Name:      "br",
+//		Ext:       ".br",
+//		NewReader: func(r io.Reader) (io.ReadCloser, error) { return io.NopCloser(brotli.NewReader(r)), nil },
+//		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return brotli.NewWriter(w), nil },
+//	})
+type ContentCoding struct {
+	// Name is the Content-Encoding token, e.g. "br".
+	Name string
+	// Ext is the file extension of shards compressed with this coding.
+	Ext       string
+	NewReader func(io.Reader) (io.ReadCloser, error)
+	NewWriter func(io.Writer) (io.WriteCloser, error)
+}
+
+var (
+	codingsMu sync.RWMutex
+	codings   = []ContentCoding{
+		{
+			Name:      "gzip",
+			Ext:       ".gz",
+			NewReader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+			NewWriter: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+		},
+		{
+			Name:      "deflate",
+			Ext:       ".zz",
+			NewReader: newDeflateReader,
+			NewWriter: func(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriter(w), nil },
+		},
+	}
+)
+
+// RegisterContentCoding adds c, replacing any coding with the same name.
+func RegisterContentCoding(c ContentCoding) {
+	codingsMu.Lock()
+	defer codingsMu.Unlock()
+	for i := range codings {
+		if codings[i].Name == c.Name {
+			codings[i] = c
+			return
+		}
+	}
+	codings = append(codings, c)
+}
+
+func lookupCoding(match func(ContentCoding) bool) (ContentCoding, bool) {
+	codingsMu.RLock()
+	defer codingsMu.RUnlock()
+	for _, c := range codings {
+		if match(c) {
+			return c, true
+		}
+	}
+	return ContentCoding{}, false
+}
+
+func codingByName(name string) (ContentCoding, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "x-gzip" {
+		name = "gzip"
+	}
+	return lookupCoding(func(c ContentCoding) bool { return c.Name == name })
+}
+
+// codingByExt finds the coding whose extension ends name.
+func codingByExt(name string) (ContentCoding, bool) {
+	return lookupCoding(func(c ContentCoding) bool { return c.Ext != "" && strings.HasSuffix(name, c.Ext) })
+}
+
+func codingExts() []string {
+	codingsMu.RLock()
+	defer codingsMu.RUnlock()
+	exts := make([]string, 0, len(codings))
+	for _, c := range codings {
+		if c.Ext != "" {
+			exts = append(exts, c.Ext)
+		}
+	}
+	return exts
+}
+
+// acceptEncoding lists every coding that can be decoded.
+func acceptEncoding() string {
+	codingsMu.RLock()
+	defer codingsMu.RUnlock()
+	names := make([]string, 0, len(codings))
+	for _, c := range codings {
+		if c.NewReader != nil {
+			names = append(names, c.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// decodeContent undoes the codings listed in a Content-Encoding header,
+// which are applied in order and so are removed last to first.
+func decodeContent(body io.Reader, contentEncoding string) (io.Reader, error) {
+	if contentEncoding == "" {
+		return body, nil
+	}
+	names := strings.Split(contentEncoding, ",")
+	for i := len(names) - 1; i >= 0; i-- {
+		name := strings.TrimSpace(names[i])
+		if name == "" || strings.EqualFold(name, "identity") {
+			continue
+		}
+		c, ok := codingByName(name)
+		if !ok || c.NewReader == nil {
+			return nil, fmt.Errorf("unsupported content encoding %q", name)
+		}
+		r, err := c.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		body = r
+	}
+	return body, nil
+}
+
+// newDeflateReader accepts both zlib-wrapped deflate, which is what HTTP
+// specifies, and the raw deflate streams some servers send instead.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err == nil && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 && header[0]&0x0f == 8 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
@@ -0,0 +1,105 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// registerBase64Coding registers "x-base64", a stand-in for third-party
+// codings such as Brotli.
+var registerBase64Coding = sync.OnceFunc(func() {
+	RegisterContentCoding(ContentCoding{Name: "x-base64", Ext: ".b64"})
+	RegisterContentCoding(ContentCoding{
+		Name: "x-base64",
+		Ext:  ".b64",
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+		},
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return base64.NewEncoder(base64.StdEncoding, w), nil },
+	})
+})
+
+func compressed(t *testing.T, newWriter func(io.Writer) io.WriteCloser, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	if _, err := io.WriteString(w, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeContent(t *testing.T) {
+	registerBase64Coding()
+	const data = "<urlset></urlset>"
+	zlibbed := compressed(t, func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }, data)
+	raw := compressed(t, func(w io.Writer) io.WriteCloser { fw, _ := flate.NewWriter(w, flate.DefaultCompression); return fw }, data)
+	gz := gzipped(t, func(w io.Writer) { io.WriteString(w, data) })
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+	}{
+		{"none", "", []byte(data)},
+		{"identity", "identity", []byte(data)},
+		{"gzip", "gzip", gz},
+		{"x-gzip", "X-Gzip", gz},
+		{"zlib deflate", "deflate", zlibbed},
+		{"raw deflate", "deflate", raw},
+		{"registered", "x-base64", []byte(base64.StdEncoding.EncodeToString([]byte(data)))},
+		{"stacked", "gzip, x-base64", []byte(base64.StdEncoding.EncodeToString(gz))},
+	}
+	for _, tt := range tests {
+		r, err := decodeContent(bytes.NewReader(tt.body), tt.encoding)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got, err := io.ReadAll(r); err != nil || string(got) != data {
+			t.Errorf("%s: got %q, %v", tt.name, got, err)
+		}
+	}
+	if _, err := decodeContent(strings.NewReader(data), "compress"); err == nil {
+		t.Error("unknown coding accepted")
+	}
+}
+
+func TestRegisterContentCoding(t *testing.T) {
+	registerBase64Coding()
+	if c, ok := codingByName("x-base64"); !ok || c.NewReader == nil {
+		t.Fatal("registering again did not replace the coding")
+	}
+	if c, ok := codingByExt("sitemap-1.xml.b64"); !ok || c.Name != "x-base64" {
+		t.Errorf("codingByExt = %+v, %v", c, ok)
+	}
+	if !strings.Contains(acceptEncoding(), "x-base64") || !strings.HasPrefix(acceptEncoding(), "gzip, deflate") {
+		t.Errorf("Accept-Encoding = %q", acceptEncoding())
+	}
+
+	storage := &memStorage{}
+	p := &Publisher{Storage: storage, BaseURL: "https://example.com/", Shards: ShardOptions{MaxURLs: 2, Compression: "x-base64"}}
+	if _, err := p.Publish(context.Background(), numberedSet(t, 3, "")); err != nil {
+		t.Fatal(err)
+	}
+	if meta := storage.meta["sitemap-1.xml.b64"]; meta.ContentEncoding != "x-base64" {
+		t.Errorf("shard meta = %+v; objects %v", meta, storage.puts)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(string(storage.get("sitemap-2.xml.b64")))
+	if err != nil || !strings.Contains(string(decoded), "https://example.com/2") {
+		t.Errorf("shard = %q, %v", decoded, err)
+	}
+
+	if _, err := numberedSet(t, 1, "").GenerateShards(context.Background(), ShardOptions{Compression: "compress"}); err == nil {
+		t.Error("unknown shard compression accepted")
+	}
+}
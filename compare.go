@@ -0,0 +1,227 @@
+package sitemap_go
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CompareField is a mask of URL fields for Diff and Equal to ignore.
+type CompareField uint
+
+const (
+	CompareLastMod CompareField = 1 << iota
+	ComparePriority
+	CompareChangeFreq
+	CompareImages
+	CompareVideos
+	CompareGeo
+	CompareAlternates
+
+	// CompareVolatile covers the fields generators typically restamp on
+	// every run.
+	CompareVolatile = CompareLastMod | ComparePriority | CompareChangeFreq
+)
+
+type CompareOptions struct {
+	// Ignore masks fields out of the comparison.
+	Ignore CompareField
+	// Equivalence matches locs that differ only cosmetically; see
+	// CanonicalLoc. Reported locs are as they appear in their set.
+	Equivalence LocEquivalence
+}
+
+// URLDiff is a URL present in both sets whose compared fields differ.
+type URLDiff struct {
+	Loc string
+	// Fields names the differing fields, by their element names: lastmod,
+	// priority, changefreq, image, video, geo and alternate.
+	Fields []string
+	A, B   *URL
+}
+
+// SetDiff lists how set b differs from set a. Locs are listed in the order
+// they appear in their set.
+type SetDiff struct {
+	Added   []string
+	Removed []string
+	Changed []URLDiff
+}
+
+func (d SetDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares the URLs of a and b by loc, ignoring their order and Meta.
+// When a loc is listed more than once, its first entry is compared.
+func Diff(a, b *URLSet, opts CompareOptions) SetDiff {
+	var d SetDiff
+	key := func(u *URL) string { return CanonicalLoc(u.Loc, opts.Equivalence) }
+	byLoc := make(map[string]*URL, len(a.URLs))
+	for _, u := range a.URLs {
+		if _, ok := byLoc[key(u)]; !ok {
+			byLoc[key(u)] = u
+		}
+	}
+	inB := make(map[string]bool, len(b.URLs))
+	for _, u := range b.URLs {
+		k := key(u)
+		if inB[k] {
+			continue
+		}
+		inB[k] = true
+		old, ok := byLoc[k]
+		if !ok {
+			d.Added = append(d.Added, u.Loc)
+			continue
+		}
+		if fields := diffFields(old, u, opts.Ignore); len(fields) > 0 {
+			d.Changed = append(d.Changed, URLDiff{Loc: u.Loc, Fields: fields, A: old, B: u})
+		}
+	}
+	for _, u := range a.URLs {
+		if k := key(u); !inB[k] && byLoc[k] == u {
+			d.Removed = append(d.Removed, u.Loc)
+		}
+	}
+	return d
+}
+
+// Equal reports whether Diff finds no difference between a and b.
+func Equal(a, b *URLSet, opts CompareOptions) bool {
+	return Diff(a, b, opts).Empty()
+}
+
+func diffFields(a, b *URL, ignore CompareField) []string {
+	var fields []string
+	check := func(field CompareField, name string, equal bool) {
+		if ignore&field == 0 && !equal {
+			fields = append(fields, name)
+		}
+	}
+	check(CompareLastMod, "lastmod", equalPtr(a.LastMod, b.LastMod, time.Time.Equal))
+	check(ComparePriority, "priority", equalPtr(a.Priority, b.Priority, func(x, y float64) bool { return x == y }))
+	check(CompareChangeFreq, "changefreq", a.ChangeFreq == b.ChangeFreq)
+	check(CompareImages, "image", slices.Equal(a.Images, b.Images))
+	check(CompareVideos, "video", slices.EqualFunc(a.Videos, b.Videos, equalVideo))
+	check(CompareGeo, "geo", equalPtr(a.Geo, b.Geo, func(x, y Geo) bool { return x == y }))
+	check(CompareAlternates, "alternate", slices.Equal(a.Alternate, b.Alternate))
+	return fields
+}
+
+func equalVideo(a, b Video) bool {
+	return equalPtr(a.Rating, b.Rating, func(x, y float64) bool { return x == y }) &&
+		equalPtr(a.PublicationDate, b.PublicationDate, time.Time.Equal) &&
+		equalPtr(a.ExpirationDate, b.ExpirationDate, time.Time.Equal) &&
+		slices.Equal(a.Tags, b.Tags) &&
+		a.Loc == b.Loc && a.ThumbnailLoc == b.ThumbnailLoc && a.Title == b.Title &&
+		a.Description == b.Description && a.ContentLoc == b.ContentLoc &&
+		a.PlayerLoc == b.PlayerLoc && a.Duration == b.Duration && a.Category == b.Category
+}
+
+func equalPtr[T any](a, b *T, equal func(T, T) bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return equal(*a, *b)
+}
+
+// LocEquivalence is a mask of cosmetic loc differences for comparisons and
+// dedup to disregard.
+type LocEquivalence uint
+
+const (
+	// EquivUnreservedEscapes treats a percent-escaped unreserved character,
+	// such as %7E, as the character itself.
+	EquivUnreservedEscapes LocEquivalence = 1 << iota
+	// EquivEscapeCase treats %7e and %7E alike.
+	EquivEscapeCase
+	// EquivIDN treats a Unicode host or path like its punycode and
+	// percent-encoded form, and hosts case-insensitively.
+	EquivIDN
+
+	EquivAll = EquivUnreservedEscapes | EquivEscapeCase | EquivIDN
+)
+
+// CanonicalLoc returns the same key for every loc equivalent to loc under
+// eq. It is meant for comparison, not for output.
+func CanonicalLoc(loc string, eq LocEquivalence) string {
+	if eq&EquivIDN != 0 {
+		if ascii, err := IRIToURI(loc); err == nil {
+			loc = lowerHost(ascii)
+		}
+	}
+	if eq&(EquivUnreservedEscapes|EquivEscapeCase) == 0 || !strings.Contains(loc, "%") {
+		return loc
+	}
+	var b strings.Builder
+	for i := 0; i < len(loc); i++ {
+		if loc[i] != '%' || i+2 >= len(loc) || !isHex(loc[i+1]) || !isHex(loc[i+2]) {
+			b.WriteByte(loc[i])
+			continue
+		}
+		c := unhex(loc[i+1])<<4 | unhex(loc[i+2])
+		switch {
+		case eq&EquivUnreservedEscapes != 0 && isUnreserved(c):
+			b.WriteByte(c)
+		case eq&EquivEscapeCase != 0:
+			b.WriteString(strings.ToUpper(loc[i : i+3]))
+		default:
+			b.WriteString(loc[i : i+3])
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+func lowerHost(loc string) string {
+	i := strings.Index(loc, "://")
+	if i < 0 {
+		return loc
+	}
+	start := i + 3
+	end := strings.IndexAny(loc[start:], "/?#")
+	if end < 0 {
+		end = len(loc) - start
+	}
+	authority := loc[start : start+end]
+	if at := strings.LastIndex(authority, "@"); at >= 0 {
+		start += at + 1
+		authority = authority[at+1:]
+	}
+	return loc[:start] + strings.ToLower(authority) + loc[start+len(authority):]
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	}
+	return c - '0'
+}
+
+// isUnreserved reports whether c is an RFC 3986 unreserved character.
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// DedupLocs drops URLs whose loc is equivalent under eq to one that has
+// already passed through it, keeping the first.
+func DedupLocs(eq LocEquivalence) Transformer {
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	return TransformFunc(func(u *URL) (*URL, error) {
+		key := CanonicalLoc(u.Loc, eq)
+		mu.Lock()
+		defer mu.Unlock()
+		if seen[key] {
+			return nil, nil
+		}
+		seen[key] = true
+		return u, nil
+	})
+}
@@ -0,0 +1,110 @@
+package sitemap_go
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	a := richSet(4)
+	b := richSet(5)
+	b.URLs = b.URLs[1:]
+	lastMod := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.URLs[0].LastMod = &lastMod
+	b.URLs[1].ChangeFreq = ChangeFreqDaily
+	b.URLs[1].Images = nil
+	b.URLs = append(b.URLs, b.URLs[0].Clone())
+
+	d := Diff(a, b, CompareOptions{})
+	if want := []string{"https://example.com/page/4?a=1&b=<2>"}; !slices.Equal(d.Added, want) {
+		t.Errorf("added = %q, want %q", d.Added, want)
+	}
+	if want := []string{"https://example.com/page/0?a=1&b=<2>"}; !slices.Equal(d.Removed, want) {
+		t.Errorf("removed = %q, want %q", d.Removed, want)
+	}
+	if len(d.Changed) != 2 {
+		t.Fatalf("changed = %+v", d.Changed)
+	}
+	if c := d.Changed[0]; c.Loc != "https://example.com/page/1?a=1&b=<2>" || !slices.Equal(c.Fields, []string{"lastmod"}) || c.A != a.URLs[1] || c.B != b.URLs[0] {
+		t.Errorf("first change = %+v", c)
+	}
+	if c := d.Changed[1]; !slices.Equal(c.Fields, []string{"changefreq", "image"}) {
+		t.Errorf("second change fields = %q", c.Fields)
+	}
+
+	d = Diff(a, b, CompareOptions{Ignore: CompareVolatile | CompareImages})
+	if len(d.Changed) != 0 || len(d.Added) != 1 || len(d.Removed) != 1 {
+		t.Errorf("masked diff = %+v", d)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a, b := richSet(6), richSet(6)
+	slices.Reverse(b.URLs)
+	b.URLs[0].Meta = map[string]any{"source": "cms"}
+	if !Equal(a, b, CompareOptions{}) {
+		t.Errorf("order or Meta made sets unequal: %+v", Diff(a, b, CompareOptions{}))
+	}
+	tags := append([]string(nil), b.URLs[2].Videos[0].Tags...)
+	b.URLs[2].Videos[0].Tags = append(tags, "c")
+	if Equal(a, b, CompareOptions{}) || !Equal(a, b, CompareOptions{Ignore: CompareVideos}) {
+		t.Error("video tags not compared, or not masked")
+	}
+	b.URLs[0].Geo = nil
+	if d := Diff(a, b, CompareOptions{Ignore: CompareVideos}); len(d.Changed) != 1 || !slices.Equal(d.Changed[0].Fields, []string{"geo"}) {
+		t.Errorf("geo change = %+v", d.Changed)
+	}
+}
+
+func TestCanonicalLoc(t *testing.T) {
+	tests := []struct {
+		a, b string
+		eq   LocEquivalence
+		want bool
+	}{
+		{"https://example.com/%7Euser", "https://example.com/~user", EquivUnreservedEscapes, true},
+		{"https://example.com/%7Euser", "https://example.com/~user", EquivEscapeCase, false},
+		{"https://example.com/a%2fb", "https://example.com/a%2Fb", EquivEscapeCase, true},
+		{"https://example.com/a%2fb", "https://example.com/a%2Fb", EquivUnreservedEscapes, false},
+		{"https://example.com/a%2Fb", "https://example.com/a/b", EquivAll, false},
+		{"https://bücher.example/straße", "https://xn--bcher-kva.example/stra%C3%9Fe", EquivIDN, true},
+		{"https://EXAMPLE.com/Path", "https://example.com/Path", EquivIDN, true},
+		{"https://EXAMPLE.com/Path", "https://example.com/path", EquivAll, false},
+		{"https://user@EXAMPLE.com/", "https://user@example.com/", EquivIDN, true},
+		{"https://bücher.example/%7e", "https://xn--bcher-kva.example/~", EquivAll, true},
+		{"https://example.com/%7E", "https://example.com/~", 0, false},
+		{"https://example.com/100%", "https://example.com/100%", EquivAll, true},
+	}
+	for _, tt := range tests {
+		if got := CanonicalLoc(tt.a, tt.eq) == CanonicalLoc(tt.b, tt.eq); got != tt.want {
+			t.Errorf("%q vs %q under %b: equivalent = %v, want %v", tt.a, tt.b, tt.eq, got, tt.want)
+		}
+	}
+}
+
+func TestDiffEquivalence(t *testing.T) {
+	a := setOf(t, "https://example.com/%7Ea", "https://bücher.example/")
+	b := setOf(t, "https://example.com/~a", "https://xn--bcher-kva.example/")
+	if Equal(a, b, CompareOptions{}) {
+		t.Error("locs matched without Equivalence")
+	}
+	d := Diff(a, b, CompareOptions{Equivalence: EquivAll})
+	if !d.Empty() {
+		t.Errorf("diff = %+v", d)
+	}
+	b.URLs = b.URLs[:1]
+	if d := Diff(a, b, CompareOptions{Equivalence: EquivAll}); !slices.Equal(d.Removed, []string{"https://bücher.example/"}) {
+		t.Errorf("removed = %q, want the loc as it appears in a", d.Removed)
+	}
+}
+
+func TestDedupLocs(t *testing.T) {
+	set := setOf(t, "https://example.com/%7ea", "https://example.com/~a", "https://EXAMPLE.com/%7Ea", "https://example.com/b")
+	if err := set.Transform(DedupLocs(EquivAll)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"https://example.com/%7ea", "https://example.com/b"}; !slices.Equal(locsOf(set), want) {
+		t.Errorf("got %q, want %q", locsOf(set), want)
+	}
+}
@@ -0,0 +1,94 @@
+package sitemap_go
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+var ErrNoURLColumn = errors.New("coverage export has no URL column")
+
+// coverageURLColumns are the header names Search Console has used for the
+// page column across export formats and UI languages we have seen.
+var coverageURLColumns = []string{"url", "urls", "page", "pages", "top pages"}
+
+// ParseCoverageCSV reads the URLs from a Google Search Console page
+// indexing export. The URL column is found by its header name.
+func ParseCoverageCSV(r io.Reader) ([]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, ErrNoURLColumn
+	}
+	if err != nil {
+		return nil, err
+	}
+	column := -1
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for _, want := range coverageURLColumns {
+			if name == want {
+				column = i
+				break
+			}
+		}
+		if column >= 0 {
+			break
+		}
+	}
+	if column < 0 {
+		return nil, ErrNoURLColumn
+	}
+
+	var locs []string
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return locs, nil
+		}
+		if err != nil {
+			return locs, err
+		}
+		if column < len(record) {
+			if loc := strings.TrimSpace(record[column]); loc != "" {
+				locs = append(locs, loc)
+			}
+		}
+	}
+}
+
+type CoverageGap struct {
+	// SubmittedNotIndexed are locs in the sitemap missing from the export,
+	// in sitemap order.
+	SubmittedNotIndexed []string
+	// IndexedNotSubmitted are exported URLs missing from the sitemap, in
+	// export order.
+	IndexedNotSubmitted []string
+}
+
+// CompareCoverage compares the set with the indexed URLs from a Search
+// Console export, such as those returned by ParseCoverageCSV.
+func (u *URLSet) CompareCoverage(indexed []string) CoverageGap {
+	var gap CoverageGap
+	indexedLocs := make(map[string]bool, len(indexed))
+	for _, loc := range indexed {
+		indexedLocs[loc] = true
+	}
+	submitted := make(map[string]bool, len(u.URLs))
+	for _, entry := range u.URLs {
+		if !submitted[entry.Loc] && !indexedLocs[entry.Loc] {
+			gap.SubmittedNotIndexed = append(gap.SubmittedNotIndexed, entry.Loc)
+		}
+		submitted[entry.Loc] = true
+	}
+	for _, loc := range indexed {
+		if !submitted[loc] {
+			gap.IndexedNotSubmitted = append(gap.IndexedNotSubmitted, loc)
+			submitted[loc] = true
+		}
+	}
+	return gap
+}
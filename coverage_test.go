@@ -0,0 +1,39 @@
+package sitemap_go
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseCoverageCSV(t *testing.T) {
+	export := "\ufeffLast crawled,Top pages,Status\n" +
+		"2024-01-01,https://example.com/a,ok\n" +
+		"2024-01-02, https://example.com/b ,ok\n" +
+		"2024-01-03,,ok\n" +
+		"short\n"
+	locs, err := ParseCoverageCSV(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"https://example.com/a", "https://example.com/b"}; !slices.Equal(locs, want) {
+		t.Errorf("got %q, want %q", locs, want)
+	}
+	for _, doc := range []string{"", "Date,Status\n2024-01-01,ok\n"} {
+		if _, err := ParseCoverageCSV(strings.NewReader(doc)); !errors.Is(err, ErrNoURLColumn) {
+			t.Errorf("%q: err = %v, want ErrNoURLColumn", doc, err)
+		}
+	}
+}
+
+func TestCompareCoverage(t *testing.T) {
+	set := setOf(t, "https://example.com/a", "https://example.com/b", "https://example.com/b", "https://example.com/c")
+	gap := set.CompareCoverage([]string{"https://example.com/c", "https://example.com/x", "https://example.com/x"})
+	if want := []string{"https://example.com/a", "https://example.com/b"}; !slices.Equal(gap.SubmittedNotIndexed, want) {
+		t.Errorf("SubmittedNotIndexed = %q", gap.SubmittedNotIndexed)
+	}
+	if want := []string{"https://example.com/x"}; !slices.Equal(gap.IndexedNotSubmitted, want) {
+		t.Errorf("IndexedNotSubmitted = %q", gap.IndexedNotSubmitted)
+	}
+}
@@ -0,0 +1,576 @@
+package sitemap_go
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxImagesPerPage        = 1000
+	defaultCrawlMaxPages    = 10000
+	defaultCrawlConcurrency = 4
+	maxCrawlBodyBytes       = 10 << 20
+)
+
+// Crawler discovers the pages of a site by following links from a start
+// URL, staying on the start URL's scheme and host. Pages are visited
+// breadth first, one depth level at a time, so the resulting set is the
+// same whatever the concurrency.
+type Crawler struct {
+	HTTPClient *http.Client
+	// UserAgent is sent with every request, and its product token selects
+	// the robots.txt group and meta robots tags that apply.
+	UserAgent string
+	// MaxPages caps the URLs recorded. It defaults to 10000.
+	MaxPages int
+	// MaxDepth limits how many links away from the start URL the crawl
+	// goes. Zero means unlimited.
+	MaxDepth    int
+	Concurrency int
+	// IgnoreRobots disables robots.txt, meta robots and X-Robots-Tag
+	// handling.
+	IgnoreRobots bool
+	// UseCanonical records a page under the URL of its rel="canonical" link
+	// instead of the fetched URL, so parameterized duplicates collapse into
+	// one entry. Pages declaring a canonical on another site are dropped.
+	UseCanonical bool
+	// Redirects decides how a page reached through a redirect is listed.
+	// With the default, RedirectReplace, it is listed under its final
+	// URL.
+	Redirects RedirectPolicy
+	// OnRedirect, when set, is called with every redirected page.
+	OnRedirect func(LinkStatus)
+	// Media controls discovery of image and video extension entries.
+	Media MediaRules
+	// Seed adds URLs, typically from the site's existing sitemap, to the
+	// start of the crawl. A seed's lastmod is used for its page when the
+	// response carries no Last-Modified header.
+	Seed []*URL
+	// Filter decides which pages are recorded. Links and seeds it excludes
+	// are never fetched; pages that are merely outside its include patterns
+	// are still crawled for links.
+	Filter *Filter
+	// RateLimiter paces requests per host. It defaults to a limiter shared
+	// by the package allowing 10 requests per second to each host; use
+	// &RateLimiter{} to disable limiting.
+	RateLimiter *RateLimiter
+	// Progress is called after each page is processed, with the pages
+	// fetched and URLs recorded so far.
+	Progress ProgressFunc
+}
+
+type MediaRules struct {
+	// Images records <img> sources, using alt text as the caption.
+	Images bool
+	// Videos records <video> elements and og:video metadata that carry a
+	// thumbnail, title and description.
+	Videos bool
+	// IncludeImage and IncludeVideo, when set, decide which discovered
+	// entries are kept.
+	IncludeImage func(Image) bool
+	IncludeVideo func(Video) bool
+}
+
+type crawledPage struct {
+	requested *url.URL
+	final     *url.URL
+	status    int
+	// redirectStatus and redirectLocation describe the first response of
+	// a redirected request.
+	redirectStatus   int
+	redirectLocation string
+	header           http.Header
+	tags             []htmlTag
+	isHTML           bool
+	err              error
+}
+
+func (c *Crawler) Crawl(ctx context.Context, start string) (URLSet, error) {
+	out := MakeUrlSet()
+	root, err := url.Parse(start)
+	if err != nil {
+		return out, err
+	}
+	if root.Scheme != "http" && root.Scheme != "https" || root.Host == "" {
+		return out, fmt.Errorf("crawl %s: %w", start, ErrInvalidLoc)
+	}
+	root.Fragment, root.RawFragment = "", ""
+
+	var robots *Robots
+	if !c.IgnoreRobots {
+		if robots, err = c.fetchRobots(ctx, root); err != nil {
+			return out, err
+		}
+		if !robots.Allowed(c.UserAgent, root.String()) {
+			return out, nil
+		}
+	}
+
+	maxPages := c.MaxPages
+	if maxPages <= 0 {
+		maxPages = defaultCrawlMaxPages
+	}
+	seen := map[string]bool{root.String(): true}
+	recorded := map[string]bool{}
+	level := []*url.URL{root}
+	seedLastMod := map[string]time.Time{}
+	for _, u := range c.Seed {
+		link, err := url.Parse(u.Loc)
+		if err != nil || !sameSite(root, link) {
+			continue
+		}
+		link.Fragment, link.RawFragment = "", ""
+		key := link.String()
+		if u.LastMod != nil {
+			seedLastMod[key] = *u.LastMod
+		}
+		if seen[key] || c.Filter.Excluded(key) || (robots != nil && !robots.Allowed(c.UserAgent, key)) {
+			continue
+		}
+		seen[key] = true
+		level = append(level, link)
+	}
+	pages := 0
+	for depth := 0; len(level) > 0; depth++ {
+		var next []*url.URL
+		for _, page := range c.fetchAll(ctx, level) {
+			if err := ctx.Err(); err != nil {
+				return out, err
+			}
+			pages++
+			if c.Progress != nil {
+				c.Progress(Progress{Pages: pages, URLs: len(out.URLs)})
+			}
+			if page.err != nil || !sameSite(root, page.final) {
+				continue
+			}
+			if page.final.String() != page.requested.String() {
+				if c.OnRedirect != nil {
+					c.OnRedirect(page.redirect())
+				}
+				if seen[page.final.String()] {
+					continue
+				}
+				seen[page.final.String()] = true
+			}
+
+			noindex, nofollow := c.robotsDirectives(page)
+			if page.status == http.StatusOK && page.isHTML && !noindex {
+				if loc, ok := c.recordLoc(root, page); ok && !recorded[loc] && c.Filter.Allowed(loc) {
+					recorded[loc] = true
+					entry := MakeUrl(loc, WithLastMod(pageLastMod(page, seedLastMod)))
+					entry.Alternate = pageAlternates(page)
+					if c.Media.Images {
+						entry.Images = pageImages(page, c.Media.IncludeImage)
+					}
+					if c.Media.Videos {
+						entry.Videos = pageVideos(page, c.Media.IncludeVideo)
+					}
+					out.URLs = append(out.URLs, entry)
+					if len(out.URLs) >= maxPages {
+						return out, nil
+					}
+				}
+			}
+			if nofollow || (c.MaxDepth > 0 && depth >= c.MaxDepth) {
+				continue
+			}
+			for _, link := range pageLinks(page) {
+				key := link.String()
+				if seen[key] || !sameSite(root, link) {
+					continue
+				}
+				seen[key] = true
+				if c.Filter.Excluded(key) || (robots != nil && !robots.Allowed(c.UserAgent, key)) {
+					continue
+				}
+				next = append(next, link)
+			}
+		}
+		level = next
+	}
+	return out, nil
+}
+
+// pageLastMod prefers the Last-Modified header, then the seed entry's
+// lastmod, and only then the crawl time.
+func pageLastMod(page crawledPage, seedLastMod map[string]time.Time) time.Time {
+	if t, err := http.ParseTime(page.header.Get("Last-Modified")); err == nil {
+		return t.UTC()
+	}
+	for _, u := range []*url.URL{page.requested, page.final} {
+		if t, ok := seedLastMod[u.String()]; ok {
+			return t
+		}
+	}
+	return time.Now().UTC()
+}
+
+// recordLoc returns the loc a page should be listed under, and false when
+// it should not be listed at all.
+func (c *Crawler) recordLoc(root *url.URL, page crawledPage) (string, bool) {
+	if page.final.String() != page.requested.String() {
+		status := page.redirect()
+		moved := c.Redirects.apply(&URL{Loc: status.Loc}, status)
+		switch {
+		case moved == nil:
+			return "", false
+		case moved.Loc == status.Loc:
+			return status.Loc, true
+		}
+	}
+	if !c.UseCanonical {
+		return page.final.String(), true
+	}
+	canonical := pageCanonical(page)
+	if canonical == nil {
+		return page.final.String(), true
+	}
+	if !sameSite(root, canonical) {
+		return "", false
+	}
+	return canonical.String(), true
+}
+
+// fetchAll fetches pages concurrently, returning results in input order.
+func (c *Crawler) fetchAll(ctx context.Context, pages []*url.URL) []crawledPage {
+	results := make([]crawledPage, len(pages))
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultCrawlConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, page := range pages {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = c.fetchPage(ctx, page)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func (c *Crawler) fetchPage(ctx context.Context, page *url.URL) crawledPage {
+	out := crawledPage{requested: page, final: page}
+	resp, err := c.get(ctx, page.String())
+	if err != nil {
+		out.err = err
+		return out
+	}
+	defer resp.Body.Close()
+
+	out.final = resp.Request.URL
+	if first := resp.Request.Response; first != nil {
+		for first.Request.Response != nil {
+			first = first.Request.Response
+		}
+		out.redirectStatus, out.redirectLocation = first.StatusCode, first.Header.Get("Location")
+	}
+	out.status = resp.StatusCode
+	out.header = resp.Header
+	out.isHTML = strings.Contains(strings.ToLower(resp.Header.Get("Content-Type")), "html")
+	if !out.isHTML || resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+		return out
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCrawlBodyBytes))
+	if err != nil {
+		out.err = err
+		return out
+	}
+	out.tags = scanHTML(body, "a", "base", "meta", "link", "img", "video", "source")
+	return out
+}
+
+func (c *Crawler) get(ctx context.Context, loc string) (*http.Response, error) {
+	if err := waitForHost(ctx, c.RateLimiter, loc); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	client := clientOrBulk(c.HTTPClient)
+	return client.Do(req)
+}
+
+// redirect describes how the page's request was redirected.
+func (page crawledPage) redirect() LinkStatus {
+	return LinkStatus{
+		Loc:         page.requested.String(),
+		StatusCode:  page.redirectStatus,
+		Location:    page.redirectLocation,
+		Final:       page.final.String(),
+		FinalStatus: page.status,
+	}
+}
+
+func (c *Crawler) fetchRobots(ctx context.Context, root *url.URL) (*Robots, error) {
+	return fetchRobotsTxt(ctx, c.HTTPClient, c.UserAgent, root)
+}
+
+// robotsDirectives reads noindex and nofollow from the X-Robots-Tag header
+// and robots meta tags addressed to all crawlers or to our user agent.
+func (c *Crawler) robotsDirectives(page crawledPage) (noindex, nofollow bool) {
+	if c.IgnoreRobots {
+		return false, false
+	}
+	token := strings.ToLower(c.UserAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+	apply := func(directives string) {
+		for _, d := range strings.Split(strings.ToLower(directives), ",") {
+			switch strings.TrimSpace(d) {
+			case "noindex":
+				noindex = true
+			case "nofollow":
+				nofollow = true
+			case "none":
+				noindex, nofollow = true, true
+			}
+		}
+	}
+
+	for _, value := range page.header.Values("X-Robots-Tag") {
+		if agent, rest, ok := strings.Cut(value, ":"); ok {
+			agent = strings.ToLower(strings.TrimSpace(agent))
+			if !isRobotsDirective(agent) {
+				if agent != token {
+					continue
+				}
+				value = rest
+			}
+		}
+		apply(value)
+	}
+	for _, tag := range page.tags {
+		if tag.name != "meta" {
+			continue
+		}
+		name := strings.ToLower(tag.attr("name"))
+		if name == "robots" || (token != "" && name == token) {
+			apply(tag.attr("content"))
+		}
+	}
+	return noindex, nofollow
+}
+
+// isRobotsDirective tells a directive list apart from a user agent prefix
+// in an X-Robots-Tag value such as "googlebot: noindex".
+func isRobotsDirective(s string) bool {
+	if strings.Contains(s, ",") {
+		return true
+	}
+	switch s {
+	case "all", "noindex", "nofollow", "none", "noarchive", "nosnippet", "notranslate", "noimageindex":
+		return true
+	}
+	return strings.HasPrefix(s, "unavailable_after") || strings.HasPrefix(s, "max-")
+}
+
+func pageBase(page crawledPage) *url.URL {
+	for _, tag := range page.tags {
+		if tag.name == "base" && tag.attr("href") != "" {
+			if b, err := page.final.Parse(strings.TrimSpace(tag.attr("href"))); err == nil {
+				return b
+			}
+			break
+		}
+	}
+	return page.final
+}
+
+func pageCanonical(page crawledPage) *url.URL {
+	for _, tag := range page.tags {
+		if tag.name != "link" || !hasRel(tag, "canonical") {
+			continue
+		}
+		href := strings.TrimSpace(tag.attr("href"))
+		if href == "" {
+			continue
+		}
+		canonical, err := pageBase(page).Parse(href)
+		if err != nil || (canonical.Scheme != "http" && canonical.Scheme != "https") {
+			return nil
+		}
+		canonical.Fragment, canonical.RawFragment = "", ""
+		return canonical
+	}
+	return nil
+}
+
+// pageAlternates collects the page's rel="alternate" hreflang links.
+func pageAlternates(page crawledPage) []Alternate {
+	var out []Alternate
+	seen := map[Alternate]bool{}
+	for _, tag := range page.tags {
+		lang := strings.TrimSpace(tag.attr("hreflang"))
+		if tag.name != "link" || lang == "" || !hasRel(tag, "alternate") {
+			continue
+		}
+		href, err := pageBase(page).Parse(strings.TrimSpace(tag.attr("href")))
+		if err != nil || (href.Scheme != "http" && href.Scheme != "https") {
+			continue
+		}
+		alt := Alternate{Rel: "alternate", HrefLang: lang, Href: href.String()}
+		if !seen[alt] {
+			seen[alt] = true
+			out = append(out, alt)
+		}
+	}
+	return out
+}
+
+func pageImages(page crawledPage, include func(Image) bool) []Image {
+	var out []Image
+	seen := map[string]bool{}
+	for _, tag := range page.tags {
+		if tag.name != "img" || len(out) == maxImagesPerPage {
+			continue
+		}
+		src, ok := resolveMedia(page, tag.attr("src"))
+		if !ok || seen[src] {
+			continue
+		}
+		img := Image{Loc: src, Caption: strings.TrimSpace(tag.attr("alt")), Title: strings.TrimSpace(tag.attr("title"))}
+		if include != nil && !include(img) {
+			continue
+		}
+		seen[src] = true
+		out = append(out, img)
+	}
+	return out
+}
+
+// pageVideos builds video entries from <video> elements (with their <source>
+// children) and Open Graph og:video metadata. Title and description fall
+// back to the page's og:title, og:description and meta description.
+func pageVideos(page crawledPage, include func(Video) bool) []Video {
+	og := map[string]string{}
+	for _, tag := range page.tags {
+		if tag.name != "meta" {
+			continue
+		}
+		key := strings.ToLower(tag.attr("property"))
+		if key == "" {
+			key = strings.ToLower(tag.attr("name"))
+		}
+		if _, ok := og[key]; !ok && key != "" {
+			og[key] = strings.TrimSpace(tag.attr("content"))
+		}
+	}
+	title := og["og:title"]
+	description := og["og:description"]
+	if description == "" {
+		description = og["description"]
+	}
+
+	var candidates []Video
+	for _, tag := range page.tags {
+		switch tag.name {
+		case "video":
+			thumb, _ := resolveMedia(page, tag.attr("poster"))
+			content, _ := resolveMedia(page, tag.attr("src"))
+			candidates = append(candidates, Video{
+				ThumbnailLoc: thumb,
+				ContentLoc:   content,
+				Title:        orDefault(strings.TrimSpace(tag.attr("title")), title),
+				Description:  description,
+			})
+		case "source":
+			if n := len(candidates); n > 0 && candidates[n-1].ContentLoc == "" {
+				candidates[n-1].ContentLoc, _ = resolveMedia(page, tag.attr("src"))
+			}
+		}
+	}
+	for _, key := range []string{"og:video:secure_url", "og:video:url", "og:video"} {
+		content, ok := resolveMedia(page, og[key])
+		if !ok {
+			continue
+		}
+		thumb, _ := resolveMedia(page, og["og:image"])
+		v := Video{ThumbnailLoc: thumb, ContentLoc: content, Title: title, Description: description}
+		if d, err := strconv.Atoi(og["video:duration"]); err == nil {
+			v.Duration = d
+		}
+		candidates = append(candidates, v)
+		break
+	}
+
+	var out []Video
+	seen := map[string]bool{}
+	for _, v := range candidates {
+		if v.ContentLoc == "" || v.ThumbnailLoc == "" || v.Title == "" || v.Description == "" || seen[v.ContentLoc] {
+			continue
+		}
+		if include != nil && !include(v) {
+			continue
+		}
+		seen[v.ContentLoc] = true
+		out = append(out, v)
+	}
+	return out
+}
+
+func resolveMedia(page crawledPage, ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "data:") {
+		return "", false
+	}
+	u, err := pageBase(page).Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+	return u.String(), true
+}
+
+func hasRel(tag htmlTag, rel string) bool {
+	for _, r := range strings.Fields(strings.ToLower(tag.attr("rel"))) {
+		if r == rel {
+			return true
+		}
+	}
+	return false
+}
+
+// pageLinks resolves the page's anchors against its base URL, dropping
+// fragments and non-HTTP schemes.
+func pageLinks(page crawledPage) []*url.URL {
+	base := pageBase(page)
+	var links []*url.URL
+	for _, tag := range page.tags {
+		if tag.name != "a" {
+			continue
+		}
+		href := strings.TrimSpace(tag.attr("href"))
+		if href == "" || strings.HasPrefix(href, "#") {
+			continue
+		}
+		link, err := base.Parse(href)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+			continue
+		}
+		link.Fragment, link.RawFragment = "", ""
+		links = append(links, link)
+	}
+	return links
+}
+
+func sameSite(root, u *url.URL) bool {
+	return strings.EqualFold(root.Scheme, u.Scheme) && canonicalHost(root) == canonicalHost(u)
+}
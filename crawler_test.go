@@ -0,0 +1,248 @@
+package sitemap_go
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// siteServer serves pages by path as HTML, except robots.txt, with
+// "{site}" in a body replaced by the server's URL. Other paths are 404s.
+func siteServer(t *testing.T, pages map[string]string) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/robots.txt" {
+			w.Header().Set("Content-Type", "text/plain")
+		} else {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		}
+		w.Write([]byte(strings.ReplaceAll(body, "{site}", srv.URL)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func crawl(t *testing.T, c *Crawler, start string) []string {
+	t.Helper()
+	c.RateLimiter = &RateLimiter{}
+	set, err := c.Crawl(context.Background(), start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return locsOf(&set)
+}
+
+func TestCrawlFollowsLinks(t *testing.T) {
+	srv := siteServer(t, map[string]string{
+		"/robots.txt": "User-agent: *\nDisallow: /private\n",
+		"/":           `<a href="/a">a</a> <a href="b#top">b</a> <a href="https://other.example.com/">x</a> <a href="mailto:x@example.com">m</a> <a href="/private/p">p</a>`,
+		"/a":          `<!-- <a href="/commented"> --><script>"<a href='/scripted'>"</script><a href="/a/deep">deep</a>`,
+		"/b":          `<meta name="robots" content="noindex"><a href="/c">c</a>`,
+		"/c":          `<meta name="robots" content="nofollow"><a href="/not-followed">n</a>`,
+		"/a/deep":     `<a href="/">home</a> <a href="/missing">gone</a>`,
+		"/private/p":  `private`,
+	})
+	got := crawl(t, &Crawler{}, srv.URL+"/")
+	want := []string{srv.URL + "/", srv.URL + "/a", srv.URL + "/a/deep", srv.URL + "/c"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+
+	got = crawl(t, &Crawler{MaxDepth: 1}, srv.URL+"/")
+	if want := []string{srv.URL + "/", srv.URL + "/a"}; !slices.Equal(got, want) {
+		t.Errorf("MaxDepth 1: got %q", got)
+	}
+	if got := crawl(t, &Crawler{MaxPages: 2}, srv.URL+"/"); len(got) != 2 {
+		t.Errorf("MaxPages 2: got %q", got)
+	}
+	if got := crawl(t, &Crawler{IgnoreRobots: true}, srv.URL+"/"); !slices.Contains(got, srv.URL+"/private/p") || !slices.Contains(got, srv.URL+"/b") {
+		t.Errorf("IgnoreRobots: got %q", got)
+	}
+}
+
+func TestCrawlConcurrencyDeterministic(t *testing.T) {
+	pages := map[string]string{"/": ""}
+	for _, p := range []string{"/1", "/2", "/3", "/4", "/5", "/6"} {
+		pages["/"] += `<a href="` + p + `">x</a>`
+		pages[p] = `<a href="` + p + `/child">c</a>`
+		pages[p+"/child"] = ""
+	}
+	srv := siteServer(t, pages)
+	first := crawl(t, &Crawler{Concurrency: 1}, srv.URL+"/")
+	for range 5 {
+		if got := crawl(t, &Crawler{Concurrency: 8}, srv.URL+"/"); !slices.Equal(got, first) {
+			t.Fatalf("got %q, want %q", got, first)
+		}
+	}
+}
+
+func TestCrawlXRobotsTag(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<a href="/all">a</a><a href="/bot">b</a><a href="/otherbot">c</a>`))
+		case "/all":
+			w.Header().Set("X-Robots-Tag", "noindex")
+		case "/bot":
+			w.Header().Set("X-Robots-Tag", "testbot: noindex, nofollow")
+		case "/otherbot":
+			w.Header().Set("X-Robots-Tag", "otherbot: noindex")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	got := crawl(t, &Crawler{UserAgent: "TestBot/1.0"}, srv.URL+"/")
+	if want := []string{srv.URL + "/", srv.URL + "/otherbot"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestScanHTML(t *testing.T) {
+	doc := `<A HREF='/a' data-x=1><img src=/i.jpg alt="a &amp; b"><style>a{}</style><a href="/b" rel="next nofollow">`
+	tags := scanHTML([]byte(doc), "a", "img")
+	if len(tags) != 3 {
+		t.Fatalf("got %d tags: %+v", len(tags), tags)
+	}
+	if tags[0].attr("href") != "/a" || tags[1].attr("alt") != "a & b" || tags[1].attr("src") != "/i.jpg" || !hasRel(tags[2], "nofollow") {
+		t.Errorf("tags = %+v", tags)
+	}
+}
+
+func TestCrawlUseCanonical(t *testing.T) {
+	srv := siteServer(t, map[string]string{
+		"/":          `<a href="/p?utm=1">p</a><a href="/p?utm=2">p</a><a href="/elsewhere">e</a><a href="/self">s</a>`,
+		"/p?utm=1":   `<link rel="canonical" href="/p#frag">`,
+		"/p?utm=2":   `<base href="{site}/dir/"><link rel="Canonical" href="../p">`,
+		"/elsewhere": `<link rel="canonical" href="https://other.example.com/e">`,
+		"/self":      `<link rel="canonical" href="mailto:x@example.com">`,
+	})
+	got := crawl(t, &Crawler{UseCanonical: true}, srv.URL+"/")
+	want := []string{srv.URL + "/", srv.URL + "/p", srv.URL + "/self"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+	got = crawl(t, &Crawler{}, srv.URL+"/")
+	if len(got) != 5 {
+		t.Errorf("without UseCanonical: got %q", got)
+	}
+}
+
+func TestCrawlHreflang(t *testing.T) {
+	srv := siteServer(t, map[string]string{
+		"/": `<link rel="alternate" hreflang="en" href="/">
+<link rel="alternate" hreflang="de" href="/de/">
+<link rel="alternate" hreflang="de" href="/de/">
+<link rel="alternate" hreflang="x-default" href="https://example.com/">
+<link rel="alternate" type="application/rss+xml" href="/feed">
+<link rel="stylesheet" hreflang="fr" href="/fr.css">`,
+	})
+	c := &Crawler{RateLimiter: &RateLimiter{}}
+	set, err := c.Crawl(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Alternate{
+		{Rel: "alternate", HrefLang: "en", Href: srv.URL + "/"},
+		{Rel: "alternate", HrefLang: "de", Href: srv.URL + "/de/"},
+		{Rel: "alternate", HrefLang: "x-default", Href: "https://example.com/"},
+	}
+	if got := set.URLs[0].Alternate; !slices.Equal(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestCrawlMedia(t *testing.T) {
+	srv := siteServer(t, map[string]string{
+		"/": `<meta property="og:title" content="Page">
+<meta name="description" content="About the page">
+<meta property="og:video" content="/og.mp4">
+<meta property="og:image" content="/og.jpg">
+<meta property="video:duration" content="90">
+<img src="/a.jpg" alt="A" title="T"><img src="/a.jpg"><img src="data:image/png;base64,xx"><img src="/skip.gif">
+<video poster="/poster.jpg" title="Clip"><source src="/clip.mp4"></video>
+<video src="/no-poster.mp4"></video>`,
+	})
+	c := &Crawler{Media: MediaRules{
+		Images:       true,
+		Videos:       true,
+		IncludeImage: func(img Image) bool { return !strings.HasSuffix(img.Loc, ".gif") },
+	}}
+	c.RateLimiter = &RateLimiter{}
+	set, err := c.Crawl(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := set.URLs[0]
+	if want := []Image{{Loc: srv.URL + "/a.jpg", Caption: "A", Title: "T"}}; !slices.Equal(u.Images, want) {
+		t.Errorf("images = %+v", u.Images)
+	}
+	if len(u.Videos) != 2 {
+		t.Fatalf("videos = %+v", u.Videos)
+	}
+	clip, og := u.Videos[0], u.Videos[1]
+	if clip.ContentLoc != srv.URL+"/clip.mp4" || clip.ThumbnailLoc != srv.URL+"/poster.jpg" || clip.Title != "Clip" || clip.Description != "About the page" {
+		t.Errorf("video element: %+v", clip)
+	}
+	if og.ContentLoc != srv.URL+"/og.mp4" || og.ThumbnailLoc != srv.URL+"/og.jpg" || og.Title != "Page" || og.Duration != 90 {
+		t.Errorf("og:video: %+v", og)
+	}
+
+	set, _ = (&Crawler{RateLimiter: &RateLimiter{}}).Crawl(context.Background(), srv.URL+"/")
+	if len(set.URLs[0].Images) != 0 || len(set.URLs[0].Videos) != 0 {
+		t.Error("media recorded without MediaRules")
+	}
+}
+
+func TestCrawlLastMod(t *testing.T) {
+	modified := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	seeded := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		case "/seeded", "/unknown":
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c := &Crawler{
+		RateLimiter: &RateLimiter{},
+		Seed: []*URL{
+			MakeUrl(srv.URL+"/seeded", WithLastMod(seeded)),
+			MakeUrl(srv.URL + "/unknown"),
+			MakeUrl("https://other.example.com/", WithLastMod(seeded)),
+		},
+	}
+	c.Seed[1].LastMod = nil
+	before := time.Now().Add(-time.Second)
+	set, err := c.Crawl(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.URLs) != 3 {
+		t.Fatalf("got %q", locsOf(&set))
+	}
+	if !set.URLs[0].LastMod.Equal(modified) {
+		t.Errorf("Last-Modified: got %v", set.URLs[0].LastMod)
+	}
+	if !set.URLs[1].LastMod.Equal(seeded) {
+		t.Errorf("seed lastmod: got %v", set.URLs[1].LastMod)
+	}
+	if set.URLs[2].LastMod.Before(before) {
+		t.Errorf("crawl time: got %v", set.URLs[2].LastMod)
+	}
+}
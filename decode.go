@@ -0,0 +1,372 @@
+package sitemap_go
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// w3cLayouts are the W3C Datetime profiles allowed for lastmod, most
+// specific first.
+var w3cLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+func parseW3CTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range w3cLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid W3C datetime %q", s)
+}
+
+// ParseError locates a decoding failure in the input. Line and Column are
+// 1-based and point just past the start tag of the offending element.
+type ParseError struct {
+	Line   int
+	Column int
+	Offset int64
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parseErrorAt wraps err with the decoder's current position; see
+// locateError.
+func parseErrorAt(d *xml.Decoder, err error) error {
+	line, column := d.InputPos()
+	return locateError(line, column, d.InputOffset(), err)
+}
+
+// locateError wraps err in a ParseError, leaving nil errors and those that
+// already carry a position alone.
+func locateError(line, column int, offset int64, err error) error {
+	var located *ParseError
+	var syntax *xml.SyntaxError
+	if err == nil || errors.As(err, &located) || errors.As(err, &syntax) {
+		return err
+	}
+	return &ParseError{Line: line, Column: column, Offset: offset, Err: err}
+}
+
+type ParseOptions struct {
+	// LenientChangeFreq maps changefreq values case-insensitively with
+	// ParseChangeFreqLenient instead of rejecting anything but the
+	// protocol's lowercase values.
+	LenientChangeFreq bool
+	// MaxBytes caps the size of the input. It defaults to MaxSitemapBytes;
+	// a negative value removes the cap.
+	MaxBytes int64
+	// MaxDepth caps element nesting, counting the root as 1. It defaults to
+	// 64; a negative value removes the cap.
+	MaxDepth int
+	// MaxTokenBytes caps the size of any single text, attribute value or
+	// comment. It defaults to 1 MiB; a negative value removes the cap.
+	MaxTokenBytes int
+}
+
+// ParseXMLUrlSetWithOptions is ParseXMLUrlSet with control over how
+// strictly entries are decoded and how much input is accepted.
+func ParseXMLUrlSetWithOptions(content string, opts ParseOptions) (URLSet, error) {
+	return decodeURLSet(strings.NewReader(content), -1, opts)
+}
+
+// decodeSitemapIndex decodes a sitemapindex token by token under opts'
+// limits.
+func decodeSitemapIndex(r io.Reader, opts ParseOptions) (SitemapIndex, error) {
+	var out SitemapIndex
+	lim := opts.limits()
+	dec := xml.NewDecoder(lim.reader(r))
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF && depth == 0 {
+			return out, err
+		}
+		if err != nil {
+			return out, parseErrorAt(dec, err)
+		}
+		if err := lim.check(tok, depth+1); err != nil {
+			return out, parseErrorAt(dec, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				if t.Name.Local != "sitemapindex" {
+					return out, parseErrorAt(dec, fmt.Errorf("expected element type <sitemapindex> but have <%s>", t.Name.Local))
+				}
+				out.XMLName = t.Name
+				for _, attr := range t.Attr {
+					switch {
+					case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+						out.XMLNS = attr.Value
+					case attr.Name.Space == "xmlns":
+						out.Namespaces = append(out.Namespaces, Namespace{Prefix: attr.Name.Local, URI: attr.Value})
+					}
+				}
+				depth++
+				continue
+			}
+			if t.Name.Local != "sitemap" {
+				if _, err := lim.readElement(dec, t, 2, false); err != nil {
+					return out, parseErrorAt(dec, err)
+				}
+				continue
+			}
+			var entry SitemapEntry
+			if err := lim.decodeElement(dec, t, 2, &entry); err != nil {
+				return out, parseErrorAt(dec, err)
+			}
+			out.Sitemaps = append(out.Sitemaps, entry)
+		case xml.EndElement:
+			return out, nil
+		}
+	}
+}
+
+// UnmarshalXML decodes a sitemap element, keeping children other than loc
+// and lastmod in Extensions.
+func (e *SitemapEntry) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*e = SitemapEntry{}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			if !protocolField(t.Name, "loc", "lastmod") {
+				el, err := decodeElementTree(d, t)
+				if err != nil {
+					return err
+				}
+				e.Extensions = append(e.Extensions, el)
+				continue
+			}
+			var text string
+			if err := d.DecodeElement(&text, &t); err != nil {
+				return err
+			}
+			text = strings.TrimSpace(text)
+			if t.Name.Local == "loc" {
+				e.Loc = text
+				continue
+			}
+			lastMod, err := parseW3CTime(text)
+			if err != nil {
+				return fmt.Errorf("<lastmod>: %w", err)
+			}
+			e.LastMod = &lastMod
+		}
+	}
+}
+
+// UnmarshalXML decodes a video element, reading publication_date and
+// expiration_date as W3C datetimes like lastmod, so date-only values are
+// accepted.
+func (v *Video) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain Video
+	var raw struct {
+		plain
+		PublicationDate string `xml:"publication_date"`
+		ExpirationDate  string `xml:"expiration_date"`
+	}
+	if err := d.DecodeElement(&raw, &start); err != nil {
+		return err
+	}
+	*v = Video(raw.plain)
+	for _, field := range []struct {
+		name string
+		text string
+		dst  **time.Time
+	}{
+		{"publication_date", raw.PublicationDate, &v.PublicationDate},
+		{"expiration_date", raw.ExpirationDate, &v.ExpirationDate},
+	} {
+		if strings.TrimSpace(field.text) == "" {
+			continue
+		}
+		t, err := parseW3CTime(field.text)
+		if err != nil {
+			return fmt.Errorf("<%s>: %w", field.name, err)
+		}
+		*field.dst = &t
+	}
+	return nil
+}
+
+func decodeElementTree(d *xml.Decoder, start xml.StartElement) (Element, error) {
+	el := Element{Name: start.Name, Attrs: slices.Clone(start.Attr)}
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return el, err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			child, err := decodeElementTree(d, t)
+			if err != nil {
+				return el, err
+			}
+			el.Children = append(el.Children, child)
+		case xml.EndElement:
+			el.Text = text.String()
+			if len(el.Children) > 0 && strings.TrimSpace(el.Text) == "" {
+				el.Text = ""
+			}
+			return el, nil
+		}
+	}
+}
+
+// UnmarshalXML decodes a url element by namespace rather than by local name
+// alone, so extension elements are recognised whatever prefix the document
+// binds them to. Undeclared image:, video:, geo: and xhtml: prefixes are accepted
+// as well, since they are common in hand-written sitemaps. The element is
+// taken to sit below a urlset root for the default ParseOptions limits.
+func (u *URL) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return u.decode(d, ParseOptions{}, 2)
+}
+
+// decode decodes the children of a url element at depth.
+func (u *URL) decode(d *xml.Decoder, opts ParseOptions, depth int) error {
+	*u = URL{}
+	lim := opts.limits()
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		if err := lim.check(tok, depth+1); err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			if err := u.decodeChild(d, t, lim, opts, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (u *URL) decodeChild(d *xml.Decoder, start xml.StartElement, lim parseLimits, opts ParseOptions, depth int) error {
+	line, column := d.InputPos()
+	offset := d.InputOffset()
+	err := u.decodeField(d, start, lim, opts, depth)
+	if err != nil {
+		err = fmt.Errorf("<%s>: %w", start.Name.Local, err)
+	}
+	return locateError(line, column, offset, err)
+}
+
+func (u *URL) decodeField(d *xml.Decoder, start xml.StartElement, lim parseLimits, opts ParseOptions, depth int) error {
+	switch {
+	case inNamespace(start.Name, ImageNamespace, "image") && start.Name.Local == "image":
+		var img Image
+		if err := lim.decodeElement(d, start, depth, &img); err != nil {
+			return err
+		}
+		u.Images = append(u.Images, img)
+		return nil
+	case inNamespace(start.Name, VideoNamespace, "video") && start.Name.Local == "video":
+		var v Video
+		if err := lim.decodeElement(d, start, depth, &v); err != nil {
+			return err
+		}
+		u.Videos = append(u.Videos, v)
+		return nil
+	case inNamespace(start.Name, GeoNamespace, "geo") && start.Name.Local == "geo":
+		var geo Geo
+		if err := lim.decodeElement(d, start, depth, &geo); err != nil {
+			return err
+		}
+		geo.Format = GeoFormat(strings.TrimSpace(string(geo.Format)))
+		u.Geo = &geo
+		return nil
+	case inNamespace(start.Name, XHTMLNamespace, "xhtml") && start.Name.Local == "link":
+		var alt Alternate
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "rel":
+				alt.Rel = attr.Value
+			case "hreflang":
+				alt.HrefLang = attr.Value
+			case "href":
+				alt.Href = attr.Value
+			}
+		}
+		u.Alternate = append(u.Alternate, alt)
+		_, err := lim.readElement(d, start, depth, false)
+		return err
+	case !protocolField(start.Name, "loc", "lastmod", "changefreq", "priority"):
+		_, err := lim.readElement(d, start, depth, false)
+		return err
+	}
+
+	var text string
+	if err := lim.decodeElement(d, start, depth, &text); err != nil {
+		return err
+	}
+	text = strings.TrimSpace(text)
+	var err error
+	switch start.Name.Local {
+	case "loc":
+		u.Loc = text
+	case "lastmod":
+		t, err := parseW3CTime(text)
+		if err != nil {
+			return err
+		}
+		u.LastMod = &t
+	case "changefreq":
+		parse := ParseChangeFreq
+		if opts.LenientChangeFreq {
+			parse = ParseChangeFreqLenient
+		}
+		if u.ChangeFreq, err = parse(text); err != nil {
+			return err
+		}
+	case "priority":
+		p, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("invalid priority %q", text)
+		}
+		u.Priority = &p
+	}
+	return nil
+}
+
+// protocolField reports whether name is one of the fields of the sitemap
+// protocol. Any namespace but an extension's will do, as documents
+// declare misspelt or legacy ones, such as Google's 0.84 schema.
+func protocolField(name xml.Name, fields ...string) bool {
+	return !extensionSpaces[name.Space] && slices.Contains(fields, name.Local)
+}
+
+func inNamespace(name xml.Name, uri, prefix string) bool {
+	return name.Space == uri || name.Space == prefix
+}
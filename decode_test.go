@@ -0,0 +1,270 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const videoSitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:video="http://www.google.com/schemas/sitemap-video/1.1">
+  <url>
+    <loc>https://example.com/watch</loc>
+    <video:video>
+      <video:thumbnail_loc>https://example.com/thumb.jpg</video:thumbnail_loc>
+      <video:title>Title</video:title>
+      <video:description>Description</video:description>
+      <video:content_loc>https://example.com/video.mp4</video:content_loc>
+      <video:expiration_date>2025-06-30T12:00:00+02:00</video:expiration_date>
+      <video:publication_date>2024-01-02</video:publication_date>
+      <video:tag>a</video:tag>
+      <video:category>Cat</video:category>
+    </video:video>
+  </url>
+</urlset>`
+
+func TestParseVideoW3CDates(t *testing.T) {
+	set, err := ParseXMLUrlSet(videoSitemap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.URLs) != 1 || len(set.URLs[0].Videos) != 1 {
+		t.Fatalf("got %d urls", len(set.URLs))
+	}
+	v := set.URLs[0].Videos[0]
+	if want := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC); v.PublicationDate == nil || !v.PublicationDate.Equal(want) {
+		t.Errorf("publication date = %v, want %v", v.PublicationDate, want)
+	}
+	if want := time.Date(2025, 6, 30, 10, 0, 0, 0, time.UTC); v.ExpirationDate == nil || !v.ExpirationDate.Equal(want) {
+		t.Errorf("expiration date = %v, want %v", v.ExpirationDate, want)
+	}
+	if v.Title != "Title" || v.Category != "Cat" || len(v.Tags) != 1 {
+		t.Errorf("other fields lost: %+v", v)
+	}
+}
+
+func TestVideoRoundTrip(t *testing.T) {
+	set, err := ParseXMLUrlSet(videoSitemap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := set.Encode(&buf, EncodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseXMLUrlSet(buf.String())
+	if err != nil {
+		t.Fatalf("%v\n%s", err, buf.String())
+	}
+	if !Equal(&set, &again, CompareOptions{}) {
+		t.Errorf("round trip changed the set:\n%s", buf.String())
+	}
+}
+
+func TestParseVideoInvalidDate(t *testing.T) {
+	doc := strings.Replace(videoSitemap, "2024-01-02", "January 2nd", 1)
+	if _, err := ParseXMLUrlSet(doc); err == nil || !strings.Contains(err.Error(), "publication_date") {
+		t.Errorf("err = %v, want a publication_date error", err)
+	}
+}
+
+func TestParseExtensions(t *testing.T) {
+	// Prefixes are bound by namespace, not by name.
+	const doc = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+  xmlns:i="http://www.google.com/schemas/sitemap-image/1.1"
+  xmlns:h="http://www.w3.org/1999/xhtml">
+  <url>
+    <loc>https://example.com/</loc>
+    <i:image><i:loc>https://example.com/a.jpg</i:loc><i:caption>A</i:caption></i:image>
+    <i:image><i:loc>https://example.com/b.jpg</i:loc></i:image>
+    <h:link rel="alternate" hreflang="de" href="https://example.com/de/"/>
+  </url>
+</urlset>`
+	set, err := ParseXMLUrlSet(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := set.URLs[0]
+	if len(u.Images) != 2 || u.Images[0].Loc != "https://example.com/a.jpg" || u.Images[0].Caption != "A" {
+		t.Errorf("images = %+v", u.Images)
+	}
+	if len(u.Alternate) != 1 || u.Alternate[0] != (Alternate{Rel: "alternate", HrefLang: "de", Href: "https://example.com/de/"}) {
+		t.Errorf("alternates = %+v", u.Alternate)
+	}
+}
+
+func TestParseProtocolNamespaces(t *testing.T) {
+	for _, xmlns := range []string{"", "https://www.sitemaps.org/schemas/sitemap/0.9", "http://www.google.com/schemas/sitemap/0.84"} {
+		doc := `<urlset xmlns="` + xmlns + `" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+  <url>
+    <loc>https://example.com/</loc>
+    <image:loc>https://example.com/a.jpg</image:loc>
+    <lastmod>2024-01-02</lastmod>
+    <changefreq>daily</changefreq>
+    <priority>0.8</priority>
+  </url>
+</urlset>`
+		set, err := ParseXMLUrlSet(doc)
+		if err != nil {
+			t.Fatalf("%q: %v", xmlns, err)
+		}
+		u := set.URLs[0]
+		if u.Loc != "https://example.com/" || u.LastMod == nil || u.ChangeFreq != ChangeFreqDaily || u.Priority == nil || *u.Priority != 0.8 {
+			t.Errorf("%q: got %+v", xmlns, u)
+		}
+	}
+}
+
+func TestExtensionRoundTrip(t *testing.T) {
+	set := richSet(6)
+	out, err := set.GenerateXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseXMLUrlSet(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := parsed.GenerateXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != out {
+		t.Errorf("round trip changed the document:\n%s\nwant\n%s", again, out)
+	}
+}
+
+func TestGeo(t *testing.T) {
+	const doc = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:g="http://www.google.com/geo/schemas/sitemap/1.0">
+  <url><loc>https://example.com/map.kml</loc><g:geo><g:format> kml </g:format></g:geo></url>
+  <url><loc>https://example.com/feed</loc><geo><format>georss</format></geo></url>
+</urlset>`
+	set, err := ParseXMLUrlSet(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := set.URLs[0]; u.Geo == nil || u.Geo.Format != GeoFormatKML {
+		t.Errorf("geo = %+v", u.Geo)
+	}
+	if u := set.URLs[1]; u.Geo != nil {
+		t.Errorf("geo outside its namespace decoded: %+v", u.Geo)
+	}
+
+	out := MakeUrlSet()
+	out.URLs = []*URL{MakeUrl("https://example.com/feed", WithGeo(GeoFormatGeoRSS))}
+	var buf bytes.Buffer
+	if err := out.Encode(&buf, EncodeOptions{Compact: true}); err != nil {
+		t.Fatal(err)
+	}
+	if xml := buf.String(); !strings.Contains(xml, `xmlns:geo="`+GeoNamespace+`"`) || !strings.Contains(xml, "<geo:geo><geo:format>georss</geo:format></geo:geo>") {
+		t.Errorf("geo not encoded:\n%s", xml)
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	tests := []struct {
+		doc          string
+		line, column int
+		tag          string
+		msg          string
+	}{
+		{"<urlset>\n  <url>\n    <loc>https://example.com/</loc>\n    <priority>high</priority>\n  </url>\n</urlset>", 4, 15, "<priority>", `<priority>: invalid priority "high"`},
+		{"<urlset><url><loc>https://example.com/</loc></url>\n<url><lastmod>yesterday</lastmod></url></urlset>", 2, 15, "<lastmod>", `<lastmod>: invalid W3C datetime "yesterday"`},
+		{"\n<sitemapindex></sitemapindex>", 2, 15, "<sitemapindex>", "expected element type <urlset> but have <sitemapindex>"},
+	}
+	for _, tt := range tests {
+		_, err := ParseXMLUrlSet(tt.doc)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: err = %v, want a ParseError", tt.doc, err)
+			continue
+		}
+		if parseErr.Line != tt.line || parseErr.Column != tt.column || !strings.HasSuffix(tt.doc[:parseErr.Offset], tt.tag) {
+			t.Errorf("%q: at line %d, column %d, offset %d", tt.doc, parseErr.Line, parseErr.Column, parseErr.Offset)
+		}
+		if want := fmt.Sprintf("line %d, column %d: %s", tt.line, tt.column, tt.msg); err.Error() != want {
+			t.Errorf("message = %q, want %q", err, want)
+		}
+	}
+
+	// Syntax errors carry their own line and are not wrapped again.
+	_, err := ParseXMLUrlSet("<urlset><url><loc>a</url></urlset>")
+	var parseErr *ParseError
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &parseErr) || !errors.As(err, &syntaxErr) {
+		t.Errorf("syntax error: %T %v", err, err)
+	}
+}
+
+func TestSitemapIndexExtensions(t *testing.T) {
+	const doc = `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:x="https://example.com/ns">
+  <sitemap>
+    <loc>https://example.com/sitemap-1.xml</loc>
+    <lastmod>2024-05-01</lastmod>
+    <x:meta x:kind="products"><x:count>120</x:count></x:meta>
+    <note>hand edited</note>
+  </sitemap>
+</sitemapindex>`
+	index, err := ParseXMLSitemapIndex(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Namespaces) != 1 || index.Namespaces[0] != (Namespace{Prefix: "x", URI: "https://example.com/ns"}) {
+		t.Errorf("namespaces = %+v", index.Namespaces)
+	}
+	entry := index.Sitemaps[0]
+	if entry.LastMod == nil || !entry.LastMod.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("lastmod = %v", entry.LastMod)
+	}
+	if len(entry.Extensions) != 2 {
+		t.Fatalf("extensions = %+v", entry.Extensions)
+	}
+	meta := entry.Extensions[0]
+	ns := "https://example.com/ns"
+	if meta.Name != (xml.Name{Space: ns, Local: "meta"}) || len(meta.Attrs) != 1 || meta.Attrs[0].Value != "products" ||
+		len(meta.Children) != 1 || meta.Children[0].Name.Local != "count" || meta.Children[0].Text != "120" {
+		t.Errorf("meta = %+v", meta)
+	}
+	if note := entry.Extensions[1]; note.Name.Local != "note" || note.Text != "hand edited" {
+		t.Errorf("note = %+v", note)
+	}
+
+	out, err := index.GenerateXMLWithOptions(EncodeOptions{Compact: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<lastmod>2024-05-01T00:00:00Z</lastmod><x:meta x:kind="products"><x:count>120</x:count></x:meta><note>hand edited</note></sitemap>`; !strings.Contains(out, want) {
+		t.Errorf("encoded:\n%s\nwant it to contain %s", out, want)
+	}
+	again, err := ParseXMLSitemapIndex(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out2, _ := again.GenerateXMLWithOptions(EncodeOptions{Compact: true}); out2 != out {
+		t.Errorf("round trip changed the index:\n%s\n%s", out, out2)
+	}
+}
+
+func TestSitemapIndexProtocolNamespaces(t *testing.T) {
+	for _, xmlns := range []string{"", "https://www.sitemaps.org/schemas/sitemap/0.9", "http://www.google.com/schemas/sitemap/0.84"} {
+		doc := `<sitemapindex xmlns="` + xmlns + `" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+  <sitemap>
+    <loc>https://example.com/sitemap-1.xml</loc>
+    <lastmod>2024-05-01</lastmod>
+    <image:loc>https://example.com/a.jpg</image:loc>
+  </sitemap>
+</sitemapindex>`
+		index, err := ParseXMLSitemapIndex(doc)
+		if err != nil {
+			t.Fatalf("%q: %v", xmlns, err)
+		}
+		entry := index.Sitemaps[0]
+		if entry.Loc != "https://example.com/sitemap-1.xml" || entry.LastMod == nil || len(entry.Extensions) != 1 || entry.Extensions[0].Name.Local != "loc" {
+			t.Errorf("%q: got %+v", xmlns, entry)
+		}
+	}
+}
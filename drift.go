@@ -0,0 +1,71 @@
+package sitemap_go
+
+import (
+	"context"
+	"time"
+)
+
+type LastModRegression struct {
+	Loc       string
+	Live      time.Time
+	Generated time.Time
+}
+
+// DriftReport compares a live sitemap with a freshly generated one.
+type DriftReport struct {
+	LiveOnly      []string
+	GeneratedOnly []string
+	// LastModRegressions lists URLs whose generated lastmod is older than
+	// the one currently served.
+	LastModRegressions []LastModRegression
+}
+
+func (r DriftReport) HasDrift() bool {
+	return len(r.LiveOnly) > 0 || len(r.GeneratedOnly) > 0 || len(r.LastModRegressions) > 0
+}
+
+// CompareDrift reports how generated differs from live. Locs are listed in
+// the order they appear in their set.
+func CompareDrift(live, generated *URLSet) DriftReport {
+	var report DriftReport
+	liveByLoc := make(map[string]*URL, len(live.URLs))
+	for _, u := range live.URLs {
+		liveByLoc[u.Loc] = u
+	}
+	generatedLocs := make(map[string]bool, len(generated.URLs))
+	for _, u := range generated.URLs {
+		generatedLocs[u.Loc] = true
+		l, ok := liveByLoc[u.Loc]
+		if !ok {
+			report.GeneratedOnly = append(report.GeneratedOnly, u.Loc)
+			continue
+		}
+		if l.LastMod != nil && u.LastMod != nil && u.LastMod.Before(*l.LastMod) {
+			report.LastModRegressions = append(report.LastModRegressions, LastModRegression{
+				Loc:       u.Loc,
+				Live:      *l.LastMod,
+				Generated: *u.LastMod,
+			})
+		}
+	}
+	for _, u := range live.URLs {
+		if !generatedLocs[u.Loc] {
+			report.LiveOnly = append(report.LiveOnly, u.Loc)
+		}
+	}
+	return report
+}
+
+// Drift fetches the live sitemaps at liveLocs, resolving any index among
+// them, and compares their combined URLs with generated.
+func (f *Fetcher) Drift(ctx context.Context, generated *URLSet, liveLocs ...string) (DriftReport, error) {
+	live := MakeUrlSet()
+	for _, loc := range liveLocs {
+		set, err := f.FetchSitemap(ctx, loc)
+		if err != nil {
+			return DriftReport{}, err
+		}
+		live.URLs = append(live.URLs, set.URLs...)
+	}
+	return CompareDrift(&live, generated), nil
+}
@@ -0,0 +1,46 @@
+package sitemap_go
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCompareDrift(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	live := setOf(t, "https://example.com/a", "https://example.com/b", "https://example.com/gone")
+	generated := setOf(t, "https://example.com/new", "https://example.com/a", "https://example.com/b")
+	older, newer := day.Add(-time.Hour), day.Add(time.Hour)
+	live.URLs[0].LastMod, generated.URLs[1].LastMod = &day, &older
+	live.URLs[1].LastMod, generated.URLs[2].LastMod = &day, &newer
+
+	report := CompareDrift(live, generated)
+	if !report.HasDrift() {
+		t.Error("HasDrift = false")
+	}
+	if !slices.Equal(report.LiveOnly, []string{"https://example.com/gone"}) || !slices.Equal(report.GeneratedOnly, []string{"https://example.com/new"}) {
+		t.Errorf("report = %+v", report)
+	}
+	want := []LastModRegression{{Loc: "https://example.com/a", Live: day, Generated: older}}
+	if !slices.Equal(report.LastModRegressions, want) {
+		t.Errorf("regressions = %+v", report.LastModRegressions)
+	}
+	if CompareDrift(live, live).HasDrift() {
+		t.Error("a set drifts from itself")
+	}
+}
+
+func TestFetcherDrift(t *testing.T) {
+	srv := indexServer(t, 2, 3)
+	defer srv.Close()
+	f := testFetcher(srv)
+	generated := setOf(t, "https://example.com/child-0/0", "https://example.com/child-0/1", "https://example.com/child-0/2", "https://example.com/child-1/0", "https://example.com/child-1/1")
+	report, err := f.Drift(context.Background(), generated, srv.URL+"/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(report.LiveOnly, []string{"https://example.com/child-1/2"}) || len(report.GeneratedOnly) != 0 {
+		t.Errorf("report = %+v", report)
+	}
+}
@@ -0,0 +1,405 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const defaultIndent = "  "
+
+var ErrInvalidDeclaration = errors.New("invalid XML declaration")
+
+type EncodeOptions struct {
+	// Indent is the per-level indentation. It defaults to two spaces.
+	Indent string
+	// Compact writes the whole document on a single line.
+	Compact bool
+	// OmitDeclaration drops the <?xml ...?> declaration, leaving only the
+	// root element.
+	OmitDeclaration bool
+	// Encoding overrides how the UTF-8 encoding label is spelled, such as
+	// "utf-8". The document is always written as UTF-8, so any other label
+	// fails with ErrInvalidDeclaration.
+	Encoding string
+	// Standalone adds a standalone pseudo-attribute. It must be "yes" or
+	// "no" when set.
+	Standalone string
+	// Stylesheet is the href of an XSL stylesheet, such as one written by
+	// Stylesheet.WriteXSL, referenced by an xml-stylesheet processing
+	// instruction after the declaration.
+	Stylesheet string
+	// Transformers are applied to copies of each URL while encoding; the
+	// set itself is left unchanged.
+	Transformers []Transformer
+	// LastMod sets the time zone and precision of lastmod values.
+	LastMod LastModFormat
+	// MinimalNamespaces declares the xhtml, image, video and geo namespaces
+	// only when some URL uses them, even if the set's URI fields are set.
+	// A Writer cannot know in advance and ignores it.
+	MinimalNamespaces bool
+	// Engine encodes urlset documents. It defaults to StreamingEngine;
+	// Writer and EncodeURL always stream.
+	Engine Engine
+}
+
+func (o EncodeOptions) newEncoder(w io.Writer) *xml.Encoder {
+	enc := xml.NewEncoder(w)
+	if !o.Compact {
+		indent := o.Indent
+		if indent == "" {
+			indent = defaultIndent
+		}
+		enc.Indent("", indent)
+	}
+	return enc
+}
+
+// checkDeclaration reports declaration options that would mislabel the
+// document.
+func (o EncodeOptions) checkDeclaration() error {
+	if o.Encoding != "" && !strings.EqualFold(o.Encoding, "UTF-8") {
+		return fmt.Errorf("%w: encoding %q, documents are written as UTF-8", ErrInvalidDeclaration, o.Encoding)
+	}
+	switch o.Standalone {
+	case "", "yes", "no":
+		return nil
+	}
+	return fmt.Errorf("%w: standalone %q is not \"yes\" or \"no\"", ErrInvalidDeclaration, o.Standalone)
+}
+
+func (o EncodeOptions) header() string {
+	var pi string
+	if o.Stylesheet != "" {
+		pi = stylesheetPI(o.Stylesheet)
+		if !o.Compact {
+			pi += "\n"
+		}
+	}
+	if o.OmitDeclaration {
+		return pi
+	}
+	encoding := o.Encoding
+	if encoding == "" {
+		encoding = "UTF-8"
+	}
+	decl := `<?xml version="1.0" encoding="` + encoding + `"`
+	if o.Standalone != "" {
+		decl += ` standalone="` + o.Standalone + `"`
+	}
+	decl += "?>"
+	if !o.Compact {
+		decl += "\n"
+	}
+	return decl + pi
+}
+
+func encodeDocument(w io.Writer, v any, opts EncodeOptions) error {
+	if err := opts.checkDeclaration(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, opts.header()); err != nil {
+		return err
+	}
+	enc := opts.newEncoder(w)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func generateDocument(v any, opts EncodeOptions) (string, error) {
+	var b strings.Builder
+	if err := encodeDocument(&b, v, opts); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (u *URLSet) Encode(w io.Writer, opts EncodeOptions) error {
+	return opts.engine().Encode(w, u, opts)
+}
+
+func (u *URLSet) GenerateXMLWithOptions(opts EncodeOptions) (string, error) {
+	var b strings.Builder
+	if err := u.Encode(&b, opts); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// forEncoding returns a shallow copy of the set carrying opts' lastmod
+// format, with its URLs transformed when opts has Transformers.
+func (u *URLSet) forEncoding(opts EncodeOptions) (*URLSet, error) {
+	set := u
+	if len(opts.Transformers) > 0 {
+		var err error
+		if set, err = u.transformed(opts.Transformers); err != nil {
+			return nil, err
+		}
+	}
+	out := *set
+	out.lastMod = opts.LastMod
+	out.minimalNamespaces = opts.MinimalNamespaces
+	return &out, nil
+}
+
+func (si *SitemapIndex) Encode(w io.Writer, opts EncodeOptions) error {
+	out := *si
+	out.lastMod = opts.LastMod
+	return encodeDocument(w, out, opts)
+}
+
+func (si *SitemapIndex) GenerateXMLWithOptions(opts EncodeOptions) (string, error) {
+	out := *si
+	out.lastMod = opts.LastMod
+	return generateDocument(out, opts)
+}
+
+// MarshalXML writes the urlset root with its namespace declarations in a
+// fixed order rather than relying on reflection over the struct.
+func (u URLSet) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Local: "urlset"}, Attr: u.namespaceAttrs()}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	prefixes := u.prefixes()
+	for i, entry := range u.URLs {
+		if err := entry.encode(e, prefixes, u.lastMod); err != nil {
+			return &EncodeError{Index: i, Loc: entry.Loc, Err: err}
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// MarshalXML writes the sitemapindex root with its namespace declarations,
+// defaulting xmlns to the sitemap protocol namespace.
+func (si SitemapIndex) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	attrs := []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: orDefault(si.XMLNS, SitemapNamespace)}}
+	for _, ns := range si.Namespaces {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "xmlns:" + ns.Prefix}, Value: ns.URI})
+	}
+	start = xml.StartElement{Name: xml.Name{Local: "sitemapindex"}, Attr: attrs}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	w := elementWriter{e: e}
+	// Elements in the default namespace are written unprefixed.
+	prefixes := map[string]string{attrs[0].Value: ""}
+	for _, ns := range si.Namespaces {
+		prefixes[ns.URI] = ns.Prefix
+	}
+	for _, entry := range si.Sitemaps {
+		w.open("sitemap")
+		w.text("loc", entry.Loc)
+		if entry.LastMod != nil {
+			w.text("lastmod", si.lastMod.Format(*entry.LastMod))
+		}
+		for _, ext := range entry.Extensions {
+			w.element(ext, prefixes)
+		}
+		w.close("sitemap")
+	}
+	if w.err != nil {
+		return w.err
+	}
+	return e.EncodeToken(start.End())
+}
+
+// MarshalXML encodes a single url element using the default extension
+// prefixes.
+func (u *URL) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return u.encode(e, defaultPrefixes, LastModFormat{})
+}
+
+// EncodeURL returns the url element for u on its own, compact and with the
+// default extension prefixes, exactly as it appears inside an encoded
+// urlset.
+func EncodeURL(u *URL) ([]byte, error) {
+	set := MakeUrlSet()
+	return set.EncodeURL(u, EncodeOptions{Compact: true})
+}
+
+// EncodeURL returns the url element for entry using the set's extension
+// prefixes and opts' indentation and lastmod format. The fragment starts at
+// depth zero: inside the set's document each of its lines is indented one
+// level further. opts.Transformers are not applied.
+func (u *URLSet) EncodeURL(entry *URL, opts EncodeOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := opts.newEncoder(&buf)
+	if err := entry.encode(enc, u.prefixes(), opts.LastMod); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (u *URL) encode(e *xml.Encoder, p namespacePrefixes, lastMod LastModFormat) error {
+	w := elementWriter{e: e}
+	w.open("url")
+	w.text("loc", u.Loc)
+	if u.LastMod != nil {
+		w.text("lastmod", lastMod.Format(*u.LastMod))
+	}
+	w.optional("changefreq", string(u.ChangeFreq))
+	if u.Priority != nil {
+		w.value("priority", *u.Priority)
+	}
+	for _, img := range u.Images {
+		w.open(p.image + ":image")
+		w.text(p.image+":loc", img.Loc)
+		w.optional(p.image+":caption", img.Caption)
+		w.optional(p.image+":title", img.Title)
+		w.optional(p.image+":geo_location", img.GeoLocation)
+		w.optional(p.image+":license", img.License)
+		w.close(p.image + ":image")
+	}
+	for _, v := range u.Videos {
+		w.open(p.video + ":video")
+		w.optional(p.video+":loc", v.Loc)
+		w.text(p.video+":thumbnail_loc", v.ThumbnailLoc)
+		w.text(p.video+":title", v.Title)
+		w.text(p.video+":description", v.Description)
+		w.optional(p.video+":content_loc", v.ContentLoc)
+		w.optional(p.video+":player_loc", v.PlayerLoc)
+		if v.Duration != 0 {
+			w.value(p.video+":duration", v.Duration)
+		}
+		if v.ExpirationDate != nil {
+			w.text(p.video+":expiration_date", lastMod.Format(*v.ExpirationDate))
+		}
+		if v.Rating != nil {
+			w.value(p.video+":rating", *v.Rating)
+		}
+		if v.PublicationDate != nil {
+			w.text(p.video+":publication_date", lastMod.Format(*v.PublicationDate))
+		}
+		// Google's video schema orders tags before the category.
+		for _, tag := range v.Tags {
+			w.text(p.video+":tag", tag)
+		}
+		w.optional(p.video+":category", v.Category)
+		w.close(p.video + ":video")
+	}
+	if u.Geo != nil {
+		w.open(p.geo + ":geo")
+		w.text(p.geo+":format", string(u.Geo.Format))
+		w.close(p.geo + ":geo")
+	}
+	for _, alt := range u.Alternate {
+		w.empty(p.xhtml+":link", []xml.Attr{
+			{Name: xml.Name{Local: "rel"}, Value: alt.Rel},
+			{Name: xml.Name{Local: "hreflang"}, Value: alt.HrefLang},
+			{Name: xml.Name{Local: "href"}, Value: alt.Href},
+		})
+	}
+	w.close("url")
+	return w.err
+}
+
+// EncodeError identifies the URL whose encoding failed.
+type EncodeError struct {
+	// Index is the URL's position in its set, or in the order written
+	// to a Writer.
+	Index int
+	Loc   string
+	Err   error
+}
+
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("encode url %d (%s): %v", e.Index, e.Loc, e.Err)
+}
+
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// elementWriter emits tokens until the first error, which it keeps.
+type elementWriter struct {
+	e   *xml.Encoder
+	err error
+}
+
+func (w *elementWriter) open(name string) {
+	if w.err == nil {
+		w.err = w.e.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}})
+	}
+}
+
+func (w *elementWriter) close(name string) {
+	if w.err == nil {
+		w.err = w.e.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+	}
+}
+
+func (w *elementWriter) value(name string, v any) {
+	if w.err == nil {
+		w.err = w.e.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}})
+	}
+}
+
+func (w *elementWriter) text(name, v string) {
+	w.value(name, v)
+}
+
+func (w *elementWriter) optional(name, v string) {
+	if v != "" {
+		w.value(name, v)
+	}
+}
+
+// element writes a parsed Element back out, naming it and its attributes
+// by the prefixes declared for their namespaces on the root. Names in
+// undeclared namespaces are left to the encoder to declare.
+func (w *elementWriter) element(el Element, prefixes map[string]string) {
+	if w.err != nil {
+		return
+	}
+	start := xml.StartElement{Name: prefixName(el.Name, prefixes)}
+	for _, attr := range el.Attrs {
+		if attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			continue
+		}
+		start.Attr = append(start.Attr, xml.Attr{Name: prefixName(attr.Name, prefixes), Value: attr.Value})
+	}
+	if w.err = w.e.EncodeToken(start); w.err != nil {
+		return
+	}
+	if el.Text != "" {
+		if w.err = w.e.EncodeToken(xml.CharData(el.Text)); w.err != nil {
+			return
+		}
+	}
+	for _, child := range el.Children {
+		w.element(child, prefixes)
+	}
+	if w.err == nil {
+		w.err = w.e.EncodeToken(start.End())
+	}
+}
+
+func prefixName(name xml.Name, prefixes map[string]string) xml.Name {
+	prefix, ok := prefixes[name.Space]
+	switch {
+	case !ok:
+		return name
+	case prefix == "":
+		return xml.Name{Local: name.Local}
+	}
+	return xml.Name{Local: prefix + ":" + name.Local}
+}
+
+func (w *elementWriter) empty(name string, attrs []xml.Attr) {
+	if w.err != nil {
+		return
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}, Attr: attrs}
+	if w.err = w.e.EncodeToken(start); w.err == nil {
+		w.err = w.e.EncodeToken(start.End())
+	}
+}
@@ -0,0 +1,241 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestVideoTagsBeforeCategory(t *testing.T) {
+	set, err := ParseXMLUrlSet(videoSitemap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, engine := range []Engine{StreamingEngine, ReflectionEngine} {
+		var buf bytes.Buffer
+		if err := engine.Encode(&buf, &set, EncodeOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		tag, category := strings.Index(out, "<video:tag>"), strings.Index(out, "<video:category>")
+		if tag < 0 || category < 0 || tag > category {
+			t.Errorf("%T: want tag before category:\n%s", engine, out)
+		}
+	}
+}
+
+func TestMakeUrlSetDeclaresUsedExtensions(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      *URL
+		declared []string
+		omitted  []string
+	}{
+		{"plain", &URL{Loc: "https://example.com/"}, []string{`xmlns:xhtml=`}, []string{`xmlns:image=`, `xmlns:video=`, `xmlns:geo=`}},
+		{"image", &URL{Loc: "https://example.com/", Images: []Image{{Loc: "https://example.com/a.jpg"}}}, []string{`xmlns:image=`}, []string{`xmlns:video=`}},
+		{"video", &URL{Loc: "https://example.com/", Videos: []Video{{Title: "v"}}}, []string{`xmlns:video=`}, []string{`xmlns:image=`}},
+	}
+	for _, tt := range tests {
+		for _, engine := range []Engine{StreamingEngine, ReflectionEngine} {
+			set := MakeUrlSet()
+			set.URLs = []*URL{tt.url}
+			var buf bytes.Buffer
+			if err := set.Encode(&buf, EncodeOptions{Engine: engine}); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			for _, attr := range tt.declared {
+				if !strings.Contains(out, attr) {
+					t.Errorf("%s: %s not declared:\n%s", tt.name, attr, out)
+				}
+			}
+			for _, attr := range tt.omitted {
+				if strings.Contains(out, attr) {
+					t.Errorf("%s: unused %s declared:\n%s", tt.name, attr, out)
+				}
+			}
+		}
+	}
+}
+
+func TestDeclarationOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts EncodeOptions
+		want string
+	}{
+		{"default", EncodeOptions{}, `<?xml version="1.0" encoding="UTF-8"?>`},
+		{"lowercase encoding", EncodeOptions{Encoding: "utf-8"}, `<?xml version="1.0" encoding="utf-8"?>`},
+		{"standalone", EncodeOptions{Standalone: "no"}, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`},
+		{"invalid encoding", EncodeOptions{Encoding: "ISO-8859-1"}, ""},
+		{"invalid standalone", EncodeOptions{Standalone: "true"}, ""},
+		{"quoted standalone", EncodeOptions{Standalone: `yes"?><x`}, ""},
+	}
+	set := setOf(t, "https://example.com/")
+	index := MakeSitemapIndex([]SitemapEntry{{Loc: "https://example.com/sitemap-1.xml"}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, engine := range []Engine{StreamingEngine, ReflectionEngine} {
+				opts := tt.opts
+				opts.Engine = engine
+				out, err := set.GenerateXMLWithOptions(opts)
+				if tt.want == "" {
+					if !errors.Is(err, ErrInvalidDeclaration) {
+						t.Errorf("err = %v, want ErrInvalidDeclaration", err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.HasPrefix(out, tt.want+"\n") {
+					t.Errorf("got %q, want prefix %q", out, tt.want)
+				}
+			}
+			if _, err := index.GenerateXMLWithOptions(tt.opts); (err != nil) != (tt.want == "") {
+				t.Errorf("index: err = %v", err)
+			}
+			w := NewWriter(io.Discard, nil, tt.opts)
+			if err := w.Write(set.URLs[0]); (err != nil) != (tt.want == "") {
+				t.Errorf("Writer: err = %v", err)
+			}
+			if _, err := set.GenerateShards(context.Background(), ShardOptions{Encode: tt.opts}); (err != nil) != (tt.want == "") {
+				t.Errorf("GenerateShards: err = %v", err)
+			}
+		})
+	}
+}
+
+func TestEncodeLayout(t *testing.T) {
+	set := setOf(t, "https://example.com/a")
+	set.URLs[0].Images = []Image{{Loc: "https://example.com/a.jpg"}}
+	tests := []struct {
+		name string
+		opts EncodeOptions
+		want string
+	}{
+		{"default", EncodeOptions{}, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n" +
+			`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">` + "\n" +
+			"  <url>\n    <loc>https://example.com/a</loc>\n    <image:image>\n      <image:loc>https://example.com/a.jpg</image:loc>\n    </image:image>\n  </url>\n</urlset>"},
+		{"tab", EncodeOptions{Indent: "\t", OmitDeclaration: true}, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">` + "\n" +
+			"\t<url>\n\t\t<loc>https://example.com/a</loc>\n\t\t<image:image>\n\t\t\t<image:loc>https://example.com/a.jpg</image:loc>\n\t\t</image:image>\n\t</url>\n</urlset>"},
+		{"compact", EncodeOptions{Compact: true}, `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">` +
+			"<url><loc>https://example.com/a</loc><image:image><image:loc>https://example.com/a.jpg</image:loc></image:image></url></urlset>"},
+	}
+	for _, tt := range tests {
+		for _, engine := range []Engine{StreamingEngine, ReflectionEngine} {
+			opts := tt.opts
+			opts.Engine = engine
+			got, err := set.GenerateXMLWithOptions(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestEncodeDeterministic(t *testing.T) {
+	set := richSet(20)
+	set.Namespaces = []Namespace{{Prefix: "z", URI: "https://example.com/z"}, {Prefix: "a", URI: "https://example.com/a"}}
+	first, err := set.GenerateXMLWithOptions(EncodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 20 {
+		if got, _ := set.GenerateXMLWithOptions(EncodeOptions{}); got != first {
+			t.Fatal("output differs between runs")
+		}
+	}
+	if z, a := strings.Index(first, "xmlns:z="), strings.Index(first, "xmlns:a="); z < 0 || z > a {
+		t.Errorf("Namespaces not declared in the order given")
+	}
+}
+
+func TestEncodeURL(t *testing.T) {
+	set := richSet(4)
+	var compact bytes.Buffer
+	if err := set.Encode(&compact, EncodeOptions{Compact: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range set.URLs {
+		fragment, err := EncodeURL(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(fragment, []byte("<url>")) || !bytes.Contains(compact.Bytes(), fragment) {
+			t.Errorf("fragment not found in the compact document:\n%s", fragment)
+		}
+	}
+
+	prefixed := richSet(1)
+	prefixed.Namespaces = []Namespace{{Prefix: "img", URI: ImageNamespace}}
+	var indented bytes.Buffer
+	if err := prefixed.Encode(&indented, EncodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	fragment, err := prefixed.EncodeURL(prefixed.URLs[0], EncodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(fragment, []byte("<img:image>")) {
+		t.Errorf("set's prefixes not used:\n%s", fragment)
+	}
+	nested := "  " + strings.ReplaceAll(string(fragment), "\n", "\n  ")
+	if !strings.Contains(indented.String(), nested) {
+		t.Errorf("indented fragment does not match the document:\n%s", fragment)
+	}
+}
+
+// limitedWriter accepts writes up to n bytes in total and fails the rest
+// with errWrite.
+type limitedWriter struct {
+	n int
+}
+
+var errWrite = errors.New("write failed")
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		return 0, errWrite
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestEncodeError(t *testing.T) {
+	// The encoder buffers its output after the declaration, so the write
+	// fails while encoding the URL that overflows the buffer.
+	long := "https://example.com/" + strings.Repeat("x", 8<<10)
+	set := setOf(t, "https://example.com/a", "https://example.com/b", long)
+	check := func(t *testing.T, err error, index int) {
+		t.Helper()
+		var encErr *EncodeError
+		if !errors.As(err, &encErr) || !errors.Is(err, errWrite) {
+			t.Fatalf("err = %v, want an EncodeError wrapping %v", err, errWrite)
+		}
+		if encErr.Index != index || encErr.Loc != long {
+			t.Errorf("error names url %d (%.30s)", encErr.Index, encErr.Loc)
+		}
+		if !strings.HasPrefix(err.Error(), "encode url 2 (https://example.com/xxx") {
+			t.Errorf("message = %.60s", err.Error())
+		}
+	}
+	check(t, set.Encode(&limitedWriter{n: 1 << 10}, EncodeOptions{}), 2)
+	check(t, set.Encode(&limitedWriter{n: 1 << 10}, EncodeOptions{Engine: ReflectionEngine}), 2)
+
+	w := NewWriter(&limitedWriter{n: 1 << 10}, nil, EncodeOptions{})
+	var err error
+	for _, u := range set.URLs {
+		if err = w.Write(u); err != nil {
+			break
+		}
+	}
+	check(t, err, 2)
+}
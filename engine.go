@@ -0,0 +1,240 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var ErrEngineMismatch = errors.New("encoder engines disagree")
+
+// Engine encodes a urlset document under EncodeOptions. Every engine must
+// produce byte-identical output; they differ only in how they get there.
+type Engine interface {
+	Encode(w io.Writer, set *URLSet, opts EncodeOptions) error
+}
+
+var (
+	// StreamingEngine writes tokens straight from the URL fields without
+	// reflection. It is the default.
+	StreamingEngine Engine = streamingEngine{}
+	// ReflectionEngine lays each URL out as tagged structs and leaves the
+	// rest to encoding/xml's reflection.
+	ReflectionEngine Engine = reflectionEngine{}
+)
+
+func (o EncodeOptions) engine() Engine {
+	if o.Engine == nil {
+		return StreamingEngine
+	}
+	return o.Engine
+}
+
+type streamingEngine struct{}
+
+func (streamingEngine) Encode(w io.Writer, set *URLSet, opts EncodeOptions) error {
+	set, err := set.forEncoding(opts)
+	if err != nil {
+		return err
+	}
+	return encodeDocument(w, set, opts)
+}
+
+type reflectionEngine struct{}
+
+func (reflectionEngine) Encode(w io.Writer, set *URLSet, opts EncodeOptions) error {
+	set, err := set.forEncoding(opts)
+	if err != nil {
+		return err
+	}
+	doc := reflectedURLSet{Attrs: set.namespaceAttrs(), URLs: make([]reflectedURL, len(set.URLs))}
+	p := set.prefixes()
+	for i, entry := range set.URLs {
+		doc.URLs[i] = reflectURL(entry, p, set.lastMod)
+	}
+	return encodeDocument(w, doc, opts)
+}
+
+// CompareEngines encodes the set with a and b and reports where their
+// output first differs, wrapping ErrEngineMismatch.
+func (u *URLSet) CompareEngines(opts EncodeOptions, a, b Engine) error {
+	var outA, outB bytes.Buffer
+	if err := a.Encode(&outA, u, opts); err != nil {
+		return err
+	}
+	if err := b.Encode(&outB, u, opts); err != nil {
+		return err
+	}
+	x, y := outA.Bytes(), outB.Bytes()
+	if bytes.Equal(x, y) {
+		return nil
+	}
+	i := 0
+	for i < len(x) && i < len(y) && x[i] == y[i] {
+		i++
+	}
+	return fmt.Errorf("%w at byte %d: %q vs %q", ErrEngineMismatch, i, excerpt(x, i), excerpt(y, i))
+}
+
+func excerpt(b []byte, i int) []byte {
+	return b[i:min(i+40, len(b))]
+}
+
+// The reflected types mirror encode's layout. Element names are carried in
+// XMLName fields, since the extension prefixes are only known per set.
+type reflectedURLSet struct {
+	Attrs []xml.Attr
+	URLs  []reflectedURL
+}
+
+// MarshalXML reflects one URL at a time, so a failure names its URL the
+// way the streaming engine's does.
+func (s reflectedURLSet) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Local: "urlset"}, Attr: s.Attrs}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for i, u := range s.URLs {
+		if err := e.EncodeElement(u, xml.StartElement{Name: xml.Name{Local: "url"}}); err != nil {
+			return &EncodeError{Index: i, Loc: u.Loc, Err: err}
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+type reflectedURL struct {
+	Loc        string   `xml:"loc"`
+	LastMod    string   `xml:"lastmod,omitempty"`
+	ChangeFreq string   `xml:"changefreq,omitempty"`
+	Priority   *float64 `xml:"priority"`
+	Images     []reflectedImage
+	Videos     []reflectedVideo
+	Geo        *reflectedGeo
+	Alternates []reflectedLink
+}
+
+type reflectedText struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type reflectedImage struct {
+	XMLName     xml.Name
+	Loc         reflectedText
+	Caption     *reflectedText
+	Title       *reflectedText
+	GeoLocation *reflectedText
+	License     *reflectedText
+}
+
+type reflectedVideo struct {
+	XMLName         xml.Name
+	Loc             *reflectedText
+	ThumbnailLoc    reflectedText
+	Title           reflectedText
+	Description     reflectedText
+	ContentLoc      *reflectedText
+	PlayerLoc       *reflectedText
+	Duration        *reflectedNumber
+	ExpirationDate  *reflectedText
+	Rating          *reflectedNumber
+	PublicationDate *reflectedText
+	Tags            []reflectedText
+	Category        *reflectedText
+}
+
+type reflectedNumber struct {
+	XMLName xml.Name
+	Value   any `xml:",chardata"`
+}
+
+type reflectedGeo struct {
+	XMLName xml.Name
+	Format  reflectedText
+}
+
+type reflectedLink struct {
+	XMLName  xml.Name
+	Rel      string `xml:"rel,attr"`
+	HrefLang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+func reflectURL(u *URL, p namespacePrefixes, lastMod LastModFormat) reflectedURL {
+	out := reflectedURL{Loc: u.Loc, ChangeFreq: string(u.ChangeFreq), Priority: u.Priority}
+	if u.LastMod != nil {
+		out.LastMod = lastMod.Format(*u.LastMod)
+	}
+	for _, img := range u.Images {
+		out.Images = append(out.Images, reflectedImage{
+			XMLName:     prefixed(p.image, "image"),
+			Loc:         reflectedText{prefixed(p.image, "loc"), img.Loc},
+			Caption:     optionalText(p.image, "caption", img.Caption),
+			Title:       optionalText(p.image, "title", img.Title),
+			GeoLocation: optionalText(p.image, "geo_location", img.GeoLocation),
+			License:     optionalText(p.image, "license", img.License),
+		})
+	}
+	for _, v := range u.Videos {
+		rv := reflectedVideo{
+			XMLName:         prefixed(p.video, "video"),
+			Loc:             optionalText(p.video, "loc", v.Loc),
+			ThumbnailLoc:    reflectedText{prefixed(p.video, "thumbnail_loc"), v.ThumbnailLoc},
+			Title:           reflectedText{prefixed(p.video, "title"), v.Title},
+			Description:     reflectedText{prefixed(p.video, "description"), v.Description},
+			ContentLoc:      optionalText(p.video, "content_loc", v.ContentLoc),
+			PlayerLoc:       optionalText(p.video, "player_loc", v.PlayerLoc),
+			ExpirationDate:  optionalTime(p.video, "expiration_date", v.ExpirationDate, lastMod),
+			PublicationDate: optionalTime(p.video, "publication_date", v.PublicationDate, lastMod),
+			Category:        optionalText(p.video, "category", v.Category),
+		}
+		if v.Duration != 0 {
+			rv.Duration = &reflectedNumber{prefixed(p.video, "duration"), v.Duration}
+		}
+		if v.Rating != nil {
+			rv.Rating = &reflectedNumber{prefixed(p.video, "rating"), *v.Rating}
+		}
+		for _, tag := range v.Tags {
+			rv.Tags = append(rv.Tags, reflectedText{prefixed(p.video, "tag"), tag})
+		}
+		out.Videos = append(out.Videos, rv)
+	}
+	if u.Geo != nil {
+		out.Geo = &reflectedGeo{
+			XMLName: prefixed(p.geo, "geo"),
+			Format:  reflectedText{prefixed(p.geo, "format"), string(u.Geo.Format)},
+		}
+	}
+	for _, alt := range u.Alternate {
+		out.Alternates = append(out.Alternates, reflectedLink{
+			XMLName:  prefixed(p.xhtml, "link"),
+			Rel:      alt.Rel,
+			HrefLang: alt.HrefLang,
+			Href:     alt.Href,
+		})
+	}
+	return out
+}
+
+// prefixed names an extension element the way encode does: the prefix is
+// part of the local name, declared once on the root.
+func prefixed(prefix, local string) xml.Name {
+	return xml.Name{Local: prefix + ":" + local}
+}
+
+func optionalText(prefix, local, v string) *reflectedText {
+	if v == "" {
+		return nil
+	}
+	return &reflectedText{prefixed(prefix, local), v}
+}
+
+func optionalTime(prefix, local string, t *time.Time, lastMod LastModFormat) *reflectedText {
+	if t == nil {
+		return nil
+	}
+	return &reflectedText{prefixed(prefix, local), lastMod.Format(*t)}
+}
@@ -0,0 +1,108 @@
+package sitemap_go
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+// richSet returns n URLs exercising every element the engines write:
+// images, videos, hreflang alternates, geo and the optional fields.
+func richSet(n int) *URLSet {
+	set := MakeUrlSet()
+	lastMod := time.Date(2024, 3, 4, 5, 6, 7, 890, time.UTC)
+	for i := range n {
+		loc := fmt.Sprintf("https://example.com/page/%d?a=1&b=<2>", i)
+		u := MakeUrl(loc, WithLastMod(lastMod.Add(time.Duration(i)*time.Hour)), WithChangeFreq(ChangeFreqWeekly), WithPriority(float64(i%10)/10))
+		if i%2 == 0 {
+			u.Images = []Image{
+				{Loc: fmt.Sprintf("https://cdn.example.com/%d.jpg", i), Caption: "A & B", Title: "Title", GeoLocation: "Paris", License: "https://example.com/license"},
+				{Loc: fmt.Sprintf("https://cdn.example.com/%d-2.jpg", i)},
+			}
+		}
+		if i%3 == 0 {
+			rating := 4.5
+			published := lastMod.Add(-24 * time.Hour)
+			u.Videos = []Video{{
+				Loc:             loc,
+				ThumbnailLoc:    "https://cdn.example.com/thumb.jpg",
+				Title:           "Video",
+				Description:     "<b>bold</b>",
+				ContentLoc:      "https://cdn.example.com/video.mp4",
+				PlayerLoc:       "https://example.com/player",
+				Duration:        600,
+				Rating:          &rating,
+				PublicationDate: &published,
+				ExpirationDate:  &lastMod,
+				Category:        "Sports",
+				Tags:            []string{"a", "b"},
+			}}
+		}
+		if i%4 == 0 {
+			u.Alternate = []Alternate{
+				{Rel: "alternate", HrefLang: "en", Href: loc},
+				{Rel: "alternate", HrefLang: "de", Href: loc + "&lang=de"},
+			}
+		}
+		if i%5 == 0 {
+			u.Geo = &Geo{Format: GeoFormatKML}
+		}
+		set.URLs = append(set.URLs, u)
+	}
+	return &set
+}
+
+func TestEngineParity(t *testing.T) {
+	options := map[string]EncodeOptions{
+		"default":            {},
+		"compact":            {Compact: true},
+		"tab indent":         {Indent: "\t"},
+		"no declaration":     {OmitDeclaration: true},
+		"standalone":         {Standalone: "yes"},
+		"stylesheet":         {Stylesheet: "/sitemap.xsl?v=1&x=2"},
+		"stylesheet compact": {Stylesheet: "/sitemap.xsl", Compact: true, OmitDeclaration: true},
+		"minimal namespaces": {MinimalNamespaces: true},
+		"lastmod date":       {LastMod: LastModFormat{Precision: LastModDate, Location: time.FixedZone("X", 3600)}},
+		"lastmod second":     {LastMod: LastModFormat{Precision: LastModSecond, Round: true}},
+		"transformers":       {Transformers: []Transformer{StripQueryParams()}},
+	}
+	sets := map[string]*URLSet{
+		"empty": func() *URLSet { s := MakeUrlSet(); return &s }(),
+		"rich":  richSet(30),
+		"prefixed": func() *URLSet {
+			s := richSet(8)
+			s.Namespaces = []Namespace{{Prefix: "img", URI: ImageNamespace}, {Prefix: "vid", URI: VideoNamespace}, {Prefix: "x", URI: "https://example.com/ns"}}
+			return s
+		}(),
+	}
+	for setName, set := range sets {
+		for optName, opts := range options {
+			t.Run(setName+"/"+optName, func(t *testing.T) {
+				if err := set.CompareEngines(opts, StreamingEngine, ReflectionEngine); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
+
+func benchmarkEngine(b *testing.B, engine Engine) {
+	set := richSet(5000)
+	opts := EncodeOptions{Engine: engine}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for b.Loop() {
+		if err := set.Encode(io.Discard, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStreamingEngine(b *testing.B) {
+	benchmarkEngine(b, StreamingEngine)
+}
+
+func BenchmarkReflectionEngine(b *testing.B) {
+	benchmarkEngine(b, ReflectionEngine)
+}
@@ -0,0 +1,172 @@
+package sitemap_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrUnknownChangeOp = errors.New("unknown change op")
+
+const (
+	ChangeUpsert = "upsert"
+	ChangeDelete = "delete"
+)
+
+// URLChange is an event describing a change to one entry of a sitemap. In
+// JSON it looks like {"op":"upsert","loc":"https://…","lastmod":"…"}.
+type URLChange struct {
+	Op      string     `json:"op"`
+	Loc     string     `json:"loc"`
+	LastMod *time.Time `json:"lastmod,omitempty"`
+	// URL is the full entry to store on upsert. When nil, an entry is
+	// built from Loc and LastMod with MakeUrl.
+	URL *URL `json:"url,omitempty"`
+	// Ack, when set, is called once the change has been applied, e.g. to
+	// commit a Kafka offset or acknowledge a JetStream message.
+	Ack func() error `json:"-"`
+}
+
+func ParseURLChange(data []byte) (URLChange, error) {
+	var c URLChange
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, err
+	}
+	if c.Loc == "" && c.URL != nil {
+		c.Loc = c.URL.Loc
+	}
+	return c, nil
+}
+
+// ChangeConsumer applies URL change events to a URLStore.
+type ChangeConsumer struct {
+	Store URLStore
+	// OnError is called for changes that could not be applied; the change
+	// is then skipped without being acknowledged. When nil, Consume stops
+	// at the first failure.
+	OnError func(URLChange, error)
+}
+
+// Consume applies changes until the channel is closed or ctx is cancelled.
+func (c *ChangeConsumer) Consume(ctx context.Context, changes <-chan URLChange) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case change, ok := <-changes:
+			if !ok {
+				return nil
+			}
+			err := c.Apply(ctx, change)
+			if err == nil && change.Ack != nil {
+				err = change.Ack()
+			}
+			if err != nil {
+				if c.OnError == nil {
+					return err
+				}
+				c.OnError(change, err)
+			}
+		}
+	}
+}
+
+func (c *ChangeConsumer) Apply(ctx context.Context, change URLChange) error {
+	switch change.Op {
+	case ChangeUpsert:
+		u := change.URL
+		if u == nil {
+			var options []UrlOption
+			if change.LastMod != nil {
+				options = append(options, WithLastMod(*change.LastMod))
+			}
+			u = MakeUrl(change.Loc, options...)
+		}
+		return c.Store.Upsert(ctx, u)
+	case ChangeDelete:
+		return c.Store.Delete(ctx, change.Loc)
+	}
+	return fmt.Errorf("%w %q for %s", ErrUnknownChangeOp, change.Op, change.Loc)
+}
+
+// MessageChanges decodes the JSON payloads of messages received on msgs
+// into changes, which it delivers on the returned channel. It is the
+// adapter for channel-based clients; with nats.go:
+//
+//	msgs := make(chan *nats.Msg, 64)
+//	nc.ChanSubscribe("sitemap.changes", msgs)
+//	changes := MessageChanges(ctx, msgs, func(m *nats.Msg) []byte { return m.Data }, nil)
+//
+// Messages that do not decode are passed to onError, if set, and dropped.
+func MessageChanges[M any](ctx context.Context, msgs <-chan M, data func(M) []byte, onError func(error)) <-chan URLChange {
+	out := make(chan URLChange)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				change, err := ParseURLChange(data(msg))
+				if err != nil {
+					if onError != nil {
+						onError(err)
+					}
+					continue
+				}
+				select {
+				case out <- change:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// PollChanges calls fetch in a loop and delivers the decoded changes on the
+// returned channel. It is the adapter for pull-based clients; with
+// kafka-go, committing each offset once the change has been stored:
+//
+//	changes := PollChanges(ctx, func(ctx context.Context) ([]byte, func() error, error) {
+//		m, err := reader.FetchMessage(ctx)
+//		return m.Value, func() error { return reader.CommitMessages(ctx, m) }, err
+//	}, nil)
+//
+// The channel is closed when ctx is cancelled or fetch fails; other errors
+// go to onError, if set.
+func PollChanges(ctx context.Context, fetch func(ctx context.Context) (data []byte, ack func() error, err error), onError func(error)) <-chan URLChange {
+	out := make(chan URLChange)
+	go func() {
+		defer close(out)
+		for ctx.Err() == nil {
+			data, ack, err := fetch(ctx)
+			if err != nil {
+				if onError != nil && ctx.Err() == nil {
+					onError(err)
+				}
+				return
+			}
+			change, err := ParseURLChange(data)
+			if err != nil {
+				if onError != nil {
+					onError(err)
+				}
+				continue
+			}
+			change.Ack = ack
+			select {
+			case out <- change:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,125 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseURLChange(t *testing.T) {
+	c, err := ParseURLChange([]byte(`{"op":"upsert","loc":"https://example.com/a","lastmod":"2024-01-02T03:04:05Z"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Op != ChangeUpsert || c.Loc != "https://example.com/a" || c.LastMod == nil || c.LastMod.Year() != 2024 {
+		t.Errorf("got %+v", c)
+	}
+	c, err = ParseURLChange([]byte(`{"op":"upsert","url":{"loc":"https://example.com/b"}}`))
+	if err != nil || c.Loc != "https://example.com/b" {
+		t.Errorf("loc from url = %q, %v", c.Loc, err)
+	}
+	if _, err := ParseURLChange([]byte(`{"op":`)); err == nil {
+		t.Error("malformed change accepted")
+	}
+}
+
+func TestChangeConsumer(t *testing.T) {
+	store := &MemoryStore{}
+	lastMod := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var acked []string
+	ack := func(loc string) func() error {
+		return func() error { acked = append(acked, loc); return nil }
+	}
+	changes := make(chan URLChange, 8)
+	changes <- URLChange{Op: ChangeUpsert, Loc: "https://example.com/a", LastMod: &lastMod, Ack: ack("a")}
+	changes <- URLChange{Op: ChangeUpsert, URL: &URL{Loc: "https://example.com/b"}, Ack: ack("b")}
+	changes <- URLChange{Op: ChangeUpsert, Loc: "https://example.com/c"}
+	changes <- URLChange{Op: "rename", Loc: "https://example.com/x", Ack: ack("x")}
+	changes <- URLChange{Op: ChangeDelete, Loc: "https://example.com/c", Ack: ack("c")}
+	close(changes)
+
+	var failed []error
+	c := &ChangeConsumer{Store: store, OnError: func(_ URLChange, err error) { failed = append(failed, err) }}
+	if err := c.Consume(context.Background(), changes); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := snapshotLocs(t, store), []string{"https://example.com/a", "https://example.com/b"}; !slices.Equal(got, want) {
+		t.Errorf("store = %q, want %q", got, want)
+	}
+	urls, _ := store.Snapshot(context.Background())
+	if urls[0].LastMod == nil || !urls[0].LastMod.Equal(lastMod) {
+		t.Errorf("lastmod = %v", urls[0].LastMod)
+	}
+	if want := []string{"a", "b", "c"}; !slices.Equal(acked, want) {
+		t.Errorf("acked %q, want %q", acked, want)
+	}
+	if len(failed) != 1 || !errors.Is(failed[0], ErrUnknownChangeOp) {
+		t.Errorf("errors = %v", failed)
+	}
+}
+
+func TestChangeConsumerStops(t *testing.T) {
+	errAck := errors.New("ack failed")
+	changes := make(chan URLChange, 2)
+	changes <- URLChange{Op: ChangeUpsert, Loc: "https://example.com/a", Ack: func() error { return errAck }}
+	changes <- URLChange{Op: ChangeUpsert, Loc: "https://example.com/b"}
+	store := &MemoryStore{}
+	if err := (&ChangeConsumer{Store: store}).Consume(context.Background(), changes); !errors.Is(err, errAck) {
+		t.Fatalf("err = %v, want %v", err, errAck)
+	}
+	if got := snapshotLocs(t, store); len(got) != 1 {
+		t.Errorf("consumed past the failure: %q", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := (&ChangeConsumer{Store: store}).Consume(ctx, make(chan URLChange)); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestMessageChanges(t *testing.T) {
+	msgs := make(chan string, 3)
+	msgs <- `{"op":"upsert","loc":"https://example.com/a"}`
+	msgs <- `not json`
+	msgs <- `{"op":"delete","loc":"https://example.com/a"}`
+	close(msgs)
+	var decodeErrs int
+	var got []string
+	for c := range MessageChanges(context.Background(), msgs, func(m string) []byte { return []byte(m) }, func(error) { decodeErrs++ }) {
+		got = append(got, c.Op)
+	}
+	if want := []string{ChangeUpsert, ChangeDelete}; !slices.Equal(got, want) || decodeErrs != 1 {
+		t.Errorf("got %q with %d errors", got, decodeErrs)
+	}
+}
+
+func TestPollChanges(t *testing.T) {
+	payloads := []string{`{"op":"upsert","loc":"https://example.com/a"}`, `{`, `{"op":"delete","loc":"https://example.com/b"}`}
+	errDone := errors.New("done")
+	var acks int
+	fetch := func(ctx context.Context) ([]byte, func() error, error) {
+		if len(payloads) == 0 {
+			return nil, nil, errDone
+		}
+		data := payloads[0]
+		payloads = payloads[1:]
+		return []byte(data), func() error { acks++; return nil }, nil
+	}
+	var errs []error
+	var locs []string
+	for c := range PollChanges(context.Background(), fetch, func(err error) { errs = append(errs, err) }) {
+		locs = append(locs, c.Loc)
+		if err := c.Ack(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"https://example.com/a", "https://example.com/b"}; !slices.Equal(locs, want) || acks != 2 {
+		t.Errorf("got %q with %d acks", locs, acks)
+	}
+	if len(errs) != 2 || !errors.Is(errs[1], errDone) {
+		t.Errorf("errors = %v", errs)
+	}
+}
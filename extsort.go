@@ -0,0 +1,190 @@
+package sitemap_go
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"os"
+	"sort"
+)
+
+const defaultRunSize = 100000
+
+// ExternalSorter sorts and dedupes URLs by loc without holding them all in
+// memory: every RunSize URLs the buffer is sorted and spilled to a temporary
+// file, and Sorted merges the runs. When a loc is added more than once the
+// first occurrence wins.
+//
+// Runs are spilled as JSON, which cannot carry arbitrary values, so Meta is
+// not spilled: URLs read back from a run have no Meta, and only those still
+// buffered when Sorted is called keep theirs.
+type ExternalSorter struct {
+	// RunSize is the number of URLs buffered before spilling. It defaults
+	// to 100000.
+	RunSize int
+	// TempDir holds the spilled runs. It defaults to os.TempDir().
+	TempDir string
+
+	buf  []*URL
+	runs []string
+}
+
+func (s *ExternalSorter) Add(u *URL) error {
+	s.buf = append(s.buf, u)
+	runSize := s.RunSize
+	if runSize <= 0 {
+		runSize = defaultRunSize
+	}
+	if len(s.buf) >= runSize {
+		return s.spill()
+	}
+	return nil
+}
+
+func (s *ExternalSorter) spill() error {
+	sortByLoc(s.buf)
+	f, err := os.CreateTemp(s.TempDir, "sitemap-run-*.jsonl")
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f.Name())
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, u := range s.buf {
+		entry := *u
+		entry.Meta = nil
+		if err := enc.Encode(&entry); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	s.buf = s.buf[:0]
+	return f.Close()
+}
+
+// Sorted yields the unique URLs in loc order. Iteration stops at the first
+// error reading a run.
+func (s *ExternalSorter) Sorted() iter.Seq2[*URL, error] {
+	return func(yield func(*URL, error) bool) {
+		sortByLoc(s.buf)
+
+		var h runHeap
+		for i, path := range s.runs {
+			f, err := os.Open(path)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			defer f.Close()
+			src := &runSource{order: i, dec: json.NewDecoder(bufio.NewReader(f))}
+			if err := src.next(); err != nil {
+				yield(nil, err)
+				return
+			}
+			if src.head != nil {
+				h = append(h, src)
+			}
+		}
+		if len(s.buf) > 0 {
+			mem := &runSource{order: len(s.runs), mem: s.buf}
+			_ = mem.next()
+			h = append(h, mem)
+		}
+		heap.Init(&h)
+
+		var last *URL
+		for h.Len() > 0 {
+			src := h[0]
+			u := src.head
+			if err := src.next(); err != nil {
+				yield(nil, err)
+				return
+			}
+			if src.head == nil {
+				heap.Pop(&h)
+			} else {
+				heap.Fix(&h, 0)
+			}
+			if last != nil && last.Loc == u.Loc {
+				continue
+			}
+			last = u
+			if !yield(u, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Close removes the spilled runs.
+func (s *ExternalSorter) Close() error {
+	var errs []error
+	for _, path := range s.runs {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	s.runs = nil
+	s.buf = nil
+	return errors.Join(errs...)
+}
+
+func sortByLoc(urls []*URL) {
+	sort.SliceStable(urls, func(i, j int) bool {
+		return urls[i].Loc < urls[j].Loc
+	})
+}
+
+type runSource struct {
+	order int
+	head  *URL
+	dec   *json.Decoder
+	mem   []*URL
+}
+
+func (r *runSource) next() error {
+	r.head = nil
+	if r.dec == nil {
+		if len(r.mem) > 0 {
+			r.head, r.mem = r.mem[0], r.mem[1:]
+		}
+		return nil
+	}
+	var u URL
+	if err := r.dec.Decode(&u); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+	r.head = &u
+	return nil
+}
+
+// runHeap orders sources by head loc, then by run order so earlier runs win
+// ties.
+type runHeap []*runSource
+
+func (h runHeap) Len() int { return len(h) }
+func (h runHeap) Less(i, j int) bool {
+	if h[i].head.Loc != h[j].head.Loc {
+		return h[i].head.Loc < h[j].head.Loc
+	}
+	return h[i].order < h[j].order
+}
+func (h runHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x any)   { *h = append(*h, x.(*runSource)) }
+func (h *runHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,87 @@
+package sitemap_go
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sortedLocs(t *testing.T, s *ExternalSorter) []*URL {
+	t.Helper()
+	var out []*URL
+	for u, err := range s.Sorted() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, u)
+	}
+	return out
+}
+
+func TestExternalSorter(t *testing.T) {
+	for _, runSize := range []int{1, 3, 7, 1000} {
+		t.Run(fmt.Sprint(runSize), func(t *testing.T) {
+			dir := t.TempDir()
+			s := &ExternalSorter{RunSize: runSize, TempDir: dir}
+			rng := rand.New(rand.NewPCG(1, 2))
+			want := map[string]string{}
+			for i := range 50 {
+				loc := fmt.Sprintf("https://example.com/%02d", rng.IntN(30))
+				title := fmt.Sprint(i)
+				if _, ok := want[loc]; !ok {
+					want[loc] = title
+				}
+				if err := s.Add(&URL{Loc: loc, Images: []Image{{Title: title}}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := sortedLocs(t, s)
+			if len(got) != len(want) {
+				t.Fatalf("got %d URLs, want %d", len(got), len(want))
+			}
+			if !slices.IsSortedFunc(got, func(a, b *URL) int { return strings.Compare(a.Loc, b.Loc) }) {
+				t.Error("not sorted by loc")
+			}
+			for _, u := range got {
+				if u.Images[0].Title != want[u.Loc] {
+					t.Errorf("%s: got occurrence %s, want the first, %s", u.Loc, u.Images[0].Title, want[u.Loc])
+				}
+			}
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+				t.Errorf("runs left after Close: %d", len(entries))
+			}
+		})
+	}
+}
+
+func TestExternalSorterSpillsFields(t *testing.T) {
+	s := &ExternalSorter{RunSize: 1, TempDir: t.TempDir()}
+	defer s.Close()
+	lastMod := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	in := MakeUrl("https://example.com/a", WithLastMod(lastMod), WithPriority(0.3), WithMeta("id", 7))
+	in.Images = []Image{{Loc: "https://example.com/a.jpg"}}
+	if err := s.Add(in); err != nil {
+		t.Fatal(err)
+	}
+	got := sortedLocs(t, s)
+	if len(got) != 1 {
+		t.Fatalf("got %d URLs", len(got))
+	}
+	u := got[0]
+	if !u.LastMod.Equal(lastMod) || *u.Priority != 0.3 || len(u.Images) != 1 {
+		t.Errorf("fields lost in the spill: %+v", u)
+	}
+	if u.Meta != nil {
+		t.Errorf("Meta spilled: %v", u.Meta)
+	}
+	if in.Meta["id"] != 7 {
+		t.Errorf("spilling changed the caller's Meta: %v", in.Meta)
+	}
+}
@@ -0,0 +1,323 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultFetchConcurrency = 8
+
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("fetch %s: unexpected status %d", e.URL, e.StatusCode)
+}
+
+type Fetcher struct {
+	HTTPClient *http.Client
+	UserAgent  string
+	// Concurrency bounds how many child sitemaps are fetched at once when
+	// resolving an index. It defaults to 8.
+	Concurrency int
+	// RateLimiter paces requests per host. It defaults to a limiter shared
+	// by the package allowing 10 requests per second to each host; use
+	// &RateLimiter{} to disable limiting.
+	RateLimiter *RateLimiter
+	// Progress is called as each child sitemap of an index is fetched,
+	// with the sitemaps, URLs and bytes fetched so far.
+	Progress ProgressFunc
+}
+
+type ChildSitemap struct {
+	Entry SitemapEntry
+	Set   URLSet
+}
+
+// Fetch downloads loc, transparently gunzipping .gz sitemaps and decoding
+// any registered Content-Encoding the server applied. Bodies that inflate
+// past MaxSitemapBytes fail with ErrSitemapTooLarge.
+func (f *Fetcher) Fetch(ctx context.Context, loc string) ([]byte, error) {
+	return f.fetch(ctx, loc, 0)
+}
+
+// fetch is Fetch failing with ErrBudgetExceeded once the uncompressed body
+// grows past limit bytes, unless limit is 0. A negative limit reads up to
+// one byte past MaxSitemapBytes without failing, leaving oversized bodies
+// to the caller.
+func (f *Fetcher) fetch(ctx context.Context, loc string, limit int64) ([]byte, error) {
+	if err := waitForHost(ctx, f.RateLimiter, loc); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+	req.Header.Set("Accept-Encoding", acceptEncoding())
+	client := clientOrBulk(f.HTTPClient)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+		return nil, &HTTPStatusError{URL: loc, StatusCode: resp.StatusCode}
+	}
+	decoded, err := decodeContent(resp.Body, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", loc, err)
+	}
+	body, err := readLimited(decoded, limit)
+	if err != nil {
+		return nil, err
+	}
+	return gunzipIfNeeded(body, limit)
+}
+
+func (f *Fetcher) FetchURLSet(ctx context.Context, loc string) (URLSet, error) {
+	body, err := f.Fetch(ctx, loc)
+	if err != nil {
+		return URLSet{}, err
+	}
+	return ParseXMLUrlSet(string(body))
+}
+
+func (f *Fetcher) FetchIndex(ctx context.Context, loc string) (SitemapIndex, error) {
+	body, err := f.Fetch(ctx, loc)
+	if err != nil {
+		return SitemapIndex{}, err
+	}
+	return ParseXMLSitemapIndex(string(body))
+}
+
+// FetchSitemap fetches loc and returns its URLs, resolving it through
+// ResolveIndex when it turns out to be a sitemap index.
+func (f *Fetcher) FetchSitemap(ctx context.Context, loc string) (URLSet, error) {
+	body, err := f.Fetch(ctx, loc)
+	if err != nil {
+		return URLSet{}, err
+	}
+	if rootElement(body) == "sitemapindex" {
+		return f.ResolveIndex(ctx, loc)
+	}
+	return ParseXMLUrlSet(string(body))
+}
+
+// StreamIndex fetches the index at loc and then its child sitemaps on a
+// bounded worker pool, yielding each child as soon as it has been parsed.
+// A failed child is yielded with its error and iteration continues; failing
+// to fetch the index itself ends iteration. Stopping early cancels
+// outstanding fetches.
+func (f *Fetcher) StreamIndex(ctx context.Context, loc string) iter.Seq2[ChildSitemap, error] {
+	return f.streamIndex(ctx, loc, nil)
+}
+
+// streamIndex is StreamIndex drawing the children's bytes from budget,
+// unless it is nil.
+func (f *Fetcher) streamIndex(ctx context.Context, loc string, budget *byteBudget) iter.Seq2[ChildSitemap, error] {
+	return func(yield func(ChildSitemap, error) bool) {
+		index, err := f.FetchIndex(ctx, loc)
+		if err != nil {
+			yield(ChildSitemap{}, err)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type result struct {
+			child ChildSitemap
+			err   error
+		}
+		entries := make(chan SitemapEntry)
+		results := make(chan result)
+		progress := newProgressTracker(f.Progress)
+		var wg sync.WaitGroup
+		concurrency := f.Concurrency
+		if concurrency <= 0 {
+			concurrency = defaultFetchConcurrency
+		}
+		for range concurrency {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for entry := range entries {
+					var set URLSet
+					var body []byte
+					limit, err := budget.remaining()
+					if err == nil {
+						body, err = f.fetch(ctx, entry.Loc, limit)
+						budget.spend(len(body))
+					}
+					if err == nil {
+						set, err = ParseXMLUrlSet(string(body))
+					}
+					progress.update(func(p *Progress) {
+						p.Sitemaps++
+						p.URLs += len(set.URLs)
+						p.Bytes += int64(len(body))
+					})
+					select {
+					case results <- result{ChildSitemap{Entry: entry, Set: set}, err}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		go func() {
+			defer close(entries)
+			for _, entry := range index.Sitemaps {
+				select {
+				case entries <- entry:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
+
+		for r := range results {
+			if !yield(r.child, r.err) {
+				return
+			}
+		}
+	}
+}
+
+// ResolveIndex fetches every child of the index at loc concurrently and
+// merges their URLs, in completion order, into one set. It fails on the
+// first child that cannot be fetched or parsed.
+func (f *Fetcher) ResolveIndex(ctx context.Context, loc string) (URLSet, error) {
+	return f.ResolveIndexWithOptions(ctx, loc, ResolveOptions{})
+}
+
+// ResolveOptions caps the work of ResolveIndexWithOptions. Zero fields are
+// unlimited.
+type ResolveOptions struct {
+	// MaxURLs caps the URLs collected.
+	MaxURLs int
+	// MaxBytes caps the uncompressed bytes of the child sitemaps
+	// downloaded, across all of them.
+	MaxBytes int64
+}
+
+// ResolveIndexWithOptions is ResolveIndex within opts' budget. Once either
+// cap is reached it stops fetching and returns the URLs collected so far,
+// at most MaxURLs of them, with an error wrapping ErrBudgetExceeded.
+func (f *Fetcher) ResolveIndexWithOptions(ctx context.Context, loc string, opts ResolveOptions) (URLSet, error) {
+	out := MakeUrlSet()
+	budget := &byteBudget{max: opts.MaxBytes}
+	for child, err := range f.streamIndex(ctx, loc, budget) {
+		if err != nil {
+			if child.Entry.Loc != "" {
+				return out, fmt.Errorf("resolve %s: %w", child.Entry.Loc, err)
+			}
+			return out, err
+		}
+		urls := child.Set.URLs
+		if opts.MaxURLs > 0 && len(out.URLs)+len(urls) > opts.MaxURLs {
+			out.URLs = append(out.URLs, urls[:opts.MaxURLs-len(out.URLs)]...)
+			return out, fmt.Errorf("resolve %s: %w: more than %d urls", loc, ErrBudgetExceeded, opts.MaxURLs)
+		}
+		out.URLs = append(out.URLs, urls...)
+	}
+	return out, nil
+}
+
+var ErrBudgetExceeded = errors.New("index resolution exceeded its budget")
+
+// byteBudget shares a cap on downloaded bytes between fetches. Fetches
+// running at the same time each draw on the whole remainder, so together
+// they can overshoot it. A nil budget is unlimited.
+type byteBudget struct {
+	max  int64
+	used atomic.Int64
+}
+
+// remaining returns the fetch limit for the next body, 0 meaning
+// unlimited, or ErrBudgetExceeded once the budget is spent.
+func (b *byteBudget) remaining() (int64, error) {
+	if b == nil || b.max <= 0 {
+		return 0, nil
+	}
+	left := b.max - b.used.Load()
+	if left <= 0 {
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrBudgetExceeded, b.max)
+	}
+	return left, nil
+}
+
+func (b *byteBudget) spend(n int) {
+	if b != nil {
+		b.used.Add(int64(n))
+	}
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+func gunzipIfNeeded(body []byte, limit int64) ([]byte, error) {
+	if !bytes.HasPrefix(body, gzipMagic) {
+		return body, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return readLimited(zr, limit)
+}
+
+// readLimited reads r up to limit bytes, and never past the protocol's
+// MaxSitemapBytes, so a compressed body cannot inflate without bound. A
+// negative limit returns up to MaxSitemapBytes+1 bytes instead of failing.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit < 0 {
+		return io.ReadAll(io.LimitReader(r, MaxSitemapBytes+1))
+	}
+	overflow := func() error {
+		return fmt.Errorf("%w: body exceeds the remaining %d bytes", ErrBudgetExceeded, limit)
+	}
+	if limit <= 0 || limit > MaxSitemapBytes {
+		limit = MaxSitemapBytes
+		overflow = func() error {
+			return fmt.Errorf("%w: body exceeds %d bytes", ErrSitemapTooLarge, int64(MaxSitemapBytes))
+		}
+	}
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err == nil && int64(len(body)) > limit {
+		return nil, overflow()
+	}
+	return body, err
+}
+
+func rootElement(doc []byte) string {
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipped(t testing.TB, w func(io.Writer)) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	w(zw)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func testFetcher(srv *httptest.Server) *Fetcher {
+	return &Fetcher{HTTPClient: srv.Client(), RateLimiter: &RateLimiter{}}
+}
+
+func TestFetchInflationCapped(t *testing.T) {
+	bomb := gzipped(t, func(w io.Writer) {
+		zeros := make([]byte, 1<<20)
+		for range MaxSitemapBytes/len(zeros) + 1 {
+			w.Write(zeros)
+		}
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/encoded.xml" {
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+		w.Write(bomb)
+	}))
+	defer srv.Close()
+	f := testFetcher(srv)
+	for _, path := range []string{"/encoded.xml", "/sitemap.xml.gz"} {
+		if _, err := f.Fetch(context.Background(), srv.URL+path); !errors.Is(err, ErrSitemapTooLarge) {
+			t.Errorf("%s: err = %v, want ErrSitemapTooLarge", path, err)
+		}
+	}
+}
+
+func TestFetchDecodes(t *testing.T) {
+	doc := `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/</loc></url></urlset>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/plain.xml":
+			io.WriteString(w, doc)
+		case "/encoded.xml":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(gzipped(t, func(w io.Writer) { io.WriteString(w, doc) }))
+		case "/file.xml.gz":
+			w.Write(gzipped(t, func(w io.Writer) { io.WriteString(w, doc) }))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	f := testFetcher(srv)
+	for _, path := range []string{"/plain.xml", "/encoded.xml", "/file.xml.gz"} {
+		set, err := f.FetchURLSet(context.Background(), srv.URL+path)
+		if err != nil || len(set.URLs) != 1 {
+			t.Errorf("%s: %d urls, %v", path, len(set.URLs), err)
+		}
+	}
+	var status *HTTPStatusError
+	if _, err := f.Fetch(context.Background(), srv.URL+"/missing"); !errors.As(err, &status) || status.StatusCode != 404 {
+		t.Errorf("err = %v, want a 404 HTTPStatusError", err)
+	}
+}
+
+func indexServer(t *testing.T, children, perChild int) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sitemap.xml" {
+			io.WriteString(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+			for i := range children {
+				fmt.Fprintf(w, "<sitemap><loc>%s/child-%d.xml</loc></sitemap>", srv.URL, i)
+			}
+			io.WriteString(w, `</sitemapindex>`)
+			return
+		}
+		io.WriteString(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+		for i := range perChild {
+			fmt.Fprintf(w, "<url><loc>https://example.com%s/%d</loc></url>", strings.TrimSuffix(r.URL.Path, ".xml"), i)
+		}
+		io.WriteString(w, `</urlset>`)
+	}))
+	return srv
+}
+
+func TestResolveIndexBudget(t *testing.T) {
+	srv := indexServer(t, 4, 10)
+	defer srv.Close()
+	f := testFetcher(srv)
+	ctx := context.Background()
+
+	set, err := f.ResolveIndex(ctx, srv.URL+"/sitemap.xml")
+	if err != nil || len(set.URLs) != 40 {
+		t.Fatalf("unbounded: %d urls, %v", len(set.URLs), err)
+	}
+	set, err = f.ResolveIndexWithOptions(ctx, srv.URL+"/sitemap.xml", ResolveOptions{MaxURLs: 15})
+	if !errors.Is(err, ErrBudgetExceeded) || len(set.URLs) != 15 {
+		t.Errorf("MaxURLs: %d urls, %v", len(set.URLs), err)
+	}
+	_, err = f.ResolveIndexWithOptions(ctx, srv.URL+"/sitemap.xml", ResolveOptions{MaxBytes: 100})
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("MaxBytes: %v", err)
+	}
+}
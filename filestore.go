@@ -0,0 +1,199 @@
+package sitemap_go
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore rewrites its file once it holds more than compactRatio log
+// records per live entry and at least compactMinRecords records.
+const (
+	compactRatio      = 2
+	compactMinRecords = 1000
+)
+
+var ErrStoreClosed = errors.New("store is closed")
+
+type fileStoreRecord struct {
+	Delete string `json:"delete,omitempty"`
+	URL    *URL   `json:"url,omitempty"`
+}
+
+// FileStore is a durable URLStore kept in a single append-only file of JSON
+// records, for single-binary deployments without a database. The whole
+// store is held in memory; every change is appended and synced before it
+// is acknowledged, and the file is compacted once it is mostly superseded
+// records.
+type FileStore struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	urls    map[string]*URL
+	records int
+}
+
+// OpenFileStore opens the store at path, creating it if needed. A record
+// cut short by a crash at the end of the file is discarded.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, urls: map[string]*URL{}}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := s.load(f)
+	if err == nil {
+		err = f.Truncate(valid)
+	}
+	if err == nil {
+		_, err = f.Seek(valid, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	s.f = f
+	return s, nil
+}
+
+// load replays the log and returns the length of its valid prefix.
+func (s *FileStore) load(f *os.File) (int64, error) {
+	r := bufio.NewReader(f)
+	var valid int64
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return valid, nil
+		}
+		if err != nil {
+			return valid, err
+		}
+		var rec fileStoreRecord
+		if err := json.Unmarshal(bytes.TrimSpace(data), &rec); err != nil {
+			return valid, fmt.Errorf("%s:%d: %w", s.path, line, err)
+		}
+		s.apply(rec)
+		valid += int64(len(data))
+	}
+}
+
+func (s *FileStore) apply(rec fileStoreRecord) {
+	s.records++
+	if rec.URL != nil {
+		s.urls[rec.URL.Loc] = rec.URL
+	} else {
+		delete(s.urls, rec.Delete)
+	}
+}
+
+func (s *FileStore) Upsert(ctx context.Context, u *URL) error {
+	return s.append(fileStoreRecord{URL: u.Clone()})
+}
+
+func (s *FileStore) Delete(ctx context.Context, loc string) error {
+	return s.append(fileStoreRecord{Delete: loc})
+}
+
+func (s *FileStore) append(rec fileStoreRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return ErrStoreClosed
+	}
+	if _, err := s.f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if err := s.f.Sync(); err != nil {
+		return err
+	}
+	s.apply(rec)
+	if s.records > compactRatio*len(s.urls) && s.records >= compactMinRecords {
+		return s.compactLocked()
+	}
+	return nil
+}
+
+func (s *FileStore) Snapshot(ctx context.Context) ([]*URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil, ErrStoreClosed
+	}
+	out := make([]*URL, 0, len(s.urls))
+	for _, u := range s.urls {
+		out = append(out, u.Clone())
+	}
+	sortByLoc(out)
+	return out, nil
+}
+
+// Compact rewrites the file with one record per live entry.
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return ErrStoreClosed
+	}
+	return s.compactLocked()
+}
+
+func (s *FileStore) compactLocked() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".compact-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	urls := make([]*URL, 0, len(s.urls))
+	for _, u := range s.urls {
+		urls = append(urls, u)
+	}
+	sortByLoc(urls)
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, u := range urls {
+		if err = enc.Encode(fileStoreRecord{URL: u}); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		tmp.Close()
+		return err
+	}
+	s.f.Close()
+	s.f = tmp
+	s.records = len(urls)
+	return nil
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
@@ -0,0 +1,141 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func openFileStore(t *testing.T, path string) *FileStore {
+	t.Helper()
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func snapshotLocs(t *testing.T, store URLStore) []string {
+	t.Helper()
+	set, err := SnapshotURLSet(context.Background(), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return locsOf(&set)
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.log")
+	testURLStore(t, openFileStore(t, path))
+
+	reopened := openFileStore(t, path)
+	if got, want := snapshotLocs(t, reopened), []string{"https://example.com/a", "https://example.com/b"}; !slices.Equal(got, want) {
+		t.Errorf("after reopening: %q, want %q", got, want)
+	}
+}
+
+func TestFileStoreCrashRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.log")
+	ctx := context.Background()
+	s := openFileStore(t, path)
+	for _, loc := range []string{"https://example.com/a", "https://example.com/b"} {
+		if err := s.Upsert(ctx, &URL{Loc: loc}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+
+	// A crash while appending leaves a partial record at the end.
+	valid, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(valid, `{"url":{"loc":"https://exa`...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s = openFileStore(t, path)
+	if got, want := snapshotLocs(t, s), []string{"https://example.com/a", "https://example.com/b"}; !slices.Equal(got, want) {
+		t.Fatalf("recovered %q, want %q", got, want)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(valid) {
+		t.Errorf("partial record not truncated:\n%s", data)
+	}
+	if err := s.Delete(ctx, "https://example.com/a"); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if got := snapshotLocs(t, openFileStore(t, path)); !slices.Equal(got, []string{"https://example.com/b"}) {
+		t.Errorf("after appending to a recovered store: %q", got)
+	}
+}
+
+func TestFileStoreCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.log")
+	data := `{"url":{"loc":"https://example.com/a"}}` + "\n" + "garbage\n" + `{"delete":"https://example.com/a"}` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFileStore(path); err == nil || !strings.Contains(err.Error(), "urls.log:2") {
+		t.Errorf("err = %v, want one naming line 2", err)
+	}
+}
+
+func TestFileStoreCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.log")
+	ctx := context.Background()
+	s := openFileStore(t, path)
+	for range compactMinRecords / 2 {
+		for _, loc := range []string{"https://example.com/a", "https://example.com/b"} {
+			if err := s.Upsert(ctx, &URL{Loc: loc}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if data, _ := os.ReadFile(path); strings.Count(string(data), "\n") != 2 {
+		t.Errorf("log not compacted automatically: %d records", strings.Count(string(data), "\n"))
+	}
+
+	if err := s.Delete(ctx, "https://example.com/a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Upsert(ctx, &URL{Loc: "https://example.com/c"}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if got := strings.Count(string(data), "\n"); got != 2 {
+		t.Errorf("%d records after Compact and an upsert, want 2:\n%s", got, data)
+	}
+	s.Close()
+	if got, want := snapshotLocs(t, openFileStore(t, path)), []string{"https://example.com/b", "https://example.com/c"}; !slices.Equal(got, want) {
+		t.Errorf("after reopening: %q, want %q", got, want)
+	}
+	if names, _ := filepath.Glob(path + ".compact-*"); len(names) > 0 {
+		t.Errorf("left behind %q", names)
+	}
+}
+
+func TestFileStoreClosed(t *testing.T) {
+	s := openFileStore(t, filepath.Join(t.TempDir(), "urls.log"))
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := s.Upsert(ctx, &URL{Loc: "https://example.com/"}); !errors.Is(err, ErrStoreClosed) {
+		t.Errorf("Upsert: %v", err)
+	}
+	if _, err := s.Snapshot(ctx); !errors.Is(err, ErrStoreClosed) {
+		t.Errorf("Snapshot: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
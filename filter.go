@@ -0,0 +1,162 @@
+package sitemap_go
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Filter decides which locs are allowed into a sitemap by glob and regular
+// expression. A loc is allowed when it matches any include pattern, or
+// there are none, and matches no exclude pattern. A nil Filter allows
+// everything.
+//
+// Globs are matched against the loc's path: "*" and "?" stay within a path
+// segment and "**" spans segments. A glob without a slash, such as "*.pdf",
+// matches the last segment, and a trailing "/**", as in "/admin/**",
+// matches the directory itself too. Globs containing "://" are matched
+// against the whole loc instead. Regexps are always matched against the
+// whole loc.
+//
+// Filter is a Transformer dropping disallowed URLs, so the same value can
+// go in URLSet.Transformers, EncodeOptions, ShardOptions and
+// Crawler.Filter.
+type Filter struct {
+	Include       []string
+	Exclude       []string
+	IncludeRegexp []*regexp.Regexp
+	ExcludeRegexp []*regexp.Regexp
+
+	once             sync.Once
+	include, exclude []globPattern
+}
+
+type globPattern struct {
+	re      *regexp.Regexp
+	fullURL bool
+	base    bool
+}
+
+func (f *Filter) Allowed(loc string) bool {
+	if f == nil {
+		return true
+	}
+	f.compile()
+	if f.excluded(loc) {
+		return false
+	}
+	if len(f.include) == 0 && len(f.IncludeRegexp) == 0 {
+		return true
+	}
+	return anyGlob(f.include, loc) || anyRegexp(f.IncludeRegexp, loc)
+}
+
+// Excluded reports whether loc matches an exclude pattern, ignoring the
+// include patterns.
+func (f *Filter) Excluded(loc string) bool {
+	if f == nil {
+		return false
+	}
+	f.compile()
+	return f.excluded(loc)
+}
+
+func (f *Filter) excluded(loc string) bool {
+	return anyGlob(f.exclude, loc) || anyRegexp(f.ExcludeRegexp, loc)
+}
+
+func (f *Filter) Transform(u *URL) (*URL, error) {
+	if !f.Allowed(u.Loc) {
+		return nil, nil
+	}
+	return u, nil
+}
+
+func (f *Filter) compile() {
+	f.once.Do(func() {
+		for _, glob := range f.Include {
+			f.include = append(f.include, compileGlob(glob))
+		}
+		for _, glob := range f.Exclude {
+			f.exclude = append(f.exclude, compileGlob(glob))
+		}
+	})
+}
+
+func compileGlob(glob string) globPattern {
+	p := globPattern{fullURL: strings.Contains(glob, "://")}
+	if !p.fullURL {
+		p.base = !strings.Contains(glob, "/")
+		if !p.base && !strings.HasPrefix(glob, "/") {
+			glob = "/" + glob
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	skip := 0
+	for i, c := range glob {
+		if skip > 0 {
+			skip--
+			continue
+		}
+		switch rest := glob[i:]; {
+		case rest == "/**":
+			b.WriteString("(?:/.*)?")
+			skip = 2
+		case strings.HasPrefix(rest, "**/"):
+			b.WriteString("(?:.*/)?")
+			skip = 2
+		case strings.HasPrefix(rest, "**"):
+			b.WriteString(".*")
+			skip = 1
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	p.re = regexp.MustCompile(b.String())
+	return p
+}
+
+func (p globPattern) match(loc string) bool {
+	if p.fullURL {
+		return p.re.MatchString(loc)
+	}
+	parsed, err := url.Parse(loc)
+	if err != nil {
+		return false
+	}
+	target := parsed.Path
+	if target == "" {
+		target = "/"
+	}
+	if p.base {
+		target = path.Base(target)
+	}
+	return p.re.MatchString(target)
+}
+
+func anyGlob(patterns []globPattern, loc string) bool {
+	for _, p := range patterns {
+		if p.match(loc) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyRegexp(res []*regexp.Regexp, loc string) bool {
+	for _, re := range res {
+		if re.MatchString(loc) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package sitemap_go
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestFilterGlobs(t *testing.T) {
+	tests := []struct {
+		glob string
+		loc  string
+		want bool
+	}{
+		{"*.pdf", "https://example.com/docs/a.pdf", true},
+		{"*.pdf", "https://example.com/a.pdf?x=1", true},
+		{"*.pdf", "https://example.com/a.pdf/view", false},
+		{"/admin/**", "https://example.com/admin", true},
+		{"/admin/**", "https://example.com/admin/users/1", true},
+		{"/admin/**", "https://example.com/administrator", false},
+		{"admin/*", "https://example.com/admin/users", true},
+		{"/admin/*", "https://example.com/admin/users/1", false},
+		{"/blog/?", "https://example.com/blog/1", true},
+		{"/blog/?", "https://example.com/blog/12", false},
+		{"**/print", "https://example.com/print", true},
+		{"**/print", "https://example.com/a/b/print", true},
+		{"/a/**/edit", "https://example.com/a/edit", true},
+		{"/a/**/edit", "https://example.com/a/x/y/edit", true},
+		{"/**.html", "https://example.com/a/b.html", true},
+		{"/", "https://example.com", true},
+		{"/a+b(1)", "https://example.com/a+b(1)", true},
+		{"https://*.example.com/**", "https://shop.example.com/cart", true},
+		{"https://*.example.com/**", "https://example.com/cart", false},
+	}
+	for _, tt := range tests {
+		f := &Filter{Exclude: []string{tt.glob}}
+		if got := f.Excluded(tt.loc); got != tt.want {
+			t.Errorf("%q against %s: %v, want %v", tt.glob, tt.loc, got, tt.want)
+		}
+	}
+}
+
+func TestFilterAllowed(t *testing.T) {
+	f := &Filter{
+		Include:       []string{"/products/**"},
+		IncludeRegexp: []*regexp.Regexp{regexp.MustCompile(`[?&]featured=1`)},
+		Exclude:       []string{"*.pdf"},
+		ExcludeRegexp: []*regexp.Regexp{regexp.MustCompile(`/draft-`)},
+	}
+	tests := map[string]bool{
+		"https://example.com/products/1":              true,
+		"https://example.com/blog/1?featured=1":       true,
+		"https://example.com/blog/1":                  false,
+		"https://example.com/products/manual.pdf":     false,
+		"https://example.com/products/draft-2":        false,
+		"https://example.com/blog/2?x&featured=1":     true,
+		"https://example.com/blog/draft-3?featured=1": false,
+	}
+	for loc, want := range tests {
+		if got := f.Allowed(loc); got != want {
+			t.Errorf("%s: allowed %v, want %v", loc, got, want)
+		}
+	}
+	if f.Excluded("https://example.com/blog/1") {
+		t.Error("Excluded applied the include patterns")
+	}
+
+	var none *Filter
+	if !none.Allowed("https://example.com/") || none.Excluded("https://example.com/") {
+		t.Error("a nil Filter must allow everything")
+	}
+	if !(&Filter{Exclude: []string{"*.pdf"}}).Allowed("https://example.com/") {
+		t.Error("a Filter without includes must allow what it does not exclude")
+	}
+}
+
+func TestFilterTransform(t *testing.T) {
+	set := setOf(t, "https://example.com/a", "https://example.com/a.pdf", "https://example.com/b")
+	if err := set.Transform(&Filter{Exclude: []string{"*.pdf"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := locsOf(set), []string{"https://example.com/a", "https://example.com/b"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCrawlFilter(t *testing.T) {
+	srv := siteServer(t, map[string]string{
+		"/":            `<a href="/docs/">docs</a> <a href="/admin/">admin</a>`,
+		"/docs/":       `<a href="/docs/a">a</a>`,
+		"/docs/a":      `a`,
+		"/admin/":      `<a href="/admin/users">users</a>`,
+		"/admin/users": `users`,
+	})
+	got := crawl(t, &Crawler{Filter: &Filter{Exclude: []string{"/admin/**"}}}, srv.URL+"/")
+	want := []string{srv.URL + "/", srv.URL + "/docs/", srv.URL + "/docs/a"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
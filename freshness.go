@@ -0,0 +1,121 @@
+package sitemap_go
+
+import (
+	"sort"
+	"time"
+)
+
+const defaultClusterThreshold = 1000
+
+type AgeBucket struct {
+	Name string
+	// MaxAge is the exclusive upper bound of the bucket. Zero means
+	// unbounded and should only be used for the last bucket.
+	MaxAge time.Duration
+}
+
+const oneDay = 24 * time.Hour
+
+var DefaultAgeBuckets = []AgeBucket{
+	{Name: "<7d", MaxAge: 7 * oneDay},
+	{Name: "<30d", MaxAge: 30 * oneDay},
+	{Name: "<1y", MaxAge: 365 * oneDay},
+	{Name: "stale"},
+}
+
+type FreshnessOptions struct {
+	// Now is the reference time for ages. It defaults to time.Now.
+	Now time.Time
+	// Buckets must be in ascending MaxAge order. They default to
+	// DefaultAgeBuckets.
+	Buckets []AgeBucket
+	// ClusterThreshold is how many URLs must share an identical lastmod
+	// before it is reported as a cluster. It defaults to 1000.
+	ClusterThreshold int
+}
+
+type BucketCount struct {
+	Name  string
+	Count int
+}
+
+// TimestampCluster is a lastmod shared by suspiciously many URLs, which
+// usually means the generator stamped them with its own run time.
+type TimestampCluster struct {
+	LastMod time.Time
+	Count   int
+}
+
+type FreshnessReport struct {
+	Buckets []BucketCount
+	// NoLastMod counts URLs without a lastmod; they are not bucketed.
+	NoLastMod int
+	// Future counts URLs whose lastmod is after Now. They are bucketed
+	// with the freshest URLs.
+	Future         int
+	Oldest, Newest *time.Time
+	// Clusters are ordered by descending count.
+	Clusters []TimestampCluster
+}
+
+// Freshness buckets the set's URLs by lastmod age and reports timestamps
+// shared by at least opts.ClusterThreshold URLs.
+func (u *URLSet) Freshness(opts FreshnessOptions) FreshnessReport {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	buckets := opts.Buckets
+	if len(buckets) == 0 {
+		buckets = DefaultAgeBuckets
+	}
+	threshold := opts.ClusterThreshold
+	if threshold <= 0 {
+		threshold = defaultClusterThreshold
+	}
+
+	report := FreshnessReport{Buckets: make([]BucketCount, len(buckets))}
+	for i, b := range buckets {
+		report.Buckets[i].Name = b.Name
+	}
+	stamps := map[int64]int{}
+	for _, entry := range u.URLs {
+		if entry.LastMod == nil {
+			report.NoLastMod++
+			continue
+		}
+		lastMod := *entry.LastMod
+		if report.Oldest == nil || lastMod.Before(*report.Oldest) {
+			report.Oldest = &lastMod
+		}
+		if report.Newest == nil || lastMod.After(*report.Newest) {
+			report.Newest = &lastMod
+		}
+		stamps[lastMod.UnixNano()]++
+
+		age := now.Sub(lastMod)
+		if age < 0 {
+			report.Future++
+		}
+		for i, b := range buckets {
+			if b.MaxAge == 0 || age < b.MaxAge {
+				report.Buckets[i].Count++
+				break
+			}
+		}
+	}
+
+	for stamp, count := range stamps {
+		if count >= threshold {
+			report.Clusters = append(report.Clusters, TimestampCluster{LastMod: time.Unix(0, stamp).UTC(), Count: count})
+		}
+	}
+	sort.Slice(report.Clusters, func(a, b int) bool {
+		ca, cb := report.Clusters[a], report.Clusters[b]
+		if ca.Count != cb.Count {
+			return ca.Count > cb.Count
+		}
+		return ca.LastMod.Before(cb.LastMod)
+	})
+	return report
+}
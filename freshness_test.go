@@ -0,0 +1,46 @@
+package sitemap_go
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreshness(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	stamp := now.Add(-40 * oneDay)
+	set := setOf(t, "https://example.com/1", "https://example.com/2", "https://example.com/3", "https://example.com/4", "https://example.com/5", "https://example.com/6")
+	for i, lastMod := range []time.Time{now.Add(time.Hour), now.Add(-2 * oneDay), stamp, stamp, now.Add(-400 * oneDay)} {
+		set.URLs[i].LastMod = &lastMod
+	}
+
+	report := set.Freshness(FreshnessOptions{Now: now, ClusterThreshold: 2})
+	want := []BucketCount{{"<7d", 2}, {"<30d", 0}, {"<1y", 2}, {"stale", 1}}
+	for i, b := range want {
+		if report.Buckets[i] != b {
+			t.Errorf("bucket %d = %+v, want %+v", i, report.Buckets[i], b)
+		}
+	}
+	if report.NoLastMod != 1 || report.Future != 1 {
+		t.Errorf("NoLastMod %d, Future %d", report.NoLastMod, report.Future)
+	}
+	if !report.Oldest.Equal(now.Add(-400*oneDay)) || !report.Newest.Equal(now.Add(time.Hour)) {
+		t.Errorf("Oldest %v, Newest %v", report.Oldest, report.Newest)
+	}
+	if len(report.Clusters) != 1 || !report.Clusters[0].LastMod.Equal(stamp) || report.Clusters[0].Count != 2 {
+		t.Errorf("clusters = %+v", report.Clusters)
+	}
+	if report := set.Freshness(FreshnessOptions{Now: now}); len(report.Clusters) != 0 {
+		t.Errorf("default threshold reported %+v", report.Clusters)
+	}
+}
+
+func TestFreshnessCustomBuckets(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	set := setOf(t, "https://example.com/1", "https://example.com/2")
+	recent, old := now.Add(-time.Hour), now.Add(-48*time.Hour)
+	set.URLs[0].LastMod, set.URLs[1].LastMod = &recent, &old
+	report := set.Freshness(FreshnessOptions{Now: now, Buckets: []AgeBucket{{Name: "today", MaxAge: oneDay}}})
+	if len(report.Buckets) != 1 || report.Buckets[0].Count != 1 {
+		t.Errorf("buckets = %+v", report.Buckets)
+	}
+}
@@ -0,0 +1,116 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fuzzSeeds are documents at and past the parser's limits, added to every
+// fuzz target's corpus.
+var fuzzSeeds = []string{
+	"",
+	videoSitemap,
+	`<?xml version="1.0" encoding="UTF-8"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/</loc><lastmod>2024-01-02</lastmod><changefreq>daily</changefreq><priority>0.5</priority></url></urlset>`,
+	`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><sitemap><loc>https://example.com/sitemap-1.xml</loc><lastmod>2024-01-02T03:04:05Z</lastmod></sitemap></sitemapindex>`,
+	// Deep nesting.
+	"<urlset><url>" + strings.Repeat("<x>", 200) + strings.Repeat("</x>", 200) + "</url></urlset>",
+	"<urlset>" + strings.Repeat("<url>", 100),
+	// Huge attributes and text.
+	`<urlset xmlns="` + strings.Repeat("a", 2<<20) + `"></urlset>`,
+	"<urlset><url><loc>" + strings.Repeat("b", 2<<20) + "</loc></url></urlset>",
+	// Invalid UTF-8, whole and split.
+	"<urlset><url><loc>https://example.com/\xff</loc></url></urlset>",
+	"<urlset><url><loc>https://example.com/\xe2\x82</loc></url></urlset>",
+	"\xef\xbb\xbf<urlset></urlset>",
+	// Malformed XML.
+	"<urlset><url><loc>a</url></urlset>",
+	"<urlset><url><priority>high</priority></url></urlset>",
+	"<urlset><url><changefreq>sometimes</changefreq></url></urlset>",
+	"<urlset><url><lastmod>yesterday</lastmod></url></urlset>",
+	"<!DOCTYPE x [<!ENTITY a \"aaaa\">]><urlset>&a;</urlset>",
+}
+
+func addFuzzSeeds(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+}
+
+// checkParseError fails unless err is nil or one of the errors parsing is
+// documented to return.
+func checkParseError(t *testing.T, err error) {
+	t.Helper()
+	if err == nil {
+		return
+	}
+	var parseErr *ParseError
+	var syntaxErr *xml.SyntaxError
+	switch {
+	case errors.As(err, &parseErr), errors.As(err, &syntaxErr):
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+	case errors.Is(err, ErrInputTooLarge), errors.Is(err, ErrTooDeep),
+		errors.Is(err, ErrTokenTooLarge), errors.Is(err, ErrInvalidUTF8):
+	default:
+		t.Fatalf("untyped error %T: %v", err, err)
+	}
+}
+
+func FuzzParseXMLUrlSet(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		set, err := ParseXMLUrlSet(string(data))
+		checkParseError(t, err)
+		if err != nil {
+			return
+		}
+		var buf bytes.Buffer
+		if err := set.Encode(&buf, EncodeOptions{}); err != nil {
+			return
+		}
+		if _, err := ParseXMLUrlSetWithOptions(buf.String(), ParseOptions{MaxBytes: -1}); err != nil {
+			t.Fatalf("re-parsing encoded set: %v\n%s", err, buf.String())
+		}
+	})
+}
+
+func FuzzParseXMLSitemapIndex(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, err := ParseXMLSitemapIndex(string(data))
+		checkParseError(t, err)
+	})
+}
+
+func FuzzLocs(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		n := 0
+		for _, err := range Locs(bytes.NewReader(data)) {
+			if err != nil {
+				checkParseError(t, err)
+				break
+			}
+			n++
+		}
+		count, err := CountURLs(bytes.NewReader(data))
+		checkParseError(t, err)
+		if err == nil && n > count {
+			t.Fatalf("Locs yielded %d locs but CountURLs counted %d entries", n, count)
+		}
+	})
+}
+
+func FuzzParseSample(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		set, err := ParseSample(bytes.NewReader(data), 2)
+		checkParseError(t, err)
+		if len(set.URLs) > 2 {
+			t.Fatalf("sample of 2 returned %d URLs", len(set.URLs))
+		}
+	})
+}
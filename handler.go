@@ -0,0 +1,119 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Handler serves the sitemaps held by a Registry, with ETag and
+// Last-Modified support for conditional requests.
+type Handler struct {
+	Registry *Registry
+	// Codings names registered ContentCodings, such as "gzip", responses
+	// may be compressed with. Each document is compressed once per coding
+	// and cached, and a request gets the smallest variant its
+	// Accept-Encoding allows. Documents stored compressed are decompressed
+	// for clients that do not accept their encoding.
+	Codings []string
+	// TextExt, when set, serves each urlset in the text format, one loc per
+	// line, under its name with the .xml extension replaced by TextExt,
+	// such as ".txt" for sitemap.txt next to sitemap.xml. Documents stored
+	// under the requested name itself take precedence.
+	TextExt string
+}
+
+func (r *Registry) Handler() *Handler {
+	return &Handler{Registry: r}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	doc, err := h.Registry.lookup(req, req.URL.Path)
+	text := false
+	if err == nil && doc == nil && h.TextExt != "" && strings.HasSuffix(req.URL.Path, h.TextExt) {
+		doc, err = h.lookupXML(req, strings.TrimSuffix(req.URL.Path, h.TextExt))
+		text = true
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if doc == nil {
+		http.NotFound(w, req)
+		return
+	}
+	h.serveDocument(w, req, doc, text)
+}
+
+// lookupXML finds the XML document a text route is derived from, trying
+// the compressed names a Publisher may have stored it under after the
+// plain one.
+func (h *Handler) lookupXML(req *http.Request, base string) (*servedDocument, error) {
+	for _, ext := range append([]string{""}, codingExts()...) {
+		doc, err := h.Registry.lookup(req, base+".xml"+ext)
+		if doc != nil || err != nil {
+			return doc, err
+		}
+	}
+	return nil, nil
+}
+
+func (h *Handler) serveDocument(w http.ResponseWriter, req *http.Request, doc *servedDocument, text bool) {
+	candidates := []string{""}
+	if !text && doc.meta.ContentEncoding != "" {
+		candidates = append(candidates, doc.meta.ContentEncoding)
+	}
+	for _, c := range h.Codings {
+		if c = strings.ToLower(c); !slices.Contains(candidates, c) {
+			candidates = append(candidates, c)
+		}
+	}
+	accepts := acceptedCodings(req.Header.Get("Accept-Encoding"))
+	var coding string
+	var body []byte
+	for _, c := range candidates {
+		if c != "" && !accepts(c) {
+			continue
+		}
+		b, err := doc.variant(text, c)
+		if err != nil || b == nil {
+			continue
+		}
+		if body == nil || len(b) < len(body) {
+			coding, body = c, b
+		}
+	}
+	if body == nil {
+		if text {
+			http.NotFound(w, req)
+			return
+		}
+		// The stored document could not be decoded; serve it as is.
+		coding, body = doc.meta.ContentEncoding, doc.body
+	}
+
+	contentType := doc.meta.ContentType
+	if text {
+		contentType = "text/plain; charset=utf-8"
+	} else if contentType == "" || contentType == "application/xml" {
+		contentType = "application/xml; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	if coding != "" {
+		w.Header().Set("Content-Encoding", coding)
+	}
+	if len(candidates) > 1 {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	if doc.meta.CacheControl != "" {
+		w.Header().Set("Cache-Control", doc.meta.CacheControl)
+	}
+	w.Header().Set("ETag", doc.variantETag(text, coding))
+	http.ServeContent(w, req, "", doc.modTime, bytes.NewReader(body))
+}
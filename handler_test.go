@@ -0,0 +1,110 @@
+package sitemap_go
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serve(t *testing.T, h http.Handler, method, host, path string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	if host != "" {
+		req.Host = host
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerConditional(t *testing.T) {
+	r := MakeRegistry()
+	if _, err := r.Swap("sitemap.xml", setOf(t, "https://example.com/a")); err != nil {
+		t.Fatal(err)
+	}
+	h := r.Handler()
+
+	rec := serve(t, h, "GET", "", "/sitemap.xml", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "https://example.com/a") {
+		t.Fatalf("status %d:\n%s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/xml; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	etag, lastMod := rec.Header().Get("ETag"), rec.Header().Get("Last-Modified")
+	if etag == "" || lastMod == "" {
+		t.Fatalf("ETag %q, Last-Modified %q", etag, lastMod)
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"if-none-match", http.Header{"If-None-Match": {etag}}, http.StatusNotModified},
+		{"if-none-match other", http.Header{"If-None-Match": {`"other"`}}, http.StatusOK},
+		{"if-modified-since", http.Header{"If-Modified-Since": {lastMod}}, http.StatusNotModified},
+		{"if-match other", http.Header{"If-Match": {`"other"`}}, http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		if rec := serve(t, h, "GET", "", "/sitemap.xml", tt.header); rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+
+	if _, err := r.Swap("sitemap.xml", setOf(t, "https://example.com/b")); err != nil {
+		t.Fatal(err)
+	}
+	rec = serve(t, h, "GET", "", "/sitemap.xml", http.Header{"If-None-Match": {etag}})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag || !strings.Contains(rec.Body.String(), "https://example.com/b") {
+		t.Errorf("after swap: status %d, ETag %s", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestHandlerRouting(t *testing.T) {
+	r := MakeRegistry()
+	ctx := context.Background()
+	_ = r.Put(ctx, "sitemap.xml", []byte("<urlset>shared</urlset>"), ObjectMeta{CacheControl: "max-age=60"})
+	_ = r.Put(ctx, "example.com/sitemap.xml", []byte("<urlset>host</urlset>"), ObjectMeta{ContentType: "text/xml"})
+	h := r.Handler()
+
+	tests := []struct {
+		method, host, path string
+		status             int
+		body               string
+	}{
+		{"GET", "other.example.com", "/sitemap.xml", http.StatusOK, "shared"},
+		{"GET", "Example.com:443", "/sitemap.xml", http.StatusOK, "host"},
+		{"HEAD", "other.example.com", "/sitemap.xml", http.StatusOK, ""},
+		{"GET", "example.com", "/missing.xml", http.StatusNotFound, ""},
+		{"POST", "example.com", "/sitemap.xml", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		rec := serve(t, h, tt.method, tt.host, tt.path, nil)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s%s: status %d, want %d", tt.method, tt.host, tt.path, rec.Code, tt.status)
+			continue
+		}
+		if tt.status == http.StatusOK && !strings.Contains(rec.Body.String(), tt.body) {
+			t.Errorf("%s %s%s: body %q, want %q", tt.method, tt.host, tt.path, rec.Body, tt.body)
+		}
+	}
+
+	rec := serve(t, h, "GET", "example.com", "/sitemap.xml", nil)
+	if ct := rec.Header().Get("Content-Type"); ct != "text/xml" {
+		t.Errorf("Content-Type = %q, want the stored type", ct)
+	}
+	rec = serve(t, h, "GET", "other.example.com", "/sitemap.xml", nil)
+	if cc := rec.Header().Get("Cache-Control"); cc != "max-age=60" {
+		t.Errorf("Cache-Control = %q", cc)
+	}
+	rec = serve(t, h, "POST", "", "/sitemap.xml", nil)
+	if allow := rec.Header().Get("Allow"); allow != "GET, HEAD" {
+		t.Errorf("Allow = %q", allow)
+	}
+}
@@ -0,0 +1,214 @@
+package sitemap_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	HealthOK      = "ok"
+	HealthStale   = "stale"
+	HealthFailing = "failing"
+	// HealthUnknown is reported until the first generation is recorded.
+	HealthUnknown = "unknown"
+)
+
+// Health records the state of the sitemap pipeline and serves it as JSON
+// for orchestration and alerting. Feed it with RecordRun as a Scheduler's
+// OnRun and PingWith as its Ping, or add it to Publisher.Notifiers and
+// call RecordPings when pipelines are wired by hand. Responses are 200 OK
+// while the status is HealthOK and 503 Service Unavailable otherwise.
+type Health struct {
+	// MaxAge, when set, makes the status HealthStale once the last
+	// successful run is older than this.
+	MaxAge time.Duration
+
+	mu          sync.Mutex
+	generated   time.Time
+	lastSuccess time.Time
+	urls        int
+	shards      int
+	publish     *PublishResult
+	err         error
+	pings       []PingResult
+	pinged      time.Time
+}
+
+type HealthStatus struct {
+	Status         string         `json:"status"`
+	LastGeneration *time.Time     `json:"last_generation,omitempty"`
+	LastSuccess    *time.Time     `json:"last_success,omitempty"`
+	AgeSeconds     float64        `json:"age_seconds"`
+	URLs           int            `json:"urls"`
+	Shards         int            `json:"shards"`
+	LastError      string         `json:"last_error,omitempty"`
+	LastPublish    *publishStatus `json:"last_publish,omitempty"`
+	LastPinged     *time.Time     `json:"last_pinged,omitempty"`
+	LastPings      []pingStatus   `json:"last_pings,omitempty"`
+}
+
+type publishStatus struct {
+	Time     time.Time `json:"time"`
+	IndexURL string    `json:"index_url"`
+	URLs     int       `json:"urls"`
+	Shards   int       `json:"shards"`
+	Skipped  int       `json:"skipped,omitempty"`
+	Added    int       `json:"added"`
+	Removed  int       `json:"removed"`
+	Updated  int       `json:"updated"`
+	// NotifyErrors are the messages of notifiers that failed.
+	NotifyErrors []string `json:"notify_errors,omitempty"`
+}
+
+type pingStatus struct {
+	Endpoint   string `json:"endpoint"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// RecordRun records a scheduled run; it fits Scheduler.OnRun. A run that
+// failed keeps the counts and publish result of the last one that did not.
+// Once the publish succeeded, errors of the Ping step show in the pings
+// rather than failing the run.
+func (h *Health) RecordRun(run ScheduledRun) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.generated = run.Finished
+	published := run.Result.IndexURL != ""
+	if run.Err != nil && !published {
+		h.err = run.Err
+		return
+	}
+	h.err = nil
+	h.lastSuccess = run.Finished
+	h.urls = run.URLs
+	if published {
+		h.recordPublishLocked(run.Result)
+	}
+}
+
+// Notify implements Notifier, recording every successful publish.
+func (h *Health) Notify(ctx context.Context, result PublishResult) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.generated = result.Time
+	h.lastSuccess = result.Time
+	h.urls = result.URLs
+	h.err = nil
+	h.recordPublishLocked(result)
+	return nil
+}
+
+func (h *Health) recordPublishLocked(result PublishResult) {
+	h.publish = &result
+	h.shards = len(result.ShardURLs)
+}
+
+// RecordError records a failed run of a pipeline wired by hand.
+func (h *Health) RecordError(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.generated = time.Now()
+	h.err = err
+}
+
+func (h *Health) RecordPings(results []PingResult) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pings = append([]PingResult(nil), results...)
+	h.pinged = time.Now()
+}
+
+// PingWith is the package-level PingWith, recording the results before
+// they are reduced to an error.
+func (h *Health) PingWith(r *PingRegistry) func(ctx context.Context, result PublishResult) error {
+	return func(ctx context.Context, result PublishResult) error {
+		results := r.Ping(ctx, result.IndexURL)
+		h.RecordPings(results)
+		var errs []error
+		for _, res := range results {
+			errs = append(errs, res.Err)
+		}
+		return errors.Join(errs...)
+	}
+}
+
+func (h *Health) Status() HealthStatus {
+	return h.status(time.Now())
+}
+
+func (h *Health) status(now time.Time) HealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := HealthStatus{Status: HealthOK, URLs: h.urls, Shards: h.shards}
+	switch {
+	case h.generated.IsZero():
+		s.Status = HealthUnknown
+	case h.err != nil:
+		s.Status = HealthFailing
+	case h.MaxAge > 0 && now.Sub(h.lastSuccess) > h.MaxAge:
+		s.Status = HealthStale
+	}
+	if generated := h.generated; !generated.IsZero() {
+		s.LastGeneration = &generated
+	}
+	if success := h.lastSuccess; !success.IsZero() {
+		s.LastSuccess = &success
+		s.AgeSeconds = now.Sub(h.lastSuccess).Round(time.Millisecond).Seconds()
+	}
+	if h.err != nil {
+		s.LastError = h.err.Error()
+	}
+	if p := h.publish; p != nil {
+		s.LastPublish = &publishStatus{
+			Time:     p.Time,
+			IndexURL: p.IndexURL,
+			URLs:     p.URLs,
+			Shards:   len(p.ShardURLs),
+			Skipped:  len(p.Skipped),
+			Added:    p.Diff.Added,
+			Removed:  p.Diff.Removed,
+			Updated:  p.Diff.Updated,
+		}
+		for _, err := range p.NotifyErrs {
+			s.LastPublish.NotifyErrors = append(s.LastPublish.NotifyErrors, err.Error())
+		}
+	}
+	if pinged := h.pinged; !pinged.IsZero() {
+		s.LastPinged = &pinged
+	}
+	for _, res := range h.pings {
+		ps := pingStatus{Endpoint: res.Endpoint, StatusCode: res.StatusCode}
+		if res.Err != nil {
+			ps.Error = res.Err.Error()
+		}
+		s.LastPings = append(s.LastPings, ps)
+	}
+	return s
+}
+
+func (h *Health) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	s := h.Status()
+	body, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if s.Status != HealthOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if req.Method != http.MethodHead {
+		w.Write(append(body, '\n'))
+	}
+}
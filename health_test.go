@@ -0,0 +1,141 @@
+package sitemap_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthStatus(t *testing.T) {
+	h := &Health{MaxAge: time.Hour}
+	start := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	if s := h.status(start); s.Status != HealthUnknown || s.LastGeneration != nil || s.LastSuccess != nil {
+		t.Errorf("before any run: %+v", s)
+	}
+
+	result := PublishResult{
+		Time:       start,
+		IndexURL:   "https://example.com/sitemap.xml",
+		ShardURLs:  []string{"https://example.com/sitemap-1.xml", "https://example.com/sitemap-2.xml"},
+		URLs:       3,
+		Diff:       DiffStats{Added: 3},
+		Skipped:    []string{"sitemap-1.xml"},
+		NotifyErrs: []error{errors.New("webhook down")},
+	}
+	h.RecordRun(ScheduledRun{Started: start.Add(-time.Minute), Finished: start, URLs: 3, Result: result})
+	s := h.status(start.Add(90 * time.Second))
+	if s.Status != HealthOK || s.URLs != 3 || s.Shards != 2 || s.AgeSeconds != 90 || !s.LastSuccess.Equal(start) {
+		t.Errorf("after a run: %+v", s)
+	}
+	p := s.LastPublish
+	if p == nil || p.IndexURL != result.IndexURL || p.Shards != 2 || p.Skipped != 1 || p.Added != 3 || len(p.NotifyErrors) != 1 || p.NotifyErrors[0] != "webhook down" {
+		t.Errorf("last publish: %+v", p)
+	}
+	if s := h.status(start.Add(time.Hour + time.Second)); s.Status != HealthStale {
+		t.Errorf("after MaxAge: status %s", s.Status)
+	}
+
+	failed := start.Add(10 * time.Minute)
+	h.RecordRun(ScheduledRun{Finished: failed, Err: errors.New("source down")})
+	s = h.status(failed)
+	if s.Status != HealthFailing || s.LastError != "source down" || !s.LastGeneration.Equal(failed) || !s.LastSuccess.Equal(start) {
+		t.Errorf("after a failed run: %+v", s)
+	}
+	if s.URLs != 3 || s.LastPublish == nil || s.LastPublish.Time != start {
+		t.Errorf("failed run dropped the last counts: %+v", s)
+	}
+
+	// A publish that went through counts even when the pings failed.
+	pinged := start.Add(20 * time.Minute)
+	result.Time, result.URLs = pinged, 4
+	h.RecordRun(ScheduledRun{Finished: pinged, URLs: 4, Result: result, Err: errors.New("ping failed")})
+	if s := h.status(pinged); s.Status != HealthOK || s.LastError != "" || s.URLs != 4 {
+		t.Errorf("after a failed ping: %+v", s)
+	}
+
+	h.RecordError(errors.New("boom"))
+	if s := h.Status(); s.Status != HealthFailing || s.LastError != "boom" {
+		t.Errorf("after RecordError: %+v", s)
+	}
+	notified := time.Now()
+	if err := h.Notify(context.Background(), PublishResult{Time: notified, URLs: 5, ShardURLs: []string{"a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if s := h.Status(); s.Status != HealthOK || s.URLs != 5 || s.Shards != 1 || !s.LastSuccess.Equal(notified) {
+		t.Errorf("after Notify: %+v", s)
+	}
+}
+
+func TestHealthServeHTTP(t *testing.T) {
+	h := &Health{}
+	rec := serve(t, h, "GET", "", "/healthz", nil)
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Content-Type") != "application/json" || rec.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("unknown: status %d, headers %v", rec.Code, rec.Header())
+	}
+
+	h.Notify(context.Background(), PublishResult{Time: time.Now(), IndexURL: "https://example.com/sitemap.xml", URLs: 2})
+	rec = serve(t, h, "GET", "", "/healthz", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d:\n%s", rec.Code, rec.Body)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["status"] != HealthOK || body["urls"] != 2.0 || body["last_error"] != nil {
+		t.Errorf("body %v", body)
+	}
+	if publish, _ := body["last_publish"].(map[string]any); publish["index_url"] != "https://example.com/sitemap.xml" {
+		t.Errorf("last_publish %v", body["last_publish"])
+	}
+
+	if rec := serve(t, h, "HEAD", "", "/healthz", nil); rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("HEAD: status %d, %d bytes", rec.Code, rec.Body.Len())
+	}
+	if rec := serve(t, h, "POST", "", "/healthz", nil); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status %d", rec.Code)
+	}
+	h.RecordError(errors.New("boom"))
+	if rec := serve(t, h, "HEAD", "", "/healthz", nil); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("failing: status %d", rec.Code)
+	}
+}
+
+func TestHealthPingWith(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+	r := MakePingRegistry(
+		PingEndpoint{Name: "ok", URL: srv.URL + "/ok?sitemap={sitemap}"},
+		PingEndpoint{Name: "fail", URL: srv.URL + "/fail?sitemap={sitemap}"},
+	)
+	h := &Health{}
+	if err := h.PingWith(r)(context.Background(), PublishResult{IndexURL: "https://example.com/sitemap.xml"}); err == nil {
+		t.Error("failed ping not reported")
+	}
+	s := h.Status()
+	if s.LastPinged == nil || len(s.LastPings) != 2 {
+		t.Fatalf("pings %+v", s.LastPings)
+	}
+	if ok := s.LastPings[0]; ok.Endpoint != "ok" || ok.StatusCode != 200 || ok.Error != "" {
+		t.Errorf("ok: %+v", ok)
+	}
+	if fail := s.LastPings[1]; fail.Endpoint != "fail" || fail.StatusCode != 500 || fail.Error == "" {
+		t.Errorf("fail: %+v", fail)
+	}
+
+	r.Remove("fail")
+	if err := h.PingWith(r)(context.Background(), PublishResult{IndexURL: "https://example.com/sitemap.xml"}); err != nil {
+		t.Errorf("err = %v", err)
+	}
+	if s := h.Status(); len(s.LastPings) != 1 {
+		t.Errorf("pings not replaced: %+v", s.LastPings)
+	}
+}
@@ -0,0 +1,112 @@
+package sitemap_go
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrDuplicateHreflang    = errors.New("hreflang is listed more than once")
+	ErrMissingReturnLink    = errors.New("alternate does not link back")
+	ErrMissingSelfReference = errors.New("alternates do not include the URL itself")
+)
+
+// hreflangIssues checks every hreflang cluster in urls: a URL must not
+// repeat a language code, must list itself among its alternates, and every
+// alternate that is also in the set must list the URL in return.
+func hreflangIssues(urls []*URL) []ValidationIssue {
+	byLoc := make(map[string]*URL, len(urls))
+	for _, entry := range urls {
+		if _, ok := byLoc[entry.Loc]; !ok {
+			byLoc[entry.Loc] = entry
+		}
+	}
+
+	var issues []ValidationIssue
+	for i, entry := range urls {
+		if len(entry.Alternate) == 0 {
+			continue
+		}
+		report := func(err error) {
+			issues = append(issues, ValidationIssue{Index: i, Loc: entry.Loc, Err: err})
+		}
+
+		langs := map[string]bool{}
+		self := false
+		for _, alt := range entry.Alternate {
+			lang := strings.ToLower(alt.HrefLang)
+			if langs[lang] {
+				report(fmt.Errorf("%w: %s", ErrDuplicateHreflang, alt.HrefLang))
+			}
+			langs[lang] = true
+
+			if alt.Href == entry.Loc {
+				self = true
+				continue
+			}
+			if other, ok := byLoc[alt.Href]; ok && !linksTo(other, entry.Loc) {
+				report(fmt.Errorf("%w: %s", ErrMissingReturnLink, alt.Href))
+			}
+		}
+		if !self {
+			report(ErrMissingSelfReference)
+		}
+	}
+	return issues
+}
+
+func linksTo(u *URL, href string) bool {
+	for _, alt := range u.Alternate {
+		if alt.Href == href {
+			return true
+		}
+	}
+	return false
+}
+
+// HreflangSet builds a set from translations of pages, keyed by page ID and
+// then by language code. Every language version gets its own entry listing
+// all versions of its page, itself included, so every cluster is complete
+// and reciprocal. A "x-default" version is listed as an alternate of the
+// others and gets an entry only if no language version shares its loc.
+// Entries are ordered by page ID and language; the given URLs are cloned,
+// not modified.
+func HreflangSet(translations map[string]map[string]*URL) (URLSet, error) {
+	out := MakeUrlSet()
+	for _, id := range slices.Sorted(maps.Keys(translations)) {
+		versions := translations[id]
+		langs := slices.Sorted(maps.Keys(versions))
+
+		alternates := make([]Alternate, 0, len(langs))
+		for _, lang := range langs {
+			alt, err := NewAlternate(lang, versions[lang].Loc)
+			if err != nil {
+				return out, fmt.Errorf("page %s: %w", id, err)
+			}
+			alternates = append(alternates, alt)
+		}
+		for _, lang := range langs {
+			version := versions[lang]
+			if strings.EqualFold(lang, "x-default") && sharesLoc(versions, lang) {
+				continue
+			}
+			entry := version.Clone()
+			entry.Alternate = slices.Clone(alternates)
+			out.URLs = append(out.URLs, entry)
+		}
+	}
+	return out, nil
+}
+
+// sharesLoc reports whether another version has the same loc as lang.
+func sharesLoc(versions map[string]*URL, lang string) bool {
+	for other, u := range versions {
+		if other != lang && u.Loc == versions[lang].Loc {
+			return true
+		}
+	}
+	return false
+}
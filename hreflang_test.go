@@ -0,0 +1,94 @@
+package sitemap_go
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestValidateHreflang(t *testing.T) {
+	alt := func(lang, href string) Alternate { return Alternate{Rel: "alternate", HrefLang: lang, Href: href} }
+	en, de, fr := "https://example.com/en", "https://example.com/de", "https://example.com/fr"
+	set := setOf(t, en, de, fr)
+	set.URLs[0].Alternate = []Alternate{alt("en", en), alt("de", de), alt("fr", fr)}
+	set.URLs[1].Alternate = []Alternate{alt("de", de), alt("DE", de)}
+	set.URLs[2].Alternate = []Alternate{alt("en", en)}
+
+	want := map[int][]error{
+		0: {ErrMissingReturnLink},
+		1: {ErrDuplicateHreflang},
+		2: {ErrMissingSelfReference},
+	}
+	got := map[int][]error{}
+	for _, issue := range set.Validate() {
+		for _, target := range []error{ErrDuplicateHreflang, ErrMissingReturnLink, ErrMissingSelfReference} {
+			if errors.Is(issue, target) {
+				got[issue.Index] = append(got[issue.Index], target)
+			}
+		}
+	}
+	for i, errs := range want {
+		if len(got[i]) != len(errs) || got[i][0] != errs[0] {
+			t.Errorf("URL %d: got %v, want %v", i, got[i], errs)
+		}
+	}
+}
+
+func TestHreflangSet(t *testing.T) {
+	set, err := HreflangSet(map[string]map[string]*URL{
+		"home": {
+			"en":        {Loc: "https://example.com/"},
+			"de":        {Loc: "https://example.com/de/"},
+			"x-default": {Loc: "https://example.com/"},
+		},
+		"about": {
+			"en": {Loc: "https://example.com/about"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"https://example.com/about", "https://example.com/de/", "https://example.com/"}
+	got := locsOf(&set)
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+	if n := len(set.URLs[1].Alternate); n != 3 {
+		t.Errorf("de lists %d alternates, want 3", n)
+	}
+	if issues := set.Validate(); len(issues) != 0 {
+		t.Errorf("built set has issues: %v", issues)
+	}
+	if _, err := HreflangSet(map[string]map[string]*URL{"p": {"not a lang!": {Loc: "https://example.com/"}}}); !errors.Is(err, ErrInvalidHreflang) {
+		t.Errorf("err = %v, want ErrInvalidHreflang", err)
+	}
+}
+
+func TestHreflangSetXDefault(t *testing.T) {
+	en := &URL{Loc: "https://example.com/en/"}
+	set, err := HreflangSet(map[string]map[string]*URL{
+		"home": {
+			"en":        en,
+			"x-default": {Loc: "https://example.com/choose"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := locsOf(&set), []string{"https://example.com/en/", "https://example.com/choose"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q; a distinct x-default gets its own entry", got, want)
+	}
+	if en.Alternate != nil || set.URLs[0] == en {
+		t.Error("the given URL was modified instead of cloned")
+	}
+	for _, u := range set.URLs {
+		if len(u.Alternate) != 2 || u.Alternate[1].HrefLang != "x-default" {
+			t.Errorf("%s: alternates %+v", u.Loc, u.Alternate)
+		}
+	}
+}
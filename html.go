@@ -0,0 +1,125 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"html"
+	"strings"
+)
+
+// htmlTag is a start tag found by scanHTML; attribute names are lowercased.
+type htmlTag struct {
+	name  string
+	attrs map[string]string
+}
+
+func (t htmlTag) attr(name string) string {
+	return t.attrs[name]
+}
+
+// scanHTML extracts the start tags named in want from an HTML document. It
+// is not a full HTML parser, but it skips comments and the contents of
+// script and style elements, which is enough to read links and metadata
+// reliably.
+func scanHTML(doc []byte, want ...string) []htmlTag {
+	var tags []htmlTag
+	for i := 0; i < len(doc); {
+		lt := bytes.IndexByte(doc[i:], '<')
+		if lt < 0 {
+			break
+		}
+		i += lt + 1
+		if bytes.HasPrefix(doc[i:], []byte("!--")) {
+			end := bytes.Index(doc[i+3:], []byte("-->"))
+			if end < 0 {
+				break
+			}
+			i += 3 + end + 3
+			continue
+		}
+		if i >= len(doc) || !isASCIILetter(doc[i]) {
+			continue
+		}
+
+		tag, n := parseHTMLTag(doc[i:])
+		i += n
+		switch tag.name {
+		case "script", "style":
+			end := bytes.Index(bytes.ToLower(doc[i:]), []byte("</"+tag.name))
+			if end < 0 {
+				return tags
+			}
+			i += end
+		}
+		for _, name := range want {
+			if tag.name == name {
+				tags = append(tags, tag)
+				break
+			}
+		}
+	}
+	return tags
+}
+
+// parseHTMLTag parses the tag starting at doc[0] (just past the '<') and
+// returns it with the number of bytes consumed.
+func parseHTMLTag(doc []byte) (htmlTag, int) {
+	i := 0
+	for i < len(doc) && !isHTMLSpace(doc[i]) && doc[i] != '>' && doc[i] != '/' {
+		i++
+	}
+	tag := htmlTag{name: strings.ToLower(string(doc[:i])), attrs: map[string]string{}}
+
+	for i < len(doc) {
+		for i < len(doc) && (isHTMLSpace(doc[i]) || doc[i] == '/') {
+			i++
+		}
+		if i >= len(doc) {
+			break
+		}
+		if doc[i] == '>' {
+			return tag, i + 1
+		}
+		start := i
+		for i < len(doc) && !isHTMLSpace(doc[i]) && doc[i] != '=' && doc[i] != '>' && doc[i] != '/' {
+			i++
+		}
+		name := strings.ToLower(string(doc[start:i]))
+		for i < len(doc) && isHTMLSpace(doc[i]) {
+			i++
+		}
+		value := ""
+		if i < len(doc) && doc[i] == '=' {
+			i++
+			for i < len(doc) && isHTMLSpace(doc[i]) {
+				i++
+			}
+			if i < len(doc) && (doc[i] == '"' || doc[i] == '\'') {
+				quote := doc[i]
+				end := bytes.IndexByte(doc[i+1:], quote)
+				if end < 0 {
+					end = len(doc) - i - 1
+				}
+				value = string(doc[i+1 : i+1+end])
+				i += end + 2
+			} else {
+				start := i
+				for i < len(doc) && !isHTMLSpace(doc[i]) && doc[i] != '>' {
+					i++
+				}
+				value = string(doc[start:i])
+			}
+		}
+		if _, ok := tag.attrs[name]; !ok && name != "" {
+			tag.attrs[name] = html.UnescapeString(value)
+		}
+	}
+	return tag, min(i, len(doc))
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,84 @@
+package sitemap_go
+
+import (
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LastModStrategy decides the lastmod a shard is listed with in its
+// sitemap index.
+type LastModStrategy interface {
+	// IndexLastMod returns the shard's lastmod, or nil for none, for an
+	// index generated at now.
+	IndexLastMod(shard Shard, now time.Time) *time.Time
+}
+
+// NewestLastMod lists a shard with the newest lastmod among its URLs, and
+// without a lastmod when none of them has one.
+type NewestLastMod struct{}
+
+func (NewestLastMod) IndexLastMod(shard Shard, now time.Time) *time.Time {
+	return shard.LastMod
+}
+
+// GenerationTime lists every shard with the time the index was generated.
+type GenerationTime struct{}
+
+func (GenerationTime) IndexLastMod(shard Shard, now time.Time) *time.Time {
+	return &now
+}
+
+// ContentChangeTime lists a shard with the time its content last changed,
+// judged by Shard.Hash, so regenerating identical shards does not make
+// them look modified. A shard seen for the first time is dated now.
+type ContentChangeTime struct {
+	// Versions maps shard names to the content last seen under them.
+	// Persist it between runs to keep dates across restarts.
+	Versions map[string]ContentVersion
+
+	mu sync.Mutex
+}
+
+type ContentVersion struct {
+	// Hash is the hex-encoded Shard.Hash.
+	Hash  string
+	Since time.Time
+}
+
+func (c *ContentChangeTime) IndexLastMod(shard Shard, now time.Time) *time.Time {
+	hash := hex.EncodeToString(shard.Hash[:])
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.Versions[shard.Name]
+	if !ok || v.Hash != hash {
+		if c.Versions == nil {
+			c.Versions = map[string]ContentVersion{}
+		}
+		v = ContentVersion{Hash: hash, Since: now}
+		c.Versions[shard.Name] = v
+	}
+	return &v.Since
+}
+
+// MakeShardIndex returns an index listing each shard under baseURL, with
+// lastmods set by strategy, NewestLastMod when nil.
+func MakeShardIndex(baseURL string, shards []Shard, strategy LastModStrategy) SitemapIndex {
+	return shardIndex(baseURL, shards, strategy, time.Now().UTC())
+}
+
+func shardIndex(baseURL string, shards []Shard, strategy LastModStrategy, now time.Time) SitemapIndex {
+	if strategy == nil {
+		strategy = NewestLastMod{}
+	}
+	index := MakeSitemapIndex(nil)
+	base := strings.TrimSuffix(baseURL, "/") + "/"
+	for _, shard := range shards {
+		index.Sitemaps = append(index.Sitemaps, SitemapEntry{
+			Loc:     base + shard.Name,
+			LastMod: strategy.IndexLastMod(shard, now),
+		})
+	}
+	return index
+}
@@ -0,0 +1,88 @@
+package sitemap_go
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIndexLastModStrategies(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	newest := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	shards := []Shard{{Name: "sitemap-1.xml", LastMod: &newest}, {Name: "sitemap-2.xml"}}
+
+	index := shardIndex("https://example.com/sitemaps", shards, nil, now)
+	if index.Sitemaps[0].Loc != "https://example.com/sitemaps/sitemap-1.xml" || index.Sitemaps[0].LastMod != &newest || index.Sitemaps[1].LastMod != nil {
+		t.Errorf("NewestLastMod index = %+v", index.Sitemaps)
+	}
+	index = shardIndex("https://example.com/", shards, GenerationTime{}, now)
+	for _, entry := range index.Sitemaps {
+		if entry.LastMod == nil || !entry.LastMod.Equal(now) {
+			t.Errorf("GenerationTime: %s dated %v", entry.Loc, entry.LastMod)
+		}
+	}
+}
+
+func TestContentChangeTime(t *testing.T) {
+	c := &ContentChangeTime{}
+	first := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	later := first.Add(24 * time.Hour)
+	a := Shard{Name: "sitemap-1.xml", Hash: [32]byte{1}}
+	b := Shard{Name: "sitemap-2.xml", Hash: [32]byte{2}}
+
+	if got := c.IndexLastMod(a, first); !got.Equal(first) {
+		t.Errorf("new shard dated %v", got)
+	}
+	c.IndexLastMod(b, first)
+	if got := c.IndexLastMod(a, later); !got.Equal(first) {
+		t.Errorf("unchanged shard redated to %v", got)
+	}
+	b.Hash[1] = 1
+	if got := c.IndexLastMod(b, later); !got.Equal(later) {
+		t.Errorf("changed shard dated %v", got)
+	}
+
+	// Persisted versions carry dates across restarts.
+	restored := &ContentChangeTime{Versions: c.Versions}
+	if got := restored.IndexLastMod(a, later.Add(time.Hour)); !got.Equal(first) {
+		t.Errorf("restored strategy dated %v", got)
+	}
+}
+
+func TestPublishIndexLastMod(t *testing.T) {
+	ctx := context.Background()
+	storage := &memStorage{}
+	p := &Publisher{Storage: storage, BaseURL: "https://example.com/", Shards: ShardOptions{MaxURLs: 2}}
+	result, err := p.Publish(ctx, numberedSet(t, 3, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := ParseXMLSitemapIndex(storage.get("sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range index.Sitemaps {
+		if entry.LastMod == nil || !entry.LastMod.Equal(result.Time) {
+			t.Errorf("default: %s dated %v, want the publish time %v", entry.Loc, entry.LastMod, result.Time)
+		}
+	}
+
+	p.Shards.IndexLastMod = &ContentChangeTime{}
+	first, err := p.Publish(ctx, numberedSet(t, 3, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	set := numberedSet(t, 3, "")
+	set.URLs[2].Loc += "?changed"
+	if _, err := p.Publish(ctx, set); err != nil {
+		t.Fatal(err)
+	}
+	index, err = ParseXMLSitemapIndex(storage.get("sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !index.Sitemaps[0].LastMod.Equal(first.Time) || index.Sitemaps[1].LastMod.Equal(first.Time) {
+		t.Errorf("ContentChangeTime: dated %v and %v, first publish at %v", index.Sitemaps[0].LastMod, index.Sitemaps[1].LastMod, first.Time)
+	}
+}
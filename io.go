@@ -0,0 +1,62 @@
+package sitemap_go
+
+import (
+	"bufio"
+	"io"
+)
+
+// WriteTo implements io.WriterTo, encoding the set with the default
+// EncodeOptions.
+func (u *URLSet) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := u.Encode(cw, EncodeOptions{})
+	return cw.n, err
+}
+
+// ReadFrom implements io.ReaderFrom, replacing the set with the urlset
+// decoded from r.
+func (u *URLSet) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	out, err := decodeURLSet(cr, -1, ParseOptions{})
+	if err == nil {
+		*u = out
+	}
+	return cr.n, err
+}
+
+// WriteText writes the set in the sitemap text format: one loc per line,
+// UTF-8 encoded, without any of the other fields.
+func (u *URLSet) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, url := range u.URLs {
+		bw.WriteString(url.Loc)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+func (si *SitemapIndex) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := si.Encode(cw, EncodeOptions{})
+	return cw.n, err
+}
+
+func (si *SitemapIndex) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	out, err := decodeSitemapIndex(cr, ParseOptions{})
+	if err == nil {
+		*si = out
+	}
+	return cr.n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,61 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestURLSetWriteToReadFrom(t *testing.T) {
+	set := richSet(5)
+	var want bytes.Buffer
+	if err := set.Encode(&want, EncodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	var w io.WriterTo = set
+	n, err := w.WriteTo(&buf)
+	if err != nil || n != int64(want.Len()) || buf.String() != want.String() {
+		t.Fatalf("WriteTo wrote %d bytes, %v; want the Encode output of %d bytes", n, err, want.Len())
+	}
+
+	var got URLSet
+	var r io.ReaderFrom = &got
+	n, err = r.ReadFrom(&buf)
+	if err != nil || n != int64(want.Len()) {
+		t.Fatalf("ReadFrom read %d bytes, %v", n, err)
+	}
+	if !slices.Equal(locsOf(&got), locsOf(set)) || len(got.URLs[0].Images) != 2 {
+		t.Errorf("ReadFrom decoded %q", locsOf(&got))
+	}
+
+	got = *setOf(t, "https://example.com/kept")
+	if _, err := got.ReadFrom(strings.NewReader("<urlset><url>")); err == nil {
+		t.Error("truncated document accepted")
+	}
+	if !slices.Equal(locsOf(&got), []string{"https://example.com/kept"}) {
+		t.Errorf("failed ReadFrom changed the set: %q", locsOf(&got))
+	}
+}
+
+func TestSitemapIndexWriteToReadFrom(t *testing.T) {
+	index := &SitemapIndex{Sitemaps: []SitemapEntry{{Loc: "https://example.com/sitemap-1.xml"}, {Loc: "https://example.com/sitemap-2.xml"}}}
+	var buf bytes.Buffer
+	n, err := index.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("WriteTo = %d, %v", n, err)
+	}
+	var got SitemapIndex
+	if _, err := got.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Sitemaps) != 2 || got.Sitemaps[1].Loc != "https://example.com/sitemap-2.xml" {
+		t.Errorf("got %+v", got.Sitemaps)
+	}
+	if _, err := got.ReadFrom(strings.NewReader("<urlset></urlset>")); err == nil {
+		t.Error("urlset read as an index")
+	}
+}
@@ -0,0 +1,106 @@
+package sitemap_go
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IRIToURI maps an internationalized resource identifier to a URI: the host
+// is converted to punycode and any other non-ASCII character is
+// percent-encoded as UTF-8, per RFC 3987 section 3.1. ASCII-only input is
+// returned unchanged.
+func IRIToURI(iri string) (string, error) {
+	if isASCII(iri) {
+		return iri, nil
+	}
+
+	rest := iri
+	var prefix string
+	if i := strings.Index(rest, "://"); i >= 0 {
+		prefix, rest = rest[:i+3], rest[i+3:]
+	}
+	if prefix != "" {
+		end := strings.IndexAny(rest, "/?#")
+		if end < 0 {
+			end = len(rest)
+		}
+		authority, err := iriAuthorityToASCII(rest[:end])
+		if err != nil {
+			return "", fmt.Errorf("convert %q: %w", iri, err)
+		}
+		prefix += authority
+		rest = rest[end:]
+	}
+	return prefix + percentEncodeNonASCII(rest), nil
+}
+
+func iriAuthorityToASCII(authority string) (string, error) {
+	var userinfo string
+	if i := strings.LastIndex(authority, "@"); i >= 0 {
+		userinfo, authority = percentEncodeNonASCII(authority[:i+1]), authority[i+1:]
+	}
+	host, port := authority, ""
+	if i := strings.LastIndex(authority, ":"); i >= 0 && !strings.Contains(authority[i:], "]") {
+		host, port = authority[:i], authority[i:]
+	}
+	host, err := toASCIIHost(host)
+	if err != nil {
+		return "", err
+	}
+	return userinfo + host + port, nil
+}
+
+func percentEncodeNonASCII(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x80 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0f])
+	}
+	return b.String()
+}
+
+// ConvertIRIs rewrites every loc in the set, including image, video and
+// alternate locations, with IRIToURI.
+func (u *URLSet) ConvertIRIs() error {
+	for _, entry := range u.URLs {
+		if err := entry.mapLocs(IRIToURI); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *URL) mapLocs(fn func(string) (string, error)) error {
+	var err error
+	apply := func(s *string) {
+		if err != nil || *s == "" {
+			return
+		}
+		*s, err = fn(*s)
+	}
+	apply(&u.Loc)
+	for i := range u.Images {
+		apply(&u.Images[i].Loc)
+		apply(&u.Images[i].License)
+	}
+	for i := range u.Videos {
+		apply(&u.Videos[i].Loc)
+		apply(&u.Videos[i].ThumbnailLoc)
+		apply(&u.Videos[i].ContentLoc)
+		apply(&u.Videos[i].PlayerLoc)
+	}
+	for i := range u.Alternate {
+		apply(&u.Alternate[i].Href)
+	}
+	return err
+}
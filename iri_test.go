@@ -0,0 +1,52 @@
+package sitemap_go
+
+import "testing"
+
+func TestIRIToURI(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"https://example.com/a?b=c", "https://example.com/a?b=c"},
+		{"https://münchen.de/straße", "https://xn--mnchen-3ya.de/stra%C3%9Fe"},
+		{"https://Bücher.example:8080/", "https://xn--bcher-kva.example:8080/"},
+		{"https://例え.テスト/", "https://xn--r8jz45g.xn--zckzah/"},
+		{"https://usér@example.com/?q=ü#é", "https://us%C3%A9r@example.com/?q=%C3%BC#%C3%A9"},
+		{"/relative/ü", "/relative/%C3%BC"},
+	}
+	for _, tt := range tests {
+		got, err := IRIToURI(tt.in)
+		if err != nil {
+			t.Errorf("%s: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPunycodeEncode(t *testing.T) {
+	// Samples from RFC 3492 section 7.1.
+	tests := []struct{ in, want string }{
+		{"他们为什么不说中文", "ihqwcrb4cv8a8dqg056pqjye"},
+		{"Pročprostěnemluvíčesky", "Proprostnemluvesky-uyb24dma41a"},
+		{"ü", "tda"},
+	}
+	for _, tt := range tests {
+		got, err := punycodeEncode(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestConvertIRIs(t *testing.T) {
+	set := setOf(t, "https://example.com/ü")
+	set.URLs[0].Images = []Image{{Loc: "https://example.com/é.jpg"}}
+	set.URLs[0].Alternate = []Alternate{{Rel: "alternate", HrefLang: "de", Href: "https://münchen.de/"}}
+	if err := set.ConvertIRIs(); err != nil {
+		t.Fatal(err)
+	}
+	u := set.URLs[0]
+	if u.Loc != "https://example.com/%C3%BC" || u.Images[0].Loc != "https://example.com/%C3%A9.jpg" || u.Alternate[0].Href != "https://xn--mnchen-3ya.de/" {
+		t.Errorf("got %s, %s, %s", u.Loc, u.Images[0].Loc, u.Alternate[0].Href)
+	}
+}
@@ -0,0 +1,52 @@
+package sitemap_go
+
+import "time"
+
+type LastModPrecision int
+
+const (
+	// LastModFull keeps sub-second precision.
+	LastModFull LastModPrecision = iota
+	LastModSecond
+	LastModMinute
+	// LastModDate writes only the calendar date.
+	LastModDate
+)
+
+// LastModFormat controls how lastmod values are serialized. The zero value
+// writes full-precision UTC timestamps.
+type LastModFormat struct {
+	// Location is the time zone lastmods are written in. It defaults to
+	// UTC.
+	Location  *time.Location
+	Precision LastModPrecision
+	// Round rounds to the nearest unit of Precision instead of truncating.
+	Round bool
+}
+
+func (f LastModFormat) Format(t time.Time) string {
+	loc := f.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	switch f.Precision {
+	case LastModSecond:
+		return f.reduce(t, time.Second).Format(time.RFC3339)
+	case LastModMinute:
+		return f.reduce(t, time.Minute).Format("2006-01-02T15:04Z07:00")
+	case LastModDate:
+		if f.Round {
+			t = t.Add(12 * time.Hour)
+		}
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func (f LastModFormat) reduce(t time.Time, unit time.Duration) time.Time {
+	if f.Round {
+		return t.Round(unit)
+	}
+	return t.Truncate(unit)
+}
@@ -0,0 +1,72 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLastModFormat(t *testing.T) {
+	ts := time.Date(2024, 3, 4, 22, 59, 30, 600_000_000, time.UTC)
+	paris := time.FixedZone("CET", 3600)
+	tests := []struct {
+		name string
+		f    LastModFormat
+		want string
+	}{
+		{"zero", LastModFormat{}, "2024-03-04T22:59:30.6Z"},
+		{"location", LastModFormat{Location: paris}, "2024-03-04T23:59:30.6+01:00"},
+		{"second", LastModFormat{Precision: LastModSecond}, "2024-03-04T22:59:30Z"},
+		{"second round", LastModFormat{Precision: LastModSecond, Round: true}, "2024-03-04T22:59:31Z"},
+		{"minute", LastModFormat{Precision: LastModMinute}, "2024-03-04T22:59Z"},
+		{"minute round", LastModFormat{Precision: LastModMinute, Round: true}, "2024-03-04T23:00Z"},
+		{"minute location", LastModFormat{Precision: LastModMinute, Location: paris}, "2024-03-04T23:59+01:00"},
+		{"date", LastModFormat{Precision: LastModDate}, "2024-03-04"},
+		{"date round", LastModFormat{Precision: LastModDate, Round: true}, "2024-03-05"},
+		{"date location", LastModFormat{Precision: LastModDate, Location: time.FixedZone("X", 2*3600)}, "2024-03-05"},
+	}
+	for _, tt := range tests {
+		if got := tt.f.Format(ts); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+	// Every format must parse back.
+	for _, tt := range tests {
+		set, err := ParseXMLUrlSet(`<urlset><url><loc>a</loc><lastmod>` + tt.want + `</lastmod></url></urlset>`)
+		if err != nil || set.URLs[0].LastMod == nil {
+			t.Errorf("%s: %s does not parse: %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestEncodeLastMod(t *testing.T) {
+	ts := time.Date(2024, 3, 4, 5, 6, 7, 8, time.UTC)
+	set := MakeUrlSet()
+	set.URLs = append(set.URLs, MakeUrl("https://example.com/", WithLastMod(ts)))
+	index := SitemapIndex{Sitemaps: []SitemapEntry{{Loc: "https://example.com/sitemap-1.xml", LastMod: &ts}}}
+	opts := EncodeOptions{LastMod: LastModFormat{Precision: LastModDate}}
+
+	var buf bytes.Buffer
+	if err := set.Encode(&buf, opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Encode(&buf, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(buf.String(), "<lastmod>2024-03-04</lastmod>"); got != 2 {
+		t.Errorf("%d lastmods in the date format:\n%s", got, buf.String())
+	}
+
+	buf.Reset()
+	w := NewWriter(&buf, nil, opts)
+	if err := w.Write(set.URLs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<lastmod>2024-03-04</lastmod>") {
+		t.Errorf("Writer ignored the lastmod format:\n%s", buf.String())
+	}
+}
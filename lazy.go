@@ -0,0 +1,278 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// URLSource provides the URLs of a sitemap rendered on request. Slice must
+// return URLs in the same order on every call, so shards stay stable.
+type URLSource interface {
+	Len(ctx context.Context) (int, error)
+	Slice(ctx context.Context, offset, limit int) ([]*URL, error)
+}
+
+type setSource struct {
+	set *URLSet
+}
+
+// SetSource serves the URLs of set, which must not be modified afterwards.
+func SetSource(set *URLSet) URLSource {
+	return setSource{set: set}
+}
+
+func (s setSource) Len(ctx context.Context) (int, error) {
+	return len(s.set.URLs), nil
+}
+
+func (s setSource) Slice(ctx context.Context, offset, limit int) ([]*URL, error) {
+	urls := s.set.URLs
+	offset = min(offset, len(urls))
+	return urls[offset:min(offset+limit, len(urls))], nil
+}
+
+type storeSource struct {
+	store URLStore
+}
+
+// StoreSource serves the URLs of store in loc order. Every call snapshots
+// the store.
+func StoreSource(store URLStore) URLSource {
+	return storeSource{store: store}
+}
+
+func (s storeSource) Len(ctx context.Context) (int, error) {
+	urls, err := s.store.Snapshot(ctx)
+	return len(urls), err
+}
+
+func (s storeSource) Slice(ctx context.Context, offset, limit int) ([]*URL, error) {
+	urls, err := s.store.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	offset = min(offset, len(urls))
+	return urls[offset:min(offset+limit, len(urls))], nil
+}
+
+// LazySitemap serves a URLSource as a sitemap index and numbered shards,
+// such as /sitemap.xml and /sitemaps/1.xml, encoding each document when it
+// is requested. Shards are cut by URL count only; their index entries carry
+// no lastmod, since that would mean reading every shard to render the
+// index.
+type LazySitemap struct {
+	Source URLSource
+	// BaseURL is the public URL the documents are served under, e.g.
+	// "https://example.com".
+	BaseURL string
+	// IndexName defaults to "sitemap.xml".
+	IndexName string
+	// ShardDir holds the shards, named 1.xml, 2.xml and so on by
+	// SequentialNames without a prefix. It defaults to "sitemaps/".
+	ShardDir string
+	// MaxURLs caps the URLs per shard. It defaults to MaxURLsPerSitemap.
+	MaxURLs int
+	// Template supplies the namespaces and settings of every shard. It
+	// defaults to MakeUrlSet().
+	Template *URLSet
+	Encode   EncodeOptions
+	// Cache keeps rendered documents until CacheTTL passes, if it is
+	// positive, or until the sitemap is swapped in or out of a registry
+	// again. Swapping is how a changed Source is announced.
+	Cache    bool
+	CacheTTL time.Duration
+	// OnCache, when set, is called for every request to a cached sitemap
+	// with the document's name and whether it was served from the cache.
+	OnCache func(name string, hit bool)
+
+	mu     sync.Mutex
+	cache  map[string]lazyCacheEntry
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type lazyCacheEntry struct {
+	doc     *servedDocument
+	expires time.Time
+}
+
+// LazyCacheStats counts the requests served from and past the cache.
+type LazyCacheStats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+}
+
+func (l *LazySitemap) CacheStats() LazyCacheStats {
+	l.mu.Lock()
+	entries := len(l.cache)
+	l.mu.Unlock()
+	return LazyCacheStats{Hits: l.hits.Load(), Misses: l.misses.Load(), Entries: entries}
+}
+
+// Invalidate drops every cached document.
+func (l *LazySitemap) Invalidate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.cache)
+}
+
+// SwapLazy installs l as the lazily rendered sitemap for host, or for every
+// host when host is empty, returning the previous one. Documents stored
+// under the same names take precedence. A nil l removes it.
+func (r *Registry) SwapLazy(host string, l *LazySitemap) *LazySitemap {
+	host = strings.ToLower(host)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.lazy[host]
+	if old != nil {
+		old.Invalidate()
+	}
+	if l == nil {
+		delete(r.lazy, host)
+		return old
+	}
+	if r.lazy == nil {
+		r.lazy = map[string]*LazySitemap{}
+	}
+	l.Invalidate()
+	r.lazy[host] = l
+	return old
+}
+
+func (r *Registry) lazyFor(host string) *LazySitemap {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if l, ok := r.lazy[host]; ok {
+		return l
+	}
+	return r.lazy[""]
+}
+
+func (l *LazySitemap) indexName() string {
+	return orDefault(l.IndexName, defaultIndexName)
+}
+
+func (l *LazySitemap) shardDir() string {
+	dir := strings.Trim(orDefault(l.ShardDir, "sitemaps/"), "/")
+	if dir == "" {
+		return ""
+	}
+	return dir + "/"
+}
+
+func (l *LazySitemap) maxURLs() int {
+	return ShardOptions{MaxURLs: l.MaxURLs}.maxURLs()
+}
+
+// render returns the document served under path, or nil if path is not
+// one of l's routes or names a shard past the end, going through the cache
+// when it is enabled.
+func (l *LazySitemap) render(ctx context.Context, path string) (*servedDocument, error) {
+	if !l.Cache {
+		return l.renderUncached(ctx, path)
+	}
+	now := time.Now()
+	l.mu.Lock()
+	entry, ok := l.cache[path]
+	l.mu.Unlock()
+	if ok && (entry.expires.IsZero() || now.Before(entry.expires)) {
+		l.recordCache(path, true)
+		return entry.doc, nil
+	}
+
+	doc, err := l.renderUncached(ctx, path)
+	if err != nil || doc == nil {
+		return doc, err
+	}
+	l.recordCache(path, false)
+	entry = lazyCacheEntry{doc: doc}
+	if l.CacheTTL > 0 {
+		entry.expires = now.Add(l.CacheTTL)
+	}
+	l.mu.Lock()
+	if l.cache == nil {
+		l.cache = map[string]lazyCacheEntry{}
+	}
+	l.cache[path] = entry
+	l.mu.Unlock()
+	return doc, nil
+}
+
+func (l *LazySitemap) recordCache(name string, hit bool) {
+	if hit {
+		l.hits.Add(1)
+	} else {
+		l.misses.Add(1)
+	}
+	if l.OnCache != nil {
+		l.OnCache(name, hit)
+	}
+}
+
+func (l *LazySitemap) renderUncached(ctx context.Context, path string) (*servedDocument, error) {
+	if path == l.indexName() {
+		return l.renderIndex(ctx)
+	}
+	name, ok := strings.CutPrefix(path, l.shardDir())
+	if !ok {
+		return nil, nil
+	}
+	index, ok := sequentialIndex("", name)
+	if !ok {
+		return nil, nil
+	}
+	return l.renderShard(ctx, index+1)
+}
+
+func (l *LazySitemap) shardCount(ctx context.Context) (int, error) {
+	total, err := l.Source.Len(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return (total + l.maxURLs() - 1) / l.maxURLs(), nil
+}
+
+func (l *LazySitemap) renderIndex(ctx context.Context) (*servedDocument, error) {
+	count, err := l.shardCount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	index := MakeSitemapIndex(nil)
+	base := strings.TrimSuffix(l.BaseURL, "/") + "/" + l.shardDir()
+	for n := 1; n <= count; n++ {
+		name := SequentialNames{}.ShardName(ShardInfo{Index: n - 1})
+		index.Sitemaps = append(index.Sitemaps, SitemapEntry{Loc: base + name})
+	}
+	var buf bytes.Buffer
+	if err := index.Encode(&buf, l.Encode); err != nil {
+		return nil, err
+	}
+	doc := newServedDocument(buf.Bytes())
+	doc.index = &index
+	return doc, nil
+}
+
+func (l *LazySitemap) renderShard(ctx context.Context, n int) (*servedDocument, error) {
+	urls, err := l.Source.Slice(ctx, (n-1)*l.maxURLs(), l.maxURLs())
+	if err != nil {
+		return nil, fmt.Errorf("shard %d: %w", n, err)
+	}
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	set := ShardOptions{Template: l.Template}.template()
+	set.URLs = urls
+	var buf bytes.Buffer
+	if err := set.Encode(&buf, l.Encode); err != nil {
+		return nil, fmt.Errorf("shard %d: %w", n, err)
+	}
+	doc := newServedDocument(buf.Bytes())
+	doc.set = &set
+	return doc, nil
+}
@@ -0,0 +1,189 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLazySitemap(t *testing.T) {
+	r := MakeRegistry()
+	r.SwapLazy("", &LazySitemap{Source: SetSource(numberedSet(t, 5, "")), BaseURL: "https://example.com/", MaxURLs: 2})
+	h := r.Handler()
+
+	rec := serve(t, h, "GET", "example.com", "/sitemap.xml", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("index: status %d", rec.Code)
+	}
+	index, err := ParseXMLSitemapIndex(rec.Body.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var locs []string
+	for _, entry := range index.Sitemaps {
+		locs = append(locs, entry.Loc)
+	}
+	want := []string{"https://example.com/sitemaps/1.xml", "https://example.com/sitemaps/2.xml", "https://example.com/sitemaps/3.xml"}
+	if !slices.Equal(locs, want) {
+		t.Errorf("index lists %q, want %q", locs, want)
+	}
+
+	rec = serve(t, h, "GET", "example.com", "/sitemaps/3.xml", nil)
+	set, err := ParseXMLUrlSet(rec.Body.String())
+	if err != nil || !slices.Equal(locsOf(&set), []string{"https://example.com/4"}) {
+		t.Errorf("last shard = %q, %v", locsOf(&set), err)
+	}
+	for _, path := range []string{"/sitemaps/4.xml", "/sitemaps/0.xml", "/sitemaps/a.xml", "/other/1.xml"} {
+		if rec := serve(t, h, "GET", "example.com", path, nil); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", path, rec.Code)
+		}
+	}
+}
+
+func TestLazySitemapRouting(t *testing.T) {
+	r := MakeRegistry()
+	shared := &LazySitemap{Source: SetSource(setOf(t, "https://example.com/shared"))}
+	host := &LazySitemap{Source: SetSource(setOf(t, "https://other.example.com/own")), IndexName: "map.xml", ShardDir: "/"}
+	r.SwapLazy("", shared)
+	r.SwapLazy("Other.example.com", host)
+	if err := r.Put(context.Background(), "sitemaps/1.xml", []byte("<urlset>stored</urlset>"), ObjectMeta{}); err != nil {
+		t.Fatal(err)
+	}
+	h := r.Handler()
+
+	tests := []struct {
+		host, path, want string
+	}{
+		{"example.com", "/sitemaps/1.xml", "stored"},
+		{"other.example.com", "/1.xml", "https://other.example.com/own"},
+		{"other.example.com:8080", "/map.xml", "/1.xml"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, h, "GET", tt.host, tt.path, nil)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s%s: status %d, body:\n%s", tt.host, tt.path, rec.Code, rec.Body)
+		}
+	}
+
+	if old := r.SwapLazy("other.example.com", nil); old != host {
+		t.Errorf("SwapLazy returned %v, want the host's sitemap", old)
+	}
+	if rec := serve(t, h, "GET", "other.example.com", "/sitemap.xml", nil); rec.Code != http.StatusOK {
+		t.Errorf("after removal: status %d, want the shared sitemap", rec.Code)
+	}
+}
+
+// failingSource fails every call with errSource.
+type failingSource struct{}
+
+var errSource = errors.New("source failed")
+
+func (failingSource) Len(ctx context.Context) (int, error) { return 0, errSource }
+
+func (failingSource) Slice(ctx context.Context, offset, limit int) ([]*URL, error) {
+	return nil, errSource
+}
+
+func TestLazySitemapStoreSource(t *testing.T) {
+	store := &MemoryStore{}
+	ctx := context.Background()
+	for _, loc := range []string{"https://example.com/b", "https://example.com/a", "https://example.com/c"} {
+		store.Upsert(ctx, &URL{Loc: loc})
+	}
+	l := &LazySitemap{Source: StoreSource(store), MaxURLs: 2}
+	doc, err := l.render(ctx, "sitemaps/1.xml")
+	if err != nil || !slices.Equal(locsOf(doc.set), []string{"https://example.com/a", "https://example.com/b"}) {
+		t.Errorf("first shard = %v, %v", doc, err)
+	}
+
+	r := MakeRegistry()
+	r.SwapLazy("", &LazySitemap{Source: failingSource{}})
+	if rec := serve(t, r.Handler(), "GET", "example.com", "/sitemap.xml", nil); rec.Code != http.StatusInternalServerError {
+		t.Errorf("failing source: status %d, want 500", rec.Code)
+	}
+}
+
+// countingSource counts the Slice calls made to a SetSource.
+type countingSource struct {
+	URLSource
+	slices int
+}
+
+func (s *countingSource) Slice(ctx context.Context, offset, limit int) ([]*URL, error) {
+	s.slices++
+	return s.URLSource.Slice(ctx, offset, limit)
+}
+
+func TestLazySitemapCache(t *testing.T) {
+	source := &countingSource{URLSource: SetSource(numberedSet(t, 3, ""))}
+	var events []string
+	l := &LazySitemap{Source: source, Cache: true, OnCache: func(name string, hit bool) {
+		if hit {
+			name += " hit"
+		}
+		events = append(events, name)
+	}}
+	r := MakeRegistry()
+	r.SwapLazy("", l)
+	h := r.Handler()
+	get := func(path string) {
+		t.Helper()
+		if rec := serve(t, h, "GET", "example.com", path, nil); rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", path, rec.Code)
+		}
+	}
+
+	get("/sitemaps/1.xml")
+	get("/sitemaps/1.xml")
+	get("/sitemap.xml")
+	if source.slices != 1 {
+		t.Errorf("rendered the shard %d times, want 1", source.slices)
+	}
+	if want := []string{"sitemaps/1.xml", "sitemaps/1.xml hit", "sitemap.xml"}; !slices.Equal(events, want) {
+		t.Errorf("events = %q, want %q", events, want)
+	}
+	if stats := l.CacheStats(); stats != (LazyCacheStats{Hits: 1, Misses: 2, Entries: 2}) {
+		t.Errorf("stats = %+v", stats)
+	}
+	if rec := serve(t, h, "GET", "example.com", "/sitemaps/9.xml", nil); rec.Code != http.StatusNotFound || l.CacheStats().Entries != 2 {
+		t.Errorf("missing shard: status %d, %d entries", rec.Code, l.CacheStats().Entries)
+	}
+
+	// Swapping the sitemap in again announces a changed source.
+	source.slices = 0
+	r.SwapLazy("", l)
+	get("/sitemaps/1.xml")
+	if source.slices != 1 {
+		t.Errorf("swap did not invalidate the cache: %d renders", source.slices)
+	}
+	l.Invalidate()
+	if l.CacheStats().Entries != 0 {
+		t.Error("Invalidate kept entries")
+	}
+}
+
+func TestLazySitemapCacheTTL(t *testing.T) {
+	source := &countingSource{URLSource: SetSource(numberedSet(t, 1, ""))}
+	ctx := context.Background()
+	expiring := &LazySitemap{Source: source, Cache: true, CacheTTL: 1}
+	for range 3 {
+		if _, err := expiring.render(ctx, "sitemaps/1.xml"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if source.slices != 3 {
+		t.Errorf("expired entries served: %d renders, want 3", source.slices)
+	}
+
+	source.slices = 0
+	uncached := &LazySitemap{Source: source}
+	for range 2 {
+		uncached.render(ctx, "sitemaps/1.xml")
+	}
+	if source.slices != 2 || uncached.CacheStats() != (LazyCacheStats{}) {
+		t.Errorf("without Cache: %d renders, stats %+v", source.slices, uncached.CacheStats())
+	}
+}
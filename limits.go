@@ -0,0 +1,200 @@
+package sitemap_go
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+var (
+	ErrInputTooLarge = errors.New("input exceeds the parser's size limit")
+	ErrTooDeep       = errors.New("elements are nested too deeply")
+	ErrTokenTooLarge = errors.New("text or attribute exceeds the parser's size limit")
+	ErrInvalidUTF8   = errors.New("input is not valid UTF-8")
+)
+
+const (
+	defaultMaxParseDepth = 64
+	defaultMaxTokenBytes = 1 << 20
+)
+
+// parseLimits are the limits of a ParseOptions with defaults applied. Zero
+// means unlimited.
+type parseLimits struct {
+	bytes int64
+	depth int
+	token int
+}
+
+func (o ParseOptions) limits() parseLimits {
+	return parseLimits{
+		bytes: limitOrDefault(o.MaxBytes, MaxSitemapBytes),
+		depth: limitOrDefault(o.MaxDepth, defaultMaxParseDepth),
+		token: limitOrDefault(o.MaxTokenBytes, defaultMaxTokenBytes),
+	}
+}
+
+func limitOrDefault[T int | int64](v, def T) T {
+	switch {
+	case v < 0:
+		return 0
+	case v == 0:
+		return def
+	}
+	return v
+}
+
+// reader wraps r so the decoder reading from it fails with ErrInputTooLarge
+// past the byte limit and with ErrInvalidUTF8 on the first malformed byte.
+func (l parseLimits) reader(r io.Reader) io.Reader {
+	return &guardedReader{r: r, limit: l.bytes}
+}
+
+// check enforces the depth and size limits on tok, read at depth: the
+// nesting of the element it starts or belongs to, counting the root as 1.
+// Tokens are checked once the decoder has read them, so it is the byte
+// limit that bounds memory.
+func (l parseLimits) check(tok xml.Token, depth int) error {
+	size := 0
+	switch t := tok.(type) {
+	case xml.StartElement:
+		if l.depth > 0 && depth > l.depth {
+			return fmt.Errorf("%w: <%s> is more than %d levels deep", ErrTooDeep, t.Name.Local, l.depth)
+		}
+		for _, attr := range t.Attr {
+			size = max(size, len(attr.Value))
+		}
+	case xml.CharData:
+		size = len(t)
+	case xml.Comment:
+		size = len(t)
+	case xml.Directive:
+		size = len(t)
+	case xml.ProcInst:
+		size = len(t.Inst)
+	}
+	if l.token > 0 && size > l.token {
+		return fmt.Errorf("%w: %d bytes", ErrTokenTooLarge, size)
+	}
+	return nil
+}
+
+// readElement consumes the rest of the element opened by start at depth,
+// checking every token. With keep set it returns the element's tokens,
+// start and end included.
+func (l parseLimits) readElement(d *xml.Decoder, start xml.StartElement, depth int, keep bool) ([]xml.Token, error) {
+	var toks []xml.Token
+	if keep {
+		toks = append(toks, start.Copy())
+	}
+	for open := 1; open > 0; {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			open++
+		case xml.EndElement:
+			open--
+		}
+		if err := l.check(tok, depth+open-1); err != nil {
+			return nil, err
+		}
+		if keep {
+			toks = append(toks, xml.CopyToken(tok))
+		}
+	}
+	return toks, nil
+}
+
+// decodeElement decodes the element opened by start at depth into v once
+// readElement has checked all of it, so reflection never sees input past
+// the limits.
+func (l parseLimits) decodeElement(d *xml.Decoder, start xml.StartElement, depth int, v any) error {
+	toks, err := l.readElement(d, start, depth, true)
+	if err != nil {
+		return err
+	}
+	list := tokenList(toks)
+	return xml.NewTokenDecoder(&list).Decode(v)
+}
+
+type tokenList []xml.Token
+
+func (t *tokenList) Token() (xml.Token, error) {
+	if len(*t) == 0 {
+		return nil, io.EOF
+	}
+	tok := (*t)[0]
+	*t = (*t)[1:]
+	return tok, nil
+}
+
+// guardedReader holds back a rune split across reads until it is complete,
+// so the decoder only ever sees validated bytes. Its reads come from the
+// decoder's bufio.Reader, whose buffer always has room for them.
+type guardedReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+	// off counts the bytes returned so far.
+	off     int64
+	pending []byte
+	err     error
+}
+
+func (g *guardedReader) Read(p []byte) (int, error) {
+	if g.err != nil {
+		return 0, g.err
+	}
+	if len(p) <= len(g.pending) {
+		return 0, io.ErrShortBuffer
+	}
+	k := copy(p, g.pending)
+	n, err := g.r.Read(p[k:])
+	g.n += int64(n)
+	if g.limit > 0 && g.n > g.limit {
+		g.err = fmt.Errorf("%w: more than %d bytes", ErrInputTooLarge, g.limit)
+		return 0, g.err
+	}
+
+	data := p[:k+n]
+	cut := len(data)
+	if err != io.EOF {
+		cut = completeRunes(data)
+	}
+	if !utf8.Valid(data[:cut]) {
+		g.err = fmt.Errorf("%w at byte %d", ErrInvalidUTF8, g.off+int64(firstInvalidRune(data[:cut])))
+		return 0, g.err
+	}
+	g.pending = append(g.pending[:0], data[cut:]...)
+	g.off += int64(cut)
+	return cut, err
+}
+
+// completeRunes returns the length of b without a rune cut off at its end.
+func completeRunes(b []byte) int {
+	for k := len(b) - 1; k >= 0 && k >= len(b)-utf8.UTFMax; k-- {
+		if utf8.RuneStart(b[k]) {
+			if !utf8.FullRune(b[k:]) {
+				return k
+			}
+			break
+		}
+	}
+	return len(b)
+}
+
+func firstInvalidRune(b []byte) int {
+	for i := 0; i < len(b); {
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return len(b)
+}
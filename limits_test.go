@@ -0,0 +1,74 @@
+package sitemap_go
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestParseLimits(t *testing.T) {
+	entry := func(inner string) string {
+		return `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/</loc>` + inner + `</url></urlset>`
+	}
+	tests := []struct {
+		name string
+		doc  string
+		opts ParseOptions
+		err  error
+	}{
+		{"within byte limit", entry(""), ParseOptions{MaxBytes: 200}, nil},
+		{"over byte limit", entry(strings.Repeat(" ", 200)), ParseOptions{MaxBytes: 200}, ErrInputTooLarge},
+		{"byte limit disabled", entry(strings.Repeat(" ", 200)), ParseOptions{MaxBytes: -1}, nil},
+		{"at depth limit", entry("<x/>"), ParseOptions{MaxDepth: 3}, nil},
+		{"too deep", entry("<x><y/></x>"), ParseOptions{MaxDepth: 3}, ErrTooDeep},
+		{"at default depth", entry(strings.Repeat("<x>", 62) + strings.Repeat("</x>", 62)), ParseOptions{}, nil},
+		{"past default depth", entry(strings.Repeat("<x>", 63) + strings.Repeat("</x>", 63)), ParseOptions{}, ErrTooDeep},
+		{"depth limit disabled", entry(strings.Repeat("<x>", 100) + strings.Repeat("</x>", 100)), ParseOptions{MaxDepth: -1}, nil},
+		{"text too large", entry(""), ParseOptions{MaxTokenBytes: 10}, ErrTokenTooLarge},
+		{"attribute too large", entry(`<x a="` + strings.Repeat("a", 40) + `"/>`), ParseOptions{MaxTokenBytes: 30}, ErrTokenTooLarge},
+		{"comment too large", entry("<!--" + strings.Repeat("c", 40) + "-->"), ParseOptions{MaxTokenBytes: 30}, ErrTokenTooLarge},
+		{"invalid UTF-8", entry("<x>\xff</x>"), ParseOptions{}, ErrInvalidUTF8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseXMLUrlSetWithOptions(tt.doc, tt.opts)
+			if tt.err == nil && err != nil || !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSitemapIndexLimits(t *testing.T) {
+	doc := "<sitemapindex><sitemap><loc>https://example.com/s.xml</loc>" + strings.Repeat("<x>", 64) + strings.Repeat("</x>", 64) + "</sitemap></sitemapindex>"
+	if _, err := ParseXMLSitemapIndex(doc); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("err = %v, want %v", err, ErrTooDeep)
+	}
+	if _, err := ParseXMLSitemapIndex("<sitemapindex><sitemap><loc>\xc3</loc></sitemap></sitemapindex>"); !errors.Is(err, ErrInvalidUTF8) {
+		t.Errorf("err = %v, want %v", err, ErrInvalidUTF8)
+	}
+}
+
+func TestGuardedReader(t *testing.T) {
+	// Reading one byte at a time splits every multi-byte rune across reads.
+	doc := "<urlset><url><loc>https://example.com/café/€/\U0001F600</loc></url></urlset>"
+	content, err := readAllGuarded(doc)
+	if err != nil || content != doc {
+		t.Errorf("read %q, %v", content, err)
+	}
+
+	_, err = readAllGuarded("<urlset>é\xe2\x82</urlset>")
+	if !errors.Is(err, ErrInvalidUTF8) || !strings.HasSuffix(err.Error(), "at byte 10") {
+		t.Errorf("err = %v, want %v at byte 10", err, ErrInvalidUTF8)
+	}
+	if _, err := readAllGuarded("\xe2\x82"); !errors.Is(err, ErrInvalidUTF8) {
+		t.Errorf("truncated rune at EOF: err = %v", err)
+	}
+}
+
+func readAllGuarded(doc string) (string, error) {
+	data, err := io.ReadAll(parseLimits{}.reader(iotest.OneByteReader(strings.NewReader(doc))))
+	return string(data), err
+}
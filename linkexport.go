@@ -0,0 +1,150 @@
+package sitemap_go
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+// LinkStatusMeta is the Meta key AttachLinkStatuses stores a URL's
+// LinkStatus under.
+const LinkStatusMeta = "link_status"
+
+// linkStatusRow is the exported form of a LinkStatus.
+type linkStatusRow struct {
+	Loc         string  `json:"loc"`
+	Status      int     `json:"status"`
+	LatencyMS   float64 `json:"latency_ms"`
+	Redirect    string  `json:"redirect,omitempty"`
+	Final       string  `json:"final,omitempty"`
+	FinalStatus int     `json:"final_status,omitempty"`
+	ContentType string  `json:"content_type,omitempty"`
+	Soft404     string  `json:"soft_404,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+var linkStatusColumns = []string{
+	"loc", "status", "latency_ms", "redirect", "final", "final_status", "content_type", "soft_404", "error",
+}
+
+func (s LinkStatus) row() linkStatusRow {
+	row := linkStatusRow{
+		Loc:         s.Loc,
+		Status:      s.StatusCode,
+		LatencyMS:   float64(s.Latency.Microseconds()) / 1000,
+		Redirect:    s.Location,
+		Final:       s.Final,
+		FinalStatus: s.FinalStatus,
+		ContentType: s.ContentType,
+		Soft404:     s.Soft404,
+	}
+	if s.Err != nil {
+		row.Error = s.Err.Error()
+	}
+	return row
+}
+
+func (row linkStatusRow) status() LinkStatus {
+	s := LinkStatus{
+		Loc:         row.Loc,
+		StatusCode:  row.Status,
+		Latency:     time.Duration(row.LatencyMS * float64(time.Millisecond)),
+		Location:    row.Redirect,
+		Final:       row.Final,
+		FinalStatus: row.FinalStatus,
+		ContentType: row.ContentType,
+		Soft404:     row.Soft404,
+	}
+	if row.Error != "" {
+		s.Err = errors.New(row.Error)
+	}
+	return s
+}
+
+// WriteLinkStatusesJSON writes statuses as JSON lines, one object per
+// status with the keys of the CSV columns.
+func WriteLinkStatusesJSON(w io.Writer, statuses []LinkStatus) error {
+	enc := json.NewEncoder(w)
+	for _, s := range statuses {
+		if err := enc.Encode(s.row()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadLinkStatusesJSON reads statuses written by WriteLinkStatusesJSON.
+// Errors come back as plain errors carrying the original message.
+func ReadLinkStatusesJSON(r io.Reader) ([]LinkStatus, error) {
+	var out []LinkStatus
+	dec := json.NewDecoder(r)
+	for {
+		var row linkStatusRow
+		err := dec.Decode(&row)
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+		out = append(out, row.status())
+	}
+}
+
+// WriteLinkStatusesCSV writes statuses as CSV with a header row: loc,
+// status, latency_ms, redirect, final, final_status, content_type, soft_404
+// and error. Unset numbers are written as empty cells.
+func WriteLinkStatusesCSV(w io.Writer, statuses []LinkStatus) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(linkStatusColumns); err != nil {
+		return err
+	}
+	number := func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+	for _, s := range statuses {
+		row := s.row()
+		err := cw.Write([]string{
+			row.Loc,
+			number(row.Status),
+			strconv.FormatFloat(row.LatencyMS, 'f', 3, 64),
+			row.Redirect,
+			row.Final,
+			number(row.FinalStatus),
+			row.ContentType,
+			row.Soft404,
+			row.Error,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// AttachLinkStatuses stores each status in the Meta of the URLs with its
+// loc, under LinkStatusMeta, so later stages of a pipeline can act on it.
+func AttachLinkStatuses(urls []*URL, statuses []LinkStatus) {
+	byLoc := make(map[string]LinkStatus, len(statuses))
+	for _, s := range statuses {
+		byLoc[s.Loc] = s
+	}
+	for _, u := range urls {
+		if s, ok := byLoc[u.Loc]; ok {
+			u.SetMeta(LinkStatusMeta, s)
+		}
+	}
+}
+
+// URLLinkStatus returns the status AttachLinkStatuses stored in u.
+func URLLinkStatus(u *URL) (LinkStatus, bool) {
+	s, ok := u.Meta[LinkStatusMeta].(LinkStatus)
+	return s, ok
+}
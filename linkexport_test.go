@@ -0,0 +1,66 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var exportStatuses = []LinkStatus{
+	{Loc: "https://example.com/a", StatusCode: 200, Latency: 12345 * time.Microsecond, ContentType: "text/html"},
+	{Loc: "https://example.com/old", StatusCode: 301, Latency: 2 * time.Millisecond, Location: "/new", Final: "https://example.com/new", FinalStatus: 200, Soft404: "small body"},
+	{Loc: "https://example.com/down", Err: errors.New(`dial tcp: connection refused, "x"`)},
+}
+
+func TestLinkStatusesJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLinkStatusesJSON(&buf, exportStatuses); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if want := `{"loc":"https://example.com/a","status":200,"latency_ms":12.345,"content_type":"text/html"}`; len(lines) != 3 || lines[0] != want {
+		t.Errorf("first line = %s, want %s", lines[0], want)
+	}
+	got, err := ReadLinkStatusesJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || !reflect.DeepEqual(got[:2], exportStatuses[:2]) {
+		t.Errorf("read back %+v", got)
+	}
+	if got[2].Err == nil || got[2].Err.Error() != exportStatuses[2].Err.Error() {
+		t.Errorf("error read back as %v", got[2].Err)
+	}
+	if _, err := ReadLinkStatusesJSON(strings.NewReader(`{"loc":`)); err == nil {
+		t.Error("truncated JSON read without error")
+	}
+}
+
+func TestLinkStatusesCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLinkStatusesCSV(&buf, exportStatuses); err != nil {
+		t.Fatal(err)
+	}
+	want := `loc,status,latency_ms,redirect,final,final_status,content_type,soft_404,error
+https://example.com/a,200,12.345,,,,text/html,,
+https://example.com/old,301,2.000,/new,https://example.com/new,200,,small body,
+https://example.com/down,,0.000,,,,,,"dial tcp: connection refused, ""x"""
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestAttachLinkStatuses(t *testing.T) {
+	set := setOf(t, "https://example.com/a", "https://example.com/b", "https://example.com/a")
+	AttachLinkStatuses(set.URLs, exportStatuses)
+	for i, want := range []bool{true, false, true} {
+		s, ok := URLLinkStatus(set.URLs[i])
+		if ok != want || ok && s.StatusCode != 200 {
+			t.Errorf("url %d: status %+v, %v", i, s, ok)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package sitemap_go
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LinkStatus is how a loc answered a link check.
+type LinkStatus struct {
+	Loc string
+	// StatusCode is the status the loc itself answered, or 0 when no
+	// response was received.
+	StatusCode int
+	// Latency is the time the loc took to answer, up to its headers.
+	Latency time.Duration
+	// Location is the target of a redirect.
+	Location string
+	// Final is where a chain of redirects ends, and FinalStatus what it
+	// answered. Both are unset for locs that do not redirect.
+	Final       string
+	FinalStatus int
+	// ContentType is the Content-Type of the page, the final destination
+	// for redirects.
+	ContentType string
+	// Soft404 says why a page that answered 200 looks like an error page,
+	// when soft 404 detection is enabled and flagged it.
+	Soft404 string
+	Err     error
+}
+
+// Gone reports whether the loc answered 404 Not Found or 410 Gone.
+func (s LinkStatus) Gone() bool {
+	return s.StatusCode == http.StatusNotFound || s.StatusCode == http.StatusGone
+}
+
+// CheckLinks requests the loc of every URL, with HEAD and falling back to
+// GET when the server does not allow HEAD, and returns their statuses in
+// order. Redirects are followed, up to 10 hops, to find their destination.
+// Requests run concurrently, up to f.Concurrency at once, and are paced by
+// f.RateLimiter.
+func (f *Fetcher) CheckLinks(ctx context.Context, urls []*URL) []LinkStatus {
+	return f.CheckLinksWithOptions(ctx, urls, LinkCheckOptions{})
+}
+
+func (f *Fetcher) CheckLinksWithOptions(ctx context.Context, urls []*URL, opts LinkCheckOptions) []LinkStatus {
+	concurrency := f.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultFetchConcurrency
+	}
+	out := make([]LinkStatus, len(urls))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			out[i] = f.checkLink(ctx, u.Loc, opts)
+		}()
+	}
+	wg.Wait()
+	return out
+}
+
+func (f *Fetcher) checkLink(ctx context.Context, loc string, opts LinkCheckOptions) LinkStatus {
+	status := f.followLink(ctx, loc)
+	if opts.Soft404 == nil || status.Err != nil {
+		return status
+	}
+	dest, code := status.Loc, status.StatusCode
+	if status.Redirected() {
+		dest, code = status.Final, status.FinalStatus
+	}
+	if code == http.StatusOK {
+		status.Soft404, status.Err = f.soft404(ctx, dest, opts.Soft404)
+	}
+	return status
+}
+
+func (f *Fetcher) followLink(ctx context.Context, loc string) LinkStatus {
+	status := LinkStatus{Loc: loc}
+	resp, err := f.head(ctx, loc)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	status.StatusCode, status.Latency = resp.status, resp.latency
+	status.Location = resp.header.Get("Location")
+	status.ContentType = resp.header.Get("Content-Type")
+	if !status.Redirected() {
+		return status
+	}
+	current, next := loc, status.Location
+	for range maxRedirects {
+		target, err := resolveReference(current, next)
+		if err != nil {
+			status.Err = err
+			return status
+		}
+		resp, err := f.head(ctx, target)
+		if err != nil {
+			status.Err = err
+			return status
+		}
+		current, next = target, resp.header.Get("Location")
+		if !isRedirect(resp.status) || next == "" {
+			status.Final, status.FinalStatus = target, resp.status
+			status.ContentType = resp.header.Get("Content-Type")
+			return status
+		}
+	}
+	status.Err = fmt.Errorf("%w: %s", ErrTooManyRedirects, loc)
+	return status
+}
+
+type linkResponse struct {
+	status  int
+	header  http.Header
+	latency time.Duration
+}
+
+// head requests loc without following redirects.
+func (f *Fetcher) head(ctx context.Context, loc string) (linkResponse, error) {
+	client := *clientOrBulk(f.HTTPClient)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	do := func(method string) (linkResponse, error) {
+		if err := waitForHost(ctx, f.RateLimiter, loc); err != nil {
+			return linkResponse{}, err
+		}
+		req, err := http.NewRequestWithContext(ctx, method, loc, nil)
+		if err != nil {
+			return linkResponse{}, err
+		}
+		if f.UserAgent != "" {
+			req.Header.Set("User-Agent", f.UserAgent)
+		}
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			return linkResponse{}, err
+		}
+		defer resp.Body.Close()
+		out := linkResponse{status: resp.StatusCode, header: resp.Header, latency: time.Since(start)}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+		return out, nil
+	}
+	resp, err := do(http.MethodHead)
+	if err == nil && (resp.status == http.StatusMethodNotAllowed || resp.status == http.StatusNotImplemented) {
+		return do(http.MethodGet)
+	}
+	return resp, err
+}
+
+// LinkPruner removes URLs from a store once they have answered 404 or 410
+// to Threshold audits in a row, so a long-lived sitemap does not accumulate
+// dead pages. A page that is only briefly missing keeps its entry.
+type LinkPruner struct {
+	Store   URLStore
+	Fetcher *Fetcher
+	// Threshold is the number of consecutive failing audits after which a
+	// URL is pruned. It defaults to 3.
+	Threshold int
+	// Redirects decides what happens to URLs that redirect. Moved URLs
+	// keep their fields under the new loc.
+	Redirects RedirectPolicy
+	// Soft404, when set, enables soft 404 detection. Soft 404s are only
+	// reported, never pruned.
+	Soft404 *Soft404Rules
+	// DryRun reports the URLs that would be pruned or moved without
+	// changing the store.
+	DryRun bool
+	// Strikes counts the consecutive failing audits of each loc. Persist it
+	// between runs to keep streaks across restarts.
+	Strikes map[string]int
+
+	mu sync.Mutex
+}
+
+type PruneReport struct {
+	Time    time.Time
+	Checked int
+	// Failing maps the locs that failed but are not yet pruned to their
+	// streaks.
+	Failing map[string]int
+	Pruned  []LinkStatus
+	// Redirects lists the locs that redirected, whatever the policy did
+	// with them.
+	Redirects []LinkStatus
+	Soft404   []LinkStatus
+	// Unreachable lists the locs that received no response. An unreachable
+	// loc neither extends nor resets its streak.
+	Unreachable []LinkStatus
+}
+
+// Audit checks every URL in the store and prunes the ones that reached the
+// threshold. It fails only when the store cannot be read or a URL cannot be
+// deleted, in which case the audit does not count toward any streak.
+func (p *LinkPruner) Audit(ctx context.Context) (PruneReport, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	report := PruneReport{Time: time.Now().UTC(), Failing: map[string]int{}}
+	urls, err := p.Store.Snapshot(ctx)
+	if err != nil {
+		return report, err
+	}
+	fetcher := p.Fetcher
+	if fetcher == nil {
+		fetcher = &Fetcher{}
+	}
+	threshold := orDefaultInt(p.Threshold, 3)
+
+	strikes := make(map[string]int, len(p.Strikes))
+	for i, status := range fetcher.CheckLinksWithOptions(ctx, urls, LinkCheckOptions{Soft404: p.Soft404}) {
+		report.Checked++
+		if status.Soft404 != "" {
+			report.Soft404 = append(report.Soft404, status)
+		}
+		if status.Redirected() {
+			report.Redirects = append(report.Redirects, status)
+			if err := p.applyRedirect(ctx, urls[i], status); err != nil {
+				return report, err
+			}
+			continue
+		}
+		switch {
+		case status.StatusCode == 0:
+			if n := p.Strikes[status.Loc]; n > 0 {
+				strikes[status.Loc] = n
+			}
+			report.Unreachable = append(report.Unreachable, status)
+			continue
+		case !status.Gone():
+			continue
+		}
+		n := p.Strikes[status.Loc] + 1
+		if n < threshold {
+			strikes[status.Loc] = n
+			report.Failing[status.Loc] = n
+			continue
+		}
+		if p.DryRun {
+			strikes[status.Loc] = n
+		} else if err := p.Store.Delete(ctx, status.Loc); err != nil {
+			return report, err
+		}
+		report.Pruned = append(report.Pruned, status)
+	}
+	p.Strikes = strikes
+	return report, nil
+}
+
+func (p *LinkPruner) applyRedirect(ctx context.Context, u *URL, status LinkStatus) error {
+	next := p.Redirects.apply(u, status)
+	if p.DryRun || next == u {
+		return nil
+	}
+	if next != nil {
+		if err := p.Store.Upsert(ctx, next); err != nil {
+			return err
+		}
+	}
+	return p.Store.Delete(ctx, u.Loc)
+}
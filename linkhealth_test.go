@@ -0,0 +1,137 @@
+package sitemap_go
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// statusServer answers each path with the status set for it, 200 by
+// default. /get-only refuses HEAD.
+type statusServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses map[string]int
+	methods  []string
+}
+
+func newStatusServer(t *testing.T) *statusServer {
+	s := &statusServer{statuses: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		code, ok := s.statuses[r.URL.Path]
+		s.methods = append(s.methods, r.Method+" "+r.URL.Path)
+		s.mu.Unlock()
+		switch {
+		case r.URL.Path == "/get-only" && r.Method == http.MethodHead:
+			code = http.StatusMethodNotAllowed
+		case !ok:
+			code = http.StatusOK
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *statusServer) set(path string, code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[path] = code
+}
+
+func TestCheckLinks(t *testing.T) {
+	srv := newStatusServer(t)
+	srv.set("/missing", http.StatusNotFound)
+	srv.set("/removed", http.StatusGone)
+	f := testFetcher(srv.Server)
+	urls := setOf(t, srv.URL+"/ok", srv.URL+"/missing", srv.URL+"/removed", srv.URL+"/get-only", "http://127.0.0.1:1/down").URLs
+	statuses := f.CheckLinks(context.Background(), urls)
+	codes := make([]int, len(statuses))
+	for i, s := range statuses {
+		codes[i] = s.StatusCode
+		if s.Loc != urls[i].Loc {
+			t.Errorf("status %d is for %s", i, s.Loc)
+		}
+	}
+	if want := []int{200, 404, 410, 200, 0}; !slices.Equal(codes, want) {
+		t.Errorf("codes = %v, want %v", codes, want)
+	}
+	if !statuses[1].Gone() || !statuses[2].Gone() || statuses[0].Gone() || statuses[4].Gone() {
+		t.Error("Gone misreports 404 and 410")
+	}
+	if statuses[0].ContentType != "text/html" || statuses[4].Err == nil {
+		t.Errorf("ok = %+v, down = %+v", statuses[0], statuses[4])
+	}
+	if !slices.Contains(srv.methods, "GET /get-only") || slices.Contains(srv.methods, "GET /ok") {
+		t.Errorf("requests = %q, want GET only after a refused HEAD", srv.methods)
+	}
+}
+
+func TestLinkPruner(t *testing.T) {
+	srv := newStatusServer(t)
+	ctx := context.Background()
+	store := &MemoryStore{}
+	for _, path := range []string{"/a", "/b", "/c"} {
+		store.Upsert(ctx, &URL{Loc: srv.URL + path})
+	}
+	store.Upsert(ctx, &URL{Loc: "http://127.0.0.1:1/down"})
+	p := &LinkPruner{Store: store, Fetcher: testFetcher(srv.Server), Threshold: 2}
+	a, b := srv.URL+"/a", srv.URL+"/b"
+	audit := func() PruneReport {
+		t.Helper()
+		report, err := p.Audit(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	srv.set("/a", http.StatusNotFound)
+	srv.set("/b", http.StatusGone)
+	report := audit()
+	if report.Checked != 4 || report.Failing[a] != 1 || report.Failing[b] != 1 || len(report.Pruned) != 0 || len(report.Unreachable) != 1 {
+		t.Errorf("first audit = %+v", report)
+	}
+
+	// b recovers, which resets its streak; a reaches the threshold.
+	srv.set("/b", http.StatusOK)
+	report = audit()
+	if len(report.Pruned) != 1 || report.Pruned[0].Loc != a || len(report.Failing) != 0 {
+		t.Errorf("second audit = %+v", report)
+	}
+	srv.set("/b", http.StatusNotFound)
+	if report := audit(); report.Failing[b] != 1 {
+		t.Errorf("b's streak was not reset: %+v", report.Failing)
+	}
+	locs := snapshotLocs(t, store)
+	slices.Sort(locs)
+	if want := []string{"http://127.0.0.1:1/down", b, srv.URL + "/c"}; !slices.Equal(locs, want) {
+		t.Errorf("store = %q, want %q", locs, want)
+	}
+}
+
+func TestLinkPrunerDryRunAndStrikes(t *testing.T) {
+	srv := newStatusServer(t)
+	srv.set("/a", http.StatusNotFound)
+	ctx := context.Background()
+	store := &MemoryStore{}
+	a := srv.URL + "/a"
+	store.Upsert(ctx, &URL{Loc: a})
+	// Strikes persisted by an earlier run carry the streak over.
+	p := &LinkPruner{Store: store, Fetcher: testFetcher(srv.Server), DryRun: true, Strikes: map[string]int{a: 2}}
+	report, err := p.Audit(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Pruned) != 1 || p.Strikes[a] != 3 {
+		t.Errorf("report = %+v, strikes = %v", report, p.Strikes)
+	}
+	if locs := snapshotLocs(t, store); len(locs) != 1 {
+		t.Errorf("dry run pruned the store: %q", locs)
+	}
+}
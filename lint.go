@@ -0,0 +1,54 @@
+package sitemap_go
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUniformPriority     = errors.New("every URL has priority 1.0")
+	ErrUniformChangeFreq   = errors.New("every URL has changefreq always")
+	ErrGenerationTimeStamp = errors.New("every lastmod is within a minute of the others, as if stamped at generation time")
+)
+
+// lintMinURLs is the smallest set the anti-pattern checks apply to; in
+// smaller sets uniform values are as likely to be accurate.
+const lintMinURLs = 10
+
+// lintIssues flags set-wide patterns search engines ignore: priority 1.0
+// or changefreq always on every URL, and lastmods that all record the
+// moment the sitemap was generated rather than when pages changed. The
+// issues have Index -1, as they concern the set rather than a URL.
+func lintIssues(urls []*URL) []ValidationIssue {
+	if len(urls) < lintMinURLs {
+		return nil
+	}
+	allTop, allAlways, allStamped := true, true, true
+	var oldest, newest time.Time
+	for i, entry := range urls {
+		allTop = allTop && entry.Priority != nil && *entry.Priority == 1
+		allAlways = allAlways && entry.ChangeFreq == ChangeFreqAlways
+		if entry.LastMod == nil {
+			allStamped = false
+			continue
+		}
+		if i == 0 || entry.LastMod.Before(oldest) {
+			oldest = *entry.LastMod
+		}
+		if i == 0 || entry.LastMod.After(newest) {
+			newest = *entry.LastMod
+		}
+	}
+	allStamped = allStamped && newest.Sub(oldest) < time.Minute
+
+	var issues []ValidationIssue
+	report := func(flagged bool, err error) {
+		if flagged {
+			issues = append(issues, ValidationIssue{Index: -1, Err: err})
+		}
+	}
+	report(allTop, ErrUniformPriority)
+	report(allAlways, ErrUniformChangeFreq)
+	report(allStamped, ErrGenerationTimeStamp)
+	return issues
+}
@@ -0,0 +1,89 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// lintSet returns n URLs built with options, the i-th lastmod offset by
+// i*spacing from a fixed time.
+func lintSet(n int, spacing time.Duration, options ...UrlOption) []*URL {
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	urls := make([]*URL, n)
+	for i := range urls {
+		urls[i] = MakeUrl(fmt.Sprintf("https://example.com/%d", i), append(options, WithLastMod(base.Add(time.Duration(i)*spacing)))...)
+	}
+	return urls
+}
+
+func TestLintIssues(t *testing.T) {
+	tests := []struct {
+		name string
+		urls []*URL
+		want []error
+	}{
+		{"varied", lintSet(lintMinURLs, time.Hour, WithPriority(0.5)), nil},
+		{"small", lintSet(lintMinURLs-1, 0, WithPriority(1), WithChangeFreq(ChangeFreqAlways)), nil},
+		{"priority", lintSet(lintMinURLs, time.Hour, WithPriority(1)), []error{ErrUniformPriority}},
+		{"changefreq", lintSet(lintMinURLs, time.Hour, WithChangeFreq(ChangeFreqAlways)), []error{ErrUniformChangeFreq}},
+		{"stamped", lintSet(lintMinURLs, time.Second, WithPriority(0.5)), []error{ErrGenerationTimeStamp}},
+		{"all", lintSet(20, time.Millisecond, WithPriority(1), WithChangeFreq(ChangeFreqAlways)), []error{ErrUniformPriority, ErrUniformChangeFreq, ErrGenerationTimeStamp}},
+	}
+	for _, tt := range tests {
+		issues := lintIssues(tt.urls)
+		if len(issues) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, issues, tt.want)
+			continue
+		}
+		for i, issue := range issues {
+			if issue.Index != -1 || !errors.Is(issue.Err, tt.want[i]) {
+				t.Errorf("%s: issue %d = %+v, want %v", tt.name, i, issue, tt.want[i])
+			}
+		}
+	}
+
+	// One page without a lastmod means they are not all generation stamps,
+	// and neither are varied priorities uniform.
+	urls := lintSet(lintMinURLs, 0, WithPriority(1))
+	urls[3].LastMod = nil
+	*urls[4].Priority = 0.9
+	if issues := lintIssues(urls); len(issues) != 0 {
+		t.Errorf("got %v", issues)
+	}
+}
+
+func TestValidateLint(t *testing.T) {
+	set := MakeUrlSet()
+	set.URLs = lintSet(lintMinURLs, time.Hour, WithPriority(1))
+	issues := set.Validate()
+	if len(issues) != 1 {
+		t.Fatalf("got %v", issues)
+	}
+	issue := issues[0]
+	if issue.Rule != RulePriorityUniform || issue.Severity != SeverityWarning {
+		t.Errorf("issue = %+v", issue)
+	}
+	if got, want := issue.Error(), "warning: sitemap: "+ErrUniformPriority.Error()+" ["+RulePriorityUniform+"]"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if issues := set.Validate(WithRuleSet(RuleSet{RulePriorityUniform: SeverityOff})); len(issues) != 0 {
+		t.Errorf("disabled rule reported: %v", issues)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, issues, "sitemap.xml"); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	result := log.Runs[0].Results[0]
+	if result.Message.Text != ErrUniformPriority.Error() || result.Locations[0].LogicalLocations[0].FullyQualifiedName != "urlset" {
+		t.Errorf("SARIF result = %+v", result)
+	}
+}
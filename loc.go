@@ -0,0 +1,74 @@
+package sitemap_go
+
+import (
+	"errors"
+	"fmt"
+)
+
+const MaxLocLength = 2048
+
+var (
+	ErrLocTooLong     = errors.New("loc exceeds 2048 characters")
+	ErrLocInvalidChar = errors.New("loc contains a character that is not legal in a URI")
+)
+
+type LocError struct {
+	Loc string
+	// Offset is the byte offset of the first illegal character, or -1.
+	Offset int
+	Err    error
+}
+
+func (e *LocError) Error() string {
+	if errors.Is(e.Err, ErrLocTooLong) {
+		return fmt.Sprintf("%v: got %d", e.Err, len(e.Loc))
+	}
+	if e.Offset >= 0 {
+		return fmt.Sprintf("%v at offset %d: %q", e.Err, e.Offset, e.Loc)
+	}
+	return fmt.Sprintf("%v: %q", e.Err, e.Loc)
+}
+
+func (e *LocError) Unwrap() error {
+	return e.Err
+}
+
+// CheckLoc reports whether loc fits the protocol's length limit and consists
+// only of RFC 3986 characters with well-formed percent escapes.
+func CheckLoc(loc string) error {
+	if len(loc) > MaxLocLength {
+		return &LocError{Loc: loc, Offset: -1, Err: ErrLocTooLong}
+	}
+	for i := 0; i < len(loc); i++ {
+		c := loc[i]
+		if c == '%' {
+			if i+2 >= len(loc) || !isHex(loc[i+1]) || !isHex(loc[i+2]) {
+				return &LocError{Loc: loc, Offset: i, Err: ErrLocInvalidChar}
+			}
+			i += 2
+			continue
+		}
+		if !isURIChar(c) {
+			return &LocError{Loc: loc, Offset: i, Err: ErrLocInvalidChar}
+		}
+	}
+	return nil
+}
+
+func isURIChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	switch c {
+	case '-', '.', '_', '~', // unreserved
+		':', '/', '?', '#', '[', ']', '@', // gen-delims
+		'!', '$', '&', '\'', '(', ')', '*', '+', ',', ';', '=': // sub-delims
+		return true
+	}
+	return false
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
@@ -0,0 +1,47 @@
+package sitemap_go
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckLoc(t *testing.T) {
+	tests := []struct {
+		loc    string
+		err    error
+		offset int
+	}{
+		{"https://example.com/a-b_c.d~e?x=1&y=%2F#top", nil, 0},
+		{"https://[::1]:8080/a;b=c", nil, 0},
+		{"https://example.com/" + strings.Repeat("a", MaxLocLength-20), nil, 0},
+		{"https://example.com/" + strings.Repeat("a", MaxLocLength-19), ErrLocTooLong, -1},
+		{"https://example.com/a b", ErrLocInvalidChar, 21},
+		{"https://example.com/é", ErrLocInvalidChar, 20},
+		{"https://example.com/%zz", ErrLocInvalidChar, 20},
+		{"https://example.com/%4", ErrLocInvalidChar, 20},
+		{"https://example.com/<", ErrLocInvalidChar, 20},
+	}
+	for _, tt := range tests {
+		err := CheckLoc(tt.loc)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%.40q: err = %v, want %v", tt.loc, err, tt.err)
+			continue
+		}
+		var locErr *LocError
+		if tt.err != nil && (!errors.As(err, &locErr) || locErr.Offset != tt.offset) {
+			t.Errorf("%.40q: got %#v, want offset %d", tt.loc, err, tt.offset)
+		}
+	}
+}
+
+func TestStrictAdd(t *testing.T) {
+	set := MakeUrlSet()
+	set.Strict = true
+	if err := set.Add(&URL{Loc: "https://example.com/a b"}); !errors.Is(err, ErrLocInvalidChar) {
+		t.Errorf("err = %v, want ErrLocInvalidChar", err)
+	}
+	if err := set.Add(&URL{Loc: "https://example.com/a"}); err != nil || len(set.URLs) != 1 {
+		t.Errorf("valid loc: %v, %d URLs", err, len(set.URLs))
+	}
+}
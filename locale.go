@@ -0,0 +1,128 @@
+package sitemap_go
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// LocaleReport summarizes the language and region targeting of a set's
+// hreflang clusters: groups of URLs linked to each other through
+// alternates.
+type LocaleReport struct {
+	Clusters []LocaleCluster
+}
+
+type LocaleCluster struct {
+	// Canonical is the cluster's x-default href, or else its first loc in
+	// set order.
+	Canonical string
+	// Locales maps each lowercased hreflang code listed in the cluster to
+	// its href.
+	Locales map[string]string
+	// Missing lists the expected locales that no alternate in the cluster
+	// covers, in the order expected.
+	Missing []string
+	// Members are the cluster's URLs in set order.
+	Members []LocaleMember
+}
+
+// LocaleMember lists the hreflang codes one URL's own alternates cover.
+type LocaleMember struct {
+	Loc     string
+	Locales []string
+	// Missing lists the cluster's locales this URL does not list, which
+	// leaves its cluster out of sync.
+	Missing []string
+}
+
+// Incomplete returns the clusters missing an expected locale.
+func (r LocaleReport) Incomplete() []LocaleCluster {
+	var out []LocaleCluster
+	for _, c := range r.Clusters {
+		if len(c.Missing) > 0 {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// LocaleReport groups the URLs that have alternates into hreflang clusters
+// and checks each against expected, a list of hreflang codes such as
+// "en-gb" or "x-default" compared case-insensitively. Clusters are ordered
+// by their first URL in the set.
+func (u *URLSet) LocaleReport(expected []string) LocaleReport {
+	parent := map[string]string{}
+	var find func(string) string
+	find = func(loc string) string {
+		p, ok := parent[loc]
+		if !ok || p == loc {
+			return loc
+		}
+		root := find(p)
+		parent[loc] = root
+		return root
+	}
+	union := func(a, b string) {
+		ra, rb := find(a), find(b)
+		parent[ra], parent[rb] = ra, ra
+	}
+
+	var members []*URL
+	for _, entry := range u.URLs {
+		if len(entry.Alternate) == 0 {
+			continue
+		}
+		members = append(members, entry)
+		union(entry.Loc, entry.Loc)
+		for _, alt := range entry.Alternate {
+			union(entry.Loc, alt.Href)
+		}
+	}
+
+	var report LocaleReport
+	clusters := map[string]int{}
+	for _, entry := range members {
+		root := find(entry.Loc)
+		i, ok := clusters[root]
+		if !ok {
+			i = len(report.Clusters)
+			clusters[root] = i
+			report.Clusters = append(report.Clusters, LocaleCluster{Canonical: entry.Loc, Locales: map[string]string{}})
+		}
+		c := &report.Clusters[i]
+		member := LocaleMember{Loc: entry.Loc}
+		for _, alt := range entry.Alternate {
+			lang := strings.ToLower(alt.HrefLang)
+			if !slices.Contains(member.Locales, lang) {
+				member.Locales = append(member.Locales, lang)
+			}
+			if _, ok := c.Locales[lang]; !ok {
+				c.Locales[lang] = alt.Href
+			}
+		}
+		c.Members = append(c.Members, member)
+	}
+
+	for i := range report.Clusters {
+		c := &report.Clusters[i]
+		if href, ok := c.Locales["x-default"]; ok {
+			c.Canonical = href
+		}
+		for _, lang := range expected {
+			if _, ok := c.Locales[strings.ToLower(lang)]; !ok {
+				c.Missing = append(c.Missing, lang)
+			}
+		}
+		langs := slices.Sorted(maps.Keys(c.Locales))
+		for j := range c.Members {
+			m := &c.Members[j]
+			for _, lang := range langs {
+				if !slices.Contains(m.Locales, lang) {
+					m.Missing = append(m.Missing, lang)
+				}
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,51 @@
+package sitemap_go
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLocaleReport(t *testing.T) {
+	alts := func(pairs ...string) []Alternate {
+		var out []Alternate
+		for i := 0; i < len(pairs); i += 2 {
+			out = append(out, Alternate{Rel: "alternate", HrefLang: pairs[i], Href: pairs[i+1]})
+		}
+		return out
+	}
+	set := setOf(t, "https://example.com/plain", "https://example.com/en/", "https://example.com/de/", "https://example.com/fr/news", "https://example.com/en/news")
+	set.URLs[1].Alternate = alts("en", "https://example.com/en/", "de", "https://example.com/de/", "x-default", "https://example.com/")
+	set.URLs[2].Alternate = alts("DE", "https://example.com/de/", "en", "https://example.com/en/")
+	set.URLs[3].Alternate = alts("fr", "https://example.com/fr/news")
+	// Linked only through the fr page's href, not its own alternates.
+	set.URLs[4].Alternate = alts("en", "https://example.com/fr/news")
+
+	report := set.LocaleReport([]string{"en", "de", "FR"})
+	if len(report.Clusters) != 2 {
+		t.Fatalf("clusters = %+v", report.Clusters)
+	}
+	home := report.Clusters[0]
+	if home.Canonical != "https://example.com/" || len(home.Locales) != 3 || home.Locales["de"] != "https://example.com/de/" {
+		t.Errorf("home cluster = %+v", home)
+	}
+	if !slices.Equal(home.Missing, []string{"FR"}) {
+		t.Errorf("home missing %q", home.Missing)
+	}
+	if len(home.Members) != 2 || len(home.Members[0].Missing) != 0 || !slices.Equal(home.Members[1].Missing, []string{"x-default"}) {
+		t.Errorf("home members = %+v", home.Members)
+	}
+	if !slices.Equal(home.Members[1].Locales, []string{"de", "en"}) {
+		t.Errorf("de page locales = %q", home.Members[1].Locales)
+	}
+
+	news := report.Clusters[1]
+	if news.Canonical != "https://example.com/fr/news" || len(news.Members) != 2 || !slices.Equal(news.Missing, []string{"de"}) {
+		t.Errorf("news cluster = %+v", news)
+	}
+	if incomplete := report.Incomplete(); len(incomplete) != 2 {
+		t.Errorf("incomplete = %d clusters", len(incomplete))
+	}
+	if incomplete := set.LocaleReport([]string{"de"}).Incomplete(); len(incomplete) != 1 || incomplete[0].Canonical != news.Canonical {
+		t.Errorf("incomplete for de = %+v", incomplete)
+	}
+}
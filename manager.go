@@ -0,0 +1,167 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// Manager maintains one sitemap per host for platforms serving many
+// customer domains. Each host has its own URLStore; publishing a host
+// shards its URLs, writes the shards and index under "<host>/" in Storage
+// and swaps them into the Manager's registry, whose handler routes on the
+// request's Host.
+type Manager struct {
+	// NewStore creates the store for a new host. It defaults to a
+	// MemoryStore.
+	NewStore func(host string) URLStore
+	// Storage optionally receives every published file, under a "<host>/"
+	// prefix.
+	Storage Storage
+	// BaseURL returns the public URL a host's sitemaps are served under.
+	// It defaults to "https://<host>/".
+	BaseURL   func(host string) string
+	Shards    ShardOptions
+	Notifiers []Notifier
+
+	registry *Registry
+	mu       sync.Mutex
+	tenants  map[string]*tenant
+}
+
+type tenant struct {
+	store     URLStore
+	publisher *Publisher
+}
+
+func MakeManager(storage Storage) *Manager {
+	return &Manager{Storage: storage, registry: MakeRegistry()}
+}
+
+// Store returns the store for host, ignoring any port, creating it on
+// first use.
+func (m *Manager) Store(host string) URLStore {
+	return m.tenant(servedHost(host)).store
+}
+
+// Hosts returns every host with a store, in sorted order.
+func (m *Manager) Hosts() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hosts := make([]string, 0, len(m.tenants))
+	for host := range m.tenants {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// Upsert adds u to the store of the host in its loc.
+func (m *Manager) Upsert(ctx context.Context, u *URL) error {
+	host := hostKey(u)
+	if host == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidLoc, u.Loc)
+	}
+	return m.Store(host).Upsert(ctx, u)
+}
+
+func (m *Manager) Delete(ctx context.Context, loc string) error {
+	host := servedHost(hostKey(&URL{Loc: loc}))
+	m.mu.Lock()
+	t, ok := m.tenants[host]
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return t.store.Delete(ctx, loc)
+}
+
+// Publish snapshots host's store and publishes its shards and index.
+func (m *Manager) Publish(ctx context.Context, host string) (PublishResult, error) {
+	host = servedHost(host)
+	m.mu.Lock()
+	t, ok := m.tenants[host]
+	m.mu.Unlock()
+	if !ok {
+		return PublishResult{}, fmt.Errorf("%w: %s", ErrUnknownTenant, host)
+	}
+	set, err := SnapshotURLSet(ctx, t.store)
+	if err != nil {
+		return PublishResult{}, err
+	}
+	return t.publisher.Publish(ctx, &set)
+}
+
+// PublishAll publishes every host, continuing past failures. The returned
+// error joins the failures of individual hosts.
+func (m *Manager) PublishAll(ctx context.Context) (map[string]PublishResult, error) {
+	results := map[string]PublishResult{}
+	var errs []error
+	for _, host := range m.Hosts() {
+		result, err := m.Publish(ctx, host)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("publish %s: %w", host, err))
+			continue
+		}
+		results[host] = result
+	}
+	return results, errors.Join(errs...)
+}
+
+// Handler serves every published host's sitemaps, routing on the Host
+// header.
+func (m *Manager) Handler() http.Handler {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.registry == nil {
+		m.registry = MakeRegistry()
+	}
+	return m.registry.Handler()
+}
+
+func (m *Manager) tenant(host string) *tenant {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.tenants[host]; ok {
+		return t
+	}
+	if m.tenants == nil {
+		m.tenants = map[string]*tenant{}
+	}
+	if m.registry == nil {
+		m.registry = MakeRegistry()
+	}
+
+	var store URLStore = &MemoryStore{}
+	if m.NewStore != nil {
+		store = m.NewStore(host)
+	}
+	baseURL := "https://" + host + "/"
+	if strings.Contains(host, ":") {
+		baseURL = "https://[" + host + "]/"
+	}
+	if m.BaseURL != nil {
+		baseURL = m.BaseURL(host)
+	}
+	storage := MultiStorage{PrefixStorage{Storage: m.registry, Prefix: host + "/"}}
+	if m.Storage != nil {
+		storage = append(storage, PrefixStorage{Storage: m.Storage, Prefix: host + "/"})
+	}
+	t := &tenant{
+		store: store,
+		publisher: &Publisher{
+			Storage:   storage,
+			BaseURL:   baseURL,
+			Shards:    m.Shards,
+			Notifiers: m.Notifiers,
+		},
+	}
+	m.tenants[host] = t
+	return t
+}
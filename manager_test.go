@@ -0,0 +1,97 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestManagerRoutesOnHost(t *testing.T) {
+	m := MakeManager(nil)
+	ctx := context.Background()
+	for _, loc := range []string{
+		"https://Example.com:8443/a",
+		"https://example.com/b",
+		"https://other.example.com/c",
+		"http://[::1]:8080/d",
+	} {
+		if err := m.Upsert(ctx, &URL{Loc: loc}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := m.Hosts(), []string{"::1", "example.com", "other.example.com"}; !slices.Equal(got, want) {
+		t.Fatalf("hosts = %q, want %q", got, want)
+	}
+	if _, err := m.PublishAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host    string
+		want    []string
+		notWant string
+	}{
+		{"example.com:8443", []string{"Example.com:8443/a", "example.com/b"}, "/c<"},
+		{"EXAMPLE.COM", []string{"/a<", "/b<"}, "/c<"},
+		{"other.example.com", []string{"/c<"}, "/a<"},
+		{"[::1]:8080", []string{"/d<"}, "/a<"},
+	}
+	h := m.Handler()
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/sitemap-1.xml", nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status %d", tt.host, rec.Code)
+			continue
+		}
+		body := rec.Body.String()
+		for _, want := range tt.want {
+			if !strings.Contains(body, want) {
+				t.Errorf("%s: %q missing from\n%s", tt.host, want, body)
+			}
+		}
+		if strings.Contains(body, tt.notWant) {
+			t.Errorf("%s: served another host's %q", tt.host, tt.notWant)
+		}
+	}
+}
+
+func TestManagerDeleteWithPort(t *testing.T) {
+	m := MakeManager(nil)
+	ctx := context.Background()
+	for _, loc := range []string{"https://example.com:8443/a", "https://example.com/b"} {
+		if err := m.Upsert(ctx, &URL{Loc: loc}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Delete(ctx, "https://example.com:8443/a"); err != nil {
+		t.Fatal(err)
+	}
+	urls, err := m.Store("example.com").Snapshot(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 1 || urls[0].Loc != "https://example.com/b" {
+		t.Errorf("left %+v", urls)
+	}
+}
+
+func TestManagerErrors(t *testing.T) {
+	m := MakeManager(nil)
+	ctx := context.Background()
+	if err := m.Upsert(ctx, &URL{Loc: "/relative"}); !errors.Is(err, ErrInvalidLoc) {
+		t.Errorf("Upsert: err = %v, want ErrInvalidLoc", err)
+	}
+	if _, err := m.Publish(ctx, "missing.example.com"); !errors.Is(err, ErrUnknownTenant) {
+		t.Errorf("Publish: err = %v, want ErrUnknownTenant", err)
+	}
+	if err := m.Delete(ctx, "https://missing.example.com/"); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+}
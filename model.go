@@ -2,23 +2,43 @@ package sitemap_go
 
 import (
 	"encoding/xml"
+	"strings"
 	"time"
 )
 
 type SitemapIndex struct {
 	XMLName  xml.Name       `xml:"sitemapindex"`
-	xmlns    string         `xml:"xmlns,attr"`
+	XMLNS    string         `xml:"xmlns,attr"`
 	Sitemaps []SitemapEntry `xml:"sitemap"`
+
+	// Namespaces declares extra namespaces on the sitemapindex root.
+	Namespaces []Namespace `xml:"-"`
+
+	lastMod LastModFormat
 }
 
 type SitemapEntry struct {
 	Loc     string     `xml:"loc"`
 	LastMod *time.Time `xml:"lastmod,omitempty"`
+	// Extensions holds the entry's other child elements, such as a
+	// platform's custom metadata. Parsing keeps them and encoding writes
+	// them back after lastmod.
+	Extensions []Element `xml:"-"`
+}
+
+// Element is an XML element kept as it was parsed. Name.Space is the
+// namespace URI; Text is the element's character data, which is dropped
+// when it is only whitespace between children.
+type Element struct {
+	Name     xml.Name
+	Attrs    []xml.Attr
+	Text     string
+	Children []Element
 }
 
 func MakeSitemapIndex(entries []SitemapEntry) SitemapIndex {
 	return SitemapIndex{
-		xmlns:    "http://www.sitemaps.org/schemas/sitemap/0.9",
+		XMLNS:    SitemapNamespace,
 		Sitemaps: entries,
 	}
 }
@@ -31,20 +51,13 @@ func (si *SitemapIndex) Add(loc string, lastMod time.Time) {
 }
 
 func (si *SitemapIndex) GenerateXML() (string, error) {
-	output, err := xml.MarshalIndent(si, "", "  ")
-	if err != nil {
-		return "", err
-	}
-	return xml.Header + string(output), nil
+	return si.GenerateXMLWithOptions(EncodeOptions{})
 }
 
+// ParseXMLSitemapIndex decodes a sitemap index under the default
+// ParseOptions limits.
 func ParseXMLSitemapIndex(content string) (SitemapIndex, error) {
-	var out SitemapIndex
-	err := xml.Unmarshal([]byte(content), &out)
-	if err != nil {
-		return out, err
-	}
-	return out, nil
+	return decodeSitemapIndex(strings.NewReader(content), ParseOptions{})
 }
 
 type URLSet struct {
@@ -53,35 +66,60 @@ type URLSet struct {
 	XHTML   string   `xml:"xhtml,attr,omitempty"`
 	Image   string   `xml:"image,attr,omitempty"`
 	Video   string   `xml:"video,attr,omitempty"`
+	Geo     string   `xml:"geo,attr,omitempty"`
 	URLs    []*URL   `xml:"url"`
+
+	// Namespaces declares extra namespaces on the urlset root. An entry whose
+	// URI matches the XHTML, Image or Video namespace renames that prefix.
+	Namespaces []Namespace `xml:"-"`
+
+	// Strict makes Add reject URLs whose loc fails CheckLoc.
+	Strict bool `xml:"-"`
+	// ConvertIRI makes Add run IRIToURI over the URL's locations first.
+	ConvertIRI bool `xml:"-"`
+	// Transformers run on every URL passed to Add, after ConvertIRI and
+	// before the Strict check. A URL they drop is silently skipped.
+	Transformers []Transformer `xml:"-"`
+
+	lastMod           LastModFormat
+	minimalNamespaces bool
 }
 
 func MakeUrlSet() URLSet {
 	return URLSet{
-		XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9",
-		XHTML: "http://www.w3.org/1999/xhtml",
+		XMLNS: SitemapNamespace,
+		XHTML: XHTMLNamespace,
 	}
 }
 
 func (u *URLSet) GenerateXML() (string, error) {
-	output, err := xml.MarshalIndent(u, "", "  ")
-	if err != nil {
-		return "", err
-	}
-	return xml.Header + string(output), nil
+	return u.GenerateXMLWithOptions(EncodeOptions{})
 }
 
+// ParseXMLUrlSet decodes a urlset under the default ParseOptions limits.
 func ParseXMLUrlSet(content string) (URLSet, error) {
-	var out URLSet
-	err := xml.Unmarshal([]byte(content), &out)
-	if err != nil {
-		return out, err
-	}
-	return out, nil
+	return ParseXMLUrlSetWithOptions(content, ParseOptions{})
 }
 
-func (u *URLSet) Add(url *URL) {
+func (u *URLSet) Add(url *URL) error {
+	if u.ConvertIRI {
+		if err := url.mapLocs(IRIToURI); err != nil {
+			return err
+		}
+	}
+	if len(u.Transformers) > 0 {
+		var err error
+		if url, err = Chain(u.Transformers...).Transform(url); err != nil || url == nil {
+			return err
+		}
+	}
+	if u.Strict {
+		if err := CheckLoc(url.Loc); err != nil {
+			return err
+		}
+	}
 	u.URLs = append(u.URLs, url)
+	return nil
 }
 
 type URL struct {
@@ -92,6 +130,18 @@ type URL struct {
 	Images     []Image     `xml:"image,omitempty"`
 	Videos     []Video     `xml:"video,omitempty"`
 	Alternate  []Alternate `xml:"link,omitempty"`
+	// Geo marks the URL as geo content, such as a KML file or GeoRSS feed.
+	Geo *Geo `xml:"geo,omitempty"`
+
+	// Meta carries caller data through pipelines. It is never encoded.
+	Meta map[string]any `xml:"-"`
+}
+
+func (u *URL) SetMeta(key string, value any) {
+	if u.Meta == nil {
+		u.Meta = make(map[string]any)
+	}
+	u.Meta[key] = value
 }
 
 type UrlOption func(*URL)
@@ -126,6 +176,18 @@ func WithVideosVideos(m []Video) UrlOption {
 	}
 }
 
+func WithGeo(format GeoFormat) UrlOption {
+	return func(u *URL) {
+		u.Geo = &Geo{Format: format}
+	}
+}
+
+func WithMeta(key string, value any) UrlOption {
+	return func(u *URL) {
+		u.SetMeta(key, value)
+	}
+}
+
 func MakeUrl(loc string, options ...UrlOption) *URL {
 	now := time.Now().UTC()
 	priority := 0.5
@@ -142,22 +204,42 @@ func MakeUrl(loc string, options ...UrlOption) *URL {
 }
 
 type Image struct {
-	Loc     string `xml:"loc"`
-	Caption string `xml:"caption,omitempty"`
-	Title   string `xml:"title,omitempty"`
+	Loc         string `xml:"loc"`
+	Caption     string `xml:"caption,omitempty"`
+	Title       string `xml:"title,omitempty"`
+	GeoLocation string `xml:"geo_location,omitempty"`
+	// License is the URL of the image's license.
+	License string `xml:"license,omitempty"`
 }
 
 type Video struct {
-	Loc          string   `xml:"loc"`
-	ThumbnailLoc string   `xml:"thumbnail_loc"`
-	Title        string   `xml:"title"`
-	Description  string   `xml:"description"`
-	ContentLoc   string   `xml:"content_loc,omitempty"`
-	Duration     int      `xml:"duration,omitempty"`
-	Category     string   `xml:"category,omitempty"`
-	Tags         []string `xml:"tag,omitempty"`
+	Loc          string `xml:"loc"`
+	ThumbnailLoc string `xml:"thumbnail_loc"`
+	Title        string `xml:"title"`
+	Description  string `xml:"description"`
+	ContentLoc   string `xml:"content_loc,omitempty"`
+	PlayerLoc    string `xml:"player_loc,omitempty"`
+	Duration     int    `xml:"duration,omitempty"`
+	// Rating is between 0.0 and 5.0.
+	Rating          *float64   `xml:"rating,omitempty"`
+	PublicationDate *time.Time `xml:"publication_date,omitempty"`
+	ExpirationDate  *time.Time `xml:"expiration_date,omitempty"`
+	Category        string     `xml:"category,omitempty"`
+	Tags            []string   `xml:"tag,omitempty"`
+}
+
+type Geo struct {
+	Format GeoFormat `xml:"format"`
 }
 
+type GeoFormat string
+
+const (
+	GeoFormatKML    GeoFormat = "kml"
+	GeoFormatKMZ    GeoFormat = "kmz"
+	GeoFormatGeoRSS GeoFormat = "georss"
+)
+
 type Alternate struct {
 	Rel      string `xml:"rel,attr"`
 	HrefLang string `xml:"hreflang,attr"`
@@ -0,0 +1,61 @@
+package sitemap_go
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSitemapIndexMarshalXML(t *testing.T) {
+	lastMod := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	index := MakeSitemapIndex([]SitemapEntry{{Loc: "https://example.com/sitemap-1.xml", LastMod: &lastMod}})
+	tests := map[string]func() (string, error){
+		"GenerateXML": index.GenerateXML,
+		"xml.Marshal": func() (string, error) {
+			out, err := xml.Marshal(index)
+			return string(out), err
+		},
+		"zero index": func() (string, error) {
+			out, err := xml.Marshal(SitemapIndex{})
+			return string(out), err
+		},
+	}
+	for name, encode := range tests {
+		out, err := encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, `<sitemapindex xmlns="`+SitemapNamespace+`">`) {
+			t.Errorf("%s: root lacks its namespace:\n%s", name, out)
+		}
+	}
+	out, _ := index.GenerateXML()
+	parsed, err := ParseXMLSitemapIndex(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Sitemaps) != 1 || parsed.Sitemaps[0].Loc != "https://example.com/sitemap-1.xml" || !parsed.Sitemaps[0].LastMod.Equal(lastMod) {
+		t.Errorf("round trip: %+v", parsed.Sitemaps)
+	}
+}
+
+func TestURLMeta(t *testing.T) {
+	u := MakeUrl("https://example.com/", WithMeta("source", "cms"))
+	u.SetMeta("id", 7)
+	if u.Meta["source"] != "cms" || u.Meta["id"] != 7 {
+		t.Errorf("Meta = %v", u.Meta)
+	}
+	set := MakeUrlSet()
+	set.URLs = []*URL{u}
+	out, err := set.GenerateXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "cms") || strings.Contains(out, "source") {
+		t.Errorf("Meta encoded:\n%s", out)
+	}
+	if clone := u.Clone(); clone.Meta["id"] != 7 {
+		t.Errorf("Clone dropped Meta: %v", clone.Meta)
+	}
+}
@@ -0,0 +1,115 @@
+package sitemap_go
+
+import "encoding/xml"
+
+const (
+	SitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	XHTMLNamespace   = "http://www.w3.org/1999/xhtml"
+	ImageNamespace   = "http://www.google.com/schemas/sitemap-image/1.1"
+	VideoNamespace   = "http://www.google.com/schemas/sitemap-video/1.1"
+	GeoNamespace     = "http://www.google.com/geo/schemas/sitemap/1.0"
+)
+
+// extensionSpaces are the namespaces of the sitemap extensions, and the
+// prefixes they are bound to in documents that use them undeclared.
+var extensionSpaces = map[string]bool{
+	XHTMLNamespace: true,
+	ImageNamespace: true,
+	VideoNamespace: true,
+	GeoNamespace:   true,
+	"http://www.google.com/schemas/sitemap-news/0.9": true,
+	"xhtml": true,
+	"image": true,
+	"video": true,
+	"geo":   true,
+	"news":  true,
+}
+
+type Namespace struct {
+	Prefix string
+	URI    string
+}
+
+type namespacePrefixes struct {
+	xhtml string
+	image string
+	video string
+	geo   string
+}
+
+var defaultPrefixes = namespacePrefixes{xhtml: "xhtml", image: "image", video: "video", geo: "geo"}
+
+// prefixes resolves the extension prefixes for the set. A Namespaces entry
+// whose URI is one of the extension namespaces renames that extension.
+func (u *URLSet) prefixes() namespacePrefixes {
+	p := defaultPrefixes
+	for _, ns := range u.Namespaces {
+		switch ns.URI {
+		case u.xhtmlURI():
+			p.xhtml = ns.Prefix
+		case u.imageURI():
+			p.image = ns.Prefix
+		case u.videoURI():
+			p.video = ns.Prefix
+		case u.geoURI():
+			p.geo = ns.Prefix
+		}
+	}
+	return p
+}
+
+func (u *URLSet) xhtmlURI() string { return orDefault(u.XHTML, XHTMLNamespace) }
+func (u *URLSet) imageURI() string { return orDefault(u.Image, ImageNamespace) }
+func (u *URLSet) videoURI() string { return orDefault(u.Video, VideoNamespace) }
+func (u *URLSet) geoURI() string   { return orDefault(u.Geo, GeoNamespace) }
+
+// namespaceAttrs returns the root declarations in a fixed order: the default
+// namespace, the xhtml, image, video and geo extensions, then any other
+// Namespaces in the order given. An extension whose URI field is empty is
+// still declared if some URL uses it, so the output stays well-formed, and
+// with minimalNamespaces only the extensions in use are declared.
+func (u *URLSet) namespaceAttrs() []xml.Attr {
+	uses := extensionUse{all: !u.minimalNamespaces}
+	for _, entry := range u.URLs {
+		uses.xhtml = uses.xhtml || len(entry.Alternate) > 0
+		uses.image = uses.image || len(entry.Images) > 0
+		uses.video = uses.video || len(entry.Videos) > 0
+		uses.geo = uses.geo || entry.Geo != nil
+	}
+	return u.rootAttrs(uses)
+}
+
+// extensionUse records which extensions are in use; all also declares those
+// whose URI field is set.
+type extensionUse struct{ all, xhtml, image, video, geo bool }
+
+func (u *URLSet) rootAttrs(uses extensionUse) []xml.Attr {
+	p := u.prefixes()
+
+	attrs := []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: orDefault(u.XMLNS, SitemapNamespace)}}
+	declare := func(prefix, uri string, set, used bool) {
+		if set && uses.all || used {
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: uri})
+		}
+	}
+	declare(p.xhtml, u.xhtmlURI(), u.XHTML != "", uses.xhtml)
+	declare(p.image, u.imageURI(), u.Image != "", uses.image)
+	declare(p.video, u.videoURI(), u.Video != "", uses.video)
+	declare(p.geo, u.geoURI(), u.Geo != "", uses.geo)
+
+	for _, ns := range u.Namespaces {
+		switch ns.URI {
+		case u.xhtmlURI(), u.imageURI(), u.videoURI(), u.geoURI():
+			continue
+		}
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "xmlns:" + ns.Prefix}, Value: ns.URI})
+	}
+	return attrs
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,77 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNamespacePrefixes(t *testing.T) {
+	set := richSet(4)
+	set.Namespaces = []Namespace{
+		{Prefix: "img", URI: ImageNamespace},
+		{Prefix: "vid", URI: VideoNamespace},
+		{Prefix: "x", URI: "https://example.com/ns"},
+	}
+	for _, engine := range []Engine{StreamingEngine, ReflectionEngine} {
+		out, err := set.GenerateXMLWithOptions(EncodeOptions{Engine: engine})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			`xmlns:img="` + ImageNamespace + `"`,
+			`xmlns:vid="` + VideoNamespace + `"`,
+			`xmlns:x="https://example.com/ns"`,
+			"<img:image>", "<img:loc>", "<vid:video>", "<vid:title>",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s missing", want)
+			}
+		}
+		for _, unwanted := range []string{"<image:", "<video:", "xmlns:image=", "xmlns:video="} {
+			if strings.Contains(out, unwanted) {
+				t.Errorf("default prefix %s still written", unwanted)
+			}
+		}
+		parsed, err := ParseXMLUrlSet(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(parsed.URLs[0].Images) != 2 || len(parsed.URLs[0].Videos) != 1 {
+			t.Errorf("prefixed extensions not parsed back: %+v", parsed.URLs[0])
+		}
+	}
+}
+
+func TestMinimalNamespaces(t *testing.T) {
+	set := MakeUrlSet()
+	set.Image, set.Video, set.Geo = ImageNamespace, VideoNamespace, GeoNamespace
+	set.URLs = []*URL{{Loc: "https://example.com/", Images: []Image{{Loc: "https://example.com/a.jpg"}}}}
+	tests := []struct {
+		minimal  bool
+		declared []string
+		omitted  []string
+	}{
+		{false, []string{`xmlns:xhtml=`, `xmlns:image=`, `xmlns:video=`, `xmlns:geo=`}, nil},
+		{true, []string{`xmlns:image=`}, []string{`xmlns:xhtml=`, `xmlns:video=`, `xmlns:geo=`}},
+	}
+	for _, tt := range tests {
+		for _, engine := range []Engine{StreamingEngine, ReflectionEngine} {
+			var buf bytes.Buffer
+			if err := set.Encode(&buf, EncodeOptions{Engine: engine, MinimalNamespaces: tt.minimal}); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			for _, attr := range tt.declared {
+				if !strings.Contains(out, attr) {
+					t.Errorf("minimal %v: %s not declared:\n%s", tt.minimal, attr, out)
+				}
+			}
+			for _, attr := range tt.omitted {
+				if strings.Contains(out, attr) {
+					t.Errorf("minimal %v: unused %s declared:\n%s", tt.minimal, attr, out)
+				}
+			}
+		}
+	}
+}
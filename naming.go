@@ -0,0 +1,172 @@
+package sitemap_go
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ShardNamer decides the file layout of shards. The splitter names every
+// shard through it, the publisher and index builders use those names as
+// they are, and cleanup asks it which stored objects are shards.
+type ShardNamer interface {
+	// ShardName returns the name of a shard, without the extension of any
+	// compression. Names must be unique within a run.
+	ShardName(info ShardInfo) string
+	// OwnsShardName reports whether name, stripped of any compression
+	// extension, is one ShardName could return for prefix, outside any
+	// section or in one of sections.
+	OwnsShardName(prefix, name string, sections ...string) bool
+}
+
+// ShardInfo describes the shard being named.
+type ShardInfo struct {
+	// Index is the shard's 0-based position in its run or section.
+	Index  int
+	Prefix string
+	// Section is set for shards of a SectionedBuilder.
+	Section string
+	URLs    []*URL
+	LastMod *time.Time
+}
+
+func (o ShardOptions) names() ShardNamer {
+	if o.Names == nil {
+		return SequentialNames{}
+	}
+	return o.Names
+}
+
+// SequentialNames numbers shards from 1: sitemap-1.xml, or
+// sitemap-products-1.xml in a section. It is the default.
+type SequentialNames struct{}
+
+func (SequentialNames) ShardName(info ShardInfo) string {
+	return joinName(info.Prefix, info.Section, strconv.Itoa(info.Index+1)) + ".xml"
+}
+
+func (SequentialNames) OwnsShardName(prefix, name string, sections ...string) bool {
+	return nameMatches(prefix, sections, sequentialPattern, name)
+}
+
+// sequentialIndex returns the 0-based index of a name SequentialNames gave
+// an unsectioned shard.
+func sequentialIndex(prefix, name string) (int, bool) {
+	digits, ok := strings.CutSuffix(name, ".xml")
+	if prefix != "" {
+		digits, ok = strings.CutPrefix(digits, prefix+"-")
+	}
+	n, err := strconv.Atoi(digits)
+	if !ok || err != nil || n < 1 || strconv.Itoa(n) != digits {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+// HashNames names shards by a hash of their locs, such as
+// sitemap-3f2a9c0d41b7e865.xml, so a shard keeps its name, and caches keep
+// their copies, for as long as its membership is unchanged.
+type HashNames struct{}
+
+func (HashNames) ShardName(info ShardInfo) string {
+	h := sha256.New()
+	for _, u := range info.URLs {
+		h.Write([]byte(u.Loc))
+		h.Write([]byte{'\n'})
+	}
+	return joinName(info.Prefix, info.Section, hex.EncodeToString(h.Sum(nil))[:16]) + ".xml"
+}
+
+func (HashNames) OwnsShardName(prefix, name string, sections ...string) bool {
+	return nameMatches(prefix, sections, hashPattern, name)
+}
+
+// DateNames names shards by the UTC date of their newest lastmod, numbered
+// within the run: sitemap-2024-05-01-1.xml. Shards without any lastmod are
+// dated "undated".
+type DateNames struct{}
+
+func (DateNames) ShardName(info ShardInfo) string {
+	date := "undated"
+	if info.LastMod != nil {
+		date = info.LastMod.UTC().Format(time.DateOnly)
+	}
+	return joinName(info.Prefix, info.Section, date, strconv.Itoa(info.Index+1)) + ".xml"
+}
+
+func (DateNames) OwnsShardName(prefix, name string, sections ...string) bool {
+	return nameMatches(prefix, sections, datePattern, name)
+}
+
+// SectionNames gives every section a directory of sequentially numbered
+// shards, such as products/sitemap-1.xml. Shards outside sections are named
+// as by SequentialNames.
+type SectionNames struct{}
+
+func (SectionNames) ShardName(info ShardInfo) string {
+	name := joinName(info.Prefix, strconv.Itoa(info.Index+1)) + ".xml"
+	if info.Section == "" {
+		return name
+	}
+	return info.Section + "/" + name
+}
+
+func (SectionNames) OwnsShardName(prefix, name string, sections ...string) bool {
+	if section, rest, ok := strings.Cut(name, "/"); ok {
+		if !slices.Contains(sections, section) {
+			return false
+		}
+		name = rest
+	}
+	return nameMatches(prefix, nil, sequentialPattern, name)
+}
+
+func joinName(parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, "-")
+}
+
+// The patterns of what follows the prefix and section in shard names.
+var (
+	sequentialPattern = regexp.MustCompile(`^[0-9]+\.xml$`)
+	hashPattern       = regexp.MustCompile(`^[0-9a-f]{16}\.xml$`)
+	datePattern       = regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2}|undated)-[0-9]+\.xml$`)
+)
+
+// nameMatches reports whether name is prefix, optionally followed by one
+// of sections, joined by dashes to a name pattern matches.
+func nameMatches(prefix string, sections []string, pattern *regexp.Regexp, name string) bool {
+	if prefix != "" {
+		var ok bool
+		if name, ok = strings.CutPrefix(name, prefix+"-"); !ok {
+			return false
+		}
+	}
+	if pattern.MatchString(name) {
+		return true
+	}
+	for _, section := range sections {
+		if rest, ok := strings.CutPrefix(name, section+"-"); ok && pattern.MatchString(rest) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripCodingExt removes the extension of a registered compression from
+// name.
+func stripCodingExt(name string) string {
+	if coding, ok := codingByExt(name); ok {
+		return strings.TrimSuffix(name, coding.Ext)
+	}
+	return name
+}
@@ -0,0 +1,123 @@
+package sitemap_go
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestShardNamers(t *testing.T) {
+	lastMod := time.Date(2024, 5, 1, 23, 0, 0, 0, time.FixedZone("X", -3600))
+	urls := setOf(t, "https://example.com/a", "https://example.com/b").URLs
+	tests := []struct {
+		namer ShardNamer
+		info  ShardInfo
+		want  string
+	}{
+		{SequentialNames{}, ShardInfo{Index: 0, Prefix: "sitemap"}, "sitemap-1.xml"},
+		{SequentialNames{}, ShardInfo{Index: 11, Prefix: "sitemap", Section: "products"}, "sitemap-products-12.xml"},
+		{SequentialNames{}, ShardInfo{Index: 2}, "3.xml"},
+		{HashNames{}, ShardInfo{Prefix: "sitemap", URLs: urls}, "sitemap-" + HashNames{}.ShardName(ShardInfo{URLs: urls})},
+		{DateNames{}, ShardInfo{Index: 1, Prefix: "sitemap", LastMod: &lastMod}, "sitemap-2024-05-02-2.xml"},
+		{DateNames{}, ShardInfo{Index: 0, Prefix: "sitemap", Section: "blog"}, "sitemap-blog-undated-1.xml"},
+		{SectionNames{}, ShardInfo{Index: 0, Prefix: "sitemap", Section: "products"}, "products/sitemap-1.xml"},
+		{SectionNames{}, ShardInfo{Index: 4, Prefix: "sitemap"}, "sitemap-5.xml"},
+	}
+	for _, tt := range tests {
+		got := tt.namer.ShardName(tt.info)
+		if got != tt.want {
+			t.Errorf("%T.ShardName(%+v) = %q, want %q", tt.namer, tt.info, got, tt.want)
+		}
+		if !tt.namer.OwnsShardName(tt.info.Prefix, got, tt.info.Section) {
+			t.Errorf("%T does not own its own name %q", tt.namer, got)
+		}
+		if tt.info.Section != "" && tt.namer.OwnsShardName(tt.info.Prefix, got) {
+			t.Errorf("%T owns %q outside its section", tt.namer, got)
+		}
+	}
+}
+
+func TestOwnsShardName(t *testing.T) {
+	tests := []struct {
+		namer ShardNamer
+		name  string
+		want  bool
+	}{
+		{SequentialNames{}, "sitemap-1.xml", true},
+		{SequentialNames{}, "sitemap-news-20.xml", true},
+		{SequentialNames{}, "sitemap-blog-20.xml", false},
+		{SequentialNames{}, "sitemap-news-x-20.xml", false},
+		{SequentialNames{}, "sitemap.xml", false},
+		{SequentialNames{}, "sitemap-1.xml.bak", false},
+		{SequentialNames{}, "other-1.xml", false},
+		{SequentialNames{}, "archive/sitemap-1.xml", false},
+		{HashNames{}, "sitemap-3f2a9c0d41b7e865.xml", true},
+		{HashNames{}, "sitemap-3F2A9C0D41B7E865.xml", false},
+		{HashNames{}, "sitemap-3f2a9c0d.xml", false},
+		{DateNames{}, "sitemap-2024-05-01-3.xml", true},
+		{DateNames{}, "sitemap-undated-1.xml", true},
+		{DateNames{}, "sitemap-2024-05-01.xml", false},
+		{DateNames{}, "sitemap-news-2024-05-01-1.xml", true},
+		{DateNames{}, "sitemap-blog-2024-05-01-1.xml", false},
+		{SectionNames{}, "products/sitemap-2.xml", true},
+		{SectionNames{}, "news/sitemap-2.xml", true},
+		{SectionNames{}, "blog/sitemap-2.xml", false},
+		{SectionNames{}, "sitemap-2.xml", true},
+		{SectionNames{}, "a/b/sitemap-2.xml", false},
+		{SectionNames{}, "products/sitemap.xml", false},
+	}
+	for _, tt := range tests {
+		if got := tt.namer.OwnsShardName("sitemap", tt.name, "news", "products"); got != tt.want {
+			t.Errorf("%T.OwnsShardName(%q) = %v, want %v", tt.namer, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHashNamesFollowMembership(t *testing.T) {
+	name := func(locs ...string) string {
+		return HashNames{}.ShardName(ShardInfo{Prefix: "sitemap", URLs: setOf(t, locs...).URLs})
+	}
+	a := name("https://example.com/a", "https://example.com/b")
+	if a != name("https://example.com/a", "https://example.com/b") {
+		t.Error("the same locs were named differently")
+	}
+	if a == name("https://example.com/a", "https://example.com/c") || a == name("https://example.com/ab") {
+		t.Error("different locs share a name")
+	}
+}
+
+func TestGenerateShardsNames(t *testing.T) {
+	shards, err := numberedSet(t, 4, "").GenerateShards(context.Background(), ShardOptions{MaxURLs: 2, Prefix: "pages", Names: HashNames{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, shard := range shards {
+		if !(HashNames{}).OwnsShardName("pages", shard.Name) {
+			t.Errorf("shard named %q", shard.Name)
+		}
+	}
+	if len(shards) != 2 || shards[0].Name == shards[1].Name {
+		t.Errorf("shards = %d, names not unique", len(shards))
+	}
+}
+
+func TestSequentialIndex(t *testing.T) {
+	tests := []struct {
+		prefix, name string
+		index        int
+		ok           bool
+	}{
+		{"sitemap", "sitemap-1.xml", 0, true},
+		{"sitemap", "sitemap-42.xml", 41, true},
+		{"", "7.xml", 6, true},
+		{"sitemap", "sitemap-0.xml", 0, false},
+		{"sitemap", "sitemap-01.xml", 0, false},
+		{"sitemap", "sitemap-news-1.xml", 0, false},
+		{"sitemap", "sitemap-1.xml.gz", 0, false},
+	}
+	for _, tt := range tests {
+		if index, ok := sequentialIndex(tt.prefix, tt.name); index != tt.index || ok != tt.ok {
+			t.Errorf("sequentialIndex(%q, %q) = %d, %v", tt.prefix, tt.name, index, ok)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// variant returns the document rendered in the text format when text is
+// set, or as XML otherwise, compressed with coding unless it is empty.
+// Renderings are cached with the document. A nil body means the document
+// has no such rendering, as indexes have no text format.
+func (d *servedDocument) variant(text bool, coding string) ([]byte, error) {
+	if !text && coding == d.meta.ContentEncoding {
+		return d.body, nil
+	}
+	d.variantsMu.Lock()
+	defer d.variantsMu.Unlock()
+	return d.variantLocked(text, coding)
+}
+
+func (d *servedDocument) variantLocked(text bool, coding string) ([]byte, error) {
+	if !text && coding == d.meta.ContentEncoding {
+		return d.body, nil
+	}
+	key := variantKey(text, coding)
+	if body, ok := d.variants[key]; ok {
+		return body, nil
+	}
+	var body []byte
+	var err error
+	switch {
+	case coding != "":
+		body, err = d.variantLocked(text, "")
+		if err == nil && body != nil {
+			body, err = encodeContent(body, coding)
+		}
+	case text:
+		body, err = d.textLocked()
+	default:
+		var r io.Reader
+		r, err = decodeContent(bytes.NewReader(d.body), d.meta.ContentEncoding)
+		if err == nil {
+			body, err = io.ReadAll(r)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.variants == nil {
+		d.variants = map[string][]byte{}
+	}
+	d.variants[key] = body
+	return body, nil
+}
+
+// textLocked renders the locs of a urlset one per line. Documents put
+// into the registry as bytes are decoded first; those that turn out not
+// to be urlsets have no text rendering.
+func (d *servedDocument) textLocked() ([]byte, error) {
+	set := d.set
+	if set == nil {
+		if d.index != nil {
+			return nil, nil
+		}
+		plain, err := d.variantLocked(false, "")
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := decodeURLSet(bytes.NewReader(plain), -1, ParseOptions{MaxBytes: -1})
+		if err != nil {
+			return nil, nil
+		}
+		set = &decoded
+	}
+	var buf bytes.Buffer
+	if err := set.WriteText(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// variantETag derives a variant's entity tag from the document's, since
+// each rendering is a different representation.
+func (d *servedDocument) variantETag(text bool, coding string) string {
+	if !text && coding == d.meta.ContentEncoding {
+		return d.etag
+	}
+	return strings.TrimSuffix(d.etag, `"`) + "-" + variantKey(text, coding) + `"`
+}
+
+func variantKey(text bool, coding string) string {
+	format := "xml"
+	if text {
+		format = "txt"
+	}
+	if coding == "" {
+		return format
+	}
+	return format + "+" + coding
+}
+
+func encodeContent(body []byte, name string) ([]byte, error) {
+	c, ok := codingByName(name)
+	if !ok || c.NewWriter == nil {
+		return nil, fmt.Errorf("unsupported content encoding %q", name)
+	}
+	var buf bytes.Buffer
+	zw, err := c.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(body); err != nil {
+		zw.Close()
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// acceptedCodings parses an Accept-Encoding header into a predicate over
+// coding names. Codings with a zero q-value are refused, and those not
+// listed fall back to "*". Identity is left to the caller, which always
+// has it to offer.
+func acceptedCodings(header string) func(string) bool {
+	weights := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "x-gzip" {
+			name = "gzip"
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			k, v, ok := strings.Cut(param, "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(k), "q") {
+				continue
+			}
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				q = f
+			}
+		}
+		weights[name] = q
+	}
+	return func(coding string) bool {
+		if q, ok := weights[coding]; ok {
+			return q > 0
+		}
+		q, ok := weights["*"]
+		return ok && q > 0
+	}
+}
@@ -0,0 +1,207 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAcceptedCodings(t *testing.T) {
+	tests := []struct {
+		header string
+		accept []string
+		refuse []string
+	}{
+		{"", nil, []string{"gzip", "deflate"}},
+		{"gzip", []string{"gzip"}, []string{"deflate"}},
+		{"GZIP, deflate;q=0.5", []string{"gzip", "deflate"}, []string{"br"}},
+		{"x-gzip", []string{"gzip"}, nil},
+		{"gzip;q=0, deflate", []string{"deflate"}, []string{"gzip"}},
+		{"*", []string{"gzip", "br"}, nil},
+		{"*;q=0, gzip", []string{"gzip"}, []string{"deflate"}},
+		{"deflate; Q=0", nil, []string{"deflate"}},
+	}
+	for _, tt := range tests {
+		accepts := acceptedCodings(tt.header)
+		for _, c := range tt.accept {
+			if !accepts(c) {
+				t.Errorf("%q refuses %s", tt.header, c)
+			}
+		}
+		for _, c := range tt.refuse {
+			if accepts(c) {
+				t.Errorf("%q accepts %s", tt.header, c)
+			}
+		}
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := setOf(t, "https://example.com/a", "https://example.com/ü").WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "https://example.com/a\nhttps://example.com/ü\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// decodedBody reads a response body through its Content-Encoding.
+func decodedBody(t *testing.T, header http.Header, body []byte) string {
+	t.Helper()
+	var r io.Reader = bytes.NewReader(body)
+	var err error
+	switch header.Get("Content-Encoding") {
+	case "gzip":
+		r, err = gzip.NewReader(r)
+	case "deflate":
+		r, err = zlib.NewReader(r)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestHandlerNegotiation(t *testing.T) {
+	r := MakeRegistry()
+	if _, err := r.Swap("sitemap.xml", numberedSet(t, 200, "")); err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{Registry: r, Codings: []string{"gzip", "deflate"}}
+	plain := serve(t, h, "GET", "", "/sitemap.xml", nil)
+	if plain.Code != http.StatusOK || plain.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("identity: status %d, Content-Encoding %q", plain.Code, plain.Header().Get("Content-Encoding"))
+	}
+	if got := plain.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("Vary = %q", got)
+	}
+
+	etags := map[string]bool{plain.Header().Get("ETag"): true}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"gzip", "gzip"},
+		{"gzip;q=0, deflate", "deflate"},
+		{"br", ""},
+		{"*;q=0", ""},
+	}
+	for _, tt := range tests {
+		rec := serve(t, h, "GET", "", "/sitemap.xml", http.Header{"Accept-Encoding": {tt.accept}})
+		if got := rec.Header().Get("Content-Encoding"); got != tt.want {
+			t.Errorf("%q: Content-Encoding = %q, want %q", tt.accept, got, tt.want)
+			continue
+		}
+		if got := decodedBody(t, rec.Header(), rec.Body.Bytes()); got != plain.Body.String() {
+			t.Errorf("%q: body differs from identity", tt.accept)
+		}
+		if tt.want == "" {
+			continue
+		}
+		if rec.Body.Len() >= plain.Body.Len() {
+			t.Errorf("%q: %d bytes, identity %d", tt.accept, rec.Body.Len(), plain.Body.Len())
+		}
+		etag := rec.Header().Get("ETag")
+		if etags[etag] {
+			t.Errorf("%q: ETag %s shared with another variant", tt.accept, etag)
+		}
+		etags[etag] = true
+		header := http.Header{"Accept-Encoding": {tt.accept}, "If-None-Match": {etag}}
+		if rec := serve(t, h, "GET", "", "/sitemap.xml", header); rec.Code != http.StatusNotModified {
+			t.Errorf("%q: conditional status %d", tt.accept, rec.Code)
+		}
+	}
+
+	if rec := serve(t, r.Handler(), "GET", "", "/sitemap.xml", http.Header{"Accept-Encoding": {"gzip"}}); rec.Header().Get("Content-Encoding") != "" || rec.Header().Get("Vary") != "" {
+		t.Errorf("no codings: Content-Encoding %q, Vary %q", rec.Header().Get("Content-Encoding"), rec.Header().Get("Vary"))
+	}
+}
+
+func TestHandlerStoredEncoding(t *testing.T) {
+	r := MakeRegistry()
+	var xml strings.Builder
+	if err := numberedSet(t, 200, "").Encode(&xml, EncodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	body := gzipped(t, func(w io.Writer) { io.WriteString(w, xml.String()) })
+	if err := r.Put(context.Background(), "sitemap.xml", body, ObjectMeta{ContentEncoding: "gzip"}); err != nil {
+		t.Fatal(err)
+	}
+	h := r.Handler()
+
+	rec := serve(t, h, "GET", "", "/sitemap.xml", http.Header{"Accept-Encoding": {"gzip"}})
+	if rec.Header().Get("Content-Encoding") != "gzip" || !bytes.Equal(rec.Body.Bytes(), body) {
+		t.Errorf("gzip: Content-Encoding %q, %d bytes", rec.Header().Get("Content-Encoding"), rec.Body.Len())
+	}
+	rec = serve(t, h, "GET", "", "/sitemap.xml", nil)
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != xml.String() {
+		t.Errorf("identity: Content-Encoding %q:\n%s", rec.Header().Get("Content-Encoding"), rec.Body)
+	}
+	if rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Vary = %q", rec.Header().Get("Vary"))
+	}
+}
+
+func TestHandlerText(t *testing.T) {
+	r := MakeRegistry()
+	ctx := context.Background()
+	if _, err := r.Swap("sitemap.xml", setOf(t, "https://example.com/a", "https://example.com/b")); err != nil {
+		t.Fatal(err)
+	}
+	index := MakeSitemapIndex([]SitemapEntry{{Loc: "https://example.com/sitemap.xml"}})
+	if _, err := r.SwapIndex("index.xml", &index); err != nil {
+		t.Fatal(err)
+	}
+	stored := gzipped(t, func(w io.Writer) {
+		io.WriteString(w, "<urlset><url><loc>https://example.com/c</loc></url></urlset>")
+	})
+	_ = r.Put(ctx, "news.xml.gz", stored, ObjectMeta{ContentEncoding: "gzip"})
+	_ = r.Put(ctx, "own.txt", []byte("own"), ObjectMeta{ContentType: "text/plain"})
+	_ = r.Put(ctx, "broken.xml", []byte("not xml"), ObjectMeta{})
+	h := &Handler{Registry: r, Codings: []string{"gzip"}, TextExt: ".txt"}
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/sitemap.txt", http.StatusOK, "https://example.com/a\nhttps://example.com/b\n"},
+		{"/news.txt", http.StatusOK, "https://example.com/c\n"},
+		{"/own.txt", http.StatusOK, "own"},
+		{"/index.txt", http.StatusNotFound, ""},
+		{"/broken.txt", http.StatusNotFound, ""},
+		{"/missing.txt", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := serve(t, h, "GET", "", tt.path, nil)
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.path, rec.Code, tt.status)
+			continue
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("%s: body %q, want %q", tt.path, rec.Body, tt.body)
+		}
+	}
+
+	rec := serve(t, h, "GET", "", "/sitemap.txt", http.Header{"Accept-Encoding": {"gzip"}})
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if got := decodedBody(t, rec.Header(), rec.Body.Bytes()); !strings.HasPrefix(got, "https://example.com/a\n") {
+		t.Errorf("gzip text: %q", got)
+	}
+	xmlTag := serve(t, h, "GET", "", "/sitemap.xml", nil).Header().Get("ETag")
+	if textTag := serve(t, h, "GET", "", "/sitemap.txt", nil).Header().Get("ETag"); textTag == xmlTag {
+		t.Errorf("text and XML share ETag %s", textTag)
+	}
+}
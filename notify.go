@@ -0,0 +1,85 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Notifier is told about every successful publish.
+type Notifier interface {
+	Notify(ctx context.Context, result PublishResult) error
+}
+
+type NotifierFunc func(ctx context.Context, result PublishResult) error
+
+func (f NotifierFunc) Notify(ctx context.Context, result PublishResult) error {
+	return f(ctx, result)
+}
+
+// WebhookNotifier POSTs a JSON summary of each publish to URL. The payload
+// carries a human-readable "text" field so it can be sent to a Slack
+// incoming webhook as is.
+type WebhookNotifier struct {
+	URL        string
+	Header     http.Header
+	HTTPClient *http.Client
+}
+
+type webhookPayload struct {
+	Text      string    `json:"text"`
+	Time      time.Time `json:"time"`
+	IndexURL  string    `json:"index_url"`
+	ShardURLs []string  `json:"shard_urls"`
+	URLs      int       `json:"urls"`
+	Shards    int       `json:"shards"`
+	Added     int       `json:"added"`
+	Removed   int       `json:"removed"`
+	Updated   int       `json:"updated"`
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, result PublishResult) error {
+	payload, err := json.Marshal(webhookPayload{
+		Text: fmt.Sprintf("Published %s: %d URLs in %d shards (+%d -%d ~%d)",
+			result.IndexURL, result.URLs, len(result.ShardURLs),
+			result.Diff.Added, result.Diff.Removed, result.Diff.Updated),
+		Time:      result.Time,
+		IndexURL:  result.IndexURL,
+		ShardURLs: result.ShardURLs,
+		URLs:      result.URLs,
+		Shards:    len(result.ShardURLs),
+		Added:     result.Diff.Added,
+		Removed:   result.Diff.Removed,
+		Updated:   result.Diff.Updated,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	for k, v := range n.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &HTTPStatusError{URL: n.URL, StatusCode: resp.StatusCode}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package sitemap_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var payload webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	n := &WebhookNotifier{URL: srv.URL, Header: http.Header{"Authorization": {"Bearer token"}}}
+	result := PublishResult{
+		IndexURL:  "https://example.com/sitemap.xml",
+		ShardURLs: []string{"https://example.com/sitemap-1.xml"},
+		URLs:      10,
+		Diff:      DiffStats{Added: 2, Removed: 1, Updated: 3},
+	}
+	if err := n.Notify(context.Background(), result); err != nil {
+		t.Fatal(err)
+	}
+	if payload.IndexURL != result.IndexURL || payload.Shards != 1 || payload.URLs != 10 || payload.Added != 2 || payload.Updated != 3 {
+		t.Errorf("payload = %+v", payload)
+	}
+	if !strings.Contains(payload.Text, "10 URLs in 1 shards (+2 -1 ~3)") {
+		t.Errorf("text = %q", payload.Text)
+	}
+}
+
+func TestWebhookNotifierStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+	err := (&WebhookNotifier{URL: srv.URL}).Notify(context.Background(), PublishResult{})
+	var status *HTTPStatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusForbidden {
+		t.Errorf("err = %v, want a 403 HTTPStatusError", err)
+	}
+}
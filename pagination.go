@@ -0,0 +1,107 @@
+package sitemap_go
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var ErrInvalidPagination = errors.New("invalid pagination")
+
+// PageRel is how a page of a paginated listing presents itself to crawlers.
+type PageRel int
+
+const (
+	// PageIndexable pages are listed.
+	PageIndexable PageRel = iota
+	// PageCanonicalized pages carry rel=canonical to another URL, usually
+	// the first page, and are left out.
+	PageCanonicalized
+	// PageNoIndex pages carry a noindex robots directive and are left out.
+	PageNoIndex
+)
+
+type PaginationOptions struct {
+	// FirstLoc is the loc of page 1, such as "https://example.com/blog".
+	// When empty, page 1 uses PageLoc too.
+	FirstLoc string
+	// PageLoc formats the loc of page n with its single %d verb, as in
+	// "https://example.com/blog/page/%d".
+	PageLoc string
+	Pages   int
+	// MaxPages caps the pages listed, since deep pages are rarely worth
+	// crawling. Zero lists them all.
+	MaxPages int
+	// Priority is the priority of page 1. It defaults to 0.8; a negative
+	// value omits priorities.
+	Priority float64
+	// Decay multiplies the priority from one page to the next. It
+	// defaults to 0.8.
+	Decay float64
+	// MinPriority is the floor of the decay. It defaults to 0.1.
+	MinPriority float64
+	ChangeFreq  ChangeFreq
+	LastMod     *time.Time
+	// Rel reports how page n presents itself; pages that are not
+	// PageIndexable are left out. When nil every page is indexable.
+	Rel func(page int) PageRel
+}
+
+// PaginatedURLs returns the entries for the pages of a paginated archive,
+// such as /blog, /blog/page/2 and so on, with priorities decaying from
+// page to page.
+func PaginatedURLs(opts PaginationOptions) ([]*URL, error) {
+	if strings.Count(opts.PageLoc, "%d") != 1 {
+		return nil, fmt.Errorf("%w: PageLoc %q needs exactly one %%d", ErrInvalidPagination, opts.PageLoc)
+	}
+	pages := opts.Pages
+	if opts.MaxPages > 0 {
+		pages = min(pages, opts.MaxPages)
+	}
+	priority := opts.Priority
+	if priority == 0 {
+		priority = 0.8
+	}
+	decay := opts.Decay
+	if decay == 0 {
+		decay = 0.8
+	}
+	floor := opts.MinPriority
+	if floor == 0 {
+		floor = 0.1
+	}
+
+	var out []*URL
+	for n := 1; n <= pages; n++ {
+		if opts.Rel != nil && opts.Rel(n) != PageIndexable {
+			continue
+		}
+		loc := opts.FirstLoc
+		if n > 1 || loc == "" {
+			loc = fmt.Sprintf(opts.PageLoc, n)
+		}
+		var options []UrlOption
+		if priority >= 0 {
+			p := max(priority*math.Pow(decay, float64(n-1)), floor)
+			options = append(options, WithPriority(math.Round(p*100)/100))
+		}
+		if opts.ChangeFreq != "" {
+			options = append(options, WithChangeFreq(opts.ChangeFreq))
+		}
+		if opts.LastMod != nil {
+			options = append(options, WithLastMod(*opts.LastMod))
+		}
+		u, err := NewURL(loc, options...)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", n, err)
+		}
+		if priority < 0 {
+			// NewURL fills in a default priority.
+			u.Priority = nil
+		}
+		out = append(out, u)
+	}
+	return out, nil
+}
@@ -0,0 +1,76 @@
+package sitemap_go
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPaginatedURLs(t *testing.T) {
+	lastMod := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	urls, err := PaginatedURLs(PaginationOptions{
+		FirstLoc:   "https://example.com/blog",
+		PageLoc:    "https://example.com/blog/page/%d",
+		Pages:      20,
+		MaxPages:   12,
+		ChangeFreq: ChangeFreqDaily,
+		LastMod:    &lastMod,
+		Rel: func(page int) PageRel {
+			switch page {
+			case 3:
+				return PageCanonicalized
+			case 4:
+				return PageNoIndex
+			}
+			return PageIndexable
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var locs []string
+	var priorities []float64
+	for _, u := range urls {
+		locs = append(locs, u.Loc)
+		priorities = append(priorities, *u.Priority)
+		if u.ChangeFreq != ChangeFreqDaily || !u.LastMod.Equal(lastMod) {
+			t.Errorf("%s: changefreq %q, lastmod %v", u.Loc, u.ChangeFreq, u.LastMod)
+		}
+	}
+	if len(locs) != 10 || locs[0] != "https://example.com/blog" || locs[1] != "https://example.com/blog/page/2" || locs[2] != "https://example.com/blog/page/5" || locs[9] != "https://example.com/blog/page/12" {
+		t.Errorf("locs = %q", locs)
+	}
+	// 0.8 decaying by 0.8 per page, rounded, down to the 0.1 floor.
+	want := []float64{0.8, 0.64, 0.33, 0.26, 0.21, 0.17, 0.13, 0.11, 0.1, 0.1}
+	if !slices.Equal(priorities, want) {
+		t.Errorf("priorities = %v, want %v", priorities, want)
+	}
+}
+
+func TestPaginatedURLsOptions(t *testing.T) {
+	urls, err := PaginatedURLs(PaginationOptions{PageLoc: "https://example.com/archive?page=%d", Pages: 3, Priority: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 3 || urls[0].Loc != "https://example.com/archive?page=1" || urls[0].Priority != nil {
+		t.Errorf("urls[0] = %+v", urls[0])
+	}
+
+	urls, err = PaginatedURLs(PaginationOptions{PageLoc: "https://example.com/p/%d", Pages: 3, Priority: 0.5, Decay: 0.5, MinPriority: 0.2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := []float64{*urls[0].Priority, *urls[1].Priority, *urls[2].Priority}; !slices.Equal(got, []float64{0.5, 0.25, 0.2}) {
+		t.Errorf("priorities = %v", got)
+	}
+
+	for _, pageLoc := range []string{"https://example.com/page", "https://example.com/%d/%d"} {
+		if _, err := PaginatedURLs(PaginationOptions{PageLoc: pageLoc, Pages: 2}); !errors.Is(err, ErrInvalidPagination) {
+			t.Errorf("PageLoc %q: err = %v", pageLoc, err)
+		}
+	}
+	if _, err := PaginatedURLs(PaginationOptions{PageLoc: "/page/%d", Pages: 2}); !errors.Is(err, ErrInvalidLoc) {
+		t.Errorf("relative PageLoc: err = %v, want %v", err, ErrInvalidLoc)
+	}
+}
@@ -0,0 +1,56 @@
+package sitemap_go
+
+import (
+	"net/url"
+	"strings"
+)
+
+// PartitionBy splits the set into one URLSet per key, preserving URL order
+// and the settings of the original set.
+func (u *URLSet) PartitionBy(key func(*URL) string) map[string]URLSet {
+	out := make(map[string]URLSet)
+	for _, entry := range u.URLs {
+		k := key(entry)
+		part, ok := out[k]
+		if !ok {
+			part = u.emptyCopy()
+		}
+		part.URLs = append(part.URLs, entry)
+		out[k] = part
+	}
+	return out
+}
+
+// GroupBy groups the set's URLs by key, preserving URL order within each
+// group. Unlike PartitionBy it returns plain slices sharing the set's URL
+// pointers, for reporting and custom sharding.
+func (u *URLSet) GroupBy(key func(*URL) string) map[string][]*URL {
+	out := make(map[string][]*URL)
+	for _, entry := range u.URLs {
+		k := key(entry)
+		out[k] = append(out[k], entry)
+	}
+	return out
+}
+
+// PartitionByHost splits the set by the lowercased host (including any port)
+// of each loc. Locs that cannot be parsed are grouped under the empty key.
+func (u *URLSet) PartitionByHost() map[string]URLSet {
+	return u.PartitionBy(hostKey)
+}
+
+func hostKey(entry *URL) string {
+	parsed, err := url.Parse(entry.Loc)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Host)
+}
+
+// emptyCopy returns a set with u's namespaces and settings but no URLs.
+func (u *URLSet) emptyCopy() URLSet {
+	out := *u
+	out.URLs = nil
+	out.Namespaces = append([]Namespace(nil), u.Namespaces...)
+	return out
+}
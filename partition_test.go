@@ -0,0 +1,43 @@
+package sitemap_go
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPartitionByHost(t *testing.T) {
+	set := setOf(t, "https://Example.com/a", "https://other.example.com/b", "https://example.com:8443/c", "::bad")
+	set.Namespaces = []Namespace{{Prefix: "x", URI: "https://example.com/ns"}}
+	parts := set.PartitionByHost()
+	tests := map[string][]string{
+		"example.com":       {"https://Example.com/a"},
+		"example.com:8443":  {"https://example.com:8443/c"},
+		"other.example.com": {"https://other.example.com/b"},
+		"":                  {"::bad"},
+	}
+	if len(parts) != len(tests) {
+		t.Errorf("got %d partitions, want %d", len(parts), len(tests))
+	}
+	for host, want := range tests {
+		part := parts[host]
+		if got := locsOf(&part); !slices.Equal(got, want) {
+			t.Errorf("%q: got %q, want %q", host, got, want)
+		}
+		if part.XMLNS != set.XMLNS || len(part.Namespaces) != 1 {
+			t.Errorf("%q: settings not copied: %+v", host, part)
+		}
+	}
+	part := parts["example.com"]
+	part.Namespaces[0].Prefix = "y"
+	if set.Namespaces[0].Prefix != "x" {
+		t.Error("partitions share the set's Namespaces")
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	set := setOf(t, "https://example.com/a/1", "https://example.com/b/1", "https://example.com/a/2")
+	groups := set.GroupBy(func(u *URL) string { return u.Loc[20:21] })
+	if len(groups["a"]) != 2 || groups["a"][1] != set.URLs[2] || len(groups["b"]) != 1 {
+		t.Errorf("groups = %v", groups)
+	}
+}
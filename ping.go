@@ -0,0 +1,176 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const pingSitemapPlaceholder = "{sitemap}"
+
+type PingEndpoint struct {
+	Name string
+	// URL is fetched with GET after replacing {sitemap} with the escaped
+	// sitemap location. It is ignored when NewRequest is set.
+	URL        string
+	NewRequest func(ctx context.Context, sitemapURL string) (*http.Request, error)
+}
+
+type PingResult struct {
+	Endpoint   string
+	StatusCode int
+	Err        error
+}
+
+func (r PingResult) OK() bool {
+	return r.Err == nil
+}
+
+var defaultPingEndpoints = []PingEndpoint{
+	{Name: "google", URL: "https://www.google.com/ping?sitemap={sitemap}"},
+	{Name: "bing", URL: "https://www.bing.com/ping?sitemap={sitemap}"},
+	{Name: "yandex", URL: "https://webmaster.yandex.com/ping?sitemap={sitemap}"},
+	{Name: "baidu", NewRequest: baiduPingRequest},
+}
+
+type PingRegistry struct {
+	HTTPClient *http.Client
+
+	mu        sync.RWMutex
+	endpoints []PingEndpoint
+}
+
+// MakePingRegistry returns a registry holding the given endpoints, or the
+// built-in Google, Bing, Yandex and Baidu endpoints when none are given.
+func MakePingRegistry(endpoints ...PingEndpoint) *PingRegistry {
+	if len(endpoints) == 0 {
+		endpoints = defaultPingEndpoints
+	}
+	r := &PingRegistry{}
+	for _, e := range endpoints {
+		r.Register(e)
+	}
+	return r
+}
+
+// Register adds e, replacing any endpoint already registered under its name.
+func (r *PingRegistry) Register(e PingEndpoint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.endpoints {
+		if r.endpoints[i].Name == e.Name {
+			r.endpoints[i] = e
+			return
+		}
+	}
+	r.endpoints = append(r.endpoints, e)
+}
+
+func (r *PingRegistry) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.endpoints {
+		if r.endpoints[i].Name == name {
+			r.endpoints = append(r.endpoints[:i], r.endpoints[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *PingRegistry) Endpoints() []PingEndpoint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]PingEndpoint(nil), r.endpoints...)
+}
+
+// Ping notifies every registered endpoint, or only those named, concurrently.
+// Results are returned in registration order.
+func (r *PingRegistry) Ping(ctx context.Context, sitemapURL string, names ...string) []PingResult {
+	var targets []PingEndpoint
+	for _, e := range r.Endpoints() {
+		if len(names) == 0 || slices.Contains(names, e.Name) {
+			targets = append(targets, e)
+		}
+	}
+
+	results := make([]PingResult, len(targets))
+	var wg sync.WaitGroup
+	for i, e := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.ping(ctx, e, sitemapURL)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func (r *PingRegistry) ping(ctx context.Context, e PingEndpoint, sitemapURL string) PingResult {
+	out := PingResult{Endpoint: e.Name}
+
+	var req *http.Request
+	if e.NewRequest != nil {
+		req, out.Err = e.NewRequest(ctx, sitemapURL)
+	} else {
+		target := strings.ReplaceAll(e.URL, pingSitemapPlaceholder, url.QueryEscape(sitemapURL))
+		req, out.Err = http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	}
+	if out.Err != nil {
+		return out
+	}
+
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		out.Err = err
+		return out
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	out.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		out.Err = fmt.Errorf("ping %s: unexpected status %s", e.Name, resp.Status)
+	}
+	return out
+}
+
+// Baidu only accepts XML-RPC weblogUpdates pings, whose parameters are the
+// site name, site URL, changed URL and feed URL.
+func baiduPingRequest(ctx context.Context, sitemapURL string) (*http.Request, error) {
+	site, err := url.Parse(sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	siteRoot := site.Scheme + "://" + site.Host + "/"
+
+	var body bytes.Buffer
+	body.WriteString(xml.Header)
+	body.WriteString("<methodCall><methodName>weblogUpdates.extendedPing</methodName><params>")
+	for _, v := range []string{site.Host, siteRoot, sitemapURL, sitemapURL} {
+		body.WriteString("<param><value><string>")
+		if err := xml.EscapeText(&body, []byte(v)); err != nil {
+			return nil, err
+		}
+		body.WriteString("</string></value></param>")
+	}
+	body.WriteString("</params></methodCall>")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://ping.baidu.com/ping/RPC2", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/xml")
+	return req, nil
+}
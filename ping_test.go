@@ -0,0 +1,79 @@
+package sitemap_go
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPingRegistry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if got := r.URL.Query().Get("sitemap"); got != "https://example.com/sitemap.xml?a=1&b=2" {
+			t.Errorf("sitemap = %q", got)
+		}
+	}))
+	defer srv.Close()
+
+	r := MakePingRegistry(
+		PingEndpoint{Name: "ok", URL: srv.URL + "/ok?sitemap={sitemap}"},
+		PingEndpoint{Name: "fail", URL: srv.URL + "/fail?sitemap={sitemap}"},
+		PingEndpoint{Name: "skipped", URL: srv.URL + "/skipped?sitemap={sitemap}"},
+	)
+	r.Remove("skipped")
+	results := r.Ping(context.Background(), "https://example.com/sitemap.xml?a=1&b=2")
+	if len(results) != 2 {
+		t.Fatalf("got %d results", len(results))
+	}
+	if results[0].Endpoint != "ok" || !results[0].OK() || results[0].StatusCode != 200 {
+		t.Errorf("ok: %+v", results[0])
+	}
+	if results[1].Endpoint != "fail" || results[1].OK() || results[1].StatusCode != 500 {
+		t.Errorf("fail: %+v", results[1])
+	}
+	if named := r.Ping(context.Background(), "https://example.com/sitemap.xml?a=1&b=2", "ok"); len(named) != 1 {
+		t.Errorf("named ping hit %d endpoints", len(named))
+	}
+}
+
+func TestPingRegistryReplaces(t *testing.T) {
+	r := MakePingRegistry()
+	r.Register(PingEndpoint{Name: "google", URL: "https://example.com/?{sitemap}"})
+	var names []string
+	for _, e := range r.Endpoints() {
+		names = append(names, e.Name)
+	}
+	if strings.Join(names, ",") != "google,bing,yandex,baidu" {
+		t.Errorf("endpoints = %v", names)
+	}
+	if r.Endpoints()[0].URL != "https://example.com/?{sitemap}" {
+		t.Error("Register did not replace the endpoint")
+	}
+}
+
+func TestBaiduPingRequest(t *testing.T) {
+	req, err := baiduPingRequest(context.Background(), "https://example.com/sitemap.xml?a=1&b=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(req.Body)
+	for _, want := range []string{
+		"<methodName>weblogUpdates.extendedPing</methodName>",
+		"<string>example.com</string>",
+		"<string>https://example.com/</string>",
+		"<string>https://example.com/sitemap.xml?a=1&amp;b=2</string>",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("%s missing from\n%s", want, body)
+		}
+	}
+	if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "text/xml" {
+		t.Errorf("got %s with %q", req.Method, req.Header.Get("Content-Type"))
+	}
+}
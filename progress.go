@@ -0,0 +1,77 @@
+package sitemap_go
+
+import (
+	"io"
+	"sync"
+)
+
+// Progress is a snapshot of a long-running operation. Counters are
+// cumulative; each operation fills in only those that apply to it.
+type Progress struct {
+	URLs  int
+	Bytes int64
+	// Shards counts shards encoded by GenerateShards.
+	Shards int
+	// Sitemaps counts child sitemaps fetched while resolving an index.
+	Sitemaps int
+	// Pages counts pages fetched by a Crawler.
+	Pages int
+}
+
+// ProgressFunc receives progress updates. Calls are serialized, but may
+// come from a goroutine other than the caller's.
+type ProgressFunc func(Progress)
+
+// progressTracker serializes updates to a shared Progress.
+type progressTracker struct {
+	fn ProgressFunc
+	mu sync.Mutex
+	p  Progress
+}
+
+func newProgressTracker(fn ProgressFunc) *progressTracker {
+	if fn == nil {
+		return nil
+	}
+	return &progressTracker{fn: fn}
+}
+
+func (t *progressTracker) update(f func(*Progress)) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f(&t.p)
+	t.fn(t.p)
+}
+
+// ProgressReader wraps r, reporting the bytes read so far after every
+// Read, for instance while a large sitemap is being parsed.
+func ProgressReader(r io.Reader, fn ProgressFunc) io.Reader {
+	return &progressReader{r: r, tracker: newProgressTracker(fn)}
+}
+
+type progressReader struct {
+	r       io.Reader
+	tracker *progressTracker
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.tracker.update(func(p *Progress) { p.Bytes += int64(n) })
+	}
+	return n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
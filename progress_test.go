@@ -0,0 +1,101 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+// recordProgress returns a ProgressFunc appending every update to *updates.
+func recordProgress(updates *[]Progress) ProgressFunc {
+	return func(p Progress) { *updates = append(*updates, p) }
+}
+
+func TestProgressReader(t *testing.T) {
+	var updates []Progress
+	data := strings.Repeat("x", 10000)
+	n, err := io.Copy(io.Discard, ProgressReader(strings.NewReader(data), recordProgress(&updates)))
+	if err != nil || n != 10000 {
+		t.Fatalf("copied %d, %v", n, err)
+	}
+	if len(updates) == 0 || updates[len(updates)-1].Bytes != 10000 {
+		t.Errorf("updates = %+v", updates)
+	}
+
+	if _, err := io.Copy(io.Discard, ProgressReader(strings.NewReader(data), nil)); err != nil {
+		t.Errorf("nil ProgressFunc: %v", err)
+	}
+}
+
+func TestWriterProgress(t *testing.T) {
+	var updates []Progress
+	var buf bytes.Buffer
+	w := NewWriter(&buf, nil, EncodeOptions{})
+	w.Progress = recordProgress(&updates)
+	for _, u := range numberedSet(t, 3, "").URLs {
+		if err := w.Write(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(updates) != 3 {
+		t.Fatalf("got %d updates, want 3", len(updates))
+	}
+	for i, p := range updates {
+		if p.URLs != i+1 || p.Bytes > int64(buf.Len()) || (i > 0 && p.Bytes < updates[i-1].Bytes) {
+			t.Errorf("update %d = %+v with %d bytes written", i, p, buf.Len())
+		}
+	}
+}
+
+func TestGenerateShardsProgress(t *testing.T) {
+	var updates []Progress
+	shards, err := numberedSet(t, 25, "").GenerateShards(context.Background(), ShardOptions{MaxURLs: 10, Parallelism: 3, Progress: recordProgress(&updates)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var size int64
+	for _, shard := range shards {
+		size += int64(len(shard.Data))
+	}
+	if len(updates) != 3 {
+		t.Fatalf("got %d updates, want 3", len(updates))
+	}
+	if last := updates[2]; last.Shards != 3 || last.URLs != 25 || last.Bytes != size {
+		t.Errorf("final update = %+v, want 3 shards, 25 URLs and %d bytes", last, size)
+	}
+}
+
+func TestResolveIndexProgress(t *testing.T) {
+	srv := indexServer(t, 3, 4)
+	defer srv.Close()
+	var updates []Progress
+	f := testFetcher(srv)
+	f.Progress = recordProgress(&updates)
+	if _, err := f.ResolveIndex(context.Background(), srv.URL+"/sitemap.xml"); err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 3 {
+		t.Fatalf("got %d updates, want 3", len(updates))
+	}
+	if last := updates[2]; last.Sitemaps != 3 || last.URLs != 12 || last.Bytes == 0 {
+		t.Errorf("final update = %+v", last)
+	}
+}
+
+func TestCrawlProgress(t *testing.T) {
+	srv := siteServer(t, map[string]string{
+		"/":  `<a href="/a">a</a> <a href="/b">b</a>`,
+		"/a": `a`,
+		"/b": `<meta name="robots" content="noindex">`,
+	})
+	var updates []Progress
+	crawl(t, &Crawler{Progress: recordProgress(&updates)}, srv.URL+"/")
+	if len(updates) != 3 {
+		t.Fatalf("got %d updates, want 3", len(updates))
+	}
+	if last := updates[2]; last.Pages != 3 || last.URLs != 2 {
+		t.Errorf("final update = %+v", last)
+	}
+}
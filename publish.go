@@ -0,0 +1,614 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultIndexName = "sitemap.xml"
+
+type ObjectMeta struct {
+	ContentType     string
+	ContentEncoding string
+	CacheControl    string
+}
+
+// Storage is the destination published sitemap files are written to.
+type Storage interface {
+	Put(ctx context.Context, name string, data []byte, meta ObjectMeta) error
+}
+
+// DeleteStorage is a Storage that can remove objects. Deleting a missing
+// object is not an error.
+type DeleteStorage interface {
+	Storage
+	Delete(ctx context.Context, name string) error
+}
+
+// ListStorage is a Storage that can list the names of its objects.
+type ListStorage interface {
+	Storage
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// MoveStorage is a Storage that can rename objects.
+type MoveStorage interface {
+	Storage
+	Move(ctx context.Context, from, to string) error
+}
+
+// DirStorage writes objects as files under Dir. Metadata is not stored.
+type DirStorage struct {
+	Dir string
+}
+
+func (s DirStorage) Put(ctx context.Context, name string, data []byte, meta ObjectMeta) error {
+	path := filepath.Join(s.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".publish-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s DirStorage) Delete(ctx context.Context, name string) error {
+	err := os.Remove(filepath.Join(s.Dir, filepath.FromSlash(name)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// List returns the slash-separated names of the files under Dir starting
+// with prefix, skipping temporary files of unfinished writes.
+func (s DirStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(s.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".publish-") {
+			return err
+		}
+		rel, err := filepath.Rel(s.Dir, path)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return names, err
+}
+
+func (s DirStorage) Move(ctx context.Context, from, to string) error {
+	dst := filepath.Join(s.Dir, filepath.FromSlash(to))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(s.Dir, filepath.FromSlash(from)), dst)
+}
+
+// PrefixStorage stores every object under Prefix in Storage.
+type PrefixStorage struct {
+	Storage Storage
+	Prefix  string
+}
+
+func (s PrefixStorage) Put(ctx context.Context, name string, data []byte, meta ObjectMeta) error {
+	return s.Storage.Put(ctx, s.Prefix+name, data, meta)
+}
+
+func (s PrefixStorage) Delete(ctx context.Context, name string) error {
+	d, ok := s.Storage.(DeleteStorage)
+	if !ok {
+		return fmt.Errorf("%w: %T cannot delete", ErrUnsupportedStorage, s.Storage)
+	}
+	return d.Delete(ctx, s.Prefix+name)
+}
+
+func (s PrefixStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	l, ok := s.Storage.(ListStorage)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T cannot list", ErrUnsupportedStorage, s.Storage)
+	}
+	names, err := l.List(ctx, s.Prefix+prefix)
+	for i, name := range names {
+		names[i] = strings.TrimPrefix(name, s.Prefix)
+	}
+	return names, err
+}
+
+func (s PrefixStorage) Move(ctx context.Context, from, to string) error {
+	m, ok := s.Storage.(MoveStorage)
+	if !ok {
+		return fmt.Errorf("%w: %T cannot move", ErrUnsupportedStorage, s.Storage)
+	}
+	return m.Move(ctx, s.Prefix+from, s.Prefix+to)
+}
+
+// MultiStorage writes every object to each storage in turn, stopping at the
+// first failure.
+type MultiStorage []Storage
+
+func (m MultiStorage) Put(ctx context.Context, name string, data []byte, meta ObjectMeta) error {
+	for _, s := range m {
+		if err := s.Put(ctx, name, data, meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete deletes from every storage that supports it.
+func (m MultiStorage) Delete(ctx context.Context, name string) error {
+	for _, s := range m {
+		if d, ok := s.(DeleteStorage); ok {
+			if err := d.Delete(ctx, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// List merges the names listed by every storage that supports it.
+func (m MultiStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	for _, s := range m {
+		if l, ok := s.(ListStorage); ok {
+			listed, err := l.List(ctx, prefix)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, listed...)
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names), nil
+}
+
+type StorageOpKind string
+
+const (
+	StoragePut    StorageOpKind = "put"
+	StorageDelete StorageOpKind = "delete"
+	StorageMove   StorageOpKind = "move"
+)
+
+// StorageOp is a storage operation recorded by DryRunStorage.
+type StorageOp struct {
+	Kind StorageOpKind
+	Name string
+	// Size is the byte size of the object put.
+	Size int
+	Meta ObjectMeta
+	// To is the new name of a moved object.
+	To string
+}
+
+// DryRunStorage records the operations made on it without performing
+// them. List is answered by Storage, when it supports listing, which is
+// never written to.
+type DryRunStorage struct {
+	Storage Storage
+
+	mu  sync.Mutex
+	ops []StorageOp
+}
+
+func (s *DryRunStorage) Put(ctx context.Context, name string, data []byte, meta ObjectMeta) error {
+	s.record(StorageOp{Kind: StoragePut, Name: name, Size: len(data), Meta: meta})
+	return nil
+}
+
+func (s *DryRunStorage) Delete(ctx context.Context, name string) error {
+	s.record(StorageOp{Kind: StorageDelete, Name: name})
+	return nil
+}
+
+func (s *DryRunStorage) Move(ctx context.Context, from, to string) error {
+	s.record(StorageOp{Kind: StorageMove, Name: from, To: to})
+	return nil
+}
+
+func (s *DryRunStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	if l, ok := s.Storage.(ListStorage); ok {
+		return l.List(ctx, prefix)
+	}
+	return nil, nil
+}
+
+// Open is answered by Storage, when it can be read back.
+func (s *DryRunStorage) Open(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error) {
+	r, ok := s.Storage.(ObjectReader)
+	if !ok {
+		return nil, ObjectInfo{}, fmt.Errorf("%w: %T cannot read", ErrUnsupportedStorage, s.Storage)
+	}
+	return r.Open(ctx, name)
+}
+
+func (s *DryRunStorage) record(op StorageOp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops = append(s.ops, op)
+}
+
+// Ops returns the recorded operations in the order they were made.
+func (s *DryRunStorage) Ops() []StorageOp {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.ops)
+}
+
+type DiffStats struct {
+	Added   int
+	Removed int
+	// Updated counts URLs present in both versions whose lastmod changed.
+	Updated int
+}
+
+type PublishResult struct {
+	Time      time.Time
+	IndexURL  string
+	ShardURLs []string
+	URLs      int
+	// Diff compares this publish with the previous one by the same
+	// Publisher. On the first publish every URL counts as added.
+	Diff DiffStats
+	// PurgedURLs are the URLs passed to the Purger.
+	PurgedURLs []string
+	// NotifyErrs holds the errors of notifiers that failed. They do not
+	// make the publish itself fail.
+	NotifyErrs []error
+	// Obsolete names the shards of earlier publishes that Cleanup removed.
+	// Their URLs are purged too.
+	Obsolete []string
+	// DryRun lists the storage operations a dry run would have made.
+	DryRun []StorageOp
+	// Skipped names the shards Selective left as they were.
+	Skipped []string
+	// Expires is when the signed URLs of the publish stop working. It is
+	// set only with a Signer.
+	Expires time.Time
+}
+
+// Publisher shards a URLSet, writes the shards and a sitemap index to
+// Storage and then notifies its Notifiers.
+type Publisher struct {
+	Storage Storage
+	// BaseURL is the public URL the Storage's objects are served under,
+	// e.g. "https://example.com/sitemaps/".
+	BaseURL string
+	// IndexName defaults to "sitemap.xml".
+	IndexName    string
+	Shards       ShardOptions
+	CacheControl string
+	Notifiers    []Notifier
+	// Purger, when set, is called after upload with the URLs of every
+	// object whose content changed since the previous publish.
+	Purger Purger
+	// HostRewrites maps hosts to the hosts published in their place, such
+	// as "staging.example.com" to "www.example.com", so one pipeline can
+	// run in every environment. Only the published output is rewritten.
+	HostRewrites map[string]string
+	// DryRun makes Publish report the storage operations it would make in
+	// PublishResult.DryRun instead of making them. Nothing is purged or
+	// notified, and the Publisher's record of the previous publish, used
+	// for Diff and purging, is left as it was.
+	DryRun bool
+	// Cleanup removes shards of earlier publishes that the new one no
+	// longer has, after the new index is written. They are found in the
+	// previous publish and, when Storage implements ListStorage, by listing
+	// names that follow the shard naming scheme, unsectioned or in one of
+	// the sections being published, so shards of other pipelines sharing
+	// the prefix are left alone.
+	Cleanup CleanupMode
+	// ArchivePrefix is prepended to the names of shards archived by
+	// CleanupArchive. It defaults to "archive/".
+	ArchivePrefix string
+	// Atomic stages every object under a temporary name and promotes them
+	// only once all uploads succeeded, rolling back on failure; see
+	// putAtomic. Storage must implement MoveStorage and DeleteStorage, and
+	// ObjectReader once there are live objects to back up.
+	Atomic bool
+	// Stylesheet, when set, is published as StylesheetName, which defaults
+	// to "sitemap.xsl", and referenced from the index and every shard.
+	Stylesheet     *Stylesheet
+	StylesheetName string
+	// Selective regenerates and uploads only the shards whose content
+	// changed since the previous publish, judged by Shard.Fingerprint,
+	// plus the index. It pays off when shard membership is stable across
+	// runs. The first publish of a Publisher writes every shard.
+	Selective bool
+	// Signer, when set, lists every shard in the index under a signed URL,
+	// and IndexURL is signed too, for publishing to a private bucket for
+	// staging reviews or partner feeds. The URLs expire SignedURLExpiry
+	// after the publish, 24 hours by default, so the index has to be
+	// republished before then. The stylesheet is still referenced
+	// unsigned.
+	Signer          URLSigner
+	SignedURLExpiry time.Duration
+
+	mu     sync.Mutex
+	last   map[string]time.Time
+	hashes map[string][sha256.Size]byte
+	// shards records the fingerprint and document hash of every shard of
+	// the previous publish.
+	shards map[string]publishedShard
+}
+
+type publishedShard struct {
+	fingerprint, hash [sha256.Size]byte
+}
+
+// Publish writes every shard before the index, so the published index
+// never refers to a shard that has not been uploaded yet. A purge failure
+// is returned as an error, with the result, before any Notifier runs.
+func (p *Publisher) Publish(ctx context.Context, set *URLSet) (PublishResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now().UTC()
+	shards, err := set.GenerateShards(ctx, p.shardOptions())
+	if err != nil {
+		return PublishResult{Time: now, URLs: len(set.URLs)}, err
+	}
+	return p.publish(ctx, now, set, shards, nil)
+}
+
+// PublishSections publishes one group of shards per section of b, named
+// after the section, under a single index.
+func (p *Publisher) PublishSections(ctx context.Context, b *SectionedBuilder) (PublishResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now().UTC()
+	all := b.all()
+	shards, err := b.shards(ctx, p.shardOptions())
+	if err != nil {
+		return PublishResult{Time: now, URLs: len(all.URLs)}, err
+	}
+	return p.publish(ctx, now, &all, shards, b.Sections())
+}
+
+func (p *Publisher) publish(ctx context.Context, now time.Time, set *URLSet, shards []Shard, sections []string) (PublishResult, error) {
+	result := PublishResult{Time: now, URLs: len(set.URLs)}
+	storage := p.Storage
+	var dryRun *DryRunStorage
+	if p.DryRun {
+		dryRun = &DryRunStorage{Storage: p.Storage}
+		storage = dryRun
+	}
+	index := MakeSitemapIndex(nil)
+	objects := make([]publishObject, 0, len(shards)+2)
+	if p.Stylesheet != nil {
+		var xsl bytes.Buffer
+		if err := p.Stylesheet.WriteXSL(&xsl); err != nil {
+			return result, err
+		}
+		objects = append(objects, publishObject{p.stylesheetName(), xsl.Bytes()})
+	}
+	strategy := p.Shards.IndexLastMod
+	if strategy == nil {
+		strategy = GenerationTime{}
+	}
+	published := make(map[string]publishedShard, len(shards))
+	var skipped []string
+	for _, shard := range shards {
+		if shard.Skipped {
+			shard.Hash = p.shards[shard.Name].hash
+			skipped = append(skipped, shard.Name)
+		} else {
+			objects = append(objects, publishObject{shard.Name, shard.Data})
+		}
+		published[shard.Name] = publishedShard{shard.Fingerprint, shard.Hash}
+		loc, err := p.signedURL(shard.Name, &result)
+		if err != nil {
+			return result, err
+		}
+		result.ShardURLs = append(result.ShardURLs, loc)
+		index.Sitemaps = append(index.Sitemaps, SitemapEntry{Loc: loc, LastMod: strategy.IndexLastMod(shard, result.Time)})
+	}
+	result.Skipped = skipped
+	var buf bytes.Buffer
+	if err := index.Encode(&buf, p.shardOptions().Encode); err != nil {
+		return result, err
+	}
+	name := p.indexName()
+	objects = append(objects, publishObject{name, buf.Bytes()})
+	indexURL, err := p.signedURL(name, &result)
+	if err != nil {
+		return result, err
+	}
+
+	write := p.putAll
+	if p.Atomic {
+		write = p.putAtomic
+	}
+	if err := write(ctx, storage, objects); err != nil {
+		return result, err
+	}
+	hashes := make(map[string][sha256.Size]byte, len(objects)+len(skipped))
+	for _, name := range skipped {
+		hashes[name] = p.hashes[name]
+	}
+	for _, obj := range objects {
+		hashes[obj.name] = sha256.Sum256(obj.data)
+		if old, ok := p.hashes[obj.name]; ok && old != hashes[obj.name] {
+			result.PurgedURLs = append(result.PurgedURLs, p.url(obj.name))
+		}
+	}
+	result.IndexURL = indexURL
+	if err := p.cleanup(ctx, storage, hashes, sections, &result); err != nil {
+		return result, fmt.Errorf("cleanup: %w", err)
+	}
+
+	current := lastModsByLoc(set)
+	result.Diff = diffStats(p.last, current)
+	if dryRun != nil {
+		result.DryRun = dryRun.Ops()
+		return result, nil
+	}
+	p.hashes = hashes
+	p.last = current
+	p.shards = published
+
+	if p.Purger != nil && len(result.PurgedURLs) > 0 {
+		if err := p.Purger.Purge(ctx, result.PurgedURLs); err != nil {
+			return result, fmt.Errorf("purge: %w", err)
+		}
+	}
+	for _, n := range p.Notifiers {
+		if err := n.Notify(ctx, result); err != nil {
+			result.NotifyErrs = append(result.NotifyErrs, err)
+		}
+	}
+	return result, nil
+}
+
+func (p *Publisher) shardOptions() ShardOptions {
+	opts := p.Shards
+	// The publisher uploads the shards itself.
+	opts.Sink = nil
+	if p.Stylesheet != nil {
+		opts.Encode.Stylesheet = p.url(p.stylesheetName())
+	}
+	if p.Selective {
+		unchanged := opts.Unchanged
+		opts.Unchanged = func(s Shard) bool {
+			prev, ok := p.shards[s.Name]
+			if ok && prev.fingerprint == s.Fingerprint {
+				return true
+			}
+			return unchanged != nil && unchanged(s)
+		}
+	}
+	if len(p.HostRewrites) > 0 {
+		opts.Transformers = append(slices.Clip(opts.Transformers), RewriteLocs(p.rewriteHost))
+	}
+	return opts
+}
+
+func (p *Publisher) rewriteHost(loc string) string {
+	parsed, err := url.Parse(loc)
+	if err != nil {
+		return loc
+	}
+	for from, to := range p.HostRewrites {
+		if strings.EqualFold(parsed.Host, from) {
+			parsed.Host = to
+			return parsed.String()
+		}
+	}
+	return loc
+}
+
+func (p *Publisher) indexName() string {
+	if p.IndexName == "" {
+		return defaultIndexName
+	}
+	return p.IndexName
+}
+
+func (p *Publisher) stylesheetName() string {
+	if p.StylesheetName == "" {
+		return "sitemap.xsl"
+	}
+	return p.StylesheetName
+}
+
+func (p *Publisher) url(name string) string {
+	return strings.TrimSuffix(p.BaseURL, "/") + "/" + name
+}
+
+// signedURL is url, signed when the Publisher has a Signer.
+func (p *Publisher) signedURL(name string, result *PublishResult) (string, error) {
+	if p.Signer == nil {
+		return p.url(name), nil
+	}
+	expiry := p.SignedURLExpiry
+	if expiry <= 0 {
+		expiry = 24 * time.Hour
+	}
+	result.Expires = result.Time.Add(expiry)
+	loc, err := p.Signer.SignURL(name, result.Expires)
+	if err != nil {
+		return "", fmt.Errorf("sign %s: %w", name, err)
+	}
+	return loc, nil
+}
+
+func (p *Publisher) meta(name string) ObjectMeta {
+	meta := nameMeta(name)
+	meta.CacheControl = p.CacheControl
+	return meta
+}
+
+// nameMeta returns the content headers of a published file, which follow
+// from its name.
+func nameMeta(name string) ObjectMeta {
+	meta := ObjectMeta{ContentType: "application/xml"}
+	if strings.HasSuffix(name, ".xsl") {
+		meta.ContentType = "text/xsl"
+	}
+	if coding, ok := codingByExt(name); ok {
+		meta.ContentEncoding = coding.Name
+	}
+	return meta
+}
+
+func lastModsByLoc(set *URLSet) map[string]time.Time {
+	out := make(map[string]time.Time, len(set.URLs))
+	for _, entry := range set.URLs {
+		var lastMod time.Time
+		if entry.LastMod != nil {
+			lastMod = *entry.LastMod
+		}
+		out[entry.Loc] = lastMod
+	}
+	return out
+}
+
+func diffStats(prev, next map[string]time.Time) DiffStats {
+	var stats DiffStats
+	for loc, lastMod := range next {
+		old, ok := prev[loc]
+		switch {
+		case !ok:
+			stats.Added++
+		case !old.Equal(lastMod):
+			stats.Updated++
+		}
+	}
+	for loc := range prev {
+		if _, ok := next[loc]; !ok {
+			stats.Removed++
+		}
+	}
+	return stats
+}
@@ -0,0 +1,259 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memStorage keeps objects in memory and records the order of puts.
+type memStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	meta    map[string]ObjectMeta
+	puts    []string
+}
+
+func (s *memStorage) Put(ctx context.Context, name string, data []byte, meta ObjectMeta) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.objects == nil {
+		s.objects, s.meta = map[string][]byte{}, map[string]ObjectMeta{}
+	}
+	s.objects[name], s.meta[name] = slices.Clone(data), meta
+	s.puts = append(s.puts, name)
+	return nil
+}
+
+func (s *memStorage) Delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, name)
+	return nil
+}
+
+func (s *memStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for name := range s.objects {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (s *memStorage) get(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return string(s.objects[name])
+}
+
+func TestPublish(t *testing.T) {
+	storage := &memStorage{}
+	var notified []PublishResult
+	p := &Publisher{
+		Storage:      storage,
+		BaseURL:      "https://example.com/sitemaps/",
+		Shards:       ShardOptions{MaxURLs: 2},
+		CacheControl: "max-age=60",
+		Notifiers: []Notifier{NotifierFunc(func(ctx context.Context, r PublishResult) error {
+			notified = append(notified, r)
+			return nil
+		})},
+	}
+	ctx := context.Background()
+	result, err := p.Publish(ctx, numberedSet(t, 3, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sitemap-1.xml", "sitemap-2.xml", "sitemap.xml"}; !slices.Equal(storage.puts, want) {
+		t.Errorf("puts = %q, want the shards before the index", storage.puts)
+	}
+	if result.IndexURL != "https://example.com/sitemaps/sitemap.xml" || len(result.ShardURLs) != 2 || result.URLs != 3 {
+		t.Errorf("result = %+v", result)
+	}
+	if result.Diff != (DiffStats{Added: 3}) {
+		t.Errorf("first diff = %+v", result.Diff)
+	}
+	if meta := storage.meta["sitemap.xml"]; meta.ContentType != "application/xml" || meta.CacheControl != "max-age=60" {
+		t.Errorf("meta = %+v", meta)
+	}
+	index, err := ParseXMLSitemapIndex(storage.get("sitemap.xml"))
+	if err != nil || len(index.Sitemaps) != 2 || index.Sitemaps[1].Loc != "https://example.com/sitemaps/sitemap-2.xml" {
+		t.Errorf("index = %+v, %v", index, err)
+	}
+
+	next := numberedSet(t, 4, "")
+	next.URLs = next.URLs[1:]
+	lastMod := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	next.URLs[0].LastMod = &lastMod
+	result, err = p.Publish(ctx, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Diff != (DiffStats{Added: 1, Removed: 1, Updated: 1}) {
+		t.Errorf("second diff = %+v", result.Diff)
+	}
+	if len(notified) != 2 || notified[1].IndexURL != result.IndexURL {
+		t.Errorf("notified %d times", len(notified))
+	}
+}
+
+func TestPublishNotifierErrors(t *testing.T) {
+	errNotify := errors.New("notify failed")
+	p := &Publisher{
+		Storage:   &memStorage{},
+		BaseURL:   "https://example.com/",
+		Notifiers: []Notifier{NotifierFunc(func(context.Context, PublishResult) error { return errNotify })},
+	}
+	result, err := p.Publish(context.Background(), numberedSet(t, 1, ""))
+	if err != nil {
+		t.Fatalf("a failing notifier failed the publish: %v", err)
+	}
+	if len(result.NotifyErrs) != 1 || !errors.Is(result.NotifyErrs[0], errNotify) {
+		t.Errorf("NotifyErrs = %v", result.NotifyErrs)
+	}
+}
+
+func TestPublishHostRewrites(t *testing.T) {
+	storage := &memStorage{}
+	p := &Publisher{
+		Storage:      storage,
+		BaseURL:      "https://www.example.com/",
+		HostRewrites: map[string]string{"staging.example.com": "www.example.com"},
+	}
+	set := setOf(t, "https://Staging.example.com/a", "https://other.example.com/b")
+	set.URLs[0].Images = []Image{{Loc: "https://staging.example.com/a.jpg"}}
+	if _, err := p.Publish(context.Background(), set); err != nil {
+		t.Fatal(err)
+	}
+	shard := storage.get("sitemap-1.xml")
+	for _, want := range []string{"https://www.example.com/a", "https://www.example.com/a.jpg", "https://other.example.com/b"} {
+		if !strings.Contains(shard, want) {
+			t.Errorf("%s missing from\n%s", want, shard)
+		}
+	}
+	if strings.Contains(shard, "staging.example.com") {
+		t.Errorf("staging host published:\n%s", shard)
+	}
+	if set.URLs[0].Loc != "https://Staging.example.com/a" || set.URLs[0].Images[0].Loc != "https://staging.example.com/a.jpg" {
+		t.Errorf("the input set was rewritten: %+v", set.URLs[0])
+	}
+	if len(p.Shards.Transformers) != 0 {
+		t.Error("HostRewrites leaked into p.Shards")
+	}
+}
+
+func TestPublishDryRun(t *testing.T) {
+	storage := &memStorage{}
+	notified := 0
+	p := &Publisher{
+		Storage: storage,
+		BaseURL: "https://example.com/",
+		Shards:  ShardOptions{MaxURLs: 2},
+		DryRun:  true,
+		Notifiers: []Notifier{NotifierFunc(func(ctx context.Context, r PublishResult) error {
+			notified++
+			return nil
+		})},
+	}
+	ctx := context.Background()
+	result, err := p.Publish(ctx, numberedSet(t, 3, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(storage.puts) != 0 || notified != 0 {
+		t.Errorf("dry run wrote %q and notified %d times", storage.puts, notified)
+	}
+	var names []string
+	for _, op := range result.DryRun {
+		if op.Kind != StoragePut || op.Size == 0 || op.Meta.ContentType != "application/xml" {
+			t.Errorf("op = %+v", op)
+		}
+		names = append(names, op.Name)
+	}
+	if want := []string{"sitemap-1.xml", "sitemap-2.xml", "sitemap.xml"}; !slices.Equal(names, want) {
+		t.Errorf("ops = %q, want %q", names, want)
+	}
+	if result.Diff != (DiffStats{Added: 3}) {
+		t.Errorf("diff = %+v", result.Diff)
+	}
+
+	p.DryRun = false
+	result, err = p.Publish(ctx, numberedSet(t, 3, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Diff != (DiffStats{Added: 3}) || len(result.DryRun) != 0 || notified != 1 {
+		t.Errorf("the dry run changed the previous publish: diff %+v", result.Diff)
+	}
+}
+
+func TestDryRunStorage(t *testing.T) {
+	backing := &memStorage{}
+	ctx := context.Background()
+	backing.Put(ctx, "a.xml", []byte("a"), ObjectMeta{})
+	s := &DryRunStorage{Storage: backing}
+	s.Put(ctx, "b.xml", []byte("bb"), ObjectMeta{})
+	s.Delete(ctx, "a.xml")
+	s.Move(ctx, "b.xml", "c.xml")
+	want := []StorageOp{
+		{Kind: StoragePut, Name: "b.xml", Size: 2},
+		{Kind: StorageDelete, Name: "a.xml"},
+		{Kind: StorageMove, Name: "b.xml", To: "c.xml"},
+	}
+	if got := s.Ops(); !slices.Equal(got, want) {
+		t.Errorf("ops = %+v, want %+v", got, want)
+	}
+	if names, err := s.List(ctx, ""); err != nil || !slices.Equal(names, []string{"a.xml"}) {
+		t.Errorf("List = %q, %v: want the untouched backing storage", names, err)
+	}
+	if _, _, err := s.Open(ctx, "a.xml"); !errors.Is(err, ErrUnsupportedStorage) {
+		t.Errorf("Open on unreadable storage: err = %v", err)
+	}
+}
+
+func TestPublishSelective(t *testing.T) {
+	storage := &memStorage{}
+	// Undated shards keep the index unchanged as long as its shards are.
+	p := &Publisher{Storage: storage, BaseURL: "https://example.com/", Shards: ShardOptions{MaxURLs: 2, IndexLastMod: NewestLastMod{}}, Selective: true}
+	ctx := context.Background()
+	if _, err := p.Publish(ctx, numberedSet(t, 5, "")); err != nil {
+		t.Fatal(err)
+	}
+	storage.puts = nil
+	set := numberedSet(t, 5, "")
+	set.URLs[2].Loc += "?v=2"
+	result, err := p.Publish(ctx, set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sitemap-2.xml", "sitemap.xml"}; !slices.Equal(storage.puts, want) {
+		t.Errorf("puts = %q, want %q", storage.puts, want)
+	}
+	if want := []string{"sitemap-1.xml", "sitemap-3.xml"}; !slices.Equal(result.Skipped, want) {
+		t.Errorf("skipped = %q, want %q", result.Skipped, want)
+	}
+	if len(result.ShardURLs) != 3 || !strings.Contains(storage.get("sitemap-2.xml"), "2?v=2") {
+		t.Errorf("result = %+v", result)
+	}
+	if want := []string{"https://example.com/sitemap-2.xml"}; !slices.Equal(result.PurgedURLs, want) {
+		t.Errorf("purged = %q, want %q", result.PurgedURLs, want)
+	}
+
+	// Publishing again remembers the skipped shards as they were.
+	storage.puts = nil
+	if result, err = p.Publish(ctx, set); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(storage.puts, []string{"sitemap.xml"}) || len(result.Skipped) != 3 || len(result.PurgedURLs) != 0 {
+		t.Errorf("puts = %q, skipped = %q, purged = %q", storage.puts, result.Skipped, result.PurgedURLs)
+	}
+}
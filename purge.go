@@ -0,0 +1,175 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CloudflareEndpoint = "https://api.cloudflare.com/client/v4"
+	FastlyEndpoint     = "https://api.fastly.com"
+	CloudFrontEndpoint = "https://cloudfront.amazonaws.com"
+
+	cloudflareMaxPurgeFiles = 30
+	cloudFrontMaxPaths      = 3000
+)
+
+// Purger invalidates CDN caches for published sitemap URLs.
+type Purger interface {
+	Purge(ctx context.Context, urls []string) error
+}
+
+type PurgerFunc func(ctx context.Context, urls []string) error
+
+func (f PurgerFunc) Purge(ctx context.Context, urls []string) error {
+	return f(ctx, urls)
+}
+
+// CloudflarePurger purges URLs from a Cloudflare zone's cache, 30 at a
+// time, using an API token with the Cache Purge permission.
+type CloudflarePurger struct {
+	ZoneID     string
+	APIToken   string
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+func (p *CloudflarePurger) Purge(ctx context.Context, urls []string) error {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = CloudflareEndpoint
+	}
+	for start := 0; start < len(urls); start += cloudflareMaxPurgeFiles {
+		payload, err := json.Marshal(map[string][]string{
+			"files": urls[start:min(start+cloudflareMaxPurgeFiles, len(urls))],
+		})
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+			endpoint+"/zones/"+url.PathEscape(p.ZoneID)+"/purge_cache", bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+p.APIToken)
+		req.Header.Set("Content-Type", "application/json")
+		if err := doPurge(p.HTTPClient, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FastlyPurger purges URLs one by one through the Fastly API.
+type FastlyPurger struct {
+	APIToken string
+	// Soft marks content as stale instead of evicting it.
+	Soft       bool
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+func (p *FastlyPurger) Purge(ctx context.Context, urls []string) error {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = FastlyEndpoint
+	}
+	for _, loc := range urls {
+		u, err := url.Parse(loc)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/purge/"+u.Host+u.RequestURI(), nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Fastly-Key", p.APIToken)
+		if p.Soft {
+			req.Header.Set("Fastly-Soft-Purge", "1")
+		}
+		if err := doPurge(p.HTTPClient, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloudFrontPurger creates CloudFront invalidations for the paths of the
+// given URLs.
+type CloudFrontPurger struct {
+	DistributionID string
+	Credentials    AWSCredentials
+	Endpoint       string
+	HTTPClient     *http.Client
+}
+
+type cloudFrontInvalidationBatch struct {
+	XMLName         xml.Name `xml:"http://cloudfront.amazonaws.com/doc/2020-05-31/ InvalidationBatch"`
+	Quantity        int      `xml:"Paths>Quantity"`
+	Items           []string `xml:"Paths>Items>Path"`
+	CallerReference string   `xml:"CallerReference"`
+}
+
+func (p *CloudFrontPurger) Purge(ctx context.Context, urls []string) error {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = CloudFrontEndpoint
+	}
+	paths := make([]string, 0, len(urls))
+	for _, loc := range urls {
+		u, err := url.Parse(loc)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, u.EscapedPath())
+	}
+	for start := 0; start < len(paths); start += cloudFrontMaxPaths {
+		batch := paths[start:min(start+cloudFrontMaxPaths, len(paths))]
+		now := time.Now()
+		body, err := xml.Marshal(cloudFrontInvalidationBatch{
+			Quantity:        len(batch),
+			Items:           batch,
+			CallerReference: "sitemap-" + strconv.FormatInt(now.UnixNano(), 10),
+		})
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+			endpoint+"/2020-05-31/distribution/"+url.PathEscape(p.DistributionID)+"/invalidation", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "text/xml")
+		signAWSV4(req, body, p.Credentials, "us-east-1", "cloudfront", now)
+		if err := doPurge(p.HTTPClient, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func doPurge(client *http.Client, req *http.Request) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("purge %s: unexpected status %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
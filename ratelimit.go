@@ -0,0 +1,120 @@
+package sitemap_go
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRateLimitRPS   = 10
+	defaultRateLimitBurst = 10
+
+	// rateLimitSweepInterval is how often reserve drops the buckets that
+	// have refilled, so a long-lived limiter does not keep one per host it
+	// has ever seen.
+	rateLimitSweepInterval = time.Minute
+)
+
+// RateLimiter spaces out requests per host with a token bucket holding up
+// to Burst tokens and refilled at RPS tokens per second. A RateLimiter
+// with a zero or negative RPS does not limit at all.
+type RateLimiter struct {
+	RPS   float64
+	Burst int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// defaultRateLimiter is used by Fetchers and Crawlers without their own
+// RateLimiter, so every client of the package shares one per-host budget.
+var defaultRateLimiter = &RateLimiter{RPS: defaultRateLimitRPS, Burst: defaultRateLimitBurst}
+
+// Wait blocks until a request to host may be sent, or ctx is done. A Wait
+// cut short by ctx gives its token back.
+func (l *RateLimiter) Wait(ctx context.Context, host string) error {
+	host = strings.ToLower(host)
+	delay := l.reserve(host, time.Now())
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.release(host)
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token for host, letting the bucket go negative, and
+// returns how long the caller must wait for its token to be earned.
+// Concurrent callers are therefore served in arrival order.
+func (l *RateLimiter) reserve(host string, now time.Time) time.Duration {
+	if l.RPS <= 0 {
+		return 0
+	}
+	burst := float64(max(l.Burst, 1))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.buckets == nil {
+		l.buckets = map[string]*tokenBucket{}
+	}
+	if now.Sub(l.swept) >= rateLimitSweepInterval {
+		l.sweep(now, burst)
+	}
+	b, ok := l.buckets[host]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		l.buckets[host] = b
+	}
+	b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*l.RPS)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / l.RPS * float64(time.Second))
+}
+
+// release returns a token reserved for host that will not be used.
+func (l *RateLimiter) release(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[host]; ok {
+		b.tokens = min(float64(max(l.Burst, 1)), b.tokens+1)
+	}
+}
+
+// sweep drops the buckets that are full again at now. A full bucket
+// behaves like the fresh one reserve creates, so nothing is lost.
+func (l *RateLimiter) sweep(now time.Time, burst float64) {
+	for host, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.RPS >= burst {
+			delete(l.buckets, host)
+		}
+	}
+	l.swept = now
+}
+
+func waitForHost(ctx context.Context, l *RateLimiter, loc string) error {
+	if l == nil {
+		l = defaultRateLimiter
+	}
+	u, err := url.Parse(loc)
+	if err != nil {
+		return nil
+	}
+	return l.Wait(ctx, u.Host)
+}
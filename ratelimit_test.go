@@ -0,0 +1,98 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	l := &RateLimiter{RPS: 2, Burst: 3}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	steps := []struct {
+		host  string
+		after time.Duration
+		want  time.Duration
+	}{
+		// The burst is free, then tokens are earned every 500ms and
+		// callers queue for them in order.
+		{"example.com", 0, 0},
+		{"example.com", 0, 0},
+		{"example.com", 0, 0},
+		{"example.com", 0, 500 * time.Millisecond},
+		{"example.com", 0, time.Second},
+		// Other hosts have their own buckets.
+		{"other.example.com", 0, 0},
+		// After the queue has drained the bucket refills up to Burst.
+		{"example.com", 10 * time.Second, 0},
+		{"example.com", 10 * time.Second, 0},
+		{"example.com", 10 * time.Second, 0},
+		{"example.com", 10 * time.Second, 500 * time.Millisecond},
+	}
+	for i, step := range steps {
+		if got := l.reserve(step.host, start.Add(step.after)); got != step.want {
+			t.Errorf("step %d: wait %v, want %v", i, got, step.want)
+		}
+	}
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+	l := &RateLimiter{Burst: 1}
+	for range 100 {
+		if d := l.reserve("example.com", time.Now()); d != 0 {
+			t.Fatalf("zero RPS waited %v", d)
+		}
+	}
+	burstless := &RateLimiter{RPS: 1}
+	now := time.Now()
+	if burstless.reserve("example.com", now) != 0 || burstless.reserve("example.com", now) != time.Second {
+		t.Error("a zero Burst must allow one request at a time")
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	l := &RateLimiter{RPS: 1, Burst: 1}
+	ctx := context.Background()
+	if err := l.Wait(ctx, "Example.com"); err != nil {
+		t.Fatal(err)
+	}
+	// Hosts share a bucket regardless of case, so this waits a second.
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := l.Wait(ctx, "example.com"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Wait ignored the context for %v", elapsed)
+	}
+	// The abandoned Wait gave its token back, so the queue is one long.
+	if d := l.reserve("example.com", time.Now()); d > time.Second {
+		t.Errorf("next reserve waits %v, want at most 1s", d)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := (&RateLimiter{}).Wait(cancelled, "example.com"); !errors.Is(err, context.Canceled) {
+		t.Errorf("unlimited Wait on a cancelled context: %v", err)
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	l := &RateLimiter{RPS: 1, Burst: 2}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.reserve("a.example.com", start)
+	l.reserve("b.example.com", start)
+	for range 200 {
+		l.reserve("c.example.com", start)
+	}
+	// After a sweep interval a and b have refilled, c is still behind.
+	l.reserve("d.example.com", start.Add(rateLimitSweepInterval))
+	if len(l.buckets) != 2 || l.buckets["c.example.com"] == nil || l.buckets["d.example.com"] == nil {
+		t.Errorf("buckets after sweep: %v", l.buckets)
+	}
+	if d := l.reserve("c.example.com", start.Add(rateLimitSweepInterval)); d <= 0 {
+		t.Errorf("swept a bucket still in debt: wait %v", d)
+	}
+}
@@ -0,0 +1,104 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+const maxRedirects = 10
+
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// RedirectPolicy decides what happens to a loc that answers with a
+// redirect. The crawler, the link pruner and ResolveRedirects all apply it
+// the same way.
+type RedirectPolicy int
+
+const (
+	// RedirectReplace lists the redirect's final destination in place of
+	// the loc, provided the destination answers 2xx; otherwise the loc is
+	// only reported. It is the default.
+	RedirectReplace RedirectPolicy = iota
+	// RedirectDrop leaves the loc out.
+	RedirectDrop
+	// RedirectReport keeps the loc as it is and only reports it.
+	RedirectReport
+)
+
+// Redirected reports whether the loc answered with a redirect.
+func (s LinkStatus) Redirected() bool {
+	return isRedirect(s.StatusCode) && s.Location != ""
+}
+
+// apply returns u as policy leaves it given its status: u itself,
+// a copy moved to the destination, or nil when it is dropped.
+func (p RedirectPolicy) apply(u *URL, status LinkStatus) *URL {
+	if !status.Redirected() {
+		return u
+	}
+	switch p {
+	case RedirectDrop:
+		return nil
+	case RedirectReplace:
+		if status.Final == "" || status.FinalStatus < 200 || status.FinalStatus > 299 {
+			return u
+		}
+		moved := u.Clone()
+		moved.Loc = status.Final
+		return moved
+	}
+	return u
+}
+
+// ResolveRedirects checks every loc of set and applies policy to the ones
+// that redirect. A destination already listed is not listed twice. It
+// returns the statuses of the redirecting locs, in set order.
+func (f *Fetcher) ResolveRedirects(ctx context.Context, set *URLSet, policy RedirectPolicy) []LinkStatus {
+	statuses := f.CheckLinks(ctx, set.URLs)
+	listed := make(map[string]bool, len(set.URLs))
+	for _, u := range set.URLs {
+		listed[u.Loc] = true
+	}
+	var redirects []LinkStatus
+	kept := set.URLs[:0]
+	for i, u := range set.URLs {
+		status := statuses[i]
+		if !status.Redirected() {
+			kept = append(kept, u)
+			continue
+		}
+		redirects = append(redirects, status)
+		next := policy.apply(u, status)
+		if next == nil || next != u && listed[next.Loc] {
+			continue
+		}
+		listed[next.Loc] = true
+		kept = append(kept, next)
+	}
+	clear(set.URLs[len(kept):])
+	set.URLs = kept
+	return redirects
+}
+
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+func resolveReference(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(r).String(), nil
+}
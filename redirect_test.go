@@ -0,0 +1,144 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// redirectServer serves /new and /page, redirects /old to /new, /chain to
+// /old, /broken to /missing, and /loop to itself. Its home page links to
+// /old and /page.
+func redirectServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	for from, to := range map[string]string{"/old": "/new", "/chain": "/old", "/broken": "/missing", "/loop": "/loop"} {
+		mux.Handle(from, http.RedirectHandler(to, http.StatusMovedPermanently))
+	}
+	page := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<a href="/old">old</a> <a href="/page">page</a>`))
+	}
+	mux.HandleFunc("/{$}", page)
+	mux.HandleFunc("/new", page)
+	mux.HandleFunc("/page", page)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRedirectPolicyApply(t *testing.T) {
+	u := &URL{Loc: "https://example.com/old", ChangeFreq: ChangeFreqDaily}
+	moved := LinkStatus{Loc: u.Loc, StatusCode: 301, Location: "/new", Final: "https://example.com/new", FinalStatus: 200}
+	broken := LinkStatus{Loc: u.Loc, StatusCode: 302, Location: "/gone", Final: "https://example.com/gone", FinalStatus: 404}
+	notRedirected := LinkStatus{Loc: u.Loc, StatusCode: 200}
+
+	if got := RedirectReplace.apply(u, moved); got == u || got.Loc != moved.Final || got.ChangeFreq != ChangeFreqDaily || u.Loc != "https://example.com/old" {
+		t.Errorf("replace: got %+v, original %s", got, u.Loc)
+	}
+	if got := RedirectReplace.apply(u, broken); got != u {
+		t.Errorf("replace with a failing destination: got %+v", got)
+	}
+	if got := RedirectDrop.apply(u, moved); got != nil {
+		t.Errorf("drop: got %+v", got)
+	}
+	if got := RedirectReport.apply(u, moved); got != u {
+		t.Errorf("report: got %+v", got)
+	}
+	if got := RedirectDrop.apply(u, notRedirected); got != u {
+		t.Errorf("drop without a redirect: got %+v", got)
+	}
+}
+
+func TestResolveRedirects(t *testing.T) {
+	srv := redirectServer(t)
+	f := testFetcher(srv)
+	ctx := context.Background()
+	paths := func(locs []string) []string {
+		for i, loc := range locs {
+			locs[i] = loc[len(srv.URL):]
+		}
+		return locs
+	}
+	tests := []struct {
+		policy RedirectPolicy
+		want   []string
+	}{
+		{RedirectReplace, []string{"/new", "/broken", "/page"}},
+		{RedirectDrop, []string{"/page"}},
+		{RedirectReport, []string{"/old", "/chain", "/broken", "/page"}},
+	}
+	for _, tt := range tests {
+		set := setOf(t, srv.URL+"/old", srv.URL+"/chain", srv.URL+"/broken", srv.URL+"/page")
+		redirects := f.ResolveRedirects(ctx, set, tt.policy)
+		if got := paths(locsOf(set)); !slices.Equal(got, tt.want) {
+			t.Errorf("policy %d: got %q, want %q", tt.policy, got, tt.want)
+		}
+		if len(redirects) != 3 || redirects[1].Final != srv.URL+"/new" || redirects[2].FinalStatus != http.StatusNotFound {
+			t.Errorf("policy %d: redirects = %+v", tt.policy, redirects)
+		}
+	}
+}
+
+func TestCheckLinksRedirectLoop(t *testing.T) {
+	srv := redirectServer(t)
+	status := testFetcher(srv).CheckLinks(context.Background(), setOf(t, srv.URL+"/loop").URLs)[0]
+	if !errors.Is(status.Err, ErrTooManyRedirects) || status.StatusCode != http.StatusMovedPermanently {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestCrawlRedirects(t *testing.T) {
+	srv := redirectServer(t)
+	tests := []struct {
+		policy RedirectPolicy
+		want   []string
+	}{
+		{RedirectReplace, []string{srv.URL + "/", srv.URL + "/new", srv.URL + "/page"}},
+		{RedirectDrop, []string{srv.URL + "/", srv.URL + "/page"}},
+		{RedirectReport, []string{srv.URL + "/", srv.URL + "/old", srv.URL + "/page"}},
+	}
+	for _, tt := range tests {
+		var redirected []LinkStatus
+		c := &Crawler{Redirects: tt.policy, OnRedirect: func(s LinkStatus) { redirected = append(redirected, s) }}
+		got := crawl(t, c, srv.URL+"/")
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("policy %d: got %q, want %q", tt.policy, got, tt.want)
+		}
+		if len(redirected) != 1 || redirected[0].Loc != srv.URL+"/old" || redirected[0].StatusCode != http.StatusMovedPermanently || redirected[0].Final != srv.URL+"/new" {
+			t.Errorf("policy %d: redirects = %+v", tt.policy, redirected)
+		}
+	}
+}
+
+func TestLinkPrunerRedirects(t *testing.T) {
+	srv := redirectServer(t)
+	ctx := context.Background()
+	for _, policy := range []RedirectPolicy{RedirectReplace, RedirectDrop, RedirectReport} {
+		store := &MemoryStore{}
+		lastMod := richSet(1).URLs[0].LastMod
+		store.Upsert(ctx, &URL{Loc: srv.URL + "/old", LastMod: lastMod})
+		p := &LinkPruner{Store: store, Fetcher: testFetcher(srv), Redirects: policy}
+		report, err := p.Audit(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Redirects) != 1 || len(report.Pruned) != 0 {
+			t.Errorf("policy %d: report = %+v", policy, report)
+		}
+		set, err := SnapshotURLSet(ctx, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[RedirectPolicy][]string{RedirectReplace: {srv.URL + "/new"}, RedirectDrop: nil, RedirectReport: {srv.URL + "/old"}}[policy]
+		if got := locsOf(&set); !slices.Equal(got, want) {
+			t.Errorf("policy %d: store = %q, want %q", policy, got, want)
+		}
+		if policy == RedirectReplace && !set.URLs[0].LastMod.Equal(*lastMod) {
+			t.Error("moved URL lost its fields")
+		}
+	}
+}
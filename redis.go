@@ -0,0 +1,240 @@
+package sitemap_go
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRedisKey      = "sitemap:urls"
+	redisScanCount       = "1000"
+	defaultRedisDialWait = 5 * time.Second
+)
+
+// RedisClient sends a single command and returns its reply: a string for
+// simple and bulk strings, nil for null replies, an int64 for integers, a
+// []any for arrays and a RedisError for error replies. RedisConn implements
+// it; clients from other Redis libraries are easily adapted.
+type RedisClient interface {
+	Do(ctx context.Context, args ...string) (any, error)
+}
+
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// RedisStore is a URLStore kept in a Redis hash mapping each loc to its
+// JSON-encoded entry, so any number of processes can upsert URLs while one
+// worker snapshots and publishes the sitemap.
+type RedisStore struct {
+	Client RedisClient
+	// Key is the hash holding the entries. It defaults to "sitemap:urls".
+	Key string
+}
+
+func (s *RedisStore) key() string {
+	if s.Key == "" {
+		return defaultRedisKey
+	}
+	return s.Key
+}
+
+func (s *RedisStore) Upsert(ctx context.Context, u *URL) error {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	_, err = s.Client.Do(ctx, "HSET", s.key(), u.Loc, string(data))
+	return err
+}
+
+func (s *RedisStore) Delete(ctx context.Context, loc string) error {
+	_, err := s.Client.Do(ctx, "HDEL", s.key(), loc)
+	return err
+}
+
+// Snapshot reads the hash with HSCAN so large sitemaps do not block the
+// server. Entries changed during the scan may or may not be included.
+func (s *RedisStore) Snapshot(ctx context.Context) ([]*URL, error) {
+	seen := map[string]bool{}
+	var out []*URL
+	cursor := "0"
+	for {
+		reply, err := s.Client.Do(ctx, "HSCAN", s.key(), cursor, "COUNT", redisScanCount)
+		if err != nil {
+			return nil, err
+		}
+		page, ok := reply.([]any)
+		if !ok || len(page) != 2 {
+			return nil, fmt.Errorf("redis: unexpected HSCAN reply %T", reply)
+		}
+		cursor, _ = page[0].(string)
+		fields, _ := page[1].([]any)
+		for i := 0; i+1 < len(fields); i += 2 {
+			loc, _ := fields[i].(string)
+			value, _ := fields[i+1].(string)
+			if seen[loc] {
+				continue
+			}
+			seen[loc] = true
+			u := &URL{}
+			if err := json.Unmarshal([]byte(value), u); err != nil {
+				return nil, fmt.Errorf("redis: entry %s: %w", loc, err)
+			}
+			out = append(out, u)
+		}
+		if cursor == "0" || cursor == "" {
+			break
+		}
+	}
+	sortByLoc(out)
+	return out, nil
+}
+
+// RedisConn is a minimal RESP2 client over a single connection. Commands
+// are serialized; a broken connection is redialed on the next command.
+type RedisConn struct {
+	Addr     string
+	Username string
+	Password string
+	DB       int
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func DialRedis(addr string) *RedisConn {
+	return &RedisConn{Addr: addr}
+}
+
+func (c *RedisConn) Do(ctx context.Context, args ...string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		if err := c.dial(ctx); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := c.roundTrip(ctx, args)
+	var redisErr RedisError
+	if err != nil && !errors.As(err, &redisErr) {
+		c.closeLocked()
+	}
+	return reply, err
+}
+
+func (c *RedisConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeLocked()
+}
+
+func (c *RedisConn) closeLocked() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn, c.r = nil, nil
+	return err
+}
+
+func (c *RedisConn) dial(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: defaultRedisDialWait}
+	conn, err := dialer.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return err
+	}
+	c.conn, c.r = conn, bufio.NewReader(conn)
+	if c.Password != "" {
+		args := []string{"AUTH", c.Password}
+		if c.Username != "" {
+			args = []string{"AUTH", c.Username, c.Password}
+		}
+		if _, err := c.roundTrip(ctx, args); err != nil {
+			c.closeLocked()
+			return err
+		}
+	}
+	if c.DB != 0 {
+		if _, err := c.roundTrip(ctx, []string{"SELECT", strconv.Itoa(c.DB)}); err != nil {
+			c.closeLocked()
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *RedisConn) roundTrip(ctx context.Context, args []string) (any, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	} else {
+		c.conn.SetDeadline(time.Time{})
+	}
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readRESP(c.r)
+}
+
+func readRESP(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, RedisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		out := make([]any, n)
+		for i := range out {
+			if out[i], err = readRESP(r); err != nil {
+				var redisErr RedisError
+				if !errors.As(err, &redisErr) {
+					return nil, err
+				}
+				out[i] = redisErr
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
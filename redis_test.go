@@ -0,0 +1,211 @@
+package sitemap_go
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestReadRESP(t *testing.T) {
+	tests := []struct {
+		in   string
+		want any
+		err  error
+	}{
+		{"+OK\r\n", "OK", nil},
+		{":42\r\n", int64(42), nil},
+		{"$5\r\nhe\r\no\r\n", "he\r\no", nil},
+		{"$0\r\n\r\n", "", nil},
+		{"$-1\r\n", nil, nil},
+		{"*-1\r\n", nil, nil},
+		{"*3\r\n+a\r\n$1\r\nb\r\n*1\r\n:1\r\n", []any{"a", "b", []any{int64(1)}}, nil},
+		{"*2\r\n-ERR x\r\n+b\r\n", []any{RedisError("ERR x"), "b"}, nil},
+		{"-WRONGTYPE bad\r\n", nil, RedisError("WRONGTYPE bad")},
+		{"$5\r\nhel", nil, io.ErrUnexpectedEOF},
+		{"+OK", nil, io.EOF},
+	}
+	for _, tt := range tests {
+		got, err := readRESP(bufio.NewReader(strings.NewReader(tt.in)))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%q: err = %v, want %v", tt.in, err, tt.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"+OK\n", "?x\r\n", ":x\r\n"} {
+		if _, err := readRESP(bufio.NewReader(strings.NewReader(in))); err == nil {
+			t.Errorf("%q: no error", in)
+		}
+	}
+}
+
+// fakeRedis is a RedisClient serving HSET, HDEL and HSCAN from a map,
+// returning pages of two fields so snapshots take several scans.
+type fakeRedis struct {
+	mu     sync.Mutex
+	hashes map[string]map[string]string
+}
+
+func (f *fakeRedis) Do(ctx context.Context, args ...string) (any, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.hashes == nil {
+		f.hashes = map[string]map[string]string{}
+	}
+	hash := f.hashes[args[1]]
+	switch args[0] {
+	case "HSET":
+		if hash == nil {
+			hash = map[string]string{}
+			f.hashes[args[1]] = hash
+		}
+		hash[args[2]] = args[3]
+		return int64(1), nil
+	case "HDEL":
+		delete(hash, args[2])
+		return int64(1), nil
+	case "HSCAN":
+		fields := slices.Sorted(maps.Keys(hash))
+		start, _ := strconv.Atoi(args[2])
+		end := min(start+2, len(fields))
+		page := []any{}
+		for _, k := range fields[start:end] {
+			page = append(page, k, hash[k])
+		}
+		next := strconv.Itoa(end)
+		if end == len(fields) {
+			next = "0"
+		}
+		return []any{next, page}, nil
+	}
+	return nil, RedisError("ERR unknown command " + args[0])
+}
+
+func TestRedisStore(t *testing.T) {
+	client := &fakeRedis{}
+	testURLStore(t, &RedisStore{Client: client})
+	if _, ok := client.hashes[defaultRedisKey]; !ok {
+		t.Errorf("entries not stored under %s", defaultRedisKey)
+	}
+}
+
+func TestRedisStoreSnapshotErrors(t *testing.T) {
+	tests := map[string]RedisClient{
+		"reply": redisClientFunc(func(args ...string) (any, error) { return "OK", nil }),
+		"entry": redisClientFunc(func(args ...string) (any, error) {
+			return []any{"0", []any{"https://example.com/", "{"}}, nil
+		}),
+	}
+	for name, client := range tests {
+		if _, err := (&RedisStore{Client: client}).Snapshot(context.Background()); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+type redisClientFunc func(args ...string) (any, error)
+
+func (f redisClientFunc) Do(ctx context.Context, args ...string) (any, error) {
+	return f(args...)
+}
+
+// redisServer accepts RESP connections and answers each command with
+// reply, recording the commands it received.
+func redisServer(t *testing.T, reply func(args []string) string) (string, func() [][]string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	var mu sync.Mutex
+	var commands [][]string
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					req, err := readRESP(r)
+					if err != nil {
+						return
+					}
+					var args []string
+					for _, arg := range req.([]any) {
+						args = append(args, arg.(string))
+					}
+					mu.Lock()
+					commands = append(commands, args)
+					mu.Unlock()
+					if _, err := io.WriteString(conn, reply(args)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String(), func() [][]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(commands)
+	}
+}
+
+func TestRedisConn(t *testing.T) {
+	addr, commands := redisServer(t, func(args []string) string {
+		switch args[0] {
+		case "GET":
+			return fmt.Sprintf("$%d\r\n%s\r\n", len(args[1]), args[1])
+		case "FAIL":
+			return "-ERR failed\r\n"
+		}
+		return "+OK\r\n"
+	})
+	conn := DialRedis(addr)
+	conn.Username, conn.Password, conn.DB = "user", "secret", 2
+	defer conn.Close()
+	ctx := context.Background()
+
+	if reply, err := conn.Do(ctx, "GET", "a b\r\nc"); err != nil || reply != "a b\r\nc" {
+		t.Fatalf("GET = %#v, %v", reply, err)
+	}
+	var redisErr RedisError
+	if _, err := conn.Do(ctx, "FAIL"); !errors.As(err, &redisErr) || redisErr != "ERR failed" {
+		t.Fatalf("err = %v, want a RedisError", err)
+	}
+	if _, err := conn.Do(ctx, "PING"); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"AUTH", "user", "secret"}, {"SELECT", "2"}, {"GET", "a b\r\nc"}, {"FAIL"}, {"PING"}}
+	if got := commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("commands = %q, want %q; error replies must not redial", got, want)
+	}
+}
+
+func TestRedisConnAuthError(t *testing.T) {
+	addr, _ := redisServer(t, func(args []string) string { return "-WRONGPASS invalid\r\n" })
+	conn := &RedisConn{Addr: addr, Password: "wrong"}
+	var redisErr RedisError
+	if _, err := conn.Do(context.Background(), "PING"); !errors.As(err, &redisErr) {
+		t.Fatalf("err = %v, want a RedisError", err)
+	}
+	if conn.conn != nil {
+		t.Error("connection kept after a failed AUTH")
+	}
+}
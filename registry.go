@@ -0,0 +1,205 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// servedDocument is an encoded sitemap ready to be served. It is never
+// modified after it has been published to a registry slot.
+type servedDocument struct {
+	set     *URLSet
+	index   *SitemapIndex
+	body    []byte
+	meta    ObjectMeta
+	etag    string
+	modTime time.Time
+
+	// variants caches the other renderings the handler has negotiated,
+	// keyed by variantKey.
+	variantsMu sync.Mutex
+	variants   map[string][]byte
+}
+
+func newServedDocument(body []byte) *servedDocument {
+	sum := sha256.Sum256(body)
+	return &servedDocument{
+		body:    body,
+		etag:    `"` + hex.EncodeToString(sum[:16]) + `"`,
+		modTime: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// Registry holds the current version of named sitemaps. Names are the
+// paths they are served under, optionally prefixed with a host, such as
+// "sitemap.xml" or "example.com/sitemap.xml". Every sitemap is encoded when
+// it is swapped in, so readers see either the old or the new document and
+// never a partially updated one.
+type Registry struct {
+	Encode EncodeOptions
+
+	mu    sync.RWMutex
+	slots map[string]*atomic.Pointer[servedDocument]
+	lazy  map[string]*LazySitemap
+}
+
+func MakeRegistry() *Registry {
+	return &Registry{slots: map[string]*atomic.Pointer[servedDocument]{}}
+}
+
+// Swap encodes set and atomically replaces the sitemap served under name,
+// returning the previous set, if any. set must not be modified afterwards.
+func (r *Registry) Swap(name string, set *URLSet) (*URLSet, error) {
+	var buf bytes.Buffer
+	if err := set.Encode(&buf, r.Encode); err != nil {
+		return nil, err
+	}
+	doc := newServedDocument(buf.Bytes())
+	doc.set = set
+	if old := r.swap(name, doc); old != nil {
+		return old.set, nil
+	}
+	return nil, nil
+}
+
+// SwapIndex is Swap for sitemap indexes.
+func (r *Registry) SwapIndex(name string, index *SitemapIndex) (*SitemapIndex, error) {
+	var buf bytes.Buffer
+	if err := index.Encode(&buf, r.Encode); err != nil {
+		return nil, err
+	}
+	doc := newServedDocument(buf.Bytes())
+	doc.index = index
+	if old := r.swap(name, doc); old != nil {
+		return old.index, nil
+	}
+	return nil, nil
+}
+
+// Put implements Storage, so a Publisher can publish straight into the
+// registry. The data is served as is, with meta's content headers.
+func (r *Registry) Put(ctx context.Context, name string, data []byte, meta ObjectMeta) error {
+	doc := newServedDocument(bytes.Clone(data))
+	doc.meta = meta
+	r.swap(name, doc)
+	return nil
+}
+
+func (r *Registry) swap(name string, doc *servedDocument) *servedDocument {
+	name = strings.TrimPrefix(name, "/")
+	r.mu.RLock()
+	slot, ok := r.slots[name]
+	r.mu.RUnlock()
+	if !ok {
+		r.mu.Lock()
+		if r.slots == nil {
+			r.slots = map[string]*atomic.Pointer[servedDocument]{}
+		}
+		if slot, ok = r.slots[name]; !ok {
+			slot = &atomic.Pointer[servedDocument]{}
+			r.slots[name] = slot
+		}
+		r.mu.Unlock()
+	}
+	return slot.Swap(doc)
+}
+
+// Get returns the set currently served under name. It reports false for
+// unknown names and for names holding a sitemap index.
+func (r *Registry) Get(name string) (*URLSet, bool) {
+	doc := r.load(name)
+	if doc == nil || doc.set == nil {
+		return nil, false
+	}
+	return doc.set, true
+}
+
+func (r *Registry) GetIndex(name string) (*SitemapIndex, bool) {
+	doc := r.load(name)
+	if doc == nil || doc.index == nil {
+		return nil, false
+	}
+	return doc.index, true
+}
+
+func (r *Registry) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.slots, strings.TrimPrefix(name, "/"))
+}
+
+// Delete implements DeleteStorage, removing the document like Remove.
+func (r *Registry) Delete(ctx context.Context, name string) error {
+	r.Remove(name)
+	return nil
+}
+
+// List implements ListStorage over the registered names.
+func (r *Registry) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	for _, name := range r.Names() {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Names returns the registered names in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.slots))
+	for name := range r.slots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Registry) load(name string) *servedDocument {
+	r.mu.RLock()
+	slot, ok := r.slots[strings.TrimPrefix(name, "/")]
+	r.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return slot.Load()
+}
+
+// servedHost returns the lowercased host name of hostport, without any port
+// or IPv6 brackets. Registry names are qualified with it.
+func servedHost(hostport string) string {
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		hostport = h
+	}
+	hostport = strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]")
+	return strings.ToLower(hostport)
+}
+
+// lookup finds the document served under path for a request, preferring
+// a name qualified with the request's host over the bare path, and stored
+// documents over lazily rendered ones.
+func (r *Registry) lookup(req *http.Request, path string) (*servedDocument, error) {
+	path = strings.TrimPrefix(path, "/")
+	host := servedHost(req.Host)
+	if doc := r.load(host + "/" + path); doc != nil {
+		return doc, nil
+	}
+	if doc := r.load(path); doc != nil {
+		return doc, nil
+	}
+	if l := r.lazyFor(host); l != nil {
+		return l.render(req.Context(), path)
+	}
+	return nil, nil
+}
@@ -0,0 +1,113 @@
+package sitemap_go
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRegistrySwap(t *testing.T) {
+	r := MakeRegistry()
+	first := setOf(t, "https://example.com/a")
+	if old, err := r.Swap("/sitemap.xml", first); err != nil || old != nil {
+		t.Fatalf("first swap = %v, %v", old, err)
+	}
+	second := setOf(t, "https://example.com/b")
+	if old, err := r.Swap("sitemap.xml", second); err != nil || old != first {
+		t.Fatalf("second swap = %v, %v; want the first set", old, err)
+	}
+	if got, ok := r.Get("sitemap.xml"); !ok || got != second {
+		t.Errorf("Get = %v, %v", got, ok)
+	}
+	if _, ok := r.GetIndex("sitemap.xml"); ok {
+		t.Error("GetIndex found a urlset")
+	}
+
+	index := &SitemapIndex{Sitemaps: []SitemapEntry{{Loc: "https://example.com/sitemap.xml"}}}
+	if _, err := r.SwapIndex("index.xml", index); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := r.GetIndex("/index.xml"); !ok || got != index {
+		t.Errorf("GetIndex = %v, %v", got, ok)
+	}
+	if got, want := r.Names(), []string{"index.xml", "sitemap.xml"}; !slices.Equal(got, want) {
+		t.Errorf("names = %q, want %q", got, want)
+	}
+	r.Remove("/index.xml")
+	if _, ok := r.GetIndex("index.xml"); ok {
+		t.Error("index still registered after Remove")
+	}
+}
+
+func TestRegistryStorage(t *testing.T) {
+	r := &Registry{}
+	ctx := context.Background()
+	for _, name := range []string{"a/sitemap.xml", "b/sitemap.xml", "a/sitemap-1.xml"} {
+		if err := r.Put(ctx, name, []byte("<urlset/>"), ObjectMeta{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err := r.List(ctx, "a/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a/sitemap-1.xml", "a/sitemap.xml"}; !slices.Equal(names, want) {
+		t.Errorf("List = %q, want %q", names, want)
+	}
+	if err := r.Delete(ctx, "a/sitemap.xml"); err != nil {
+		t.Fatal(err)
+	}
+	if r.load("a/sitemap.xml") != nil {
+		t.Error("document still served after Delete")
+	}
+}
+
+func TestRegistrySwapConcurrent(t *testing.T) {
+	r := MakeRegistry()
+	sets := []*URLSet{numberedSet(t, 50, "?v=1"), numberedSet(t, 50, "?v=2")}
+	if _, err := r.Swap("sitemap.xml", sets[0]); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				if _, err := r.Swap("sitemap.xml", sets[i%2]); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				doc := r.load("sitemap.xml")
+				body := string(doc.body)
+				if strings.Contains(body, "?v=1") == strings.Contains(body, "?v=2") {
+					t.Error("served a mix of two versions")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestServedHost(t *testing.T) {
+	tests := map[string]string{
+		"example.com":      "example.com",
+		"Example.COM:8080": "example.com",
+		"[::1]:8080":       "::1",
+		"[::1]":            "::1",
+		"":                 "",
+	}
+	for in, want := range tests {
+		if got := servedHost(in); got != want {
+			t.Errorf("servedHost(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package sitemap_go
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRobotsUnavailable  = errors.New("robots.txt could not be fetched")
+	ErrDisallowedByRobots = errors.New("loc is disallowed by robots.txt")
+)
+
+// Robots holds the rules of a robots.txt file as interpreted by RFC 9309.
+type Robots struct {
+	Sitemaps []string
+	groups   []robotsGroup
+}
+
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+func ParseRobots(r io.Reader) (*Robots, error) {
+	out := &Robots{}
+	var current *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				out.groups = append(out.groups, robotsGroup{})
+				current = &out.groups[len(out.groups)-1]
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil || (key == "disallow" && value == "") {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if secs, err := strconv.ParseFloat(value, 64); err == nil && secs >= 0 {
+				current.crawlDelay = time.Duration(secs * float64(time.Second))
+			}
+		case "sitemap":
+			out.Sitemaps = append(out.Sitemaps, value)
+		}
+	}
+	return out, scanner.Err()
+}
+
+// group picks the group naming userAgent's product token, falling back to
+// the "*" group.
+func (r *Robots) group(userAgent string) *robotsGroup {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+	var fallback *robotsGroup
+	for i := range r.groups {
+		g := &r.groups[i]
+		for _, agent := range g.agents {
+			if agent == "*" {
+				if fallback == nil {
+					fallback = g
+				}
+			} else if token != "" && agent == token {
+				return g
+			}
+		}
+	}
+	return fallback
+}
+
+// Allowed reports whether userAgent may fetch loc, which may be an absolute
+// URL or a path. The longest matching rule wins and Allow wins ties.
+func (r *Robots) Allowed(userAgent, loc string) bool {
+	g := r.group(userAgent)
+	if g == nil {
+		return true
+	}
+	path := robotsPath(loc)
+	if path == "/robots.txt" {
+		return true
+	}
+	best, allowed := -1, true
+	for _, rule := range g.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		n := len(rule.pattern)
+		if n > best || (n == best && rule.allow) {
+			best, allowed = n, rule.allow
+		}
+	}
+	return allowed
+}
+
+// CrawlDelay returns the Crawl-delay declared for userAgent, if any.
+func (r *Robots) CrawlDelay(userAgent string) time.Duration {
+	if g := r.group(userAgent); g != nil {
+		return g.crawlDelay
+	}
+	return 0
+}
+
+func robotsPath(loc string) string {
+	parsed, err := url.Parse(loc)
+	if err != nil {
+		return loc
+	}
+	path := parsed.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if parsed.RawQuery != "" {
+		path += "?" + parsed.RawQuery
+	}
+	return path
+}
+
+// robotsMatch matches path against a pattern where * matches any run of
+// characters and a trailing $ anchors the end.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for _, part := range parts[1:] {
+		i := strings.Index(path[pos:], part)
+		if i < 0 {
+			return false
+		}
+		pos += i + len(part)
+	}
+	if !anchored {
+		return true
+	}
+	if len(parts) == 1 {
+		return pos == len(path)
+	}
+	return strings.HasSuffix(path, parts[len(parts)-1])
+}
+
+// fetchRobotsTxt loads robots.txt for site's origin. A missing file (any
+// 4xx) allows everything; other failures are errors, since RFC 9309 asks
+// crawlers to assume complete disallow when robots.txt is unreachable.
+func fetchRobotsTxt(ctx context.Context, client *http.Client, userAgent string, site *url.URL) (*Robots, error) {
+	loc := site.Scheme + "://" + site.Host + "/robots.txt"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return nil, err
+	}
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	resp, err := clientOrBulk(client).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRobotsUnavailable, err)
+	}
+	defer resp.Body.Close()
+	defer io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return ParseRobots(io.LimitReader(resp.Body, 500<<10))
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return &Robots{}, nil
+	default:
+		return nil, fmt.Errorf("%w: status %d", ErrRobotsUnavailable, resp.StatusCode)
+	}
+}
+
+const defaultRobotsUserAgent = "Googlebot"
+
+// WithRobots makes Validate report locs that robots disallows for
+// userAgent, which defaults to Googlebot.
+func WithRobots(robots *Robots, userAgent string) ValidateOption {
+	if userAgent == "" {
+		userAgent = defaultRobotsUserAgent
+	}
+	return func(c *validateConfig) {
+		c.robots = func(string) *Robots { return robots }
+		c.robotsAgent = userAgent
+	}
+}
+
+// CheckRobots fetches robots.txt for every host in the set, through the
+// Fetcher's RateLimiter, and reports the locs disallowed for userAgent,
+// which defaults to Googlebot.
+func (f *Fetcher) CheckRobots(ctx context.Context, set *URLSet, userAgent string) ([]ValidationIssue, error) {
+	byOrigin := map[string]*Robots{}
+	for _, entry := range set.URLs {
+		loc, err := url.Parse(entry.Loc)
+		if err != nil || !loc.IsAbs() {
+			continue
+		}
+		origin := strings.ToLower(loc.Scheme + "://" + loc.Host)
+		if _, ok := byOrigin[origin]; ok {
+			continue
+		}
+		if err := waitForHost(ctx, f.RateLimiter, entry.Loc); err != nil {
+			return nil, err
+		}
+		robots, err := fetchRobotsTxt(ctx, f.HTTPClient, f.UserAgent, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", origin, err)
+		}
+		byOrigin[origin] = robots
+	}
+	if userAgent == "" {
+		userAgent = defaultRobotsUserAgent
+	}
+	issues := set.Validate(func(c *validateConfig) {
+		c.robots = func(loc string) *Robots {
+			parsed, err := url.Parse(loc)
+			if err != nil {
+				return nil
+			}
+			return byOrigin[strings.ToLower(parsed.Scheme+"://"+parsed.Host)]
+		}
+		c.robotsAgent = userAgent
+	})
+	return slices.DeleteFunc(issues, func(i ValidationIssue) bool { return i.Rule != RuleRobots }), nil
+}
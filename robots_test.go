@@ -0,0 +1,121 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRobots(t *testing.T) {
+	robots, err := ParseRobots(strings.NewReader(`
+User-agent: *
+Disallow: /private
+Allow: /private/open
+Disallow: /*.pdf$
+Crawl-delay: 2
+
+User-agent: testbot
+User-agent: otherbot
+Disallow: /
+
+Sitemap: https://example.com/sitemap.xml
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		agent, loc string
+		want       bool
+	}{
+		{"Googlebot", "https://example.com/", true},
+		{"Googlebot", "https://example.com/private/x", false},
+		{"Googlebot", "https://example.com/private/open/x", true},
+		{"Googlebot", "/docs/a.pdf", false},
+		{"Googlebot", "/docs/a.pdf?x=1", true},
+		{"TestBot/2.1", "https://example.com/", false},
+		{"otherbot", "/robots.txt", true},
+	}
+	for _, tt := range tests {
+		if got := robots.Allowed(tt.agent, tt.loc); got != tt.want {
+			t.Errorf("Allowed(%q, %q) = %v, want %v", tt.agent, tt.loc, got, tt.want)
+		}
+	}
+	if d := robots.CrawlDelay("Googlebot"); d.Seconds() != 2 {
+		t.Errorf("CrawlDelay = %v", d)
+	}
+	if !slices.Equal(robots.Sitemaps, []string{"https://example.com/sitemap.xml"}) {
+		t.Errorf("Sitemaps = %q", robots.Sitemaps)
+	}
+}
+
+func TestValidateWithRobots(t *testing.T) {
+	robots, _ := ParseRobots(strings.NewReader("User-agent: *\nDisallow: /private\n\nUser-agent: bingbot\nDisallow: /\n"))
+	set := setOf(t, "https://example.com/a", "https://example.com/private/b")
+	issues := set.Validate(WithRobots(robots, ""))
+	if len(issues) != 1 || issues[0].Index != 1 || !errors.Is(issues[0], ErrDisallowedByRobots) {
+		t.Errorf("Googlebot: issues = %v", issues)
+	}
+	if issues := set.Validate(WithRobots(robots, "bingbot")); len(issues) != 2 {
+		t.Errorf("bingbot: issues = %v", issues)
+	}
+}
+
+func TestCheckRobots(t *testing.T) {
+	var fetched []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched = append(fetched, r.URL.Path)
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /no\n"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	f := testFetcher(srv)
+	// The last loc breaks another rule, which CheckRobots leaves to Validate.
+	set := setOf(t, srv.URL+"/yes", srv.URL+"/no", srv.URL+"/no/more", srv.URL+"/a b")
+	issues, err := f.CheckRobots(context.Background(), set, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 || issues[0].Index != 1 || issues[1].Index != 2 || issues[0].Rule != RuleRobots {
+		t.Errorf("issues = %v", issues)
+	}
+	if !slices.Equal(fetched, []string{"/robots.txt"}) {
+		t.Errorf("fetched %q, want robots.txt once", fetched)
+	}
+
+	// robots.txt is fetched through the Fetcher's RateLimiter.
+	fetched = nil
+	f.RateLimiter = &RateLimiter{RPS: 1, Burst: 1}
+	if err := f.RateLimiter.Wait(context.Background(), strings.TrimPrefix(srv.URL, "http://")); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.CheckRobots(ctx, set, ""); !errors.Is(err, context.DeadlineExceeded) || len(fetched) != 0 {
+		t.Errorf("err = %v, fetched %q", err, fetched)
+	}
+}
+
+func TestRobotsUnavailable(t *testing.T) {
+	status := http.StatusNotFound
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	f := testFetcher(srv)
+	set := setOf(t, srv.URL+"/a")
+	if issues, err := f.CheckRobots(context.Background(), set, ""); err != nil || len(issues) != 0 {
+		t.Errorf("missing robots.txt: %v, %v", issues, err)
+	}
+	status = http.StatusServiceUnavailable
+	if _, err := f.CheckRobots(context.Background(), set, ""); !errors.Is(err, ErrRobotsUnavailable) {
+		t.Errorf("err = %v, want ErrRobotsUnavailable", err)
+	}
+}
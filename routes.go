@@ -0,0 +1,165 @@
+package sitemap_go
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Route is an application route, as registered with a router or declared
+// in an OpenAPI spec.
+type Route struct {
+	Method string
+	Path   string
+	// Secured is set for routes that require authentication.
+	Secured bool
+}
+
+// DefaultRouteExcludes are the path globs RouteURLs leaves out unless
+// RouteOptions.Exclude is set.
+var DefaultRouteExcludes = []string{"/admin/**", "/api/**", "/internal/**", "/debug/**"}
+
+type RouteOptions struct {
+	// BaseURL is joined with each route path, such as
+	// "https://example.com".
+	BaseURL string
+	// Exclude lists path globs, as in Filter, of routes to leave out. It
+	// defaults to DefaultRouteExcludes.
+	Exclude []string
+	// Options are applied to every URL.
+	Options []UrlOption
+}
+
+// ParseRoutes reads a route dump with one route per line, such as the
+// output of a chi.Walk printing "GET /about" or gin's debug log lines
+// "[GIN-debug] GET /about --> main.about (3 handlers)". On each line the
+// first HTTP method is taken with the path that follows it; other lines
+// are skipped.
+func ParseRoutes(r io.Reader) ([]Route, error) {
+	var routes []Route
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		for i, f := range fields {
+			if !isHTTPMethod(f) {
+				continue
+			}
+			if i+1 < len(fields) && strings.HasPrefix(fields[i+1], "/") {
+				routes = append(routes, Route{Method: f, Path: fields[i+1]})
+			}
+			break
+		}
+	}
+	return routes, sc.Err()
+}
+
+// ParseOpenAPIRoutes reads the operations of an OpenAPI 3 or Swagger 2
+// spec in JSON. An operation is Secured when it has security requirements
+// of its own, or inherits the spec's and does not clear them.
+func ParseOpenAPIRoutes(r io.Reader) ([]Route, error) {
+	var spec struct {
+		Security []map[string]any                      `json:"security"`
+		Paths    map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.NewDecoder(r).Decode(&spec); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	var routes []Route
+	for path, item := range spec.Paths {
+		for method, raw := range item {
+			method = strings.ToUpper(method)
+			if !isHTTPMethod(method) {
+				continue
+			}
+			var op struct {
+				Security *[]map[string]any `json:"security"`
+			}
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("openapi: %s %s: %w", method, path, err)
+			}
+			secured := len(spec.Security) > 0
+			if op.Security != nil {
+				secured = len(*op.Security) > 0
+			}
+			routes = append(routes, Route{Method: method, Path: path, Secured: secured})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes, nil
+}
+
+// RouteURLs returns the URLs of the routes that are static, public GET
+// pages, in route order. Routes with parameters or wildcards, such as
+// /users/{id}, /users/:id or /static/*, are left out along with secured
+// routes and those matching opts.Exclude.
+func RouteURLs(routes []Route, opts RouteOptions) ([]*URL, error) {
+	base, err := url.Parse(opts.BaseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("%w: route base URL %q", ErrInvalidLoc, opts.BaseURL)
+	}
+	exclude := opts.Exclude
+	if exclude == nil {
+		exclude = DefaultRouteExcludes
+	}
+	filter := &Filter{Exclude: exclude}
+	seen := map[string]bool{}
+	var out []*URL
+	for _, route := range routes {
+		if !strings.EqualFold(route.Method, http.MethodGet) || route.Secured || isParameterizedRoute(route.Path) {
+			continue
+		}
+		loc := strings.TrimSuffix(base.String(), "/") + route.Path
+		if seen[loc] || filter.Excluded(loc) {
+			continue
+		}
+		seen[loc] = true
+		u, err := NewURL(loc, opts.Options...)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, nil
+}
+
+// RouteSource is a MultiSource input seeding URLs from the routes load
+// returns.
+func RouteSource(name string, load func(ctx context.Context) ([]Route, error), opts RouteOptions) Source {
+	return Source{Name: name, Load: func(ctx context.Context) ([]*URL, error) {
+		routes, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return RouteURLs(routes, opts)
+	}}
+}
+
+func isParameterizedRoute(path string) bool {
+	if strings.ContainsAny(path, "{}*") {
+		return true
+	}
+	return slices.ContainsFunc(strings.Split(path, "/"), func(seg string) bool {
+		return strings.HasPrefix(seg, ":")
+	})
+}
+
+func isHTTPMethod(s string) bool {
+	switch s {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseRoutes(t *testing.T) {
+	const dump = `GET /about
+[GIN-debug] GET    /contact                  --> main.contact (3 handlers)
+[GIN-debug] POST   /contact                  --> main.submit (3 handlers)
+[GIN-debug] Listening and serving HTTP on :8080
+PUT users
+HEAD /health extra`
+	routes, err := ParseRoutes(strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Route{{Method: "GET", Path: "/about"}, {Method: "GET", Path: "/contact"}, {Method: "POST", Path: "/contact"}, {Method: "HEAD", Path: "/health"}}
+	if !slices.Equal(routes, want) {
+		t.Errorf("got %+v, want %+v", routes, want)
+	}
+}
+
+func TestParseOpenAPIRoutes(t *testing.T) {
+	const spec = `{
+  "security": [{"bearer": []}],
+  "paths": {
+    "/pricing": {"get": {"security": []}, "parameters": []},
+    "/account": {"get": {}, "delete": {"security": [{"bearer": []}]}},
+    "/docs":    {"get": {"security": []}, "post": {}}
+  }
+}`
+	routes, err := ParseOpenAPIRoutes(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Route{
+		{Method: "DELETE", Path: "/account", Secured: true},
+		{Method: "GET", Path: "/account", Secured: true},
+		{Method: "GET", Path: "/docs"},
+		{Method: "POST", Path: "/docs", Secured: true},
+		{Method: "GET", Path: "/pricing"},
+	}
+	if !slices.Equal(routes, want) {
+		t.Errorf("got %+v\nwant %+v", routes, want)
+	}
+	if _, err := ParseOpenAPIRoutes(strings.NewReader(`{"paths": []}`)); err == nil || !strings.HasPrefix(err.Error(), "openapi: ") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestRouteURLs(t *testing.T) {
+	routes := []Route{
+		{Method: "GET", Path: "/"},
+		{Method: "get", Path: "/about"},
+		{Method: "POST", Path: "/contact"},
+		{Method: "GET", Path: "/account", Secured: true},
+		{Method: "GET", Path: "/users/{id}"},
+		{Method: "GET", Path: "/users/:id/posts"},
+		{Method: "GET", Path: "/static/*"},
+		{Method: "GET", Path: "/admin/users"},
+		{Method: "GET", Path: "/api/v1/items"},
+		{Method: "HEAD", Path: "/about"},
+		{Method: "GET", Path: "/about"},
+	}
+	urls, err := RouteURLs(routes, RouteOptions{BaseURL: "https://example.com/", Options: []UrlOption{WithChangeFreq(ChangeFreqWeekly)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := locsOf(&URLSet{URLs: urls}), []string{"https://example.com/", "https://example.com/about"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if urls[1].ChangeFreq != ChangeFreqWeekly {
+		t.Errorf("options not applied: %+v", urls[1])
+	}
+
+	urls, err = RouteURLs(routes, RouteOptions{BaseURL: "https://example.com", Exclude: []string{}})
+	if err != nil || len(urls) != 4 {
+		t.Errorf("without excludes: %d URLs, %v", len(urls), err)
+	}
+	if _, err := RouteURLs(routes, RouteOptions{BaseURL: "/relative"}); !errors.Is(err, ErrInvalidLoc) {
+		t.Errorf("err = %v, want %v", err, ErrInvalidLoc)
+	}
+}
+
+func TestRouteSource(t *testing.T) {
+	src := RouteSource("routes", func(ctx context.Context) ([]Route, error) {
+		return []Route{{Method: "GET", Path: "/a"}}, nil
+	}, RouteOptions{BaseURL: "https://example.com"})
+	m := &MultiSource{Sources: []Source{src}}
+	set, _, err := m.Load(context.Background())
+	if err != nil || !slices.Equal(locsOf(set), []string{"https://example.com/a"}) {
+		t.Errorf("got %v, %v", set, err)
+	}
+}
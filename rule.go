@@ -0,0 +1,58 @@
+package sitemap_go
+
+// Rule is a custom lint check run by Validate alongside the built-in rules.
+// Issues it returns are tagged with its name and default severity, both of
+// which a RuleSet can override like any built-in rule.
+type Rule interface {
+	Name() string
+	DefaultSeverity() Severity
+	Check(set *URLSet) []ValidationIssue
+}
+
+func WithRules(rules ...Rule) ValidateOption {
+	return func(c *validateConfig) {
+		c.custom = append(c.custom, rules...)
+	}
+}
+
+type setRule struct {
+	name     string
+	severity Severity
+	check    func(*URLSet) []ValidationIssue
+}
+
+func (r setRule) Name() string                        { return r.name }
+func (r setRule) DefaultSeverity() Severity           { return r.severity }
+func (r setRule) Check(set *URLSet) []ValidationIssue { return r.check(set) }
+
+// SetRule builds a Rule from a check over the whole set, for house rules
+// that compare URLs with each other.
+func SetRule(name string, severity Severity, check func(*URLSet) []ValidationIssue) Rule {
+	return setRule{name: name, severity: severity, check: check}
+}
+
+// URLRule builds a Rule from a check run on every URL; each non-nil error
+// becomes an issue.
+func URLRule(name string, severity Severity, check func(*URL) error) Rule {
+	return SetRule(name, severity, func(set *URLSet) []ValidationIssue {
+		var issues []ValidationIssue
+		for i, entry := range set.URLs {
+			if err := check(entry); err != nil {
+				issues = append(issues, ValidationIssue{Index: i, Loc: entry.Loc, Err: err})
+			}
+		}
+		return issues
+	})
+}
+
+func (c *validateConfig) customIssues(set *URLSet) []ValidationIssue {
+	var out []ValidationIssue
+	for _, rule := range c.custom {
+		for _, issue := range rule.Check(set) {
+			issue.Rule = rule.Name()
+			issue.Severity = rule.DefaultSeverity()
+			out = append(out, issue)
+		}
+	}
+	return out
+}
@@ -0,0 +1,24 @@
+package sitemap_go
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCustomRules(t *testing.T) {
+	errTrailing := errors.New("loc ends in a slash")
+	rule := URLRule("no-trailing-slash", SeverityWarning, func(u *URL) error {
+		if u.Loc[len(u.Loc)-1] == '/' {
+			return errTrailing
+		}
+		return nil
+	})
+	set := setOf(t, "https://example.com/a", "https://example.com/b/")
+	issues := set.Validate(WithRules(rule))
+	if len(issues) != 1 || issues[0].Index != 1 || issues[0].Rule != "no-trailing-slash" || issues[0].Severity != SeverityWarning || !errors.Is(issues[0], errTrailing) {
+		t.Fatalf("issues = %+v", issues)
+	}
+	if issues := set.Validate(WithRules(rule), WithRuleSet(RuleSet{"no-trailing-slash": SeverityError})); !HasErrors(issues) {
+		t.Error("RuleSet did not override the custom rule")
+	}
+}
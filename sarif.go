@@ -0,0 +1,135 @@
+package sitemap_go
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription *sarifText   `json:"shortDescription,omitempty"`
+	DefaultConfig    *sarifConfig `json:"defaultConfiguration,omitempty"`
+}
+
+type sarifConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifText       `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return "note"
+}
+
+// WriteSARIF writes issues as a SARIF 2.1.0 log, attributing every result
+// to artifactURI (the sitemap's path in the repository) so CI tools can
+// annotate it.
+func WriteSARIF(w io.Writer, issues []ValidationIssue, artifactURI string) error {
+	driver := sarifDriver{Name: "sitemap-go", InformationURI: "https://github.com/KaneSud/sitemap-go"}
+	ruleIndex := map[string]int{}
+	for _, rule := range builtinRules {
+		ruleIndex[rule.id] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:               rule.id,
+			ShortDescription: &sarifText{Text: rule.errs[0].Error()},
+			DefaultConfig:    &sarifConfig{Level: sarifLevel(rule.severity)},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(issues))
+	for _, issue := range issues {
+		idx, ok := ruleIndex[issue.Rule]
+		if !ok {
+			idx = len(driver.Rules)
+			ruleIndex[issue.Rule] = idx
+			driver.Rules = append(driver.Rules, sarifRule{ID: issue.Rule})
+		}
+		message := fmt.Sprintf("%v (url %d: %s)", issue.Err, issue.Index, issue.Loc)
+		logical := sarifLogicalLocation{
+			Name:               issue.Loc,
+			FullyQualifiedName: fmt.Sprintf("urlset/url[%d]", issue.Index+1),
+			Kind:               "element",
+		}
+		if issue.Index < 0 {
+			message = issue.Err.Error()
+			logical = sarifLogicalLocation{Name: "urlset", FullyQualifiedName: "urlset", Kind: "element"}
+		}
+		results = append(results, sarifResult{
+			RuleID:    issue.Rule,
+			RuleIndex: idx,
+			Level:     sarifLevel(issue.Severity),
+			Message:   sarifText{Text: message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: artifactURI}},
+				LogicalLocations: []sarifLogicalLocation{logical},
+			}},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
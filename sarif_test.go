@@ -0,0 +1,53 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	set := setOf(t, "https://example.com/a", "http://example.com/b")
+	issues := set.Validate(WithRequireHTTPS(), WithRules(URLRule("custom", SeverityInfo, func(u *URL) error {
+		if u.Loc == "https://example.com/a" {
+			return ErrInvalidLoc
+		}
+		return nil
+	})))
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, issues, "public/sitemap.xml"); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("log = %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Results) != len(issues) {
+		t.Fatalf("got %d results for %d issues", len(run.Results), len(issues))
+	}
+	for _, result := range run.Results {
+		rule := run.Tool.Driver.Rules[result.RuleIndex]
+		if rule.ID != result.RuleID {
+			t.Errorf("result %s points at rule %s", result.RuleID, rule.ID)
+		}
+		if result.Locations[0].PhysicalLocation.ArtifactLocation.URI != "public/sitemap.xml" {
+			t.Errorf("artifact = %+v", result.Locations[0])
+		}
+		switch result.RuleID {
+		case RuleHTTPS:
+			if result.Level != "error" || result.Locations[0].LogicalLocations[0].FullyQualifiedName != "urlset/url[2]" {
+				t.Errorf("https result = %+v", result)
+			}
+		case "custom":
+			if result.Level != "note" {
+				t.Errorf("custom result = %+v", result)
+			}
+		default:
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package sitemap_go
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+// CountURLs counts the <url> entries of a urlset, or the <sitemap> entries
+// of a sitemap index, without decoding them. It scans raw tokens, so it is
+// much faster and lighter than a full parse of a large document. The
+// default ParseOptions limits apply.
+func CountURLs(r io.Reader) (int, error) {
+	lim := ParseOptions{}.limits()
+	dec := xml.NewDecoder(lim.reader(r))
+	depth, count := 0, 0
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err == nil {
+			err = lim.check(tok, depth+1)
+		}
+		if err != nil {
+			return count, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 && isEntryElement(t.Name) {
+				count++
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// Locs yields the loc of every entry of a urlset or sitemap index, in
+// document order, without decoding anything else. A syntax error is
+// yielded once and ends iteration.
+func Locs(r io.Reader) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		lim := ParseOptions{}.limits()
+		dec := xml.NewDecoder(lim.reader(r))
+		depth := 0
+		inEntry, inLoc := false, false
+		var loc strings.Builder
+		for {
+			tok, err := dec.RawToken()
+			if err == io.EOF {
+				return
+			}
+			if err == nil {
+				err = lim.check(tok, depth+1)
+			}
+			if err != nil {
+				yield("", err)
+				return
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				depth++
+				switch {
+				case depth == 2:
+					inEntry = isEntryElement(t.Name)
+				case depth == 3 && inEntry && t.Name.Space == "" && t.Name.Local == "loc":
+					inLoc = true
+					loc.Reset()
+				}
+			case xml.CharData:
+				if inLoc {
+					loc.Write(t)
+				}
+			case xml.EndElement:
+				if inLoc && depth == 3 {
+					inLoc = false
+					if !yield(strings.TrimSpace(loc.String()), nil) {
+						return
+					}
+				}
+				depth--
+			}
+		}
+	}
+}
+
+func isEntryElement(name xml.Name) bool {
+	return name.Space == "" && (name.Local == "url" || name.Local == "sitemap")
+}
+
+// ParseSample decodes the root and the first n <url> entries of a urlset
+// and stops reading, for previews and health checks that do not need the
+// whole document.
+func ParseSample(r io.Reader, n int) (URLSet, error) {
+	return decodeURLSet(r, max(n, 0), ParseOptions{})
+}
+
+// decodeURLSet decodes a urlset token by token, stopping after limit
+// entries unless limit is negative.
+func decodeURLSet(r io.Reader, limit int, opts ParseOptions) (URLSet, error) {
+	var out URLSet
+	lim := opts.limits()
+	dec := xml.NewDecoder(lim.reader(r))
+	depth := 0
+	for limit < 0 || len(out.URLs) < limit || depth == 0 {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			if depth > 0 {
+				return out, io.ErrUnexpectedEOF
+			}
+			return out, err
+		}
+		if err != nil {
+			return out, parseErrorAt(dec, err)
+		}
+		if err := lim.check(tok, depth+1); err != nil {
+			return out, parseErrorAt(dec, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				if t.Name.Local != "urlset" {
+					return out, parseErrorAt(dec, fmt.Errorf("expected element type <urlset> but have <%s>", t.Name.Local))
+				}
+				out.XMLName = t.Name
+				for _, attr := range t.Attr {
+					switch {
+					case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+						out.XMLNS = attr.Value
+					case attr.Name.Local == "xhtml":
+						out.XHTML = attr.Value
+					case attr.Name.Local == "image":
+						out.Image = attr.Value
+					case attr.Name.Local == "video":
+						out.Video = attr.Value
+					case attr.Name.Local == "geo":
+						out.Geo = attr.Value
+					}
+				}
+				depth++
+				continue
+			}
+			if t.Name.Local != "url" {
+				if _, err := lim.readElement(dec, t, 2, false); err != nil {
+					return out, parseErrorAt(dec, err)
+				}
+				continue
+			}
+			entry := &URL{}
+			if err := entry.decode(dec, opts, 2); err != nil {
+				return out, parseErrorAt(dec, err)
+			}
+			out.URLs = append(out.URLs, entry)
+		case xml.EndElement:
+			return out, nil
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,116 @@
+package sitemap_go
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const scanSitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+  <url><loc> https://example.com/a </loc><image:image><image:loc>https://example.com/a.jpg</image:loc></image:image></url>
+  <url><priority>0.5</priority><loc>https://example.com/b?x=1&amp;y=2</loc></url>
+  <url><loc><![CDATA[https://example.com/c]]></loc></url>
+</urlset>`
+
+func TestCountURLs(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want int
+	}{
+		{scanSitemap, 3},
+		{`<sitemapindex><sitemap><loc>a</loc></sitemap><sitemap><loc>b</loc></sitemap></sitemapindex>`, 2},
+		{`<urlset></urlset>`, 0},
+		{`<urlset><x><url/></x></urlset>`, 0},
+	}
+	for _, tt := range tests {
+		if got, err := CountURLs(strings.NewReader(tt.doc)); err != nil || got != tt.want {
+			t.Errorf("%.40q: got %d, %v; want %d", tt.doc, got, err, tt.want)
+		}
+	}
+	if _, err := CountURLs(strings.NewReader("<urlset>" + strings.Repeat("<x>", 200))); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("err = %v, want %v", err, ErrTooDeep)
+	}
+}
+
+func TestLocs(t *testing.T) {
+	var got []string
+	for loc, err := range Locs(strings.NewReader(scanSitemap)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, loc)
+	}
+	want := []string{"https://example.com/a", "https://example.com/b?x=1&y=2", "https://example.com/c"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for loc := range Locs(strings.NewReader(scanSitemap)) {
+		if loc != want[0] {
+			t.Errorf("first loc = %q", loc)
+		}
+		break
+	}
+
+	got = nil
+	var errs int
+	for loc, err := range Locs(strings.NewReader(`<urlset><url><loc>a</loc></url><url><loc x=>b</loc></url></urlset>`)) {
+		if err != nil {
+			errs++
+			continue
+		}
+		got = append(got, loc)
+	}
+	if !slices.Equal(got, []string{"a"}) || errs != 1 {
+		t.Errorf("malformed: got %q with %d errors", got, errs)
+	}
+}
+
+// failingReader fails every read, standing in for the unread rest of a
+// document.
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read past the sample")
+}
+
+func TestParseSample(t *testing.T) {
+	set, err := ParseSample(strings.NewReader(scanSitemap), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := locsOf(&set), []string{"https://example.com/a", "https://example.com/b?x=1&y=2"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if set.XMLNS != "http://www.sitemaps.org/schemas/sitemap/0.9" || set.Image != ImageNamespace || len(set.URLs[0].Images) != 1 {
+		t.Errorf("root or entries not decoded: %+v", set)
+	}
+
+	prefix := scanSitemap[:strings.Index(scanSitemap, "<url><priority>")]
+	if set, err := ParseSample(io.MultiReader(strings.NewReader(prefix), failingReader{}), 1); err != nil || len(set.URLs) != 1 {
+		t.Errorf("sample read past its entries: %d urls, %v", len(set.URLs), err)
+	}
+
+	tests := []struct {
+		n    int
+		want int
+	}{{0, 0}, {-1, 0}, {3, 3}, {10, 3}}
+	for _, tt := range tests {
+		set, err := ParseSample(strings.NewReader(scanSitemap), tt.n)
+		if err != nil || len(set.URLs) != tt.want || set.XMLNS == "" {
+			t.Errorf("n=%d: %d urls, %v", tt.n, len(set.URLs), err)
+		}
+	}
+
+	if _, err := ParseSample(strings.NewReader(`<sitemapindex></sitemapindex>`), 1); err == nil {
+		t.Error("sitemap index accepted")
+	}
+	_, err = ParseSample(strings.NewReader(`<urlset><url><loc>a</loc></url>`), 5)
+	if err == nil {
+		t.Error("truncated document accepted")
+	}
+	checkParseError(t, err)
+}
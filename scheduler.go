@@ -0,0 +1,168 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrRunInProgress = errors.New("a scheduled run is already in progress")
+
+type ScheduledRun struct {
+	Started  time.Time
+	Finished time.Time
+	// Triggered is set for runs requested through Trigger.
+	Triggered bool
+	URLs      int
+	Result    PublishResult
+	Err       error
+}
+
+// Scheduler regenerates sitemaps every Interval, plus a random delay of up
+// to Jitter, and whenever Trigger is called. Runs never overlap: triggers
+// received during a run are coalesced into one follow-up run.
+type Scheduler struct {
+	Interval time.Duration
+	Jitter   time.Duration
+	Generate func(ctx context.Context) (*URLSet, error)
+	// Publish is optional; (*Publisher).Publish fits.
+	Publish func(ctx context.Context, set *URLSet) (PublishResult, error)
+	// Ping is optional and runs after a successful Publish; see PingWith.
+	Ping func(ctx context.Context, result PublishResult) error
+	// OnRun is called after every run.
+	OnRun func(ScheduledRun)
+
+	running atomic.Bool
+	// pending records a trigger that arrived while RunOnce held running.
+	pending  atomic.Bool
+	initOnce sync.Once
+	trigger  chan struct{}
+}
+
+// PingWith returns a Scheduler Ping step that pings every endpoint in r
+// with the published index URL.
+func PingWith(r *PingRegistry) func(ctx context.Context, result PublishResult) error {
+	return func(ctx context.Context, result PublishResult) error {
+		var errs []error
+		for _, res := range r.Ping(ctx, result.IndexURL) {
+			errs = append(errs, res.Err)
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// Trigger requests a run as soon as the current one, if any, finishes. It
+// reports false when a triggered run is already pending.
+func (s *Scheduler) Trigger() bool {
+	select {
+	case s.triggers() <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run runs the pipeline on schedule until ctx is cancelled. A zero Interval
+// only runs on Trigger.
+func (s *Scheduler) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		var tick <-chan time.Time
+		if s.Interval > 0 {
+			timer.Reset(s.nextDelay())
+			tick = timer.C
+		}
+		triggered := false
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick:
+		case <-s.triggers():
+			triggered = true
+			timer.Stop()
+		}
+		run, err := s.run(ctx, triggered)
+		if errors.Is(err, ErrRunInProgress) {
+			if triggered {
+				s.deferTrigger()
+			}
+			continue
+		}
+		if s.OnRun != nil {
+			s.OnRun(run)
+		}
+	}
+}
+
+// RunOnce runs the pipeline immediately, failing with ErrRunInProgress
+// rather than overlapping a run already in progress. A Trigger received
+// while it runs is run by Run once it finishes.
+func (s *Scheduler) RunOnce(ctx context.Context) (ScheduledRun, error) {
+	run, err := s.run(ctx, true)
+	if err == nil && s.OnRun != nil {
+		s.OnRun(run)
+	}
+	if err == nil {
+		err = run.Err
+	}
+	return run, err
+}
+
+func (s *Scheduler) run(ctx context.Context, triggered bool) (ScheduledRun, error) {
+	if !s.running.CompareAndSwap(false, true) {
+		return ScheduledRun{}, ErrRunInProgress
+	}
+	defer func() {
+		s.running.Store(false)
+		if s.pending.Swap(false) {
+			s.Trigger()
+		}
+	}()
+
+	run := ScheduledRun{Started: time.Now(), Triggered: triggered}
+	set, err := s.Generate(ctx)
+	if err != nil {
+		run.Err = err
+		run.Finished = time.Now()
+		return run, nil
+	}
+	run.URLs = len(set.URLs)
+	if s.Publish != nil {
+		run.Result, run.Err = s.Publish(ctx, set)
+		if run.Err == nil && s.Ping != nil {
+			run.Err = s.Ping(ctx, run.Result)
+		}
+	}
+	run.Finished = time.Now()
+	return run, nil
+}
+
+// deferTrigger re-queues a trigger that found a RunOnce in progress once
+// that run finishes.
+func (s *Scheduler) deferTrigger() {
+	s.pending.Store(true)
+	// The run may have finished before pending was set.
+	if !s.running.Load() && s.pending.Swap(false) {
+		s.Trigger()
+	}
+}
+
+func (s *Scheduler) nextDelay() time.Duration {
+	delay := s.Interval
+	if s.Jitter > 0 {
+		delay += rand.N(s.Jitter)
+	}
+	return delay
+}
+
+func (s *Scheduler) triggers() chan struct{} {
+	s.initOnce.Do(func() {
+		s.trigger = make(chan struct{}, 1)
+	})
+	return s.trigger
+}
@@ -0,0 +1,95 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunOnceExcludesRuns(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	s := &Scheduler{Generate: func(ctx context.Context) (*URLSet, error) {
+		started <- struct{}{}
+		<-release
+		return setOf(t, "https://example.com/"), nil
+	}}
+	done := make(chan error)
+	go func() {
+		_, err := s.RunOnce(context.Background())
+		done <- err
+	}()
+	<-started
+	if _, err := s.RunOnce(context.Background()); !errors.Is(err, ErrRunInProgress) {
+		t.Errorf("overlapping RunOnce: err = %v, want ErrRunInProgress", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchedulerTriggerDuringRunOnce(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	var generated atomic.Int32
+	runs := make(chan ScheduledRun, 4)
+	s := &Scheduler{
+		Generate: func(ctx context.Context) (*URLSet, error) {
+			if generated.Add(1) == 1 {
+				started <- struct{}{}
+				<-release
+			}
+			return setOf(t, "https://example.com/"), nil
+		},
+		OnRun: func(run ScheduledRun) { runs <- run },
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	once := make(chan error)
+	go func() {
+		_, err := s.RunOnce(ctx)
+		once <- err
+	}()
+	<-started
+	go s.Run(ctx)
+	if !s.Trigger() {
+		t.Fatal("Trigger reported a pending run")
+	}
+	// Wait for Run to take the trigger and find RunOnce in progress.
+	for deadline := time.Now().Add(5 * time.Second); !s.pending.Load(); {
+		if time.Now().After(deadline) {
+			t.Fatal("trigger was not deferred")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	if err := <-once; err != nil {
+		t.Fatal(err)
+	}
+	<-runs
+
+	select {
+	case run := <-runs:
+		if !run.Triggered || run.Err != nil {
+			t.Errorf("follow-up run = %+v", run)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("trigger received during RunOnce was dropped")
+	}
+	if n := generated.Load(); n != 2 {
+		t.Errorf("generated %d times, want 2", n)
+	}
+}
+
+func TestSchedulerRunError(t *testing.T) {
+	errGenerate := errors.New("generate failed")
+	s := &Scheduler{Generate: func(ctx context.Context) (*URLSet, error) { return nil, errGenerate }}
+	run, err := s.RunOnce(context.Background())
+	if !errors.Is(err, errGenerate) || !errors.Is(run.Err, errGenerate) {
+		t.Errorf("err = %v, run.Err = %v", err, run.Err)
+	}
+}
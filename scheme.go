@@ -0,0 +1,61 @@
+package sitemap_go
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInsecureLoc = errors.New("loc uses http instead of https")
+
+type SchemePolicy int
+
+const (
+	SchemePolicyAllow SchemePolicy = iota
+	SchemePolicyReject
+	SchemePolicyRewrite
+)
+
+type SchemeChange struct {
+	Index int
+	From  string
+	To    string
+}
+
+// EnforceHTTPS applies policy to every http:// loc in the set. With
+// SchemePolicyRewrite the locs are upgraded in place; with SchemePolicyReject
+// the set is left untouched and an error wrapping ErrInsecureLoc is returned.
+// Either way the returned changes list every affected loc.
+func (u *URLSet) EnforceHTTPS(policy SchemePolicy) ([]SchemeChange, error) {
+	if policy == SchemePolicyAllow {
+		return nil, nil
+	}
+
+	var changes []SchemeChange
+	for i, entry := range u.URLs {
+		if !isHTTPLoc(entry.Loc) {
+			continue
+		}
+		change := SchemeChange{Index: i, From: entry.Loc, To: "https" + entry.Loc[len("http"):]}
+		changes = append(changes, change)
+		if policy == SchemePolicyRewrite {
+			entry.Loc = change.To
+		}
+	}
+
+	if policy == SchemePolicyReject && len(changes) > 0 {
+		return changes, fmt.Errorf("%w: %d locs, first %s", ErrInsecureLoc, len(changes), changes[0].From)
+	}
+	return changes, nil
+}
+
+// WithRequireHTTPS makes Validate report every http:// loc.
+func WithRequireHTTPS() ValidateOption {
+	return func(c *validateConfig) {
+		c.requireHTTPS = true
+	}
+}
+
+func isHTTPLoc(loc string) bool {
+	return len(loc) > len("http://") && strings.EqualFold(loc[:len("http://")], "http://")
+}
@@ -0,0 +1,47 @@
+package sitemap_go
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestEnforceHTTPS(t *testing.T) {
+	locs := []string{"http://example.com/a", "https://example.com/b", "HTTP://example.com/c", "httpx://example.com/d"}
+
+	set := setOf(t, locs...)
+	changes, err := set.EnforceHTTPS(SchemePolicyReject)
+	if !errors.Is(err, ErrInsecureLoc) || len(changes) != 2 {
+		t.Errorf("reject: %d changes, %v", len(changes), err)
+	}
+	if got := locsOf(set); !slices.Equal(got, locs) {
+		t.Errorf("reject changed the set: %q", got)
+	}
+
+	changes, err = set.EnforceHTTPS(SchemePolicyRewrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SchemeChange{
+		{Index: 0, From: locs[0], To: "https://example.com/a"},
+		{Index: 2, From: locs[2], To: "https://example.com/c"},
+	}
+	if !slices.Equal(changes, want) {
+		t.Errorf("changes = %+v, want %+v", changes, want)
+	}
+	if got := locsOf(set); got[0] != "https://example.com/a" || got[2] != "https://example.com/c" || got[3] != locs[3] {
+		t.Errorf("rewrite: %q", got)
+	}
+
+	if changes, err := setOf(t, locs...).EnforceHTTPS(SchemePolicyAllow); changes != nil || err != nil {
+		t.Errorf("allow: %v, %v", changes, err)
+	}
+}
+
+func TestValidateRequireHTTPS(t *testing.T) {
+	set := setOf(t, "http://example.com/a", "https://example.com/b")
+	issues := set.Validate(WithRequireHTTPS())
+	if len(issues) != 1 || issues[0].Index != 0 || !errors.Is(issues[0], ErrInsecureLoc) {
+		t.Errorf("issues = %v", issues)
+	}
+}
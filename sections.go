@@ -0,0 +1,114 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var ErrInvalidSection = errors.New("invalid section name")
+
+var sectionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// SectionedBuilder collects URLs under named sections, such as "products"
+// or "blog", and outputs one group of sitemap files per section plus an
+// index over all of them. Sections keep the order they were first used in.
+// The zero value is ready to use.
+type SectionedBuilder struct {
+	// Template supplies the namespaces and settings of new sections. It
+	// defaults to MakeUrlSet().
+	Template *URLSet
+
+	order    []string
+	sections map[string]*URLSet
+}
+
+// Section returns the set holding the named section's URLs, creating it on
+// first use. Name must be a letter or digit followed by letters, digits,
+// '-' or '_', as it becomes part of the file names.
+func (b *SectionedBuilder) Section(name string) (*URLSet, error) {
+	if set, ok := b.sections[name]; ok {
+		return set, nil
+	}
+	if !sectionPattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSection, name)
+	}
+	var set URLSet
+	if b.Template == nil {
+		set = MakeUrlSet()
+	} else {
+		set = b.Template.emptyCopy()
+	}
+	if b.sections == nil {
+		b.sections = make(map[string]*URLSet)
+	}
+	b.sections[name] = &set
+	b.order = append(b.order, name)
+	return &set, nil
+}
+
+// Add adds url to the named section, through that section's URLSet.Add.
+func (b *SectionedBuilder) Add(section string, url *URL) error {
+	set, err := b.Section(section)
+	if err != nil {
+		return err
+	}
+	return set.Add(url)
+}
+
+func (b *SectionedBuilder) Sections() []string {
+	return append([]string(nil), b.order...)
+}
+
+func (b *SectionedBuilder) Len() int {
+	n := 0
+	for _, set := range b.sections {
+		n += len(set.URLs)
+	}
+	return n
+}
+
+// Build encodes every section into shards named after it, like
+// sitemap-products-1.xml, and returns them with an index listing each
+// shard under baseURL. A shard's lastmod in the index is set by
+// opts.IndexLastMod, and defaults to the newest lastmod of its URLs.
+// opts.Prefix, when set, replaces "sitemap" in the names.
+func (b *SectionedBuilder) Build(ctx context.Context, baseURL string, opts ShardOptions) ([]Shard, SitemapIndex, error) {
+	shards, err := b.shards(ctx, opts)
+	if err != nil {
+		return nil, MakeSitemapIndex(nil), err
+	}
+	return shards, MakeShardIndex(baseURL, shards, opts.IndexLastMod), nil
+}
+
+func (b *SectionedBuilder) shards(ctx context.Context, opts ShardOptions) ([]Shard, error) {
+	var out []Shard
+	for _, name := range b.order {
+		set := b.sections[name]
+		if len(set.URLs) == 0 {
+			continue
+		}
+		sectionOpts := opts
+		sectionOpts.section = name
+		shards, err := set.GenerateShards(ctx, sectionOpts)
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", name, err)
+		}
+		for _, shard := range shards {
+			shard.Index = len(out)
+			out = append(out, shard)
+		}
+	}
+	return out, nil
+}
+
+// all returns a set holding the URLs of every section.
+func (b *SectionedBuilder) all() URLSet {
+	out := MakeUrlSet()
+	out.URLs = make([]*URL, 0, b.Len())
+	for _, name := range b.order {
+		out.URLs = append(out.URLs, b.sections[name].URLs...)
+	}
+	return out
+}
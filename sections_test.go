@@ -0,0 +1,106 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSectionedBuilder(t *testing.T) {
+	b := &SectionedBuilder{}
+	for _, name := range []string{"blog", "products", "empty"} {
+		if _, err := b.Section(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, loc := range []string{"https://example.com/p/1", "https://example.com/p/2", "https://example.com/p/3"} {
+		if err := b.Add("products", &URL{Loc: loc}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			if err := b.Add("blog", &URL{Loc: "https://example.com/blog/1"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if got, want := b.Sections(), []string{"blog", "products", "empty"}; !slices.Equal(got, want) {
+		t.Errorf("sections = %q, want %q", got, want)
+	}
+	if b.Len() != 4 {
+		t.Errorf("Len = %d, want 4", b.Len())
+	}
+
+	shards, index, err := b.Build(context.Background(), "https://example.com/", ShardOptions{MaxURLs: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names, locs []string
+	for i, shard := range shards {
+		names = append(names, shard.Name)
+		if shard.Index != i {
+			t.Errorf("shard %s has index %d, want %d", shard.Name, shard.Index, i)
+		}
+	}
+	for _, entry := range index.Sitemaps {
+		locs = append(locs, entry.Loc)
+	}
+	wantNames := []string{"sitemap-blog-1.xml", "sitemap-products-1.xml", "sitemap-products-2.xml"}
+	if !slices.Equal(names, wantNames) {
+		t.Errorf("shards = %q, want %q", names, wantNames)
+	}
+	for i, loc := range locs {
+		if loc != "https://example.com/"+wantNames[i] {
+			t.Errorf("index entry %d = %s", i, loc)
+		}
+	}
+	if !strings.Contains(string(shards[2].Data), "https://example.com/p/3") {
+		t.Errorf("last shard:\n%s", shards[2].Data)
+	}
+
+	shards, _, err = b.Build(context.Background(), "https://example.com/", ShardOptions{Prefix: "map"})
+	if err != nil || len(shards) != 2 || shards[1].Name != "map-products-1.xml" {
+		t.Errorf("with a prefix: %v", err)
+	}
+}
+
+func TestSectionNames(t *testing.T) {
+	b := &SectionedBuilder{Template: &URLSet{Strict: true}}
+	for _, name := range []string{"", "-blog", "blog/posts", "blog posts", "ü"} {
+		if _, err := b.Section(name); !errors.Is(err, ErrInvalidSection) {
+			t.Errorf("%q: err = %v, want %v", name, err, ErrInvalidSection)
+		}
+	}
+	if len(b.Sections()) != 0 {
+		t.Errorf("invalid sections created: %q", b.Sections())
+	}
+	set, err := b.Section("news_2024")
+	if err != nil || !set.Strict {
+		t.Fatalf("section = %+v, %v; want one from the template", set, err)
+	}
+	if err := b.Add("news_2024", &URL{Loc: "https://example.com/a b"}); err == nil {
+		t.Error("section did not apply the template's Strict setting")
+	}
+}
+
+func TestPublishSections(t *testing.T) {
+	b := &SectionedBuilder{}
+	b.Add("a", &URL{Loc: "https://example.com/a"})
+	b.Add("b", &URL{Loc: "https://example.com/b"})
+	storage := &memStorage{}
+	p := &Publisher{Storage: storage, BaseURL: "https://example.com/"}
+	result, err := p.PublishSections(context.Background(), b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.URLs != 2 {
+		t.Errorf("published %d URLs, want 2", result.URLs)
+	}
+	index := storage.get("sitemap.xml")
+	for _, name := range []string{"sitemap-a-1.xml", "sitemap-b-1.xml"} {
+		if storage.get(name) == "" || !strings.Contains(index, "https://example.com/"+name) {
+			t.Errorf("%s not published and indexed; index:\n%s", name, index)
+		}
+	}
+}
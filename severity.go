@@ -0,0 +1,94 @@
+package sitemap_go
+
+import "errors"
+
+type Severity int
+
+const (
+	// SeverityOff disables a rule when used in a RuleSet.
+	SeverityOff Severity = iota - 1
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityOff:
+		return "off"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "unknown"
+}
+
+const (
+	RuleLocLength             = "loc-length"
+	RuleLocSyntax             = "loc-syntax"
+	RuleLocAbsolute           = "loc-absolute"
+	RuleHTTPS                 = "https"
+	RuleLocationScope         = "location-scope"
+	RuleRobots                = "robots"
+	RuleHreflangDuplicate     = "hreflang-duplicate"
+	RuleHreflangReturnLink    = "hreflang-return-link"
+	RuleHreflangSelfReference = "hreflang-self-reference"
+	RulePriorityUniform       = "priority-uniform"
+	RuleChangeFreqUniform     = "changefreq-uniform"
+	RuleLastModGenerated      = "lastmod-generated"
+)
+
+// RuleSet overrides the severity of rules by ID. SeverityOff drops a rule's
+// issues entirely.
+type RuleSet map[string]Severity
+
+func WithRuleSet(rules RuleSet) ValidateOption {
+	return func(c *validateConfig) {
+		c.rules = rules
+	}
+}
+
+// builtinRules maps the errors raised by the built-in checks to their rule
+// ID and default severity.
+var builtinRules = []struct {
+	id       string
+	severity Severity
+	errs     []error
+}{
+	{RuleLocLength, SeverityError, []error{ErrLocTooLong}},
+	{RuleLocSyntax, SeverityError, []error{ErrLocInvalidChar}},
+	{RuleLocAbsolute, SeverityError, []error{ErrInvalidLoc}},
+	{RuleHTTPS, SeverityError, []error{ErrInsecureLoc}},
+	{RuleLocationScope, SeverityError, []error{ErrOtherScheme, ErrOtherHost, ErrAboveSitemapPath}},
+	{RuleRobots, SeverityWarning, []error{ErrDisallowedByRobots}},
+	{RuleHreflangDuplicate, SeverityWarning, []error{ErrDuplicateHreflang}},
+	{RuleHreflangReturnLink, SeverityWarning, []error{ErrMissingReturnLink}},
+	{RuleHreflangSelfReference, SeverityWarning, []error{ErrMissingSelfReference}},
+	{RulePriorityUniform, SeverityWarning, []error{ErrUniformPriority}},
+	{RuleChangeFreqUniform, SeverityWarning, []error{ErrUniformChangeFreq}},
+	{RuleLastModGenerated, SeverityWarning, []error{ErrGenerationTimeStamp}},
+}
+
+func classify(err error) (string, Severity) {
+	for _, rule := range builtinRules {
+		for _, target := range rule.errs {
+			if errors.Is(err, target) {
+				return rule.id, rule.severity
+			}
+		}
+	}
+	return "", SeverityError
+}
+
+// HasErrors reports whether any issue has error severity.
+func HasErrors(issues []ValidationIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity >= SeverityError {
+			return true
+		}
+	}
+	return false
+}
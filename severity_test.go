@@ -0,0 +1,38 @@
+package sitemap_go
+
+import "testing"
+
+func TestValidateSeverities(t *testing.T) {
+	set := setOf(t, "http://example.com/a", "/relative")
+	set.URLs[0].Alternate = []Alternate{{Rel: "alternate", HrefLang: "de", Href: "https://example.com/de"}}
+
+	severities := func(issues []ValidationIssue) map[string]Severity {
+		out := map[string]Severity{}
+		for _, issue := range issues {
+			out[issue.Rule] = issue.Severity
+		}
+		return out
+	}
+	got := severities(set.Validate(WithRequireHTTPS()))
+	want := map[string]Severity{RuleHTTPS: SeverityError, RuleLocAbsolute: SeverityError, RuleHreflangSelfReference: SeverityWarning}
+	for rule, severity := range want {
+		if got[rule] != severity {
+			t.Errorf("%s: got %v, want %v", rule, got[rule], severity)
+		}
+	}
+
+	issues := set.Validate(WithRequireHTTPS(), WithRuleSet(RuleSet{RuleHTTPS: SeverityOff, RuleLocAbsolute: SeverityInfo}))
+	got = severities(issues)
+	if _, ok := got[RuleHTTPS]; ok {
+		t.Error("rule turned off still reported")
+	}
+	if got[RuleLocAbsolute] != SeverityInfo {
+		t.Errorf("override: got %v", got[RuleLocAbsolute])
+	}
+	if HasErrors(issues) {
+		t.Errorf("HasErrors with only warnings and info: %v", issues)
+	}
+	if !HasErrors(set.Validate()) {
+		t.Error("HasErrors missed the relative loc")
+	}
+}
@@ -0,0 +1,482 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"iter"
+	"sort"
+	"sync"
+	"time"
+)
+
+const MaxURLsPerSitemap = 50000
+
+type Shard struct {
+	Index int
+	Name  string
+	Count int
+	// Data holds the encoded shard, unless it was written to a ShardSink.
+	Data []byte
+	// Size is the byte size of the shard as written, after compression.
+	Size int64
+	// Hash is the SHA-256 of the shard's uncompressed document.
+	Hash [sha256.Size]byte
+	// Fingerprint identifies the shard's content before it is encoded:
+	// shards with equal fingerprints encode to the same document.
+	Fingerprint [sha256.Size]byte
+	// Skipped is set when ShardOptions.Unchanged skipped encoding the
+	// shard, which then has no Data, Size or Hash.
+	Skipped bool
+	// LastMod is the newest lastmod among the shard's URLs, if any has one.
+	LastMod *time.Time
+}
+
+type ShardOptions struct {
+	// MaxURLs caps the URLs per shard. It defaults to MaxURLsPerSitemap.
+	MaxURLs int
+	// MaxBytes caps the uncompressed size of each shard, measured by
+	// encoding every URL as it is added. It defaults to MaxSitemapBytes.
+	MaxBytes int
+	// Buckets, when set, assigns URLs to that many shards by a consistent
+	// hash of their loc rather than by input order, so a URL stays in the
+	// same shard from run to run and changing Buckets moves few URLs. A
+	// shard's Index is its bucket, and empty buckets produce no shard. A
+	// bucket outgrowing MaxURLs or MaxBytes fails the run.
+	Buckets int
+	// Prefix starts every shard file name. It defaults to "sitemap", giving
+	// sitemap-1.xml, sitemap-2.xml and so on.
+	Prefix string
+	// Names lays out the shard files. It defaults to SequentialNames.
+	Names ShardNamer
+	Gzip  bool
+	// Compression names a registered ContentCoding, such as "br", to
+	// compress shards with. It takes precedence over Gzip.
+	Compression string
+	// Parallelism is the number of shards encoded concurrently. Shard
+	// membership depends only on input order, never on scheduling.
+	Parallelism int
+	Encode      EncodeOptions
+	// Template supplies the namespaces and settings of every shard. It
+	// defaults to MakeUrlSet().
+	Template *URLSet
+	// Transformers are applied to a copy of each URL before it is assigned
+	// to a shard.
+	Transformers []Transformer
+	// Progress is called as each shard finishes encoding, with the URLs,
+	// bytes and shards completed so far.
+	Progress ProgressFunc
+	// IndexLastMod sets the lastmod of each shard's index entry. The
+	// Publisher defaults to GenerationTime and SectionedBuilder.Build to
+	// NewestLastMod.
+	IndexLastMod LastModStrategy
+	// Unchanged, when set, is called with each shard before it is encoded,
+	// with its name and fingerprint filled in. When it returns true the
+	// shard is returned Skipped, without being encoded, compressed or
+	// written to the Sink.
+	Unchanged func(Shard) bool
+	// Sink, when set, receives every shard as it is encoded and compressed,
+	// so no shard is ever held in memory in encoded form; the shards
+	// returned then carry no Data.
+	Sink ShardSink
+
+	section string
+}
+
+// ShardSink opens the destination of the named shard. The splitter closes
+// the writer once the shard is written, including when writing it failed,
+// in which case GenerateShards returns the error and the partial shard
+// should be discarded.
+type ShardSink func(ctx context.Context, name string) (io.WriteCloser, error)
+
+func (o ShardOptions) maxURLs() int {
+	if o.MaxURLs <= 0 {
+		return MaxURLsPerSitemap
+	}
+	return o.MaxURLs
+}
+
+func (o ShardOptions) maxBytes() int {
+	if o.MaxBytes <= 0 {
+		return MaxSitemapBytes
+	}
+	return o.MaxBytes
+}
+
+func (o ShardOptions) prefix() string {
+	if o.Prefix == "" {
+		return "sitemap"
+	}
+	return o.Prefix
+}
+
+func (o ShardOptions) template() URLSet {
+	if o.Template == nil {
+		return MakeUrlSet()
+	}
+	return o.Template.emptyCopy()
+}
+
+// Split divides the set into consecutive sets of at most maxURLs URLs.
+func (u *URLSet) Split(maxURLs int) []URLSet {
+	if maxURLs <= 0 {
+		maxURLs = MaxURLsPerSitemap
+	}
+	var out []URLSet
+	for start := 0; start < len(u.URLs); start += maxURLs {
+		part := u.emptyCopy()
+		part.URLs = u.URLs[start:min(start+maxURLs, len(u.URLs))]
+		out = append(out, part)
+	}
+	return out
+}
+
+// GenerateShards splits the set and encodes each part, using the set itself
+// as the shard template unless opts overrides it.
+func (u *URLSet) GenerateShards(ctx context.Context, opts ShardOptions) ([]Shard, error) {
+	if opts.Template == nil {
+		opts.Template = u
+	}
+	return GenerateShards(ctx, urlSeq(u.URLs), opts)
+}
+
+// GenerateShards reads urls in order, cuts them into shards of at most
+// opts.MaxURLs URLs and opts.MaxBytes bytes and encodes (and optionally
+// gzips) the shards on a pool of opts.Parallelism workers. Shards are
+// returned in order. A single URL too large for a shard of its own fails
+// with ErrSitemapTooLarge. With opts.Buckets set, URLs are placed by loc
+// instead, and shards are encoded once every URL has been read.
+func GenerateShards(ctx context.Context, urls iter.Seq2[*URL, error], opts ShardOptions) ([]Shard, error) {
+	if err := opts.Encode.checkDeclaration(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		shards   []Shard
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	type job struct {
+		index       int
+		set         URLSet
+		fingerprint [sha256.Size]byte
+	}
+	jobs := make(chan job)
+	progress := newProgressTracker(opts.Progress)
+	var wg sync.WaitGroup
+	for range max(opts.Parallelism, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				shard, err := encodeShard(ctx, j.index, &j.set, j.fingerprint, opts)
+				if err != nil {
+					fail(fmt.Errorf("shard %d: %w", j.index+1, err))
+					continue
+				}
+				mu.Lock()
+				shards = append(shards, shard)
+				mu.Unlock()
+				progress.update(func(p *Progress) {
+					p.URLs += shard.Count
+					p.Bytes += shard.Size
+					p.Shards++
+				})
+			}
+		}()
+	}
+
+	send := func(j job) bool {
+		select {
+		case jobs <- j:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	type pending struct {
+		set  URLSet
+		used int
+		fp   hash.Hash
+	}
+	template := opts.template()
+	size := newShardSizer(&template, opts)
+	newPending := func() *pending {
+		return &pending{set: opts.template(), used: size.overhead, fp: size.fingerprint()}
+	}
+	flush := func(index int, p *pending) bool {
+		j := job{index: index, set: p.set}
+		p.fp.Sum(j.fingerprint[:0])
+		return send(j)
+	}
+
+	hashed := opts.Buckets > 0
+	var buckets []*pending
+	if hashed {
+		buckets = make([]*pending, opts.Buckets)
+	}
+	index := 0
+	current := newPending()
+	chain := Chain(opts.Transformers...)
+	for u, err := range urls {
+		if err == nil && len(opts.Transformers) > 0 {
+			u, err = chain.Transform(u.Clone())
+		}
+		if err != nil {
+			fail(err)
+			break
+		}
+		if u == nil {
+			continue
+		}
+		p, bucket := current, 0
+		if hashed {
+			bucket = jumpHash(locHash(u.Loc), opts.Buckets)
+			if buckets[bucket] == nil {
+				buckets[bucket] = newPending()
+			}
+			p = buckets[bucket]
+		}
+		n, frag, err := size.entry(&p.set, u)
+		if err != nil {
+			fail(err)
+			break
+		}
+		if size.overhead+n > opts.maxBytes() {
+			fail(fmt.Errorf("%w: %s encodes to %d bytes", ErrSitemapTooLarge, u.Loc, n))
+			break
+		}
+		switch {
+		case hashed && p.used+n > opts.maxBytes():
+			fail(fmt.Errorf("%w: bucket %d of %d is full", ErrSitemapTooLarge, bucket+1, opts.Buckets))
+		case hashed && len(p.set.URLs) == opts.maxURLs():
+			fail(fmt.Errorf("%w: bucket %d of %d is full", ErrTooManyURLs, bucket+1, opts.Buckets))
+		case !hashed && len(p.set.URLs) > 0 && p.used+n > opts.maxBytes():
+			if flush(index, p) {
+				index++
+				current = newPending()
+				p = current
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		p.set.URLs = append(p.set.URLs, u)
+		p.used += n
+		p.fp.Write(frag)
+		if !hashed && len(p.set.URLs) == opts.maxURLs() {
+			if !flush(index, p) {
+				break
+			}
+			index++
+			current = newPending()
+		}
+	}
+	if hashed {
+		for bucket, p := range buckets {
+			if p != nil && !flush(bucket, p) {
+				break
+			}
+		}
+	} else if len(current.set.URLs) > 0 {
+		flush(index, current)
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i].Index < shards[j].Index
+	})
+	return shards, nil
+}
+
+func encodeShard(ctx context.Context, index int, set *URLSet, fingerprint [sha256.Size]byte, opts ShardOptions) (Shard, error) {
+	shard := Shard{
+		Index:       index,
+		Count:       len(set.URLs),
+		LastMod:     newestLastMod(set.URLs),
+		Fingerprint: fingerprint,
+	}
+	shard.Name = opts.names().ShardName(ShardInfo{
+		Index:   index,
+		Prefix:  opts.prefix(),
+		Section: opts.section,
+		URLs:    set.URLs,
+		LastMod: shard.LastMod,
+	})
+	compression := opts.Compression
+	if compression == "" && opts.Gzip {
+		compression = "gzip"
+	}
+	var coding *ContentCoding
+	if compression != "" {
+		c, ok := codingByName(compression)
+		if !ok || c.NewWriter == nil {
+			return shard, fmt.Errorf("unsupported shard compression %q", compression)
+		}
+		shard.Name += c.Ext
+		coding = &c
+	}
+	if opts.Unchanged != nil && opts.Unchanged(shard) {
+		shard.Skipped = true
+		return shard, nil
+	}
+
+	if opts.Sink == nil {
+		var buf bytes.Buffer
+		cw := &countingWriter{w: &buf}
+		if err := writeShard(cw, &shard, set, opts.Encode, coding); err != nil {
+			return shard, err
+		}
+		shard.Data, shard.Size = buf.Bytes(), cw.n
+		return shard, nil
+	}
+	w, err := opts.Sink(ctx, shard.Name)
+	if err != nil {
+		return shard, err
+	}
+	cw := &countingWriter{w: w}
+	err = writeShard(cw, &shard, set, opts.Encode, coding)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	shard.Size = cw.n
+	return shard, err
+}
+
+// writeShard encodes set to w, through coding when it is not nil, and
+// records the document's hash in shard.
+func writeShard(w io.Writer, shard *Shard, set *URLSet, opts EncodeOptions, coding *ContentCoding) error {
+	h := sha256.New()
+	defer func() { copy(shard.Hash[:], h.Sum(nil)) }()
+	if coding == nil {
+		return set.Encode(io.MultiWriter(w, h), opts)
+	}
+	zw, err := coding.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	if err := set.Encode(io.MultiWriter(zw, h), opts); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// shardSizer measures how many bytes URLs add to an encoded shard.
+type shardSizer struct {
+	opts EncodeOptions
+	// empty is a shard without URLs, declaring every extension namespace,
+	// and overhead its size.
+	empty       []byte
+	overhead    int
+	compression string
+}
+
+func newShardSizer(template *URLSet, shardOpts ShardOptions) shardSizer {
+	opts := shardOpts.Encode
+	var buf bytes.Buffer
+	buf.WriteString(opts.header())
+	enc := opts.newEncoder(&buf)
+	root := xml.StartElement{
+		Name: xml.Name{Local: "urlset"},
+		Attr: template.rootAttrs(extensionUse{all: true, xhtml: true, image: true, video: true, geo: true}),
+	}
+	enc.EncodeToken(root)
+	enc.EncodeToken(root.End())
+	enc.Close()
+	compression := shardOpts.Compression
+	if compression == "" && shardOpts.Gzip {
+		compression = "gzip"
+	}
+	return shardSizer{opts: opts, empty: buf.Bytes(), overhead: buf.Len(), compression: compression}
+}
+
+// fingerprint returns a hash to feed the fragments of a shard's URLs to,
+// seeded with what else shapes its document.
+func (s shardSizer) fingerprint() hash.Hash {
+	h := sha256.New()
+	h.Write(s.empty)
+	h.Write([]byte{0})
+	h.Write([]byte(s.compression))
+	h.Write([]byte{0})
+	return h
+}
+
+// entry returns the bytes u takes inside set's document: its fragment,
+// plus the line break and one level of indentation before each line when
+// the output is indented. It also returns the fragment.
+func (s shardSizer) entry(set *URLSet, u *URL) (int, []byte, error) {
+	frag, err := set.EncodeURL(u, s.opts)
+	if err != nil {
+		return 0, nil, err
+	}
+	n := len(frag)
+	if !s.opts.Compact {
+		indent := orDefault(s.opts.Indent, defaultIndent)
+		n += 1 + len(indent)*(bytes.Count(frag, []byte("\n"))+1)
+	}
+	return n, frag, nil
+}
+
+func newestLastMod(urls []*URL) *time.Time {
+	var newest *time.Time
+	for _, u := range urls {
+		if u.LastMod != nil && (newest == nil || u.LastMod.After(*newest)) {
+			newest = u.LastMod
+		}
+	}
+	return newest
+}
+
+func urlSeq(urls []*URL) iter.Seq2[*URL, error] {
+	return func(yield func(*URL, error) bool) {
+		for _, u := range urls {
+			if !yield(u, nil) {
+				return
+			}
+		}
+	}
+}
+
+// locHash is the key consistent-hash sharding places loc by.
+func locHash(loc string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(loc))
+	return h.Sum64()
+}
+
+// jumpHash is Lamping and Veach's jump consistent hash: it maps key to one
+// of buckets so that growing buckets from n to n+1 moves only a 1/(n+1)
+// share of the keys.
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
@@ -0,0 +1,314 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestGenerateShardsParallel(t *testing.T) {
+	set := numberedSet(t, 95, "")
+	serial, err := set.GenerateShards(context.Background(), ShardOptions{MaxURLs: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(serial) != 10 || serial[9].Count != 5 {
+		t.Fatalf("got %d shards", len(serial))
+	}
+	for _, parallelism := range []int{2, 4, 16} {
+		shards, err := set.GenerateShards(context.Background(), ShardOptions{MaxURLs: 10, Parallelism: parallelism})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(shards) != len(serial) {
+			t.Fatalf("parallelism %d: got %d shards", parallelism, len(shards))
+		}
+		for i, shard := range shards {
+			if shard.Index != i || shard.Name != fmt.Sprintf("sitemap-%d.xml", i+1) {
+				t.Errorf("parallelism %d: shard %d is %d %s", parallelism, i, shard.Index, shard.Name)
+			}
+			if string(shard.Data) != string(serial[i].Data) || shard.Hash != serial[i].Hash {
+				t.Errorf("parallelism %d: shard %d differs from the serial run", parallelism, i)
+			}
+		}
+	}
+}
+
+func TestGenerateShardsContent(t *testing.T) {
+	set := numberedSet(t, 25, "")
+	shards, err := set.GenerateShards(context.Background(), ShardOptions{MaxURLs: 10, Gzip: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := 0
+	for _, shard := range shards {
+		if shard.Name != fmt.Sprintf("sitemap-%d.xml.gz", shard.Index+1) || shard.Size != int64(len(shard.Data)) {
+			t.Errorf("shard %d: %s, size %d", shard.Index, shard.Name, shard.Size)
+		}
+		body, err := gunzipIfNeeded(shard.Data, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part, err := ParseXMLUrlSet(string(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range part.URLs {
+			if want := fmt.Sprintf("https://example.com/%d", seen); u.Loc != want {
+				t.Errorf("got %s, want %s", u.Loc, want)
+			}
+			seen++
+		}
+	}
+	if seen != 25 {
+		t.Errorf("shards hold %d URLs", seen)
+	}
+}
+
+func TestGenerateShardsMaxBytes(t *testing.T) {
+	set := richSet(40)
+	const maxBytes = 4000
+	for _, parallelism := range []int{1, 4} {
+		shards, err := set.GenerateShards(context.Background(), ShardOptions{MaxBytes: maxBytes, Parallelism: parallelism})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(shards) < 2 {
+			t.Fatalf("got %d shards", len(shards))
+		}
+		next := 0
+		for i, shard := range shards {
+			if len(shard.Data) > maxBytes {
+				t.Errorf("shard %d is %d bytes", i, len(shard.Data))
+			}
+			next += shard.Count
+			if i == len(shards)-1 {
+				break
+			}
+			// Shards are filled greedily: one more URL would not fit.
+			grown := MakeUrlSet()
+			grown.URLs = set.URLs[next-shard.Count : next+1]
+			var buf bytes.Buffer
+			if err := grown.Encode(&buf, EncodeOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if buf.Len() <= maxBytes {
+				t.Errorf("shard %d closed at %d bytes with room for the next URL", i, len(shard.Data))
+			}
+		}
+		if next != len(set.URLs) {
+			t.Errorf("shards hold %d URLs, want %d", next, len(set.URLs))
+		}
+	}
+
+	if _, err := set.GenerateShards(context.Background(), ShardOptions{MaxBytes: 600}); !errors.Is(err, ErrSitemapTooLarge) {
+		t.Errorf("oversized URL: err = %v, want %v", err, ErrSitemapTooLarge)
+	}
+}
+
+// sinkBuffer is a shard destination recording whether it was closed.
+type sinkBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *sinkBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestGenerateShardsSink(t *testing.T) {
+	set := numberedSet(t, 25, "")
+	ctx := context.Background()
+	opts := ShardOptions{MaxURLs: 10, Gzip: true, Parallelism: 3}
+	buffered, err := set.GenerateShards(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	written := map[string]*sinkBuffer{}
+	opts.Sink = func(ctx context.Context, name string) (io.WriteCloser, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		b := &sinkBuffer{}
+		written[name] = b
+		return b, nil
+	}
+	streamed, err := set.GenerateShards(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(streamed) != 3 || len(written) != 3 {
+		t.Fatalf("%d shards, %d written", len(streamed), len(written))
+	}
+	for i, shard := range streamed {
+		b := written[shard.Name]
+		if shard.Data != nil || !b.closed || shard.Size != int64(b.Len()) {
+			t.Errorf("%s: data %d bytes, closed %v, size %d of %d", shard.Name, len(shard.Data), b.closed, shard.Size, b.Len())
+		}
+		if want := buffered[i]; !bytes.Equal(b.Bytes(), want.Data) || shard.Hash != want.Hash || shard.Name != want.Name {
+			t.Errorf("%s differs from the buffered shard", shard.Name)
+		}
+	}
+}
+
+func TestGenerateShardsSinkErrors(t *testing.T) {
+	errOpen := errors.New("open failed")
+	set := numberedSet(t, 5, "")
+	opts := ShardOptions{MaxURLs: 2, Sink: func(ctx context.Context, name string) (io.WriteCloser, error) {
+		return nil, errOpen
+	}}
+	if _, err := set.GenerateShards(context.Background(), opts); !errors.Is(err, errOpen) {
+		t.Errorf("err = %v, want %v", err, errOpen)
+	}
+
+	var sinks []*failingSink
+	opts.Sink = func(ctx context.Context, name string) (io.WriteCloser, error) {
+		s := &failingSink{}
+		sinks = append(sinks, s)
+		return s, nil
+	}
+	opts.Parallelism = 1
+	if _, err := set.GenerateShards(context.Background(), opts); !errors.Is(err, errWrite) {
+		t.Errorf("err = %v, want %v", err, errWrite)
+	}
+	for _, s := range sinks {
+		if !s.closed {
+			t.Error("failed shard's writer left open")
+		}
+	}
+}
+
+// failingSink fails every write with errWrite.
+type failingSink struct {
+	closed bool
+}
+
+func (s *failingSink) Write(p []byte) (int, error) { return 0, errWrite }
+
+func (s *failingSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestGenerateShardsFingerprint(t *testing.T) {
+	ctx := context.Background()
+	opts := ShardOptions{MaxURLs: 2}
+	first, err := numberedSet(t, 4, "").GenerateShards(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := numberedSet(t, 4, "")
+	changed.URLs[3].ChangeFreq = ChangeFreqDaily
+	var asked []string
+	opts.Unchanged = func(s Shard) bool {
+		asked = append(asked, s.Name)
+		return s.Fingerprint == first[s.Index].Fingerprint
+	}
+	opts.Parallelism = 1
+	again, err := changed.GenerateShards(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(asked) != 2 || asked[0] != "sitemap-1.xml" {
+		t.Errorf("Unchanged asked about %q", asked)
+	}
+	if !again[0].Skipped || again[0].Data != nil || again[0].Count != 2 || again[0].Name != first[0].Name {
+		t.Errorf("unchanged shard = %+v", again[0])
+	}
+	if again[1].Skipped || again[1].Fingerprint == first[1].Fingerprint || !bytes.Contains(again[1].Data, []byte("daily")) {
+		t.Errorf("changed shard skipped or not re-encoded")
+	}
+}
+
+func TestJumpHash(t *testing.T) {
+	// Vectors from the reference implementation of Lamping and Veach.
+	tests := []struct {
+		key     uint64
+		buckets int
+		want    int
+	}{
+		{1, 1, 0},
+		{42, 57, 43},
+		{0xDEAD10CC, 1, 0},
+		{0xDEAD10CC, 666, 361},
+		{256, 1024, 520},
+	}
+	for _, tt := range tests {
+		if got := jumpHash(tt.key, tt.buckets); got != tt.want {
+			t.Errorf("jumpHash(%#x, %d) = %d, want %d", tt.key, tt.buckets, got, tt.want)
+		}
+	}
+
+	// Growing from 10 to 11 buckets only moves keys into the new bucket.
+	moved := 0
+	for i := range 10000 {
+		key := locHash(fmt.Sprintf("https://example.com/%d", i))
+		before, after := jumpHash(key, 10), jumpHash(key, 11)
+		if before != after {
+			moved++
+			if after != 10 {
+				t.Fatalf("key %d moved from bucket %d to %d", i, before, after)
+			}
+		}
+	}
+	if moved < 700 || moved > 1100 {
+		t.Errorf("%d of 10000 keys moved, want about 1/11", moved)
+	}
+}
+
+func TestGenerateShardsBuckets(t *testing.T) {
+	ctx := context.Background()
+	placement := func(set *URLSet, buckets int) map[string]int {
+		t.Helper()
+		shards, err := set.GenerateShards(ctx, ShardOptions{Buckets: buckets, Parallelism: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		at := map[string]int{}
+		for _, shard := range shards {
+			if shard.Name != fmt.Sprintf("sitemap-%d.xml", shard.Index+1) {
+				t.Errorf("bucket %d named %s", shard.Index, shard.Name)
+			}
+			for loc, err := range Locs(bytes.NewReader(shard.Data)) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				at[loc] = shard.Index
+			}
+		}
+		return at
+	}
+	set := numberedSet(t, 200, "")
+	at := placement(set, 8)
+	for _, u := range set.URLs {
+		if want := jumpHash(locHash(u.Loc), 8); at[u.Loc] != want {
+			t.Errorf("%s in bucket %d, want %d", u.Loc, at[u.Loc], want)
+		}
+	}
+
+	// Placement does not depend on input order.
+	reversed := numberedSet(t, 200, "")
+	for i, j := 0, len(reversed.URLs)-1; i < j; i, j = i+1, j-1 {
+		reversed.URLs[i], reversed.URLs[j] = reversed.URLs[j], reversed.URLs[i]
+	}
+	for loc, bucket := range placement(reversed, 8) {
+		if at[loc] != bucket {
+			t.Errorf("%s moved to bucket %d when the input was reversed", loc, bucket)
+		}
+	}
+	for loc, bucket := range placement(set, 9) {
+		if at[loc] != bucket && bucket != 8 {
+			t.Errorf("%s moved between old buckets %d and %d", loc, at[loc], bucket)
+		}
+	}
+
+	if _, err := set.GenerateShards(ctx, ShardOptions{Buckets: 2, MaxURLs: 50}); !errors.Is(err, ErrTooManyURLs) {
+		t.Errorf("full bucket: err = %v, want %v", err, ErrTooManyURLs)
+	}
+}
@@ -0,0 +1,120 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrNoShardData = errors.New("shard has no data to compare")
+
+type ShardChangeKind int
+
+const (
+	ShardChanged ShardChangeKind = iota
+	ShardAdded
+	ShardRemoved
+)
+
+// ShardDiff is a shard that differs between two runs.
+type ShardDiff struct {
+	Name string
+	Kind ShardChangeKind
+	// URLs compares the shard's entries; every entry of an added or
+	// removed shard counts as added or removed.
+	URLs SetDiff
+}
+
+type ShardDiffReport struct {
+	// Shards lists the shards that differ: those of the new run in order,
+	// then the removed ones in the order of the old run.
+	Shards []ShardDiff
+	// Unchanged names the shards whose content is the same in both runs.
+	Unchanged []string
+}
+
+// Names returns the names of every shard that was added, changed or
+// removed, such as for purging their cached copies.
+func (r ShardDiffReport) Names() []string {
+	names := make([]string, len(r.Shards))
+	for i, d := range r.Shards {
+		names[i] = d.Name
+	}
+	return names
+}
+
+// DiffShards compares the shards of two runs by name. Shards that keep
+// their Fingerprint are unchanged without being decoded; the others are
+// decoded from their Data, which must be present, and their entries
+// compared with Diff under opts. It is most telling with stable sharding,
+// such as ShardOptions.Buckets, where a shard holds the same locs from run
+// to run.
+func DiffShards(old, current []Shard, opts CompareOptions) (ShardDiffReport, error) {
+	var report ShardDiffReport
+	byName := make(map[string]Shard, len(old))
+	for _, s := range old {
+		byName[s.Name] = s
+	}
+	empty := MakeUrlSet()
+	seen := make(map[string]bool, len(current))
+	for _, s := range current {
+		seen[s.Name] = true
+		prev, ok := byName[s.Name]
+		if ok && prev.Fingerprint == s.Fingerprint {
+			report.Unchanged = append(report.Unchanged, s.Name)
+			continue
+		}
+		after, err := decodeShard(s)
+		if err != nil {
+			return report, err
+		}
+		if !ok {
+			report.Shards = append(report.Shards, ShardDiff{Name: s.Name, Kind: ShardAdded, URLs: Diff(&empty, &after, opts)})
+			continue
+		}
+		before, err := decodeShard(prev)
+		if err != nil {
+			return report, err
+		}
+		d := Diff(&before, &after, opts)
+		if d.Empty() {
+			report.Unchanged = append(report.Unchanged, s.Name)
+			continue
+		}
+		report.Shards = append(report.Shards, ShardDiff{Name: s.Name, Kind: ShardChanged, URLs: d})
+	}
+	for _, s := range old {
+		if seen[s.Name] {
+			continue
+		}
+		before, err := decodeShard(s)
+		if err != nil {
+			return report, err
+		}
+		report.Shards = append(report.Shards, ShardDiff{Name: s.Name, Kind: ShardRemoved, URLs: Diff(&before, &empty, opts)})
+	}
+	return report, nil
+}
+
+// decodeShard decodes the shard's Data, decompressing it by the coding its
+// name's extension calls for.
+func decodeShard(s Shard) (URLSet, error) {
+	if s.Data == nil {
+		return URLSet{}, fmt.Errorf("%w: %s", ErrNoShardData, s.Name)
+	}
+	var r io.Reader = bytes.NewReader(s.Data)
+	if coding, ok := codingByExt(s.Name); ok && coding.NewReader != nil {
+		zr, err := coding.NewReader(r)
+		if err != nil {
+			return URLSet{}, fmt.Errorf("shard %s: %w", s.Name, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	set, err := decodeURLSet(r, -1, ParseOptions{MaxBytes: -1})
+	if err != nil {
+		return URLSet{}, fmt.Errorf("shard %s: %w", s.Name, err)
+	}
+	return set, nil
+}
@@ -0,0 +1,70 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDiffShards(t *testing.T) {
+	ctx := context.Background()
+	opts := ShardOptions{MaxURLs: 2, Gzip: true}
+	shards := func(set *URLSet) []Shard {
+		t.Helper()
+		out, err := set.GenerateShards(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	old := shards(numberedSet(t, 5, ""))
+
+	changed := numberedSet(t, 4, "")
+	changed.URLs[3].ChangeFreq = ChangeFreqDaily
+	report, err := DiffShards(old, shards(changed), CompareOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(report.Unchanged, []string{"sitemap-1.xml.gz"}) {
+		t.Errorf("unchanged = %q", report.Unchanged)
+	}
+	if len(report.Shards) != 2 {
+		t.Fatalf("shards = %+v", report.Shards)
+	}
+	if d := report.Shards[0]; d.Name != "sitemap-2.xml.gz" || d.Kind != ShardChanged || len(d.URLs.Changed) != 1 || d.URLs.Changed[0].Loc != "https://example.com/3" {
+		t.Errorf("changed shard = %+v", d)
+	}
+	if d := report.Shards[1]; d.Name != "sitemap-3.xml.gz" || d.Kind != ShardRemoved || !slices.Equal(d.URLs.Removed, []string{"https://example.com/4"}) {
+		t.Errorf("removed shard = %+v", d)
+	}
+	if names := report.Names(); !slices.Equal(names, []string{"sitemap-2.xml.gz", "sitemap-3.xml.gz"}) {
+		t.Errorf("names = %q", names)
+	}
+
+	// A shard whose only changes are ignored counts as unchanged.
+	report, err = DiffShards(old[:2], shards(changed), CompareOptions{Ignore: CompareChangeFreq})
+	if err != nil || len(report.Shards) != 0 || len(report.Unchanged) != 2 {
+		t.Errorf("ignoring changefreq: %+v, %v", report, err)
+	}
+
+	report, err = DiffShards(shards(numberedSet(t, 6, "")), shards(numberedSet(t, 7, "")), CompareOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Shards) != 1 || report.Shards[0].Kind != ShardAdded || !slices.Equal(report.Shards[0].URLs.Added, []string{"https://example.com/6"}) || len(report.Unchanged) != 3 {
+		t.Errorf("added: %+v", report)
+	}
+}
+
+func TestDiffShardsNoData(t *testing.T) {
+	old := []Shard{{Name: "sitemap-1.xml", Fingerprint: [32]byte{1}}}
+	current := []Shard{{Name: "sitemap-1.xml", Fingerprint: [32]byte{1}}}
+	if report, err := DiffShards(old, current, CompareOptions{}); err != nil || len(report.Unchanged) != 1 {
+		t.Errorf("equal fingerprints need no data: %+v, %v", report, err)
+	}
+	current[0].Fingerprint[0] = 2
+	if _, err := DiffShards(old, current, CompareOptions{}); !errors.Is(err, ErrNoShardData) {
+		t.Errorf("err = %v, want %v", err, ErrNoShardData)
+	}
+}
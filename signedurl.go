@@ -0,0 +1,161 @@
+package sitemap_go
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrSignedURL = errors.New("invalid signed URL")
+
+// maxS3URLExpiry is the longest validity S3 accepts for presigned URLs.
+const maxS3URLExpiry = 7 * 24 * time.Hour
+
+// URLSigner generates URLs that grant read access to a stored object
+// until they expire, for publishing to private buckets. Set one as
+// Publisher.Signer to list signed shard URLs in the index.
+type URLSigner interface {
+	SignURL(name string, expires time.Time) (string, error)
+}
+
+// S3URLSigner presigns GET URLs for objects in an S3 bucket. It works with
+// other services that accept SigV4 presigned URLs too, such as GCS with
+// HMAC keys: set Endpoint to "https://storage.googleapis.com/<bucket>" and
+// Region to "auto".
+type S3URLSigner struct {
+	Credentials AWSCredentials
+	Region      string
+	Bucket      string
+	// Endpoint defaults to the bucket's virtual-hosted endpoint,
+	// https://<bucket>.s3.<region>.amazonaws.com.
+	Endpoint string
+	// Prefix is prepended to every object name, as with PrefixStorage.
+	Prefix string
+}
+
+// SignURL presigns name for at most seven days, the longest S3 allows.
+func (s S3URLSigner) SignURL(name string, expires time.Time) (string, error) {
+	return s.signURL(name, expires, time.Now())
+}
+
+func (s S3URLSigner) signURL(name string, expires, now time.Time) (string, error) {
+	ttl := expires.Sub(now).Truncate(time.Second)
+	if ttl < time.Second || ttl > maxS3URLExpiry {
+		return "", fmt.Errorf("%w: expiry %s is not within 1s and 7 days", ErrSignedURL, ttl)
+	}
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://" + s.Bucket + ".s3." + s.Region + ".amazonaws.com"
+	}
+	// S3 wants every byte but the unreserved ones escaped in the path.
+	segments := strings.Split(s.Prefix+name, "/")
+	for i, seg := range segments {
+		segments[i] = strings.ReplaceAll(url.QueryEscape(seg), "+", "%20")
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/") + "/" + strings.Join(segments, "/"))
+	if err != nil {
+		return "", err
+	}
+	presignAWSV4(u, s.Credentials, s.Region, "s3", now, ttl)
+	return u.String(), nil
+}
+
+// HMACURLSigner signs URLs for sitemaps served by this package's own
+// handlers, such as a StorageHandler over a private bucket, with an
+// expiry and an HMAC-SHA256 signature of the object name in the query.
+// Wrap the handler with Verify to enforce them.
+type HMACURLSigner struct {
+	// BaseURL is the URL the handler serves objects under.
+	BaseURL string
+	Key     []byte
+}
+
+func (s HMACURLSigner) SignURL(name string, expires time.Time) (string, error) {
+	if len(s.Key) == 0 {
+		return "", fmt.Errorf("%w: no signing key", ErrSignedURL)
+	}
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{"expires": {exp}, "signature": {s.signature(name, exp)}}
+	return strings.TrimSuffix(s.BaseURL, "/") + "/" + escapeBlobName(name) + "?" + query.Encode(), nil
+}
+
+func (s HMACURLSigner) signature(name, expires string) string {
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(name + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify serves requests carrying a valid, unexpired signature with next
+// and refuses the others with 403 Forbidden. Request paths are taken as
+// object names, so mount it under http.StripPrefix when BaseURL has a
+// path.
+func (s HMACURLSigner) Verify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := s.check(strings.TrimPrefix(req.URL.Path, "/"), req.URL.Query(), time.Now()); err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+func (s HMACURLSigner) check(name string, query url.Values, now time.Time) error {
+	exp := query.Get("expires")
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad expiry %q", ErrSignedURL, exp)
+	}
+	if len(s.Key) == 0 || !hmac.Equal([]byte(query.Get("signature")), []byte(s.signature(name, exp))) {
+		return fmt.Errorf("%w: bad signature", ErrSignedURL)
+	}
+	if now.Unix() >= unix {
+		return fmt.Errorf("%w: expired at %s", ErrSignedURL, time.Unix(unix, 0).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// SignURL generates a read-only service SAS for the blob. It needs the
+// AccountKey; a SASToken cannot be used to sign others.
+func (s *AzureBlobStorage) SignURL(name string, expires time.Time) (string, error) {
+	if s.AccountKey == "" {
+		return "", fmt.Errorf("%w: azure signing needs the account key", ErrSignedURL)
+	}
+	key, err := base64.StdEncoding.DecodeString(s.AccountKey)
+	if err != nil {
+		return "", fmt.Errorf("azure account key: %w", err)
+	}
+	expiry := expires.UTC().Format("2006-01-02T15:04:05Z")
+	stringToSign := strings.Join([]string{
+		"r",    // signedPermissions
+		"",     // signedStart
+		expiry, // signedExpiry
+		"/blob/" + s.Account + "/" + s.Container + "/" + name,
+		"",      // signedIdentifier
+		"",      // signedIP
+		"https", // signedProtocol
+		azureStorageVersion,
+		"b",                // signedResource
+		"",                 // signedSnapshotTime
+		"",                 // signedEncryptionScope
+		"", "", "", "", "", // rscc, rscd, rsce, rscl, rsct
+	}, "\n")
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	query := url.Values{
+		"sv":  {azureStorageVersion},
+		"sr":  {"b"},
+		"sp":  {"r"},
+		"se":  {expiry},
+		"spr": {"https"},
+		"sig": {base64.StdEncoding.EncodeToString(mac.Sum(nil))},
+	}
+	return s.blobURL(name) + "?" + query.Encode(), nil
+}
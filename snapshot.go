@@ -0,0 +1,188 @@
+package sitemap_go
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	snapshotIDLayout = "20060102T150405.000000000Z"
+	snapshotExt      = ".xml.gz"
+)
+
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// RetentionPolicy bounds how many snapshots a SnapshotStore keeps. Zero
+// fields are unlimited. The newest snapshot is always kept.
+type RetentionPolicy struct {
+	MaxCount int
+	MaxAge   time.Duration
+}
+
+type Snapshot struct {
+	// ID is the snapshot's UTC timestamp and sorts chronologically.
+	ID   string
+	Time time.Time
+	// Size is the compressed size in bytes.
+	Size int64
+}
+
+// SnapshotStore archives generated sitemaps as gzipped, timestamped files
+// in Dir so earlier versions can be diffed against or restored.
+type SnapshotStore struct {
+	Dir       string
+	Retention RetentionPolicy
+}
+
+// Save archives set and then prunes the store according to its retention
+// policy.
+func (s *SnapshotStore) Save(set *URLSet) (Snapshot, error) {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return Snapshot{}, err
+	}
+	now := time.Now().UTC()
+	snap := Snapshot{ID: now.Format(snapshotIDLayout), Time: now}
+
+	tmp, err := os.CreateTemp(s.Dir, "snapshot-*.tmp")
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer os.Remove(tmp.Name())
+	zw := gzip.NewWriter(tmp)
+	err = set.Encode(zw, EncodeOptions{})
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		snap.Size, err = tmp.Seek(0, io.SeekCurrent)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if err := os.Rename(tmp.Name(), s.path(snap.ID)); err != nil {
+		return Snapshot{}, err
+	}
+
+	_, err = s.Prune(now)
+	return snap, err
+}
+
+// List returns the stored snapshots, oldest first.
+func (s *SnapshotStore) List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []Snapshot
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), snapshotExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		t, err := time.Parse(snapshotIDLayout, id)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Snapshot{ID: id, Time: t, Size: info.Size()})
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].ID < out[b].ID })
+	return out, nil
+}
+
+func (s *SnapshotStore) Latest() (Snapshot, error) {
+	snaps, err := s.List()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if len(snaps) == 0 {
+		return Snapshot{}, ErrSnapshotNotFound
+	}
+	return snaps[len(snaps)-1], nil
+}
+
+// Open returns the decompressed XML of the snapshot with the given ID,
+// byte-for-byte as it was generated.
+func (s *SnapshotStore) Open(id string) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &snapshotReader{Reader: zr, file: f}, nil
+}
+
+// Load parses the snapshot with the given ID.
+func (s *SnapshotStore) Load(id string) (URLSet, error) {
+	r, err := s.Open(id)
+	if err != nil {
+		return URLSet{}, err
+	}
+	defer r.Close()
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return URLSet{}, err
+	}
+	return ParseXMLUrlSet(string(body))
+}
+
+// Prune deletes the snapshots that fall outside the retention policy as of
+// now and returns them.
+func (s *SnapshotStore) Prune(now time.Time) ([]Snapshot, error) {
+	snaps, err := s.List()
+	if err != nil || len(snaps) <= 1 {
+		return nil, err
+	}
+	var removed []Snapshot
+	for i, snap := range snaps[:len(snaps)-1] {
+		tooMany := s.Retention.MaxCount > 0 && len(snaps)-i > s.Retention.MaxCount
+		tooOld := s.Retention.MaxAge > 0 && now.Sub(snap.Time) > s.Retention.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.Remove(s.path(snap.ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed = append(removed, snap)
+	}
+	return removed, nil
+}
+
+func (s *SnapshotStore) path(id string) string {
+	return filepath.Join(s.Dir, filepath.Base(id)+snapshotExt)
+}
+
+type snapshotReader struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (r *snapshotReader) Close() error {
+	err := r.Reader.Close()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
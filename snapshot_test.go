@@ -0,0 +1,74 @@
+package sitemap_go
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSnapshotStore(t *testing.T) {
+	s := &SnapshotStore{Dir: filepath.Join(t.TempDir(), "snapshots")}
+	if _, err := s.Latest(); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("empty store: err = %v", err)
+	}
+	first, err := s.Save(setOf(t, "https://example.com/a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Save(setOf(t, "https://example.com/a", "https://example.com/b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snaps, err := s.List()
+	if err != nil || len(snaps) != 2 || snaps[0].ID != first.ID || snaps[1].ID != second.ID {
+		t.Fatalf("List = %+v, %v", snaps, err)
+	}
+	if latest, _ := s.Latest(); latest.ID != second.ID {
+		t.Errorf("Latest = %s, want %s", latest.ID, second.ID)
+	}
+	set, err := s.Load(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(locsOf(&set), []string{"https://example.com/a"}) {
+		t.Errorf("Load = %q", locsOf(&set))
+	}
+	if _, err := s.Open("20000101T000000.000000000Z"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("Open missing: err = %v", err)
+	}
+	// Stray files in the directory are ignored.
+	os.WriteFile(filepath.Join(s.Dir, "notes.txt"), nil, 0o644)
+	if snaps, _ := s.List(); len(snaps) != 2 {
+		t.Errorf("List picked up %d snapshots", len(snaps))
+	}
+}
+
+func TestSnapshotRetention(t *testing.T) {
+	dir := t.TempDir()
+	s := &SnapshotStore{Dir: dir}
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	for day := 1; day <= 5; day++ {
+		id := now.AddDate(0, 0, day-10).Format(snapshotIDLayout)
+		if err := os.WriteFile(s.path(id), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Retention = RetentionPolicy{MaxCount: 4}
+	if removed, err := s.Prune(now); err != nil || len(removed) != 1 {
+		t.Errorf("MaxCount: removed %v, %v", removed, err)
+	}
+	s.Retention = RetentionPolicy{MaxAge: 7 * 24 * time.Hour}
+	removed, err := s.Prune(now)
+	if err != nil || len(removed) != 1 {
+		t.Errorf("MaxAge: removed %v, %v", removed, err)
+	}
+	// The newest snapshot survives even when it is too old.
+	s.Retention = RetentionPolicy{MaxAge: time.Hour, MaxCount: 1}
+	s.Prune(now)
+	if snaps, _ := s.List(); len(snaps) != 1 || !snaps[0].Time.Equal(now.AddDate(0, 0, -5)) {
+		t.Errorf("kept %+v", snaps)
+	}
+}
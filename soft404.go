@@ -0,0 +1,139 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// DefaultSoft404Patterns match the titles and headings of typical error
+// pages served with a 200 status.
+var DefaultSoft404Patterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bnot found\b`),
+	regexp.MustCompile(`(?i)\b404\b`),
+	regexp.MustCompile(`(?i)\bno longer (available|exists)\b`),
+	regexp.MustCompile(`(?i)\b(doesn't|does not) exist\b`),
+}
+
+// Soft404Rules configure the heuristics that flag soft 404s: pages that
+// answer 200 but are really error pages, which waste crawl budget without
+// ever surfacing as errors. Only HTML pages are examined.
+type Soft404Rules struct {
+	// MinBytes flags pages whose body is smaller. It defaults to 512; a
+	// negative value disables the check.
+	MinBytes int
+	// Patterns flag pages whose <title> or <h1> text matches one. They
+	// default to DefaultSoft404Patterns.
+	Patterns []*regexp.Regexp
+	// AllowCanonicalToHome disables flagging pages other than the home page
+	// whose rel="canonical" points to the home page.
+	AllowCanonicalToHome bool
+}
+
+type LinkCheckOptions struct {
+	// Soft404 enables soft 404 detection, which fetches the body of every
+	// page that answers 200.
+	Soft404 *Soft404Rules
+}
+
+// soft404 fetches loc and returns why it looks like a soft 404, or "".
+func (f *Fetcher) soft404(ctx context.Context, loc string, rules *Soft404Rules) (string, error) {
+	if err := waitForHost(ctx, f.RateLimiter, loc); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return "", err
+	}
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+	resp, err := clientOrBulk(f.HTTPClient).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK ||
+		!strings.Contains(strings.ToLower(resp.Header.Get("Content-Type")), "html") {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+		return "", nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCrawlBodyBytes))
+	if err != nil {
+		return "", err
+	}
+
+	if minBytes := orDefaultInt(rules.MinBytes, 512); rules.MinBytes >= 0 && len(bytes.TrimSpace(body)) < minBytes {
+		return "small body", nil
+	}
+	patterns := rules.Patterns
+	if patterns == nil {
+		patterns = DefaultSoft404Patterns
+	}
+	for _, text := range []string{elementText(body, "title"), elementText(body, "h1")} {
+		for _, p := range patterns {
+			if text != "" && p.MatchString(text) {
+				return "not found text", nil
+			}
+		}
+	}
+	if !rules.AllowCanonicalToHome {
+		page := crawledPage{requested: resp.Request.URL, final: resp.Request.URL, tags: scanHTML(body, "base", "link")}
+		canonical := pageCanonical(page)
+		if canonical != nil && isHomePath(canonical.Path) && !isHomePath(page.final.Path) &&
+			strings.EqualFold(canonical.Host, page.final.Host) {
+			return "canonical to home page", nil
+		}
+	}
+	return "", nil
+}
+
+// elementText returns the text of the first name element of doc, with
+// nested tags removed and whitespace collapsed.
+func elementText(doc []byte, name string) string {
+	lower := bytes.ToLower(doc)
+	start := bytes.Index(lower, []byte("<"+name))
+	for start >= 0 {
+		next := start + len(name) + 1
+		if next < len(lower) && (lower[next] == '>' || isHTMLSpace(lower[next])) {
+			break
+		}
+		i := bytes.Index(lower[next:], []byte("<"+name))
+		if i < 0 {
+			return ""
+		}
+		start = next + i
+	}
+	if start < 0 {
+		return ""
+	}
+	open := bytes.IndexByte(lower[start:], '>')
+	if open < 0 {
+		return ""
+	}
+	content := doc[start+open+1:]
+	if end := bytes.Index(lower[start+open+1:], []byte("</"+name)); end >= 0 {
+		content = content[:end]
+	}
+	var b strings.Builder
+	inTag := false
+	for _, c := range content {
+		switch {
+		case c == '<':
+			inTag = true
+			b.WriteByte(' ')
+		case c == '>':
+			inTag = false
+		case !inTag:
+			b.WriteByte(c)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func isHomePath(path string) bool {
+	return path == "" || path == "/"
+}
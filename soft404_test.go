@@ -0,0 +1,78 @@
+package sitemap_go
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSoft404(t *testing.T) {
+	filler := "<p>" + strings.Repeat("lorem ipsum ", 60) + "</p>"
+	srv := siteServer(t, map[string]string{
+		"/":         "<title>Home</title>" + filler,
+		"/ok":       "<title>Products</title><h1>404 products in stock</h1>" + filler,
+		"/small":    "<title>Empty</title>",
+		"/title":    "<title>Page Not Found | Shop</title>" + filler,
+		"/heading":  "<title>Shop</title><h1 class=x>Sorry, this page <em>no longer exists</em></h1>" + filler,
+		"/canon":    `<link rel="canonical" href="{site}/"><title>Shop</title>` + filler,
+		"/selfhome": `<link rel="canonical" href="{site}/ok"><title>Shop</title>` + filler,
+	})
+	f := testFetcher(srv)
+	ctx := context.Background()
+	check := func(rules Soft404Rules, paths ...string) []string {
+		t.Helper()
+		var locs []string
+		for _, p := range paths {
+			locs = append(locs, srv.URL+p)
+		}
+		var reasons []string
+		for _, s := range f.CheckLinksWithOptions(ctx, setOf(t, locs...).URLs, LinkCheckOptions{Soft404: &rules}) {
+			if s.Err != nil {
+				t.Fatalf("%s: %v", s.Loc, s.Err)
+			}
+			reasons = append(reasons, s.Soft404)
+		}
+		return reasons
+	}
+
+	got := check(Soft404Rules{}, "/", "/ok", "/small", "/title", "/heading", "/canon", "/selfhome")
+	want := []string{"", "not found text", "small body", "not found text", "not found text", "canonical to home page", ""}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("default rules: got %q, want %q", got, want)
+	}
+
+	got = check(Soft404Rules{MinBytes: -1, Patterns: []*regexp.Regexp{regexp.MustCompile(`(?i)not found`)}, AllowCanonicalToHome: true}, "/ok", "/small", "/title", "/canon")
+	want = []string{"", "", "not found text", ""}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("custom rules: got %q, want %q", got, want)
+	}
+
+	if s := f.CheckLinks(ctx, setOf(t, srv.URL+"/small").URLs)[0]; s.Soft404 != "" {
+		t.Errorf("checked without rules: %+v", s)
+	}
+
+	store := &MemoryStore{}
+	store.Upsert(ctx, &URL{Loc: srv.URL + "/title"})
+	report, err := (&LinkPruner{Store: store, Fetcher: f, Threshold: 1, Soft404: &Soft404Rules{}}).Audit(ctx)
+	if err != nil || len(report.Soft404) != 1 || len(report.Pruned) != 0 || len(snapshotLocs(t, store)) != 1 {
+		t.Errorf("pruner: report %+v, %v", report, err)
+	}
+}
+
+func TestElementText(t *testing.T) {
+	tests := []struct {
+		doc, name, want string
+	}{
+		{"<TITLE>  Not\n Found </TITLE>", "title", "Not Found"},
+		{"<titlebar>x</titlebar><title>Shop</title>", "title", "Shop"},
+		{"<h1 id=a>Hello <b>world</b></h1>", "h1", "Hello world"},
+		{"<h1>unterminated", "h1", "unterminated"},
+		{"<p>none</p>", "h1", ""},
+	}
+	for _, tt := range tests {
+		if got := elementText([]byte(tt.doc), tt.name); got != tt.want {
+			t.Errorf("elementText(%q, %q) = %q, want %q", tt.doc, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package sitemap_go
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Source is one named input of a MultiSource, such as the product catalog
+// or the blog.
+type Source struct {
+	Name string
+	Load func(ctx context.Context) ([]*URL, error)
+}
+
+// SourcePolicy decides what a MultiSource does when a source fails.
+type SourcePolicy int
+
+const (
+	// FailOnSourceError fails the whole generation.
+	FailOnSourceError SourcePolicy = iota
+	// SkipFailedSources leaves the failed source's URLs out.
+	SkipFailedSources
+	// StaleOnSourceError uses the URLs of the source's last successful
+	// load, or leaves them out if it never loaded.
+	StaleOnSourceError
+)
+
+type SourceFailure struct {
+	Source string
+	Err    error
+	// Stale is set when the URLs from the load at StaleSince were used
+	// instead.
+	Stale      bool
+	StaleSince time.Time
+}
+
+func (f SourceFailure) Error() string {
+	return fmt.Sprintf("source %s: %v", f.Source, f.Err)
+}
+
+func (f SourceFailure) Unwrap() error {
+	return f.Err
+}
+
+// SourceReport lists the sources that failed in a degraded generation.
+type SourceReport struct {
+	Failures []SourceFailure
+}
+
+func (r SourceReport) Degraded() bool {
+	return len(r.Failures) > 0
+}
+
+// MultiSource generates one set from several sources loaded concurrently,
+// with their URLs in source order.
+type MultiSource struct {
+	Sources []Source
+	Policy  SourcePolicy
+	// Template supplies the namespaces and settings of the generated set.
+	// It defaults to MakeUrlSet().
+	Template *URLSet
+	// OnDegraded is called by Generate when a set is produced despite
+	// failed sources.
+	OnDegraded func(SourceReport)
+
+	mu       sync.Mutex
+	lastGood map[string]loadedSource
+}
+
+type loadedSource struct {
+	urls []*URL
+	at   time.Time
+}
+
+// Generate is Load reporting degraded runs to OnDegraded, for use as a
+// Scheduler's Generate step.
+func (m *MultiSource) Generate(ctx context.Context) (*URLSet, error) {
+	set, report, err := m.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if report.Degraded() && m.OnDegraded != nil {
+		m.OnDegraded(report)
+	}
+	return set, nil
+}
+
+// Load loads every source and merges their URLs. Under FailOnSourceError
+// the first failure, in source order, is returned as a SourceFailure;
+// otherwise failures only appear in the report, unless every source failed
+// with nothing stale to fall back on.
+func (m *MultiSource) Load(ctx context.Context) (*URLSet, SourceReport, error) {
+	type loaded struct {
+		urls []*URL
+		err  error
+	}
+	results := make([]loaded, len(m.Sources))
+	var wg sync.WaitGroup
+	for i, src := range m.Sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			urls, err := src.Load(ctx)
+			results[i] = loaded{urls, err}
+		}()
+	}
+	wg.Wait()
+
+	var report SourceReport
+	failed := 0
+	set := ShardOptions{Template: m.Template}.template()
+	now := time.Now().UTC()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, src := range m.Sources {
+		res := results[i]
+		if res.err == nil {
+			if m.lastGood == nil {
+				m.lastGood = map[string]loadedSource{}
+			}
+			m.lastGood[src.Name] = loadedSource{urls: res.urls, at: now}
+			set.URLs = append(set.URLs, res.urls...)
+			continue
+		}
+
+		failure := SourceFailure{Source: src.Name, Err: res.err}
+		switch m.Policy {
+		case FailOnSourceError:
+			return nil, report, failure
+		case StaleOnSourceError:
+			if last, ok := m.lastGood[src.Name]; ok {
+				failure.Stale, failure.StaleSince = true, last.at
+				set.URLs = append(set.URLs, last.urls...)
+			}
+		}
+		if !failure.Stale {
+			failed++
+		}
+		report.Failures = append(report.Failures, failure)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, report, err
+	}
+	if failed > 0 && failed == len(m.Sources) {
+		return nil, report, report.Failures[0]
+	}
+	return &set, report, nil
+}
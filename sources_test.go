@@ -0,0 +1,95 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// staticSource loads the URLs of locs, or fails with errSource while *fail
+// is set.
+func staticSource(t *testing.T, name string, fail *bool, locs ...string) Source {
+	set := setOf(t, locs...)
+	return Source{Name: name, Load: func(ctx context.Context) ([]*URL, error) {
+		if fail != nil && *fail {
+			return nil, errSource
+		}
+		return set.URLs, nil
+	}}
+}
+
+func TestMultiSource(t *testing.T) {
+	ctx := context.Background()
+	failBlog := false
+	m := &MultiSource{Sources: []Source{
+		staticSource(t, "catalog", nil, "https://example.com/p/1", "https://example.com/p/2"),
+		staticSource(t, "blog", &failBlog, "https://example.com/blog/1"),
+	}}
+	set, report, err := m.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"https://example.com/p/1", "https://example.com/p/2", "https://example.com/blog/1"}; !slices.Equal(locsOf(set), want) {
+		t.Errorf("got %q, want %q", locsOf(set), want)
+	}
+	if report.Degraded() {
+		t.Errorf("report = %+v", report)
+	}
+
+	failBlog = true
+	_, _, err = m.Load(ctx)
+	var failure SourceFailure
+	if !errors.As(err, &failure) || failure.Source != "blog" || !errors.Is(err, errSource) {
+		t.Errorf("FailOnSourceError: err = %v", err)
+	}
+
+	m.Policy = SkipFailedSources
+	set, report, err = m.Load(ctx)
+	if err != nil || len(set.URLs) != 2 || len(report.Failures) != 1 || report.Failures[0].Stale {
+		t.Errorf("SkipFailedSources: %v, %d URLs, report %+v", err, len(set.URLs), report)
+	}
+
+	m.Policy = StaleOnSourceError
+	set, report, err = m.Load(ctx)
+	if err != nil || len(set.URLs) != 3 {
+		t.Fatalf("StaleOnSourceError: %v, %d URLs", err, len(set.URLs))
+	}
+	if f := report.Failures[0]; !f.Stale || f.StaleSince.IsZero() {
+		t.Errorf("stale failure = %+v", f)
+	}
+}
+
+func TestMultiSourceAllFailed(t *testing.T) {
+	fail := true
+	for _, policy := range []SourcePolicy{SkipFailedSources, StaleOnSourceError} {
+		m := &MultiSource{Policy: policy, Sources: []Source{
+			staticSource(t, "a", &fail, "https://example.com/a"),
+			staticSource(t, "b", &fail, "https://example.com/b"),
+		}}
+		if _, _, err := m.Load(context.Background()); !errors.Is(err, errSource) {
+			t.Errorf("policy %d: err = %v, want %v", policy, err, errSource)
+		}
+	}
+}
+
+func TestMultiSourceGenerate(t *testing.T) {
+	fail := true
+	var reports []SourceReport
+	m := &MultiSource{
+		Policy:     SkipFailedSources,
+		Sources:    []Source{staticSource(t, "a", nil, "https://example.com/a"), staticSource(t, "b", &fail)},
+		OnDegraded: func(r SourceReport) { reports = append(reports, r) },
+	}
+	ctx := context.Background()
+	if _, err := m.Generate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	fail = false
+	if _, err := m.Generate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].Failures[0].Source != "b" {
+		t.Errorf("reports = %+v", reports)
+	}
+}
@@ -0,0 +1,210 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ObjectInfo describes a stored object being read back.
+type ObjectInfo struct {
+	Meta ObjectMeta
+	// Size is the stored byte size, or -1 when unknown.
+	Size    int64
+	ModTime time.Time
+	ETag    string
+}
+
+// ObjectReader is a storage backend published objects can be read back
+// from. Open fails with an error wrapping fs.ErrNotExist for missing
+// objects.
+type ObjectReader interface {
+	Open(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error)
+}
+
+// Open reads the file back, guessing its metadata from the name as a
+// Publisher would have set it.
+func (s DirStorage) Open(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error) {
+	f, err := os.Open(filepath.Join(s.Dir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	st, err := f.Stat()
+	if err == nil && st.IsDir() {
+		err = fmt.Errorf("%s is a directory: %w", name, fs.ErrNotExist)
+	}
+	if err != nil {
+		f.Close()
+		return nil, ObjectInfo{}, err
+	}
+	return f, ObjectInfo{Meta: nameMeta(name), Size: st.Size(), ModTime: st.ModTime()}, nil
+}
+
+func (s PrefixStorage) Open(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error) {
+	r, ok := s.Storage.(ObjectReader)
+	if !ok {
+		return nil, ObjectInfo{}, fmt.Errorf("%w: %T cannot read", ErrUnsupportedStorage, s.Storage)
+	}
+	return r.Open(ctx, s.Prefix+name)
+}
+
+// HTTPOrigin reads objects from a bucket or CDN served over HTTP, such as a
+// public S3 or GCS bucket, by appending their names to BaseURL.
+type HTTPOrigin struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func (o HTTPOrigin) Open(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(o.BaseURL, "/")+"/"+escapeBlobName(name), nil)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	// Asking for the codings explicitly keeps the transport from decoding
+	// objects stored compressed, so they can be passed on as they are.
+	req.Header.Set("Accept-Encoding", acceptEncoding())
+	client := o.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	return openedObject(resp, "origin", name)
+}
+
+// openedObject returns the body and metadata of a successful GET of a
+// stored object, closing the response otherwise.
+func openedObject(resp *http.Response, op, name string) (io.ReadCloser, ObjectInfo, error) {
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ObjectInfo{}, fmt.Errorf("%s get %s: %w", op, name, fs.ErrNotExist)
+	case resp.StatusCode != http.StatusOK:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, ObjectInfo{}, fmt.Errorf("%s get %s: unexpected status %s: %s", op, name, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	info := ObjectInfo{
+		Meta: ObjectMeta{
+			ContentType:     resp.Header.Get("Content-Type"),
+			ContentEncoding: resp.Header.Get("Content-Encoding"),
+			CacheControl:    resp.Header.Get("Cache-Control"),
+		},
+		Size: -1,
+		ETag: resp.Header.Get("ETag"),
+	}
+	if n, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+		info.Size = n
+	}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = t
+	}
+	return resp.Body, info, nil
+}
+
+// StorageHandler serves published sitemap files straight from the storage
+// backend they were published to, so sitemaps kept in object storage can
+// be served from the site's own domain. Files are streamed rather than
+// buffered, with the stored caching headers and conditional request
+// support.
+type StorageHandler struct {
+	Storage ObjectReader
+	// CacheControl is sent for objects stored without one. It defaults to
+	// "public, max-age=3600".
+	CacheControl string
+}
+
+func (h *StorageHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(req.URL.Path, "/")
+	if !fs.ValidPath(name) || name == "." {
+		http.NotFound(w, req)
+		return
+	}
+	body, info, err := h.Storage.Open(req.Context(), name)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, req)
+		return
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+	defer body.Close()
+
+	// Clients that do not accept the stored encoding get the object
+	// decoded on the fly, a different representation under its own tag.
+	var r io.Reader = body
+	coding := info.Meta.ContentEncoding
+	if coding != "" {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptedCodings(req.Header.Get("Accept-Encoding"))(coding) {
+			if decoded, err := decodeContent(body, coding); err == nil {
+				r, coding, info.Size = decoded, "", -1
+				if info.ETag != "" {
+					info.ETag = strings.TrimSuffix(info.ETag, `"`) + `-identity"`
+				}
+			}
+		}
+	}
+
+	contentType := info.Meta.ContentType
+	if contentType == "" || contentType == "application/xml" {
+		contentType = "application/xml; charset=utf-8"
+	}
+	w.Header().Set("Cache-Control", orDefault(info.Meta.CacheControl, orDefault(h.CacheControl, "public, max-age=3600")))
+	if info.ETag != "" {
+		w.Header().Set("ETag", info.ETag)
+	}
+	if !info.ModTime.IsZero() {
+		w.Header().Set("Last-Modified", info.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if notModified(req, info.ETag, info.ModTime) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	if coding != "" {
+		w.Header().Set("Content-Encoding", coding)
+	}
+	if info.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	}
+	if req.Method == http.MethodHead {
+		return
+	}
+	io.Copy(w, r)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when it is
+// absent, against the object served.
+func notModified(req *http.Request, etag string, modTime time.Time) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" {
+			return false
+		}
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	return err == nil && !modTime.IsZero() && !modTime.Truncate(time.Second).After(since)
+}
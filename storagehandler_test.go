@@ -0,0 +1,169 @@
+package sitemap_go
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStorageHandlerDir(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	storage := DirStorage{Dir: dir}
+	xml := "<urlset><url><loc>https://example.com/a</loc></url></urlset>"
+	compressed := gzipped(t, func(w io.Writer) { io.WriteString(w, xml) })
+	for name, data := range map[string][]byte{"sitemap.xml": []byte(xml), "sitemap-1.xml.gz": compressed, "sitemap.xsl": []byte("<xsl/>")} {
+		if err := storage.Put(ctx, name, data, nameMeta(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	h := &StorageHandler{Storage: storage}
+
+	rec := serve(t, h, "GET", "", "/sitemap.xml", nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != xml {
+		t.Fatalf("status %d:\n%s", rec.Code, rec.Body)
+	}
+	header := rec.Header()
+	if header.Get("Content-Type") != "application/xml; charset=utf-8" || header.Get("Cache-Control") != "public, max-age=3600" || header.Get("Content-Length") != strconv.Itoa(len(xml)) {
+		t.Errorf("headers %v", header)
+	}
+	lastMod := header.Get("Last-Modified")
+	if rec := serve(t, h, "GET", "", "/sitemap.xml", http.Header{"If-Modified-Since": {lastMod}}); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("If-Modified-Since: status %d", rec.Code)
+	}
+	if rec := serve(t, h, "HEAD", "", "/sitemap.xml", nil); rec.Code != http.StatusOK || rec.Body.Len() != 0 || rec.Header().Get("Content-Length") != strconv.Itoa(len(xml)) {
+		t.Errorf("HEAD: status %d, %d bytes", rec.Code, rec.Body.Len())
+	}
+	if rec := serve(t, h, "GET", "", "/sitemap.xsl", nil); rec.Header().Get("Content-Type") != "text/xsl" {
+		t.Errorf("xsl Content-Type = %q", rec.Header().Get("Content-Type"))
+	}
+
+	rec = serve(t, h, "GET", "", "/sitemap-1.xml.gz", http.Header{"Accept-Encoding": {"gzip"}})
+	if rec.Header().Get("Content-Encoding") != "gzip" || rec.Body.String() != string(compressed) || rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("gzip: headers %v", rec.Header())
+	}
+	rec = serve(t, h, "GET", "", "/sitemap-1.xml.gz", nil)
+	if rec.Header().Get("Content-Encoding") != "" || rec.Header().Get("Content-Length") != "" || rec.Body.String() != xml {
+		t.Errorf("identity: headers %v\n%s", rec.Header(), rec.Body)
+	}
+
+	h.CacheControl = "no-cache"
+	if rec := serve(t, h, "GET", "", "/sitemap.xml", nil); rec.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("Cache-Control = %q", rec.Header().Get("Cache-Control"))
+	}
+
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{"GET", "/missing.xml", http.StatusNotFound},
+		{"GET", "/sub", http.StatusNotFound},
+		{"GET", "/", http.StatusNotFound},
+		{"GET", "/a//b.xml", http.StatusNotFound},
+		{"POST", "/sitemap.xml", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if rec := serve(t, h, tt.method, "", tt.path, nil); rec.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.path, rec.Code, tt.status)
+		}
+	}
+}
+
+func TestStorageHandlerOrigin(t *testing.T) {
+	modTime := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	compressed := gzipped(t, func(w io.Writer) { io.WriteString(w, "<urlset/>") })
+	var gotAccept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bucket/sitemap.xml.gz":
+			gotAccept = r.Header.Get("Accept-Encoding")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("ETag", `"abc"`)
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+			w.Write(compressed)
+		case "/bucket/denied.xml":
+			http.Error(w, "AccessDenied", http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	h := &StorageHandler{Storage: HTTPOrigin{BaseURL: srv.URL + "/bucket/", HTTPClient: srv.Client()}}
+
+	rec := serve(t, h, "GET", "", "/sitemap.xml.gz", http.Header{"Accept-Encoding": {"gzip"}})
+	if rec.Code != http.StatusOK || rec.Body.String() != string(compressed) {
+		t.Fatalf("status %d, %d bytes", rec.Code, rec.Body.Len())
+	}
+	if !strings.Contains(gotAccept, "gzip") {
+		t.Errorf("origin asked with Accept-Encoding %q", gotAccept)
+	}
+	header := rec.Header()
+	if header.Get("ETag") != `"abc"` || header.Get("Cache-Control") != "max-age=60" || header.Get("Content-Encoding") != "gzip" {
+		t.Errorf("headers %v", header)
+	}
+	if header.Get("Last-Modified") != modTime.Format(http.TimeFormat) {
+		t.Errorf("Last-Modified = %q", header.Get("Last-Modified"))
+	}
+
+	rec = serve(t, h, "GET", "", "/sitemap.xml.gz", nil)
+	if rec.Body.String() != "<urlset/>" || rec.Header().Get("ETag") != `"abc-identity"` {
+		t.Errorf("identity: ETag %q:\n%s", rec.Header().Get("ETag"), rec.Body)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		header http.Header
+		status int
+	}{
+		{"if-none-match", "/sitemap.xml.gz", http.Header{"Accept-Encoding": {"gzip"}, "If-None-Match": {`W/"other", "abc"`}}, http.StatusNotModified},
+		{"if-none-match identity", "/sitemap.xml.gz", http.Header{"If-None-Match": {`"abc"`}}, http.StatusOK},
+		{"if-none-match wins", "/sitemap.xml.gz", http.Header{"Accept-Encoding": {"gzip"}, "If-None-Match": {`"other"`}, "If-Modified-Since": {modTime.Format(http.TimeFormat)}}, http.StatusOK},
+		{"if-modified-since", "/sitemap.xml.gz", http.Header{"Accept-Encoding": {"gzip"}, "If-Modified-Since": {modTime.Format(http.TimeFormat)}}, http.StatusNotModified},
+		{"modified since", "/sitemap.xml.gz", http.Header{"Accept-Encoding": {"gzip"}, "If-Modified-Since": {modTime.Add(-time.Second).Format(http.TimeFormat)}}, http.StatusOK},
+		{"missing", "/missing.xml", nil, http.StatusNotFound},
+		{"origin error", "/denied.xml", nil, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		if rec := serve(t, h, "GET", "", tt.path, tt.header); rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.status)
+		}
+	}
+
+	_, _, err := h.Storage.Open(context.Background(), "denied.xml")
+	if err == nil || errors.Is(err, fs.ErrNotExist) || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestPrefixStorageOpen(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	if err := (DirStorage{Dir: dir}).Put(ctx, "de/sitemap.xml", []byte("<urlset/>"), ObjectMeta{}); err != nil {
+		t.Fatal(err)
+	}
+	r, info, err := PrefixStorage{Storage: DirStorage{Dir: dir}, Prefix: "de/"}.Open(ctx, "sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if data, _ := io.ReadAll(r); string(data) != "<urlset/>" || info.Size != 9 || info.Meta.ContentType != "application/xml" {
+		t.Errorf("got %q, info %+v", data, info)
+	}
+	if _, _, err := (PrefixStorage{Storage: &memStorage{}}).Open(ctx, "sitemap.xml"); !errors.Is(err, ErrUnsupportedStorage) {
+		t.Errorf("err = %v, want %v", err, ErrUnsupportedStorage)
+	}
+}
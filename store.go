@@ -0,0 +1,61 @@
+package sitemap_go
+
+import (
+	"context"
+	"sync"
+)
+
+// URLStore holds the URLs of a sitemap that is maintained incrementally:
+// application code upserts and deletes entries as content changes, and a
+// generator periodically snapshots the store and publishes the result.
+type URLStore interface {
+	// Upsert adds u or replaces the entry with the same loc.
+	Upsert(ctx context.Context, u *URL) error
+	// Delete removes the entry for loc. Deleting a missing loc is not an
+	// error.
+	Delete(ctx context.Context, loc string) error
+	// Snapshot returns every entry, ordered by loc.
+	Snapshot(ctx context.Context) ([]*URL, error)
+}
+
+// MemoryStore is a URLStore held in memory.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	urls map[string]*URL
+}
+
+func (s *MemoryStore) Upsert(ctx context.Context, u *URL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.urls == nil {
+		s.urls = map[string]*URL{}
+	}
+	s.urls[u.Loc] = u.Clone()
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, loc string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.urls, loc)
+	return nil
+}
+
+func (s *MemoryStore) Snapshot(ctx context.Context) ([]*URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*URL, 0, len(s.urls))
+	for _, u := range s.urls {
+		out = append(out, u.Clone())
+	}
+	sortByLoc(out)
+	return out, nil
+}
+
+// SnapshotURLSet returns the contents of store as a URLSet.
+func SnapshotURLSet(ctx context.Context, store URLStore) (URLSet, error) {
+	set := MakeUrlSet()
+	urls, err := store.Snapshot(ctx)
+	set.URLs = urls
+	return set, err
+}
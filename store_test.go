@@ -0,0 +1,55 @@
+package sitemap_go
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testURLStore checks the URLStore contract against an empty store.
+func testURLStore(t *testing.T, store URLStore) {
+	t.Helper()
+	ctx := context.Background()
+	lastMod := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, loc := range []string{"https://example.com/c", "https://example.com/a", "https://example.com/b"} {
+		if err := store.Upsert(ctx, &URL{Loc: loc}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	u := MakeUrl("https://example.com/a", WithLastMod(lastMod), WithPriority(0.5))
+	u.Images = []Image{{Loc: "https://example.com/a.jpg"}}
+	if err := store.Upsert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	u.Images[0].Loc = "changed"
+	if err := store.Delete(ctx, "https://example.com/c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "https://example.com/missing"); err != nil {
+		t.Errorf("deleting a missing loc: %v", err)
+	}
+
+	set, err := SnapshotURLSet(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := locsOf(&set), []string{"https://example.com/a", "https://example.com/b"}; !slices.Equal(got, want) {
+		t.Fatalf("snapshot = %q, want %q", got, want)
+	}
+	got := set.URLs[0]
+	if got.LastMod == nil || !got.LastMod.Equal(lastMod) || got.Priority == nil || *got.Priority != 0.5 {
+		t.Errorf("replaced entry = %+v", got)
+	}
+	if len(got.Images) != 1 || got.Images[0].Loc != "https://example.com/a.jpg" {
+		t.Errorf("images = %+v; the store must keep its own copy", got.Images)
+	}
+	set.URLs[1].Loc = "changed"
+	if urls, _ := store.Snapshot(ctx); urls[1].Loc != "https://example.com/b" {
+		t.Error("modifying a snapshot changed the store")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testURLStore(t, &MemoryStore{})
+}
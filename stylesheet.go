@@ -0,0 +1,192 @@
+package sitemap_go
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+var ErrInvalidStylesheet = errors.New("invalid stylesheet")
+
+// StylesheetColumn is a column of the URL table a Stylesheet renders.
+type StylesheetColumn string
+
+const (
+	ColumnLoc        StylesheetColumn = "loc"
+	ColumnLastMod    StylesheetColumn = "lastmod"
+	ColumnChangeFreq StylesheetColumn = "changefreq"
+	ColumnPriority   StylesheetColumn = "priority"
+	// ColumnImages and ColumnAlternates count the URL's image entries and
+	// hreflang alternates.
+	ColumnImages     StylesheetColumn = "images"
+	ColumnAlternates StylesheetColumn = "alternates"
+)
+
+// Stylesheet generates an XSL stylesheet that renders sitemaps and sitemap
+// indexes as branded HTML pages when opened in a browser. Publish it next
+// to the sitemaps and reference it with EncodeOptions.Stylesheet, or let
+// Publisher.Stylesheet do both.
+type Stylesheet struct {
+	// Title heads the page. It defaults to "XML Sitemap".
+	Title   string
+	LogoURL string
+	// Columns are the URL table's columns, in order. They default to loc,
+	// lastmod, changefreq and priority.
+	Columns []StylesheetColumn
+	// Colors are CSS colors, such as "#1a73e8". AccentColor defaults to
+	// #1a73e8, TextColor to #202124 and BackgroundColor to #ffffff.
+	AccentColor     string
+	TextColor       string
+	BackgroundColor string
+	// FontFamily is a CSS font-family list. It defaults to system fonts.
+	FontFamily string
+}
+
+var (
+	cssColorPattern = regexp.MustCompile(`^(#[0-9A-Fa-f]{3,8}|[a-zA-Z]+|(rgb|rgba|hsl|hsla)\([0-9., %]+\))$`)
+	cssFontPattern  = regexp.MustCompile(`^[A-Za-z0-9 ,'"_-]+$`)
+)
+
+var stylesheetColumnHeaders = map[StylesheetColumn]string{
+	ColumnLoc:        "URL",
+	ColumnLastMod:    "Last modified",
+	ColumnChangeFreq: "Change frequency",
+	ColumnPriority:   "Priority",
+	ColumnImages:     "Images",
+	ColumnAlternates: "Alternates",
+}
+
+var stylesheetColumnCells = map[StylesheetColumn]string{
+	ColumnLoc:        `<a href="{s:loc}"><xsl:value-of select="s:loc"/></a>`,
+	ColumnLastMod:    `<xsl:value-of select="s:lastmod"/>`,
+	ColumnChangeFreq: `<xsl:value-of select="s:changefreq"/>`,
+	ColumnPriority:   `<xsl:value-of select="s:priority"/>`,
+	ColumnImages:     `<xsl:value-of select="count(image:image)"/>`,
+	ColumnAlternates: `<xsl:value-of select="count(xhtml:link)"/>`,
+}
+
+var stylesheetTemplate = template.Must(template.New("xsl").Funcs(template.FuncMap{
+	"escape": html.EscapeString,
+	// attr escapes an attribute value, doubling the braces XSLT would
+	// otherwise read as an attribute value template.
+	"attr": func(s string) string {
+		return strings.NewReplacer("{", "{{", "}", "}}").Replace(html.EscapeString(s))
+	},
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<xsl:stylesheet version="1.0"
+  xmlns:xsl="http://www.w3.org/1999/XSL/Transform"
+  xmlns:s="{{.SitemapNS}}"
+  xmlns:image="{{.ImageNS}}"
+  xmlns:xhtml="{{.XHTMLNS}}"
+  exclude-result-prefixes="s image xhtml">
+  <xsl:output method="html" encoding="UTF-8" indent="yes"/>
+  <xsl:template match="/">
+    <html>
+      <head>
+        <meta name="viewport" content="width=device-width, initial-scale=1"/>
+        <title>{{escape .Title}}</title>
+        <style>
+          body { margin: 0; font-family: {{.FontFamily}}; color: {{.TextColor}}; background: {{.BackgroundColor}}; }
+          header { display: flex; align-items: center; gap: 1rem; padding: 1rem 2rem; background: {{.AccentColor}}; color: #fff; }
+          header img { max-height: 2.5rem; }
+          header h1 { margin: 0; font-size: 1.4rem; }
+          main { padding: 1rem 2rem; }
+          table { width: 100%; border-collapse: collapse; }
+          th { text-align: left; border-bottom: 2px solid {{.AccentColor}}; padding: .5rem; }
+          td { border-bottom: 1px solid #e0e0e0; padding: .5rem; word-break: break-all; }
+          a { color: {{.AccentColor}}; }
+        </style>
+      </head>
+      <body>
+        <header>
+          {{- if .LogoURL}}
+          <img src="{{attr .LogoURL}}" alt=""/>
+          {{- end}}
+          <h1>{{escape .Title}}</h1>
+        </header>
+        <main>
+          <xsl:choose>
+            <xsl:when test="s:sitemapindex">
+              <p><xsl:value-of select="count(s:sitemapindex/s:sitemap)"/> sitemaps</p>
+              <table>
+                <thead><tr><th>Sitemap</th><th>Last modified</th></tr></thead>
+                <tbody>
+                  <xsl:for-each select="s:sitemapindex/s:sitemap">
+                    <tr><td><a href="{s:loc}"><xsl:value-of select="s:loc"/></a></td><td><xsl:value-of select="s:lastmod"/></td></tr>
+                  </xsl:for-each>
+                </tbody>
+              </table>
+            </xsl:when>
+            <xsl:otherwise>
+              <p><xsl:value-of select="count(s:urlset/s:url)"/> URLs</p>
+              <table>
+                <thead><tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr></thead>
+                <tbody>
+                  <xsl:for-each select="s:urlset/s:url">
+                    <tr>{{range .Cells}}<td>{{.}}</td>{{end}}</tr>
+                  </xsl:for-each>
+                </tbody>
+              </table>
+            </xsl:otherwise>
+          </xsl:choose>
+        </main>
+      </body>
+    </html>
+  </xsl:template>
+</xsl:stylesheet>
+`))
+
+// WriteXSL writes the stylesheet. Colors and fonts that are not plain CSS
+// values fail with ErrInvalidStylesheet.
+func (s Stylesheet) WriteXSL(w io.Writer) error {
+	data := struct {
+		Title, LogoURL                          string
+		AccentColor, TextColor, BackgroundColor string
+		FontFamily                              string
+		Headers, Cells                          []string
+		SitemapNS, ImageNS, XHTMLNS             string
+	}{
+		Title:           orDefault(s.Title, "XML Sitemap"),
+		LogoURL:         s.LogoURL,
+		AccentColor:     orDefault(s.AccentColor, "#1a73e8"),
+		TextColor:       orDefault(s.TextColor, "#202124"),
+		BackgroundColor: orDefault(s.BackgroundColor, "#ffffff"),
+		FontFamily:      orDefault(s.FontFamily, `system-ui, -apple-system, "Segoe UI", Roboto, sans-serif`),
+		SitemapNS:       SitemapNamespace,
+		ImageNS:         ImageNamespace,
+		XHTMLNS:         XHTMLNamespace,
+	}
+	for _, color := range []string{data.AccentColor, data.TextColor, data.BackgroundColor} {
+		if !cssColorPattern.MatchString(color) {
+			return fmt.Errorf("%w: color %q", ErrInvalidStylesheet, color)
+		}
+	}
+	if !cssFontPattern.MatchString(data.FontFamily) {
+		return fmt.Errorf("%w: font family %q", ErrInvalidStylesheet, data.FontFamily)
+	}
+	data.FontFamily = html.EscapeString(data.FontFamily)
+
+	columns := s.Columns
+	if len(columns) == 0 {
+		columns = []StylesheetColumn{ColumnLoc, ColumnLastMod, ColumnChangeFreq, ColumnPriority}
+	}
+	for _, c := range columns {
+		cell, ok := stylesheetColumnCells[c]
+		if !ok {
+			return fmt.Errorf("%w: unknown column %q", ErrInvalidStylesheet, c)
+		}
+		data.Headers = append(data.Headers, stylesheetColumnHeaders[c])
+		data.Cells = append(data.Cells, cell)
+	}
+	return stylesheetTemplate.Execute(w, data)
+}
+
+// stylesheetPI returns the processing instruction referencing the
+// stylesheet at href.
+func stylesheetPI(href string) string {
+	return `<?xml-stylesheet type="text/xsl" href="` + html.EscapeString(href) + `"?>`
+}
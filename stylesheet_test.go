@@ -0,0 +1,93 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStylesheetWriteXSL(t *testing.T) {
+	s := Stylesheet{
+		Title:       "Shop <Sitemap> & more",
+		LogoURL:     "https://cdn.example.com/logo.png?v={1}",
+		Columns:     []StylesheetColumn{ColumnLoc, ColumnImages, ColumnAlternates},
+		AccentColor: "rgb(10, 20, 30)",
+		FontFamily:  `"Open Sans", sans-serif`,
+	}
+	var buf bytes.Buffer
+	if err := s.WriteXSL(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// The stylesheet must be well-formed XML for browsers to apply it.
+	dec := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("malformed stylesheet: %v\n%s", err, buf.String())
+		}
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"<title>Shop &lt;Sitemap&gt; &amp; more</title>",
+		`<img src="https://cdn.example.com/logo.png?v={{1}}" alt=""/>`,
+		"<th>URL</th><th>Images</th><th>Alternates</th>",
+		`<td><xsl:value-of select="count(image:image)"/></td>`,
+		"background: rgb(10, 20, 30)",
+		"font-family: &#34;Open Sans&#34;, sans-serif",
+		"color: #202124",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stylesheet lacks %s", want)
+		}
+	}
+	if strings.Contains(out, "<th>Priority</th>") {
+		t.Error("stylesheet has a column that was not asked for")
+	}
+}
+
+func TestStylesheetInvalid(t *testing.T) {
+	for _, s := range []Stylesheet{
+		{AccentColor: "red; } body { display: none"},
+		{TextColor: "url(https://evil.example/)"},
+		{FontFamily: "Arial; color: red"},
+		{Columns: []StylesheetColumn{"video"}},
+	} {
+		if err := s.WriteXSL(io.Discard); !errors.Is(err, ErrInvalidStylesheet) {
+			t.Errorf("%+v: err = %v", s, err)
+		}
+	}
+}
+
+func TestEncodeStylesheet(t *testing.T) {
+	set := setOf(t, "https://example.com/")
+	out, err := set.GenerateXMLWithOptions(EncodeOptions{Stylesheet: "/sitemap.xsl?a=1&b=2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<?xml-stylesheet type="text/xsl" href="/sitemap.xsl?a=1&amp;b=2"?>` + "\n<urlset"
+	if !strings.HasPrefix(out, want) {
+		t.Errorf("got %.160s", out)
+	}
+}
+
+func TestPublishStylesheet(t *testing.T) {
+	storage := &memStorage{}
+	p := &Publisher{Storage: storage, BaseURL: "https://example.com/sitemaps/", Shards: ShardOptions{MaxURLs: 1}, Stylesheet: &Stylesheet{Title: "Shop"}}
+	if _, err := p.Publish(context.Background(), numberedSet(t, 2, "")); err != nil {
+		t.Fatal(err)
+	}
+	if xsl := storage.get("sitemap.xsl"); !strings.Contains(xsl, "<title>Shop</title>") || storage.meta["sitemap.xsl"].ContentType != "text/xsl" {
+		t.Errorf("stylesheet %.80s with meta %+v", xsl, storage.meta["sitemap.xsl"])
+	}
+	pi := `<?xml-stylesheet type="text/xsl" href="https://example.com/sitemaps/sitemap.xsl"?>`
+	for _, name := range []string{"sitemap.xml", "sitemap-1.xml", "sitemap-2.xml"} {
+		if !strings.Contains(storage.get(name), pi) {
+			t.Errorf("%s does not reference the stylesheet", name)
+		}
+	}
+}
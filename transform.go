@@ -0,0 +1,220 @@
+package sitemap_go
+
+import (
+	"maps"
+	"net/url"
+	"strings"
+)
+
+// Transformer rewrites a URL on its way into or out of a set. Returning a
+// nil URL drops it.
+type Transformer interface {
+	Transform(*URL) (*URL, error)
+}
+
+type TransformFunc func(*URL) (*URL, error)
+
+func (f TransformFunc) Transform(u *URL) (*URL, error) {
+	return f(u)
+}
+
+type transformChain []Transformer
+
+func (c transformChain) Transform(u *URL) (*URL, error) {
+	for _, t := range c {
+		if u == nil {
+			return nil, nil
+		}
+		var err error
+		if u, err = t.Transform(u); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// Chain composes transformers, applying them left to right and stopping as
+// soon as one drops the URL.
+func Chain(ts ...Transformer) Transformer {
+	return transformChain(ts)
+}
+
+// NormalizeLoc lowercases the scheme and host, drops default ports and the
+// fragment, and gives host-only locs a trailing slash.
+func NormalizeLoc() Transformer {
+	return TransformFunc(func(u *URL) (*URL, error) {
+		parsed, err := url.Parse(u.Loc)
+		if err != nil {
+			return nil, err
+		}
+		parsed.Scheme = strings.ToLower(parsed.Scheme)
+		parsed.Host = canonicalHost(parsed)
+		parsed.Fragment, parsed.RawFragment = "", ""
+		if parsed.Path == "" && parsed.Opaque == "" {
+			parsed.Path = "/"
+		}
+		u.Loc = parsed.String()
+		return u, nil
+	})
+}
+
+// StripQueryParams removes the named query parameters from each loc, or the
+// whole query string when no names are given.
+func StripQueryParams(names ...string) Transformer {
+	return TransformFunc(func(u *URL) (*URL, error) {
+		parsed, err := url.Parse(u.Loc)
+		if err != nil {
+			return nil, err
+		}
+		if parsed.RawQuery == "" {
+			return u, nil
+		}
+		if len(names) == 0 {
+			parsed.RawQuery = ""
+		} else {
+			query := parsed.Query()
+			for _, name := range names {
+				query.Del(name)
+			}
+			parsed.RawQuery = query.Encode()
+		}
+		u.Loc = parsed.String()
+		return u, nil
+	})
+}
+
+// SetDefaults fills in lastmod, changefreq and priority from defaults
+// wherever a URL leaves them unset.
+func SetDefaults(defaults URL) Transformer {
+	return TransformFunc(func(u *URL) (*URL, error) {
+		if u.LastMod == nil && defaults.LastMod != nil {
+			t := *defaults.LastMod
+			u.LastMod = &t
+		}
+		if u.ChangeFreq == "" {
+			u.ChangeFreq = defaults.ChangeFreq
+		}
+		if u.Priority == nil && defaults.Priority != nil {
+			p := *defaults.Priority
+			u.Priority = &p
+		}
+		return u, nil
+	})
+}
+
+// DropIf drops every URL for which pred returns true.
+func DropIf(pred func(*URL) bool) Transformer {
+	return TransformFunc(func(u *URL) (*URL, error) {
+		if pred(u) {
+			return nil, nil
+		}
+		return u, nil
+	})
+}
+
+// RewriteLocs passes every location of a URL through rewrite: its loc,
+// alternate hrefs and image and video locations.
+func RewriteLocs(rewrite func(loc string) string) Transformer {
+	return TransformFunc(func(u *URL) (*URL, error) {
+		return u, u.mapLocs(func(loc string) (string, error) {
+			return rewrite(loc), nil
+		})
+	})
+}
+
+// ReplaceHost returns a rewrite for RewriteLocs and Rewrite that moves locs
+// on host from to host to. Hosts include any port and compare
+// case-insensitively; other locs are returned unchanged.
+func ReplaceHost(from, to string) func(loc string) string {
+	return func(loc string) string {
+		parsed, err := url.Parse(loc)
+		if err != nil || !strings.EqualFold(parsed.Host, from) {
+			return loc
+		}
+		parsed.Host = to
+		return parsed.String()
+	}
+}
+
+// Rewrite passes every location of every URL in the set through rewrite,
+// in place, for domain and CDN migrations.
+func (u *URLSet) Rewrite(rewrite func(loc string) string) {
+	t := RewriteLocs(rewrite)
+	for _, entry := range u.URLs {
+		t.Transform(entry)
+	}
+}
+
+// RewriteHost moves every location on host from to host to.
+func (u *URLSet) RewriteHost(from, to string) {
+	u.Rewrite(ReplaceHost(from, to))
+}
+
+// Transform applies ts to every URL in the set in place, removing the URLs
+// they drop. On error the set keeps all of its URLs, although transformers
+// that modify URLs in place may have changed those before the failing one.
+func (u *URLSet) Transform(ts ...Transformer) error {
+	chain := Chain(ts...)
+	kept := make([]*URL, 0, len(u.URLs))
+	for _, entry := range u.URLs {
+		out, err := chain.Transform(entry)
+		if err != nil {
+			return err
+		}
+		if out != nil {
+			kept = append(kept, out)
+		}
+	}
+	u.URLs = kept
+	return nil
+}
+
+// transformed returns a copy of the set with ts applied to clones of its
+// URLs, leaving the original untouched.
+func (u *URLSet) transformed(ts []Transformer) (*URLSet, error) {
+	out := u.emptyCopy()
+	chain := Chain(ts...)
+	for _, entry := range u.URLs {
+		t, err := chain.Transform(entry.Clone())
+		if err != nil {
+			return nil, err
+		}
+		if t != nil {
+			out.URLs = append(out.URLs, t)
+		}
+	}
+	return &out, nil
+}
+
+func (u *URL) Clone() *URL {
+	out := *u
+	if u.LastMod != nil {
+		t := *u.LastMod
+		out.LastMod = &t
+	}
+	if u.Priority != nil {
+		p := *u.Priority
+		out.Priority = &p
+	}
+	out.Images = append([]Image(nil), u.Images...)
+	out.Videos = make([]Video, len(u.Videos))
+	for i, v := range u.Videos {
+		v.Tags = append([]string(nil), v.Tags...)
+		v.Rating = clonePtr(v.Rating)
+		v.PublicationDate = clonePtr(v.PublicationDate)
+		v.ExpirationDate = clonePtr(v.ExpirationDate)
+		out.Videos[i] = v
+	}
+	out.Alternate = append([]Alternate(nil), u.Alternate...)
+	out.Geo = clonePtr(u.Geo)
+	out.Meta = maps.Clone(u.Meta)
+	return &out
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
@@ -0,0 +1,124 @@
+package sitemap_go
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func locsOf(set *URLSet) []string {
+	locs := make([]string, len(set.URLs))
+	for i, u := range set.URLs {
+		locs[i] = u.Loc
+	}
+	return locs
+}
+
+func setOf(t *testing.T, locs ...string) *URLSet {
+	t.Helper()
+	set := MakeUrlSet()
+	for _, loc := range locs {
+		set.URLs = append(set.URLs, &URL{Loc: loc})
+	}
+	return &set
+}
+
+func TestTransformers(t *testing.T) {
+	tests := []struct {
+		name string
+		t    Transformer
+		in   string
+		want string
+	}{
+		{"normalize", NormalizeLoc(), "HTTPS://Example.COM:443#top", "https://example.com/"},
+		{"normalize keeps path", NormalizeLoc(), "http://example.com:8080/a?b=1", "http://example.com:8080/a?b=1"},
+		{"normalize ipv6", NormalizeLoc(), "http://[::1]:80/x", "http://[::1]/x"},
+		{"normalize ipv6 port", NormalizeLoc(), "http://[::1]:8080/x", "http://[::1]:8080/x"},
+		{"strip all", StripQueryParams(), "https://example.com/a?x=1&y=2", "https://example.com/a"},
+		{"strip named", StripQueryParams("utm_source"), "https://example.com/a?utm_source=x&id=2", "https://example.com/a?id=2"},
+		{"replace host", RewriteLocs(ReplaceHost("old.example.com", "new.example.com")), "https://OLD.example.com/a", "https://new.example.com/a"},
+		{"replace other host", RewriteLocs(ReplaceHost("old.example.com", "new.example.com")), "https://example.com/a", "https://example.com/a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := tt.t.Transform(&URL{Loc: tt.in})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Loc != tt.want {
+				t.Errorf("got %q, want %q", out.Loc, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformDrops(t *testing.T) {
+	set := setOf(t, "https://example.com/a", "https://example.com/drop", "https://example.com/b")
+	err := set.Transform(DropIf(func(u *URL) bool { return u.Loc == "https://example.com/drop" }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := locsOf(set), []string{"https://example.com/a", "https://example.com/b"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTransformErrorLeavesSet(t *testing.T) {
+	locs := []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}
+	set := setOf(t, locs...)
+	errFail := errors.New("fail")
+	err := set.Transform(TransformFunc(func(u *URL) (*URL, error) {
+		switch u.Loc {
+		case locs[0]:
+			return nil, nil
+		case locs[2]:
+			return nil, errFail
+		}
+		return u, nil
+	}))
+	if !errors.Is(err, errFail) {
+		t.Fatalf("err = %v, want %v", err, errFail)
+	}
+	if got := locsOf(set); !slices.Equal(got, locs) {
+		t.Errorf("set changed on error: %q", got)
+	}
+}
+
+func TestTransformedLeavesOriginal(t *testing.T) {
+	set := setOf(t, "https://example.com/a?x=1")
+	out, err := set.transformed([]Transformer{StripQueryParams()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.URLs[0].Loc != "https://example.com/a" || set.URLs[0].Loc != "https://example.com/a?x=1" {
+		t.Errorf("got %q and original %q", out.URLs[0].Loc, set.URLs[0].Loc)
+	}
+}
+
+func TestRewriteHost(t *testing.T) {
+	set := richSet(4)
+	set.RewriteHost("example.com", "www.example.org")
+	set.RewriteHost("cdn.example.com", "media.example.org")
+	u := set.URLs[0]
+	locs := []string{u.Loc, u.Alternate[0].Href, u.Alternate[1].Href, u.Images[0].Loc, u.Images[0].License, u.Videos[0].Loc, u.Videos[0].ThumbnailLoc, u.Videos[0].ContentLoc, u.Videos[0].PlayerLoc}
+	want := []string{
+		"https://www.example.org/page/0?a=1&b=<2>",
+		"https://www.example.org/page/0?a=1&b=<2>",
+		"https://www.example.org/page/0?a=1&b=<2>&lang=de",
+		"https://media.example.org/0.jpg",
+		"https://www.example.org/license",
+		"https://www.example.org/page/0?a=1&b=<2>",
+		"https://media.example.org/thumb.jpg",
+		"https://media.example.org/video.mp4",
+		"https://www.example.org/player",
+	}
+	if !slices.Equal(locs, want) {
+		t.Errorf("got  %q\nwant %q", locs, want)
+	}
+
+	set.Rewrite(func(loc string) string { return strings.Replace(loc, "/page/", "/p/", 1) })
+	if got := set.URLs[3].Loc; got != "https://www.example.org/p/3?a=1&b=<2>" {
+		t.Errorf("Rewrite: loc = %s", got)
+	}
+}
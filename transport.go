@@ -0,0 +1,99 @@
+package sitemap_go
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxIdleConns          = 256
+	defaultMaxIdleConnsPerHost   = 32
+	defaultIdleConnTimeout       = 90 * time.Second
+	defaultTLSHandshakeTimeout   = 10 * time.Second
+	defaultResponseHeaderTimeout = 30 * time.Second
+
+	// maxDrainBytes is how much of an unread response body is discarded
+	// so its connection can be reused.
+	maxDrainBytes = 64 << 10
+)
+
+// TransportOptions tunes the HTTP client used for bulk requests. Zero
+// fields take the defaults noted on them.
+type TransportOptions struct {
+	// MaxConnsPerHost caps connections, active or idle, to one host. Zero
+	// means unlimited.
+	MaxConnsPerHost int
+	// MaxIdleConnsPerHost defaults to 32, well above net/http's default of
+	// 2, so a worker pool fetching from one origin reuses its connections
+	// instead of opening and abandoning sockets.
+	MaxIdleConnsPerHost int
+	// MaxIdleConns defaults to 256.
+	MaxIdleConns int
+	// IdleConnTimeout defaults to 90s.
+	IdleConnTimeout time.Duration
+	// TLSHandshakeTimeout defaults to 10s.
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout defaults to 30s.
+	ResponseHeaderTimeout time.Duration
+	// Timeout bounds whole requests, including reading the body. Zero
+	// means no limit beyond the request's context.
+	Timeout time.Duration
+	// DisableHTTP2 restricts the client to HTTP/1.1. By default HTTP/2 is
+	// negotiated over TLS, multiplexing requests to a host over a single
+	// connection.
+	DisableHTTP2 bool
+}
+
+// NewHTTPClient returns a client tuned for fetching many sitemaps or pages
+// from a few hosts.
+func NewHTTPClient(opts TransportOptions) *http.Client {
+	t := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     !opts.DisableHTTP2,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		MaxIdleConns:          orDefaultInt(opts.MaxIdleConns, defaultMaxIdleConns),
+		MaxIdleConnsPerHost:   orDefaultInt(opts.MaxIdleConnsPerHost, defaultMaxIdleConnsPerHost),
+		IdleConnTimeout:       orDefaultDuration(opts.IdleConnTimeout, defaultIdleConnTimeout),
+		TLSHandshakeTimeout:   orDefaultDuration(opts.TLSHandshakeTimeout, defaultTLSHandshakeTimeout),
+		ResponseHeaderTimeout: orDefaultDuration(opts.ResponseHeaderTimeout, defaultResponseHeaderTimeout),
+		ExpectContinueTimeout: time.Second,
+	}
+	if opts.DisableHTTP2 {
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return &http.Client{Transport: t, Timeout: opts.Timeout}
+}
+
+// bulkClient is shared by the Fetcher and Crawler when they have no
+// HTTPClient, so connections are pooled across them.
+var bulkClient = sync.OnceValue(func() *http.Client {
+	return NewHTTPClient(TransportOptions{})
+})
+
+func clientOrBulk(client *http.Client) *http.Client {
+	if client == nil {
+		return bulkClient()
+	}
+	return client
+}
+
+func orDefaultInt(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+func orDefaultDuration(v, def time.Duration) time.Duration {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,83 @@
+package sitemap_go
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	client := NewHTTPClient(TransportOptions{})
+	tr := client.Transport.(*http.Transport)
+	if tr.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost || tr.MaxIdleConns != defaultMaxIdleConns ||
+		tr.IdleConnTimeout != defaultIdleConnTimeout || tr.ResponseHeaderTimeout != defaultResponseHeaderTimeout ||
+		!tr.ForceAttemptHTTP2 || client.Timeout != 0 {
+		t.Errorf("defaults not applied: %+v", tr)
+	}
+
+	client = NewHTTPClient(TransportOptions{MaxConnsPerHost: 4, MaxIdleConnsPerHost: 8, Timeout: time.Minute, DisableHTTP2: true})
+	tr = client.Transport.(*http.Transport)
+	if tr.MaxConnsPerHost != 4 || tr.MaxIdleConnsPerHost != 8 || client.Timeout != time.Minute {
+		t.Errorf("options not applied: %+v", tr)
+	}
+	if tr.ForceAttemptHTTP2 || tr.TLSNextProto == nil || len(tr.TLSNextProto) != 0 {
+		t.Error("HTTP/2 not disabled")
+	}
+}
+
+func TestHTTPClientProtocols(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		disable bool
+		want    int
+	}{{false, 2}, {true, 1}} {
+		client := NewHTTPClient(TransportOptions{DisableHTTP2: tt.disable})
+		client.Transport.(*http.Transport).TLSClientConfig = &tls.Config{RootCAs: srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.ProtoMajor != tt.want {
+			t.Errorf("DisableHTTP2 %v: negotiated %s", tt.disable, resp.Proto)
+		}
+	}
+}
+
+func TestFetcherReusesConnections(t *testing.T) {
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, strings.Repeat("not found ", 1000), http.StatusNotFound)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	f := &Fetcher{HTTPClient: NewHTTPClient(TransportOptions{}), RateLimiter: &RateLimiter{}}
+	for range 10 {
+		var statusErr *HTTPStatusError
+		if _, err := f.Fetch(context.Background(), srv.URL+"/missing.xml"); !errors.As(err, &statusErr) {
+			t.Fatalf("err = %v", err)
+		}
+	}
+	if n := conns.Load(); n != 1 {
+		t.Errorf("opened %d connections for 10 sequential requests, want 1", n)
+	}
+}
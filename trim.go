@@ -0,0 +1,68 @@
+package sitemap_go
+
+import (
+	"math"
+	"sort"
+)
+
+type URLScorer func(*URL) float64
+
+// ScoreByPriority scores a URL by its priority, treating an unset priority as
+// the protocol default of 0.5.
+func ScoreByPriority(u *URL) float64 {
+	if u.Priority == nil {
+		return 0.5
+	}
+	return *u.Priority
+}
+
+// ScoreByRecency scores more recently modified URLs higher. URLs without a
+// lastmod sort last.
+func ScoreByRecency(u *URL) float64 {
+	if u.LastMod == nil {
+		return math.Inf(-1)
+	}
+	return float64(u.LastMod.Unix())
+}
+
+// TrimTo keeps the n highest-scoring URLs and returns the ones it removed.
+// Ties are broken by position, and kept URLs retain their original order.
+// A nil scorer defaults to ScoreByPriority.
+func (u *URLSet) TrimTo(n int, scorer URLScorer) []*URL {
+	if n < 0 {
+		n = 0
+	}
+	if len(u.URLs) <= n {
+		return nil
+	}
+	if scorer == nil {
+		scorer = ScoreByPriority
+	}
+
+	scores := make([]float64, len(u.URLs))
+	order := make([]int, len(u.URLs))
+	for i, url := range u.URLs {
+		scores[i] = scorer(url)
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+
+	keep := make([]bool, len(u.URLs))
+	for _, i := range order[:n] {
+		keep[i] = true
+	}
+
+	kept := make([]*URL, 0, n)
+	removed := make([]*URL, 0, len(u.URLs)-n)
+	for i, url := range u.URLs {
+		if keep[i] {
+			kept = append(kept, url)
+		} else {
+			removed = append(removed, url)
+		}
+	}
+	u.URLs = kept
+	return removed
+}
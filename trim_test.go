@@ -0,0 +1,42 @@
+package sitemap_go
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTrimTo(t *testing.T) {
+	priority := func(p float64) *float64 { return &p }
+	set := setOf(t, "https://example.com/a", "https://example.com/b", "https://example.com/c", "https://example.com/d")
+	set.URLs[0].Priority = priority(0.2)
+	set.URLs[2].Priority = priority(0.9)
+	set.URLs[3].Priority = priority(0.5)
+
+	removed := set.TrimTo(2, nil)
+	// b has no priority and scores the default 0.5, like d, but comes first.
+	if got, want := locsOf(set), []string{"https://example.com/b", "https://example.com/c"}; !slices.Equal(got, want) {
+		t.Errorf("kept %q, want %q", got, want)
+	}
+	if len(removed) != 2 || removed[0].Loc != "https://example.com/a" || removed[1].Loc != "https://example.com/d" {
+		t.Errorf("removed %v", removed)
+	}
+	if removed := set.TrimTo(5, nil); removed != nil || len(set.URLs) != 2 {
+		t.Errorf("trimming to more than the set removed %v", removed)
+	}
+	if set.TrimTo(-1, nil); len(set.URLs) != 0 {
+		t.Errorf("negative n kept %d URLs", len(set.URLs))
+	}
+}
+
+func TestTrimToRecency(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	set := setOf(t, "https://example.com/old", "https://example.com/none", "https://example.com/new")
+	old, recent := now.Add(-time.Hour), now
+	set.URLs[0].LastMod = &old
+	set.URLs[2].LastMod = &recent
+	set.TrimTo(2, ScoreByRecency)
+	if got, want := locsOf(set), []string{"https://example.com/old", "https://example.com/new"}; !slices.Equal(got, want) {
+		t.Errorf("kept %q, want %q", got, want)
+	}
+}
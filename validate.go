@@ -0,0 +1,165 @@
+package sitemap_go
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidLoc       = errors.New("loc is not an absolute URL")
+	ErrOtherScheme      = errors.New("loc uses a different scheme than the sitemap")
+	ErrOtherHost        = errors.New("loc is on a different host than the sitemap")
+	ErrAboveSitemapPath = errors.New("loc is outside the sitemap's directory")
+)
+
+type ValidationIssue struct {
+	Index    int
+	Loc      string
+	Rule     string
+	Severity Severity
+	Err      error
+}
+
+func (i ValidationIssue) Error() string {
+	if i.Index < 0 {
+		return fmt.Sprintf("%s: sitemap: %v [%s]", i.Severity, i.Err, i.Rule)
+	}
+	return fmt.Sprintf("%s: url %d (%s): %v [%s]", i.Severity, i.Index, i.Loc, i.Err, i.Rule)
+}
+
+func (i ValidationIssue) Unwrap() error {
+	return i.Err
+}
+
+type ValidateOption func(*validateConfig)
+
+type validateConfig struct {
+	sitemapLoc   *url.URL
+	sitemapErr   error
+	requireHTTPS bool
+	robots       func(loc string) *Robots
+	robotsAgent  string
+	rules        RuleSet
+	custom       []Rule
+}
+
+// WithSitemapLocation enables the protocol's location scoping rule: every loc
+// must share the scheme and host of the sitemap and live in its directory or
+// below. A loc that is not an absolute URL is reported as a sitemap-level
+// issue, since the rule cannot be checked.
+func WithSitemapLocation(loc string) ValidateOption {
+	return func(c *validateConfig) {
+		parsed, err := url.Parse(loc)
+		if err != nil || !parsed.IsAbs() || parsed.Host == "" {
+			c.sitemapErr = fmt.Errorf("%w: sitemap location %q", ErrInvalidLoc, loc)
+			return
+		}
+		c.sitemapLoc = parsed
+	}
+}
+
+func (u *URLSet) Validate(options ...ValidateOption) []ValidationIssue {
+	var cfg validateConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	var issues []ValidationIssue
+	if cfg.sitemapErr != nil {
+		issues = append(issues, ValidationIssue{Index: -1, Err: cfg.sitemapErr})
+	}
+	for i, entry := range u.URLs {
+		for _, err := range cfg.check(entry) {
+			issues = append(issues, ValidationIssue{Index: i, Loc: entry.Loc, Err: err})
+		}
+	}
+	issues = append(issues, hreflangIssues(u.URLs)...)
+	issues = append(issues, lintIssues(u.URLs)...)
+	issues = append(issues, cfg.customIssues(u)...)
+	sort.SliceStable(issues, func(a, b int) bool {
+		return issues[a].Index < issues[b].Index
+	})
+	return cfg.applyRules(issues)
+}
+
+// applyRules tags each issue with its rule and severity and applies any
+// RuleSet overrides, dropping disabled rules.
+func (c *validateConfig) applyRules(issues []ValidationIssue) []ValidationIssue {
+	out := issues[:0]
+	for _, issue := range issues {
+		if issue.Rule == "" {
+			issue.Rule, issue.Severity = classify(issue.Err)
+		}
+		if severity, ok := c.rules[issue.Rule]; ok {
+			issue.Severity = severity
+		}
+		if issue.Severity != SeverityOff {
+			out = append(out, issue)
+		}
+	}
+	return out
+}
+
+func (c *validateConfig) check(entry *URL) []error {
+	var errs []error
+	if err := CheckLoc(entry.Loc); err != nil {
+		errs = append(errs, err)
+	}
+	loc, err := url.Parse(entry.Loc)
+	if err != nil || !loc.IsAbs() || loc.Host == "" {
+		return append(errs, ErrInvalidLoc)
+	}
+
+	if c.requireHTTPS && strings.EqualFold(loc.Scheme, "http") {
+		errs = append(errs, ErrInsecureLoc)
+	}
+	if c.sitemapLoc != nil {
+		if err := checkScope(c.sitemapLoc, loc); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.robots != nil {
+		if r := c.robots(entry.Loc); r != nil && !r.Allowed(c.robotsAgent, entry.Loc) {
+			errs = append(errs, ErrDisallowedByRobots)
+		}
+	}
+	return errs
+}
+
+func checkScope(sitemap, loc *url.URL) error {
+	if !strings.EqualFold(sitemap.Scheme, loc.Scheme) {
+		return ErrOtherScheme
+	}
+	if canonicalHost(sitemap) != canonicalHost(loc) {
+		return ErrOtherHost
+	}
+	dir := sitemap.EscapedPath()
+	dir = dir[:strings.LastIndex(dir, "/")+1]
+	path := loc.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if dir != "" && !strings.HasPrefix(path, dir) {
+		return ErrAboveSitemapPath
+	}
+	return nil
+}
+
+func canonicalHost(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	port := u.Port()
+	switch {
+	case port == "":
+	case port == "80" && strings.EqualFold(u.Scheme, "http"):
+	case port == "443" && strings.EqualFold(u.Scheme, "https"):
+	default:
+		host += ":" + port
+	}
+	return host
+}
@@ -0,0 +1,58 @@
+package sitemap_go
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateScope(t *testing.T) {
+	tests := []struct {
+		sitemap, loc string
+		want         error
+	}{
+		{"https://example.com/sitemap.xml", "https://example.com/a", nil},
+		{"https://example.com/sitemap.xml", "https://EXAMPLE.com:443/a", nil},
+		{"https://example.com/sitemap.xml", "http://example.com/a", ErrOtherScheme},
+		{"https://example.com/sitemap.xml", "https://www.example.com/a", ErrOtherHost},
+		{"https://example.com/blog/sitemap.xml", "https://example.com/blog/post", nil},
+		{"https://example.com/blog/sitemap.xml", "https://example.com/about", ErrAboveSitemapPath},
+		{"http://[::1]:8080/sitemap.xml", "http://[::1]:8080/a", nil},
+		{"http://[::1]:8080/sitemap.xml", "http://[::1]:8081/a", ErrOtherHost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sitemap+" "+tt.loc, func(t *testing.T) {
+			set := setOf(t, tt.loc)
+			var got error
+			for _, issue := range set.Validate(WithSitemapLocation(tt.sitemap)) {
+				for _, target := range []error{ErrOtherScheme, ErrOtherHost, ErrAboveSitemapPath} {
+					if errors.Is(issue, target) {
+						got = target
+					}
+				}
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateInvalidSitemapLocation(t *testing.T) {
+	for _, loc := range []string{"/sitemap.xml", "://bad", ""} {
+		set := setOf(t, "https://example.com/a")
+		issues := set.Validate(WithSitemapLocation(loc))
+		if len(issues) == 0 || issues[0].Index != -1 || !errors.Is(issues[0], ErrInvalidLoc) {
+			t.Errorf("%q: got %v, want a sitemap-level ErrInvalidLoc issue", loc, issues)
+		}
+	}
+}
+
+func TestCanonicalHostIPv6(t *testing.T) {
+	set := setOf(t, "http://[::1]:8080/")
+	if err := set.Transform(NormalizeLoc()); err != nil {
+		t.Fatal(err)
+	}
+	if got := set.URLs[0].Loc; got != "http://[::1]:8080/" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,117 @@
+package sitemap_go
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+var ErrWriterClosed = errors.New("sitemap writer is closed")
+
+// Writer streams a urlset document one URL at a time, so a sitemap can be
+// produced without holding its URLs in memory.
+type Writer struct {
+	// BeforeEncode is called with each URL right before it is encoded. The
+	// URL it returns is written in its place, and nil skips the entry.
+	BeforeEncode func(*URL) (*URL, error)
+	// Progress is called after each URL is written, with the URL count and
+	// the bytes flushed to the underlying writer so far.
+	Progress ProgressFunc
+
+	w        *countingWriter
+	opts     EncodeOptions
+	template URLSet
+	prefixes namespacePrefixes
+	enc      *xml.Encoder
+	root     xml.StartElement
+	count    int
+	started  bool
+	closed   bool
+}
+
+// NewWriter returns a Writer that takes its namespaces and prefixes from
+// template, or from MakeUrlSet() when template is nil. Since the root is
+// written before any URL is seen, every extension namespace is always
+// declared.
+func NewWriter(w io.Writer, template *URLSet, opts EncodeOptions) *Writer {
+	var t URLSet
+	if template == nil {
+		t = MakeUrlSet()
+	} else {
+		t = template.emptyCopy()
+	}
+	cw := &countingWriter{w: w}
+	return &Writer{
+		w:        cw,
+		opts:     opts,
+		template: t,
+		prefixes: t.prefixes(),
+		enc:      opts.newEncoder(cw),
+	}
+}
+
+// Count returns the number of URLs written so far.
+func (w *Writer) Count() int {
+	return w.count
+}
+
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	if err := w.opts.checkDeclaration(); err != nil {
+		return err
+	}
+	w.started = true
+	if _, err := io.WriteString(w.w, w.opts.header()); err != nil {
+		return err
+	}
+	attrs := w.template.rootAttrs(extensionUse{all: true, xhtml: true, image: true, video: true, geo: true})
+	w.root = xml.StartElement{Name: xml.Name{Local: "urlset"}, Attr: attrs}
+	return w.enc.EncodeToken(w.root)
+}
+
+func (w *Writer) Write(u *URL) error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+	if len(w.opts.Transformers) > 0 {
+		var err error
+		if u, err = Chain(w.opts.Transformers...).Transform(u.Clone()); err != nil || u == nil {
+			return err
+		}
+	}
+	if w.BeforeEncode != nil {
+		var err error
+		if u, err = w.BeforeEncode(u); err != nil || u == nil {
+			return err
+		}
+	}
+	if err := u.encode(w.enc, w.prefixes, w.opts.LastMod); err != nil {
+		return &EncodeError{Index: w.count, Loc: u.Loc, Err: err}
+	}
+	w.count++
+	if w.Progress != nil {
+		w.Progress(Progress{URLs: w.count, Bytes: w.w.n})
+	}
+	return nil
+}
+
+// Close ends the document and flushes it. It does not close the underlying
+// io.Writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+	w.closed = true
+	if err := w.enc.EncodeToken(w.root.End()); err != nil {
+		return err
+	}
+	return w.enc.Close()
+}
@@ -0,0 +1,84 @@
+package sitemap_go
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriterMatchesEncode(t *testing.T) {
+	set := richSet(12)
+	var want bytes.Buffer
+	if err := set.Encode(&want, EncodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	w := NewWriter(&got, set, EncodeOptions{})
+	for _, u := range set.URLs {
+		if err := w.Write(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The writer declares every extension up front, so only the bodies are
+	// compared.
+	body := func(s string) string { return s[strings.Index(s, "<url>"):] }
+	if body(got.String()) != body(want.String()) {
+		t.Errorf("Writer output differs from Encode:\n%s\nwant\n%s", got.String(), want.String())
+	}
+	if w.Count() != 12 {
+		t.Errorf("Count = %d", w.Count())
+	}
+	if err := w.Write(set.URLs[0]); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("Write after Close: err = %v", err)
+	}
+}
+
+func TestWriterBeforeEncode(t *testing.T) {
+	errHook := errors.New("hook failed")
+	var buf bytes.Buffer
+	w := NewWriter(&buf, nil, EncodeOptions{Compact: true})
+	w.BeforeEncode = func(u *URL) (*URL, error) {
+		switch u.Loc {
+		case "https://example.com/skip":
+			return nil, nil
+		case "https://example.com/fail":
+			return nil, errHook
+		}
+		out := u.Clone()
+		out.Loc += "?hooked"
+		return out, nil
+	}
+	for _, loc := range []string{"https://example.com/a", "https://example.com/skip"} {
+		if err := w.Write(&URL{Loc: loc}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Write(&URL{Loc: "https://example.com/fail"}); !errors.Is(err, errHook) {
+		t.Errorf("err = %v, want %v", err, errHook)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "<loc>https://example.com/a?hooked</loc>") || strings.Contains(out, "skip") || w.Count() != 1 {
+		t.Errorf("got %d URLs:\n%s", w.Count(), out)
+	}
+	if _, err := ParseXMLUrlSet(out); err != nil {
+		t.Errorf("output does not parse: %v", err)
+	}
+}
+
+func TestWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewWriter(&buf, nil, EncodeOptions{}).Close(); err != nil {
+		t.Fatal(err)
+	}
+	set, err := ParseXMLUrlSet(buf.String())
+	if err != nil || len(set.URLs) != 0 {
+		t.Errorf("empty document: %v, %d URLs", err, len(set.URLs))
+	}
+}